@@ -28,6 +28,7 @@ package autoscalingv2
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	v2 "k8s.io/api/autoscaling/v2"
@@ -39,21 +40,41 @@ import (
 
 	"github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/hpa"
+	"github.com/mercari/tortoise/pkg/policy"
 	"github.com/mercari/tortoise/pkg/tortoise"
 )
 
 //+kubebuilder:webhook:path=/mutate-autoscaling-v2-horizontalpodautoscaler,mutating=true,failurePolicy=fail,sideEffects=None,groups=autoscaling,resources=horizontalpodautoscalers,verbs=create;update,versions=v2,name=mhorizontalpodautoscaler.kb.io,admissionReviewVersions=v1
 
-func New(tortoiseService *tortoise.Service, hpaService *hpa.Service) *HPAWebhook {
+func New(tortoiseService *tortoise.Service, hpaService *hpa.Service, policyService *policy.Service) *HPAWebhook {
 	return &HPAWebhook{
 		tortoiseService: tortoiseService,
 		hpaService:      hpaService,
+		policyService:   policyService,
 	}
 }
 
 type HPAWebhook struct {
+	// mu protects tortoiseService and hpaService, which are swapped when the config file is reloaded.
+	mu              sync.RWMutex
 	tortoiseService *tortoise.Service
 	hpaService      *hpa.Service
+	// policyService is nil when TortoisePolicy isn't used.
+	policyService *policy.Service
+}
+
+// Reload swaps the services with the ones built from the reloaded config file.
+func (h *HPAWebhook) Reload(tortoiseService *tortoise.Service, hpaService *hpa.Service) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tortoiseService = tortoiseService
+	h.hpaService = hpaService
+}
+
+func (h *HPAWebhook) services() (*tortoise.Service, *hpa.Service) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.tortoiseService, h.hpaService
 }
 
 var _ admission.CustomDefaulter = &HPAWebhook{}
@@ -61,7 +82,8 @@ var _ admission.CustomDefaulter = &HPAWebhook{}
 // Default implements admission.CustomDefaulter so a webhook will be registered for the type
 func (h *HPAWebhook) Default(ctx context.Context, obj runtime.Object) error {
 	hpa := obj.(*v2.HorizontalPodAutoscaler)
-	tl, err := h.tortoiseService.ListTortoise(ctx, hpa.Namespace)
+	tortoiseService, hpaService := h.services()
+	tl, err := tortoiseService.ListTortoise(ctx, hpa.Namespace)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			return nil
@@ -95,7 +117,17 @@ func (h *HPAWebhook) Default(ctx context.Context, obj runtime.Object) error {
 	// tortoisePhase may be changed in ChangeHPAFromTortoiseRecommendation, so we need to get it before calling it.
 	tortoisePhase := tortoise.Status.TortoisePhase
 
-	modifiedhpa, _, err := h.hpaService.ChangeHPAFromTortoiseRecommendation(tortoise, hpa.DeepCopy(), time.Now(), false) // we don't need to record metrics.
+	if h.policyService != nil {
+		overrides, err := h.policyService.Resolve(ctx, tortoise.Namespace)
+		if err != nil {
+			// Block updating HPA may be critical. Just ignore it with error logs.
+			log.FromContext(ctx).Error(err, "failed to resolve the tortoise policies for mutating webhook of HPA", "hpa", klog.KObj(hpa), "tortoise", tortoise.Name)
+			return nil
+		}
+		hpaService = hpaService.WithOverrides(overrides)
+	}
+
+	modifiedhpa, _, err := hpaService.ChangeHPAFromTortoiseRecommendation(tortoise, hpa.DeepCopy(), time.Now(), false) // we don't need to record metrics.
 	if err != nil {
 		// Block updating HPA may be critical. Just ignore it with error logs.
 		log.FromContext(ctx).Error(err, "failed to get tortoise for mutating webhook of HPA", "hpa", klog.KObj(hpa), "tortoise", tortoise.Name)
@@ -137,7 +169,8 @@ func (*HPAWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Ob
 // Return an error if the object is invalid.
 func (h *HPAWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) (warnings admission.Warnings, err error) {
 	hpa := obj.(*v2.HorizontalPodAutoscaler)
-	tl, err := h.tortoiseService.ListTortoise(ctx, hpa.Namespace)
+	tortoiseService, _ := h.services()
+	tl, err := tortoiseService.ListTortoise(ctx, hpa.Namespace)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			// expected scenario - tortoise is deleted before HPA is deleted.
@@ -166,12 +166,12 @@ var _ = BeforeSuite(func() {
 	config, err := config.ParseConfig("")
 	Expect(err).NotTo(HaveOccurred())
 	eventRecorder := mgr.GetEventRecorderFor("tortoise-controller")
-	tortoiseService, err := tortoise.New(mgr.GetClient(), eventRecorder, config.RangeOfMinMaxReplicasRecommendationHours, config.TimeZone, config.TortoiseUpdateInterval, config.GatheringDataPeriodType, config.GlobalDisableMode)
+	tortoiseService, err := tortoise.New(mgr.GetClient(), eventRecorder, config.RangeOfMinMaxReplicasRecommendationHours, config.TimeZone, config.TortoiseUpdateInterval, config.GatheringDataPeriodType, config.GlobalDisableMode, config.MaximumEmergencyModeDuration, tortoise.NewVerticalApplyLimiter(config.VerticalApplyRateLimit, config.VerticalApplyBurst), config.VerticalMaintenanceWindows, config.VerticalScaleUpCooldown, config.VerticalScaleDownCooldown)
 	Expect(err).NotTo(HaveOccurred())
 	hpaService, err := hpa.New(mgr.GetClient(), eventRecorder, config.ReplicaReductionFactor, config.MaximumTargetResourceUtilization, 100, time.Hour, nil, 1000, 10000, 3, "", config.EmergencyModeGracePeriod, config.GlobalDisableMode)
 	Expect(err).NotTo(HaveOccurred())
 
-	hpaWebhook := New(tortoiseService, hpaService)
+	hpaWebhook := New(tortoiseService, hpaService, nil)
 
 	err = ctrl.NewWebhookManagedBy(mgr).
 		WithDefaulter(hpaWebhook).
@@ -28,6 +28,7 @@ package v1
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -38,6 +39,7 @@ import (
 
 	"github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/canary"
 	"github.com/mercari/tortoise/pkg/pod"
 	"github.com/mercari/tortoise/pkg/tortoise"
 )
@@ -52,16 +54,36 @@ import (
 func New(
 	tortoiseService *tortoise.Service,
 	podService *pod.Service,
+	canaryService *canary.Service,
 ) *PodWebhook {
 	return &PodWebhook{
 		tortoiseService: tortoiseService,
 		podService:      podService,
+		canaryService:   canaryService,
 	}
 }
 
 type PodWebhook struct {
+	// mu protects the services, which are swapped when the config file is reloaded.
+	mu              sync.RWMutex
 	tortoiseService *tortoise.Service
 	podService      *pod.Service
+	// canaryService isn't swapped because it doesn't depend on the config file.
+	canaryService *canary.Service
+}
+
+// Reload swaps the services with the ones built from the reloaded config file.
+func (h *PodWebhook) Reload(tortoiseService *tortoise.Service, podService *pod.Service) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tortoiseService = tortoiseService
+	h.podService = podService
+}
+
+func (h *PodWebhook) services() (*tortoise.Service, *pod.Service) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.tortoiseService, h.podService
 }
 
 var _ admission.CustomDefaulter = &PodWebhook{}
@@ -69,23 +91,24 @@ var _ admission.CustomDefaulter = &PodWebhook{}
 // Default implements admission.CustomDefaulter so a webhook will be registered for the type
 func (h *PodWebhook) Default(ctx context.Context, obj runtime.Object) error {
 	pod := obj.(*v1.Pod)
+	tortoiseService, podService := h.services()
 
-	deploymentName, err := h.podService.GetDeploymentForPod(pod)
+	workloadKind, workloadName, err := podService.GetWorkloadForPod(pod)
 	if err != nil {
 		// Block updating HPA may be critical. Just ignore it with error logs.
-		log.FromContext(ctx).Error(err, "failed to get deployment for pod in the Pod mutating webhook", "pod", klog.KObj(pod))
+		log.FromContext(ctx).Error(err, "failed to get workload for pod in the Pod mutating webhook", "pod", klog.KObj(pod))
 		return nil
 	}
 	if pod.Annotations == nil {
 		pod.Annotations = map[string]string{}
 	}
-	if deploymentName == "" {
-		// This Pod isn't managed by any deployment.
-		pod.Annotations[annotation.PodMutationAnnotation] = "this pod is not managed by deployment"
+	if workloadName == "" {
+		// This Pod isn't managed by any workload which tortoise supports.
+		pod.Annotations[annotation.PodMutationAnnotation] = "this pod is not managed by any workload that tortoise supports"
 		return nil
 	}
 
-	tl, err := h.tortoiseService.ListTortoise(ctx, pod.Namespace)
+	tl, err := tortoiseService.ListTortoise(ctx, pod.Namespace)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			return nil
@@ -101,7 +124,7 @@ func (h *PodWebhook) Default(ctx context.Context, obj runtime.Object) error {
 
 	var tortoise *v1beta3.Tortoise
 	for _, t := range tl.Items {
-		if t.Status.Targets.ScaleTargetRef.Name == deploymentName {
+		if t.Status.Targets.ScaleTargetRef.Kind == workloadKind && t.Status.Targets.ScaleTargetRef.Name == workloadName {
 			tortoise = t.DeepCopy()
 			break
 		}
@@ -118,7 +141,17 @@ func (h *PodWebhook) Default(ctx context.Context, obj runtime.Object) error {
 		return nil
 	}
 
-	h.podService.ModifyPodSpecResource(&pod.Spec, tortoise)
+	tortoise, isCanary, err := h.canaryService.MutatePod(ctx, pod, tortoise)
+	if err != nil {
+		// The Pod gets the current resource requests instead of the new ones being tried on the canary Pods.
+		log.FromContext(ctx).Error(err, "failed to check if the pod should be the canary pod in the Pod mutating webhook", "pod", klog.KObj(pod))
+	}
+
+	podService.ModifyPodSpecResource(&pod.Spec, tortoise)
+	if isCanary {
+		pod.Annotations[annotation.PodMutationAnnotation] = fmt.Sprintf("this pod is mutated by tortoise (%s) as the canary pod", tortoise.Name)
+		return nil
+	}
 	pod.Annotations[annotation.PodMutationAnnotation] = fmt.Sprintf("this pod is mutated by tortoise (%s)", tortoise.Name)
 
 	return nil
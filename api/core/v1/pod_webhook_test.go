@@ -145,5 +145,8 @@ var _ = Describe("v1.Pod Webhook", func() {
 		It("Pod with Off Tortoise is not mutated", func() {
 			mutateTest(filepath.Join("testdata", "mutating", "off-tortoise"))
 		})
+		It("Pod becomes the canary Pod while the canary rollout is in progress", func() {
+			mutateTest(filepath.Join("testdata", "mutating", "canary-tortoise"))
+		})
 	})
 })
@@ -56,6 +56,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/canary"
 	"github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/pod"
 	"github.com/mercari/tortoise/pkg/tortoise"
@@ -166,7 +167,7 @@ var _ = BeforeSuite(func() {
 	config, err := config.ParseConfig("")
 	Expect(err).NotTo(HaveOccurred())
 	eventRecorder := mgr.GetEventRecorderFor("tortoise-controller")
-	tortoiseService, err := tortoise.New(mgr.GetClient(), eventRecorder, config.RangeOfMinMaxReplicasRecommendationHours, config.TimeZone, config.TortoiseUpdateInterval, config.GatheringDataPeriodType, config.GlobalDisableMode)
+	tortoiseService, err := tortoise.New(mgr.GetClient(), eventRecorder, config.RangeOfMinMaxReplicasRecommendationHours, config.TimeZone, config.TortoiseUpdateInterval, config.GatheringDataPeriodType, config.GlobalDisableMode, config.MaximumEmergencyModeDuration, tortoise.NewVerticalApplyLimiter(config.VerticalApplyRateLimit, config.VerticalApplyBurst), config.VerticalMaintenanceWindows, config.VerticalScaleUpCooldown, config.VerticalScaleDownCooldown)
 	Expect(err).NotTo(HaveOccurred())
 
 	const (
@@ -181,10 +182,10 @@ var _ = BeforeSuite(func() {
 	factory := informers.NewSharedInformerFactory(kubeClient, defaultResyncPeriod)
 
 	controllerFetcher := controllerfetcher.NewControllerFetcher(mgr.GetConfig(), kubeClient, factory, scaleCacheEntryFreshnessTime, scaleCacheEntryLifetime, scaleCacheEntryJitterFactor)
-	podService, err := pod.New(map[string]int64{}, "0", controllerFetcher, nil)
+	podService, err := pod.New(map[string]int64{}, "0", controllerFetcher, nil, nil)
 	Expect(err).NotTo(HaveOccurred())
 
-	podWebhook := New(tortoiseService, podService)
+	podWebhook := New(tortoiseService, podService, canary.New(mgr.GetClient(), mgr.GetAPIReader(), eventRecorder))
 	err = ctrl.NewWebhookManagedBy(mgr).
 		WithDefaulter(podWebhook).
 		For(&v1.Pod{}).
@@ -0,0 +1,139 @@
+/*
+MIT License
+
+Copyright (c) 2023 mercari
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScheduledScalingSpec defines the desired state of ScheduledScaling
+type ScheduledScalingSpec struct {
+	// TargetRefs has reference to the target of the scheduled scaling.
+	TargetRefs TargetRefs `json:"targetRefs" protobuf:"bytes,1,name=targetRefs"`
+	// Strategy describes how the target is scaled during this ScheduledScaling is active.
+	Strategy Strategy `json:"strategy" protobuf:"bytes,2,name=strategy"`
+	// Schedule is the period during which this ScheduledScaling is active.
+	Schedule Schedule `json:"schedule" protobuf:"bytes,3,name=schedule"`
+}
+
+type TargetRefs struct {
+	// TortoiseName is the name of the target Tortoise.
+	// The Tortoise has to be in the same namespace as this ScheduledScaling.
+	TortoiseName string `json:"tortoiseName" protobuf:"bytes,1,name=tortoiseName"`
+}
+
+type Strategy struct {
+	// Static is the strategy to give the static constraints to the target Tortoise.
+	// +optional
+	Static *StaticStrategy `json:"static,omitempty" protobuf:"bytes,1,opt,name=static"`
+}
+
+type StaticStrategy struct {
+	// MinimumMinReplicas is the minimum replica number that Tortoise gives to HPA during this ScheduledScaling is active.
+	// +optional
+	MinimumMinReplicas *int32 `json:"minimumMinReplicas,omitempty" protobuf:"varint,1,opt,name=minimumMinReplicas"`
+	// MinAllocatedResources is the minimum resource request that Tortoise gives to the Pods during this ScheduledScaling is active.
+	// +optional
+	MinAllocatedResources []ContainerResourceRequirements `json:"minAllocatedResources,omitempty" protobuf:"bytes,2,opt,name=minAllocatedResources"`
+}
+
+type ContainerResourceRequirements struct {
+	// ContainerName is the name of target container.
+	ContainerName string `json:"containerName" protobuf:"bytes,1,name=containerName"`
+	// Resources is the minimum amount of resources which is given to the container.
+	Resources v1.ResourceList `json:"resources" protobuf:"bytes,2,name=resources"`
+}
+
+type Schedule struct {
+	// StartAt is the time when this ScheduledScaling starts.
+	// If empty, it starts right after the creation.
+	// +optional
+	StartAt *metav1.Time `json:"startAt,omitempty" protobuf:"bytes,1,opt,name=startAt"`
+	// FinishAt is the time when this ScheduledScaling finishes.
+	// After this time, Tortoise gradually goes back to the usual scaling.
+	FinishAt metav1.Time `json:"finishAt" protobuf:"bytes,2,name=finishAt"`
+}
+
+// ScheduledScalingStatus defines the observed state of ScheduledScaling
+type ScheduledScalingStatus struct {
+	// Phase is the current phase of this ScheduledScaling.
+	// +optional
+	Phase ScheduledScalingPhase `json:"phase,omitempty" protobuf:"bytes,1,opt,name=phase"`
+	// Reason is the reason why this ScheduledScaling is in the current phase.
+	// +optional
+	Reason string `json:"reason,omitempty" protobuf:"bytes,2,opt,name=reason"`
+	// LastTransitionTime is the last time the phase transitioned from one to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty" protobuf:"bytes,3,opt,name=lastTransitionTime"`
+}
+
+type ScheduledScalingPhase string
+
+const (
+	// ScheduledScalingPhasePending means the time to start hasn't come yet.
+	// Possible flow: (none) → Pending
+	ScheduledScalingPhasePending ScheduledScalingPhase = "Pending"
+	// ScheduledScalingPhaseActive means the constraints are given to the target Tortoise.
+	// Possible flow: Pending → Active
+	ScheduledScalingPhaseActive ScheduledScalingPhase = "Active"
+	// ScheduledScalingPhaseFinished means the time to finish has come, and the constraints are removed from the target Tortoise.
+	// Possible flow: Active → Finished
+	ScheduledScalingPhaseFinished ScheduledScalingPhase = "Finished"
+	// ScheduledScalingPhaseFailed means the constraints cannot be given to the target Tortoise.
+	// For example, the target Tortoise doesn't exist.
+	// Possible flow: Pending/Active → Failed
+	ScheduledScalingPhaseFailed ScheduledScalingPhase = "Failed"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="TORTOISE",type="string",JSONPath=".spec.targetRefs.tortoiseName"
+//+kubebuilder:printcolumn:name="START",type="string",JSONPath=".spec.schedule.startAt"
+//+kubebuilder:printcolumn:name="FINISH",type="string",JSONPath=".spec.schedule.finishAt"
+//+kubebuilder:printcolumn:name="PHASE",type="string",JSONPath=".status.phase"
+
+// ScheduledScaling is the Schema for the scheduledscalings API
+type ScheduledScaling struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScheduledScalingSpec   `json:"spec,omitempty"`
+	Status ScheduledScalingStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScheduledScalingList contains a list of ScheduledScaling
+type ScheduledScalingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScheduledScaling `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScheduledScaling{}, &ScheduledScalingList{})
+}
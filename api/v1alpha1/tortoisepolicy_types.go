@@ -0,0 +1,116 @@
+/*
+MIT License
+
+Copyright (c) 2023 mercari
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TortoisePolicySpec defines the desired state of TortoisePolicy
+type TortoisePolicySpec struct {
+	// NamespaceSelector selects the namespaces whose Tortoises this TortoisePolicy applies to.
+	// The empty selector selects all the namespaces.
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector" protobuf:"bytes,1,name=namespaceSelector"`
+	// Priority decides which TortoisePolicy wins when several TortoisePolicies select the same namespace and set the same field.
+	// The bigger one wins, and the one with the smaller name wins when the priorities are the same.
+	// +optional
+	Priority int32 `json:"priority,omitempty" protobuf:"varint,2,opt,name=priority"`
+	// Overrides has the values which override the global configurations in the admin config.
+	// The unset fields keep the global configurations.
+	Overrides ConfigOverrides `json:"overrides" protobuf:"bytes,3,name=overrides"`
+}
+
+// ConfigOverrides has the values overriding the global configurations for the Tortoises in the selected namespaces.
+// See the admin config for the meaning of each field.
+type ConfigOverrides struct {
+	// MinimumMinReplicas overrides MinimumMinReplicas in the admin config.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinimumMinReplicas *int32 `json:"minimumMinReplicas,omitempty" protobuf:"varint,1,opt,name=minimumMinReplicas"`
+	// MaximumMinReplicas overrides MaximumMinReplicas in the admin config.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaximumMinReplicas *int32 `json:"maximumMinReplicas,omitempty" protobuf:"varint,2,opt,name=maximumMinReplicas"`
+	// PreferredMaxReplicas overrides PreferredMaxReplicas in the admin config.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PreferredMaxReplicas *int32 `json:"preferredMaxReplicas,omitempty" protobuf:"varint,3,opt,name=preferredMaxReplicas"`
+	// MaximumMaxReplicas overrides MaximumMaxReplicas in the admin config.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaximumMaxReplicas *int32 `json:"maximumMaxReplicas,omitempty" protobuf:"varint,4,opt,name=maximumMaxReplicas"`
+	// MinimumCPURequest overrides MinimumCPURequest in the admin config.
+	// MinimumCPURequestPerContainer in the admin config is still prioritized.
+	// +optional
+	MinimumCPURequest *resource.Quantity `json:"minimumCPURequest,omitempty" protobuf:"bytes,5,opt,name=minimumCPURequest"`
+	// MinimumMemoryRequest overrides MinimumMemoryRequest in the admin config.
+	// MinimumMemoryRequestPerContainer in the admin config is still prioritized.
+	// +optional
+	MinimumMemoryRequest *resource.Quantity `json:"minimumMemoryRequest,omitempty" protobuf:"bytes,6,opt,name=minimumMemoryRequest"`
+	// MaximumCPURequest overrides MaximumCPURequest in the admin config.
+	// +optional
+	MaximumCPURequest *resource.Quantity `json:"maximumCPURequest,omitempty" protobuf:"bytes,7,opt,name=maximumCPURequest"`
+	// MaximumMemoryRequest overrides MaximumMemoryRequest in the admin config.
+	// +optional
+	MaximumMemoryRequest *resource.Quantity `json:"maximumMemoryRequest,omitempty" protobuf:"bytes,8,opt,name=maximumMemoryRequest"`
+	// MinimumTargetResourceUtilization overrides MinimumTargetResourceUtilization in the admin config.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MinimumTargetResourceUtilization *int32 `json:"minimumTargetResourceUtilization,omitempty" protobuf:"varint,9,opt,name=minimumTargetResourceUtilization"`
+	// MaximumTargetResourceUtilization overrides MaximumTargetResourceUtilization in the admin config.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaximumTargetResourceUtilization *int32 `json:"maximumTargetResourceUtilization,omitempty" protobuf:"varint,10,opt,name=maximumTargetResourceUtilization"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="PRIORITY",type="integer",JSONPath=".spec.priority"
+
+// TortoisePolicy is the Schema for the tortoisepolicies API.
+// It overrides the global configurations for the Tortoises in the namespaces selected by the label selector.
+type TortoisePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TortoisePolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TortoisePolicyList contains a list of TortoisePolicy
+type TortoisePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TortoisePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TortoisePolicy{}, &TortoisePolicyList{})
+}
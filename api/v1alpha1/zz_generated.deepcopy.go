@@ -0,0 +1,372 @@
+//go:build !ignore_autogenerated
+
+/*
+MIT License
+
+Copyright (c) 2023 mercari
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"k8s.io/api/core/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigOverrides) DeepCopyInto(out *ConfigOverrides) {
+	*out = *in
+	if in.MinimumMinReplicas != nil {
+		in, out := &in.MinimumMinReplicas, &out.MinimumMinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaximumMinReplicas != nil {
+		in, out := &in.MaximumMinReplicas, &out.MaximumMinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PreferredMaxReplicas != nil {
+		in, out := &in.PreferredMaxReplicas, &out.PreferredMaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaximumMaxReplicas != nil {
+		in, out := &in.MaximumMaxReplicas, &out.MaximumMaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinimumCPURequest != nil {
+		in, out := &in.MinimumCPURequest, &out.MinimumCPURequest
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MinimumMemoryRequest != nil {
+		in, out := &in.MinimumMemoryRequest, &out.MinimumMemoryRequest
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaximumCPURequest != nil {
+		in, out := &in.MaximumCPURequest, &out.MaximumCPURequest
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaximumMemoryRequest != nil {
+		in, out := &in.MaximumMemoryRequest, &out.MaximumMemoryRequest
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MinimumTargetResourceUtilization != nil {
+		in, out := &in.MinimumTargetResourceUtilization, &out.MinimumTargetResourceUtilization
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaximumTargetResourceUtilization != nil {
+		in, out := &in.MaximumTargetResourceUtilization, &out.MaximumTargetResourceUtilization
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigOverrides.
+func (in *ConfigOverrides) DeepCopy() *ConfigOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerResourceRequirements) DeepCopyInto(out *ContainerResourceRequirements) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerResourceRequirements.
+func (in *ContainerResourceRequirements) DeepCopy() *ContainerResourceRequirements {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerResourceRequirements)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Schedule) DeepCopyInto(out *Schedule) {
+	*out = *in
+	if in.StartAt != nil {
+		in, out := &in.StartAt, &out.StartAt
+		*out = (*in).DeepCopy()
+	}
+	in.FinishAt.DeepCopyInto(&out.FinishAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Schedule.
+func (in *Schedule) DeepCopy() *Schedule {
+	if in == nil {
+		return nil
+	}
+	out := new(Schedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledScaling) DeepCopyInto(out *ScheduledScaling) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledScaling.
+func (in *ScheduledScaling) DeepCopy() *ScheduledScaling {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledScaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScheduledScaling) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledScalingList) DeepCopyInto(out *ScheduledScalingList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScheduledScaling, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledScalingList.
+func (in *ScheduledScalingList) DeepCopy() *ScheduledScalingList {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledScalingList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScheduledScalingList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledScalingSpec) DeepCopyInto(out *ScheduledScalingSpec) {
+	*out = *in
+	out.TargetRefs = in.TargetRefs
+	in.Strategy.DeepCopyInto(&out.Strategy)
+	in.Schedule.DeepCopyInto(&out.Schedule)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledScalingSpec.
+func (in *ScheduledScalingSpec) DeepCopy() *ScheduledScalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledScalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledScalingStatus) DeepCopyInto(out *ScheduledScalingStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledScalingStatus.
+func (in *ScheduledScalingStatus) DeepCopy() *ScheduledScalingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledScalingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticStrategy) DeepCopyInto(out *StaticStrategy) {
+	*out = *in
+	if in.MinimumMinReplicas != nil {
+		in, out := &in.MinimumMinReplicas, &out.MinimumMinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinAllocatedResources != nil {
+		in, out := &in.MinAllocatedResources, &out.MinAllocatedResources
+		*out = make([]ContainerResourceRequirements, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticStrategy.
+func (in *StaticStrategy) DeepCopy() *StaticStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Strategy) DeepCopyInto(out *Strategy) {
+	*out = *in
+	if in.Static != nil {
+		in, out := &in.Static, &out.Static
+		*out = new(StaticStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Strategy.
+func (in *Strategy) DeepCopy() *Strategy {
+	if in == nil {
+		return nil
+	}
+	out := new(Strategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetRefs) DeepCopyInto(out *TargetRefs) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetRefs.
+func (in *TargetRefs) DeepCopy() *TargetRefs {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetRefs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TortoisePolicy) DeepCopyInto(out *TortoisePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TortoisePolicy.
+func (in *TortoisePolicy) DeepCopy() *TortoisePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TortoisePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TortoisePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TortoisePolicyList) DeepCopyInto(out *TortoisePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TortoisePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TortoisePolicyList.
+func (in *TortoisePolicyList) DeepCopy() *TortoisePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(TortoisePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TortoisePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TortoisePolicySpec) DeepCopyInto(out *TortoisePolicySpec) {
+	*out = *in
+	in.NamespaceSelector.DeepCopyInto(&out.NamespaceSelector)
+	in.Overrides.DeepCopyInto(&out.Overrides)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TortoisePolicySpec.
+func (in *TortoisePolicySpec) DeepCopy() *TortoisePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TortoisePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -6,16 +6,46 @@ import (
 
 	v1 "k8s.io/api/apps/v1"
 	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// ScaleSubresourceWorkload is the additional workload which tortoise can target.
+type ScaleSubresourceWorkload struct {
+	// APIVersion is the API version of the workload. e.g., argoproj.io/v1alpha1
+	APIVersion string
+	// Kind is the kind of the workload. e.g., Rollout
+	Kind string
+	// PodTemplatePath is the path to the pod template in the workload object. e.g., ["spec", "template"]
+	PodTemplatePath []string
+}
+
 type service struct {
 	c client.Client
+	// scaleSubresourceWorkloads are the additional workloads which tortoise can target, keyed by kind.
+	scaleSubresourceWorkloads map[string]ScaleSubresourceWorkload
 }
 
-func newService(c client.Client) *service {
-	return &service{c: c}
+func newService(c client.Client, scaleSubresourceWorkloads []ScaleSubresourceWorkload) *service {
+	workloads := make(map[string]ScaleSubresourceWorkload, len(scaleSubresourceWorkloads))
+	for _, w := range scaleSubresourceWorkloads {
+		workloads[w.Kind] = w
+	}
+	return &service{c: c, scaleSubresourceWorkloads: workloads}
+}
+
+// supportedScaleTargetKinds returns the kinds of the workloads which tortoise can target.
+func (c *service) supportedScaleTargetKinds() sets.Set[string] {
+	kinds := builtinScaleTargetKinds.Clone()
+	for k := range c.scaleSubresourceWorkloads {
+		kinds.Insert(k)
+	}
+	return kinds
 }
 
 func (c *service) GetDeploymentOnTortoise(ctx context.Context, tortoise *Tortoise) (*v1.Deployment, error) {
@@ -30,6 +60,74 @@ func (c *service) GetDeploymentOnTortoise(ctx context.Context, tortoise *Tortois
 	return d, nil
 }
 
+func (c *service) GetStatefulSetOnTortoise(ctx context.Context, tortoise *Tortoise) (*v1.StatefulSet, error) {
+	if tortoise.Spec.TargetRefs.ScaleTargetRef.Kind != "StatefulSet" {
+		return nil, fmt.Errorf("target kind is not statefulset: %s", tortoise.Spec.TargetRefs.ScaleTargetRef.Kind)
+	}
+
+	s := &v1.StatefulSet{}
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Spec.TargetRefs.ScaleTargetRef.Name}, s); err != nil {
+		return nil, fmt.Errorf("failed to get statefulset on tortoise: %w", err)
+	}
+	return s, nil
+}
+
+func (c *service) GetDaemonSetOnTortoise(ctx context.Context, tortoise *Tortoise) (*v1.DaemonSet, error) {
+	if tortoise.Spec.TargetRefs.ScaleTargetRef.Kind != "DaemonSet" {
+		return nil, fmt.Errorf("target kind is not daemonset: %s", tortoise.Spec.TargetRefs.ScaleTargetRef.Kind)
+	}
+
+	ds := &v1.DaemonSet{}
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Spec.TargetRefs.ScaleTargetRef.Name}, ds); err != nil {
+		return nil, fmt.Errorf("failed to get daemonset on tortoise: %w", err)
+	}
+	return ds, nil
+}
+
+// GetPodTemplateOnTortoise returns the pod template of the workload which the tortoise targets.
+func (c *service) GetPodTemplateOnTortoise(ctx context.Context, tortoise *Tortoise) (*corev1.PodTemplateSpec, error) {
+	switch tortoise.Spec.TargetRefs.ScaleTargetRef.Kind {
+	case "Deployment":
+		d, err := c.GetDeploymentOnTortoise(ctx, tortoise)
+		if err != nil {
+			return nil, err
+		}
+		return &d.Spec.Template, nil
+	case "StatefulSet":
+		s, err := c.GetStatefulSetOnTortoise(ctx, tortoise)
+		if err != nil {
+			return nil, err
+		}
+		return &s.Spec.Template, nil
+	case "DaemonSet":
+		ds, err := c.GetDaemonSetOnTortoise(ctx, tortoise)
+		if err != nil {
+			return nil, err
+		}
+		return &ds.Spec.Template, nil
+	}
+
+	w, ok := c.scaleSubresourceWorkloads[tortoise.Spec.TargetRefs.ScaleTargetRef.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported target kind: %s", tortoise.Spec.TargetRefs.ScaleTargetRef.Kind)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(w.APIVersion, w.Kind))
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Spec.TargetRefs.ScaleTargetRef.Name}, obj); err != nil {
+		return nil, fmt.Errorf("failed to get %s on tortoise: %w", w.Kind, err)
+	}
+	m, found, err := unstructured.NestedMap(obj.Object, w.PodTemplatePath...)
+	if err != nil || !found {
+		return nil, fmt.Errorf("failed to get the pod template of %s: found=%v, err=%v", w.Kind, found, err)
+	}
+	template := &corev1.PodTemplateSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, template); err != nil {
+		return nil, fmt.Errorf("failed to convert the pod template of %s: %w", w.Kind, err)
+	}
+	return template, nil
+}
+
 func (c *service) GetHPAFromUser(ctx context.Context, tortoise *Tortoise) (*v2.HorizontalPodAutoscaler, error) {
 	if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName == nil {
 		// user doesn't specify HPA.
@@ -96,8 +96,111 @@ type TortoiseSpec struct {
 	// If nil, Tortoise uses the cluster wide default value, which is currently hard-coded.
 	// +optional
 	HorizontalPodAutoscalerBehavior *v2.HorizontalPodAutoscalerBehavior `json:"horizontalPodAutoscalerBehavior,omitempty" protobuf:"bytes,7,opt,name=horizontalPodAutoscalerBehavior"`
+	// Recommenders are responsible for generating the recommendation for this Tortoise, in addition to the Tortoise controller.
+	// The Tortoise controller doesn't update the recommendations that the recommenders here are responsible for.
+	//
+	// Usually, you don't need to set this field by yourself;
+	// for example, the ScheduledScaling controller sets this field while the ScheduledScaling is active.
+	// +optional
+	Recommenders []Recommender `json:"recommenders,omitempty" protobuf:"bytes,8,opt,name=recommenders"`
+	// VerticalRollout configures how tortoise rolls out the new resource requests to the Pods.
+	// If nil, all the Pods get the new resource requests at once with VerticalRolloutStrategy in the controller config.
+	// +optional
+	VerticalRollout *VerticalRollout `json:"verticalRollout,omitempty" protobuf:"bytes,9,opt,name=verticalRollout"`
+	// ApplySchedule restricts when tortoise applies the recommendations,
+	// that is, changes the resource requests (which restarts the Pods) and the target utilizations of HPA.
+	// The recommendations are still calculated and recorded in the status, and applied in the next allowed window.
+	// If nil, tortoise applies the recommendations anytime.
+	// +optional
+	ApplySchedule *ApplySchedule `json:"applySchedule,omitempty" protobuf:"bytes,10,opt,name=applySchedule"`
+}
+
+type ApplySchedule struct {
+	// Windows are the windows when tortoise can apply the recommendations.
+	// If empty, tortoise can apply them anytime unless they're frozen by FreezeUntil.
+	// +optional
+	Windows []ApplyWindow `json:"windows,omitempty" protobuf:"bytes,1,opt,name=windows"`
+	// FreezeUntil stops applying the recommendations until the time, e.g., during a release freeze.
+	// It takes precedence over Windows.
+	// +optional
+	FreezeUntil *metav1.Time `json:"freezeUntil,omitempty" protobuf:"bytes,2,opt,name=freezeUntil"`
+	// TimeZone is the time zone of Windows, e.g., "Asia/Tokyo".
+	// If empty, it's UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty" protobuf:"bytes,3,opt,name=timeZone"`
+}
+
+type ApplyWindow struct {
+	// WeekDays are the days of the week when the window is open.
+	// If empty, the window is open every day.
+	// +optional
+	WeekDays []WeekDay `json:"weekDays,omitempty" protobuf:"bytes,1,opt,name=weekDays"`
+	// From is the hour when the window opens.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=23
+	From int32 `json:"from" protobuf:"varint,2,name=from"`
+	// To is the hour when the window closes, which is exclusive.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=24
+	To int32 `json:"to" protobuf:"varint,3,name=to"`
+}
+
+// +kubebuilder:validation:Enum=Sunday;Monday;Tuesday;Wednesday;Thursday;Friday;Saturday
+type WeekDay string
+
+type VerticalRollout struct {
+	// Canary makes tortoise try the new resource requests on a part of the Pods first.
+	// Tortoise watches the canary Pods during the bake duration,
+	// and applies the new resource requests to all the Pods only when the canary Pods don't get OOMKilled or restarted.
+	// Otherwise, tortoise rolls back the canary Pods to the current resource requests.
+	// +optional
+	Canary *CanaryRollout `json:"canary,omitempty" protobuf:"bytes,1,opt,name=canary"`
+	// Strategy is how tortoise applies the new resource requests to the running Pods.
+	// If "Restart", tortoise restarts the workload, and the Pod mutating webhook gives the new resource requests to the recreated Pods.
+	// If "InPlace", tortoise resizes the containers of the running Pods via the resize subresource without recreating them.
+	// It falls back to "Restart" when a container needs to be restarted for the resize (e.g., resizePolicy is RestartContainer).
+	// If empty, VerticalRolloutStrategy in the controller config is used.
+	// +optional
+	Strategy VerticalRolloutStrategy `json:"strategy,omitempty" protobuf:"bytes,2,opt,name=strategy"`
 }
 
+// +kubebuilder:validation:Enum=Restart;InPlace
+type VerticalRolloutStrategy string
+
+const (
+	VerticalRolloutStrategyRestart VerticalRolloutStrategy = "Restart"
+	VerticalRolloutStrategyInPlace VerticalRolloutStrategy = "InPlace"
+)
+
+type CanaryRollout struct {
+	// Percent is the percentage of the Pods which get the new resource requests first.
+	// At least one Pod is the canary.
+	// If nil, it's 10.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Percent *int32 `json:"percent,omitempty" protobuf:"varint,1,opt,name=percent"`
+	// BakeDuration is how long tortoise watches the canary Pods before applying the new resource requests to all the Pods.
+	// If nil, it's 30 minutes.
+	// +optional
+	BakeDuration *metav1.Duration `json:"bakeDuration,omitempty" protobuf:"bytes,2,opt,name=bakeDuration"`
+}
+
+type Recommender struct {
+	// Name is the name of the recommender.
+	Name string `json:"name" protobuf:"bytes,1,name=name"`
+	// ResponsibleFor represents which kind of recommendation this recommender generates.
+	ResponsibleFor []ResponsibleFor `json:"responsibleFor" protobuf:"bytes,2,name=responsibleFor"`
+}
+
+// +kubebuilder:validation:Enum=Constraints
+type ResponsibleFor string
+
+const (
+	// ResponsibleForConstraints means the recommender is responsible for generating .status.recommendations.constraints.
+	ResponsibleForConstraints ResponsibleFor = "Constraints"
+)
+
 type ContainerAutoscalingPolicy struct {
 	// ContainerName is the name of target container.
 	ContainerName string `json:"containerName" protobuf:"bytes,1,name=containerName"`
@@ -125,6 +228,66 @@ type ContainerResourcePolicy struct {
 	// If nil, Tortoise uses the cluster wide default value, which can be configured via the admin config.
 	// +optional
 	MaxAllocatedResources v1.ResourceList `json:"maxAllocatedResources,omitempty" protobuf:"bytes,3,opt,name=maxAllocatedResources"`
+
+	// Behavior is the autoscaling behavior of each resource in the container.
+	// If nil, Tortoise uses the cluster wide default value, which can be configured via the admin config.
+	// +optional
+	Behavior map[v1.ResourceName]ResourceBehavior `json:"behavior,omitempty" protobuf:"bytes,4,opt,name=behavior"`
+}
+
+// ResourceBehavior is the autoscaling behavior of a resource.
+// Each field overrides the corresponding cluster wide value only for this resource.
+type ResourceBehavior struct {
+	// VerticalBufferPercent is the buffer added to the recommendation when the resource is scaled vertically, in percentage.
+	// For example, if it's 10 and the recommendation is 1 core, the resource request will be 1.1 cores.
+	// If nil, Tortoise uses BufferRatioOnVerticalResource in the admin config.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	VerticalBufferPercent *int32 `json:"verticalBufferPercent,omitempty" protobuf:"varint,1,opt,name=verticalBufferPercent"`
+	// VerticalScaleDownCooldown is the minimum interval between scaling down the resource request.
+	// Scaling up is never blocked by this cooldown, but by VerticalScaleUpCooldown.
+	// When several resources are scaled down at the same time, the longest cooldown among them is used.
+	// If nil, Tortoise uses VerticalScaleDownCooldown in the admin config (1 hour by default).
+	// +optional
+	VerticalScaleDownCooldown *metav1.Duration `json:"verticalScaleDownCooldown,omitempty" protobuf:"bytes,2,opt,name=verticalScaleDownCooldown"`
+	// MinReplicasRecommendationMultiplierPercent is the factor to calculate the minReplicas recommendation from the current replica number, in percentage,
+	// which is used when the resource is scaled horizontally.
+	// When several horizontal resources in the tortoise have this field, the biggest one is used.
+	// If nil, Tortoise uses MinReplicasRecommendationMultiplier in the admin config.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinReplicasRecommendationMultiplierPercent *int32 `json:"minReplicasRecommendationMultiplierPercent,omitempty" protobuf:"varint,3,opt,name=minReplicasRecommendationMultiplierPercent"`
+	// MaxReplicasRecommendationMultiplierPercent is the factor to calculate the maxReplicas recommendation from the current replica number, in percentage,
+	// which is used when the resource is scaled horizontally.
+	// When several horizontal resources in the tortoise have this field, the biggest one is used.
+	// If nil, Tortoise uses MaxReplicasRecommendationMultiplier in the admin config.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicasRecommendationMultiplierPercent *int32 `json:"maxReplicasRecommendationMultiplierPercent,omitempty" protobuf:"varint,4,opt,name=maxReplicasRecommendationMultiplierPercent"`
+	// VerticalScaleUpCooldown is the minimum interval between scaling up the resource request.
+	// When several resources are scaled up at the same time, the longest cooldown among them is used.
+	// If nil, Tortoise uses VerticalScaleUpCooldown in the admin config (0 by default).
+	// +optional
+	VerticalScaleUpCooldown *metav1.Duration `json:"verticalScaleUpCooldown,omitempty" protobuf:"bytes,5,opt,name=verticalScaleUpCooldown"`
+	// VerticalMinimumChange is the minimum change of the resource request to apply.
+	// Tortoise ignores the recommendation which is closer to the current resource request than this.
+	// If nil, Tortoise uses VerticalMinimumCPUChange or VerticalMinimumMemoryChange in the admin config.
+	// +optional
+	VerticalMinimumChange *resource.Quantity `json:"verticalMinimumChange,omitempty" protobuf:"bytes,6,opt,name=verticalMinimumChange"`
+	// VerticalMinimumChangePercent is the minimum change of the resource request to apply, relative to the current resource request, in percentage.
+	// For example, if it's 10 and the current resource request is 100m, Tortoise ignores the recommendation between 91m and 109m.
+	// The increases are ignored only when the current resource request still covers the recommendation without the buffer.
+	// If nil, Tortoise uses VerticalMinimumChangeRatio in the admin config.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=99
+	// +optional
+	VerticalMinimumChangePercent *int32 `json:"verticalMinimumChangePercent,omitempty" protobuf:"varint,7,opt,name=verticalMinimumChangePercent"`
+	// VerticalMaxStepPercent is the maximum change of the resource request per apply, relative to the current resource request, in percentage.
+	// For example, if it's 50 and the current resource request is 100m, Tortoise changes it to between 50m and 150m at once.
+	// If nil, Tortoise uses VerticalMaxStepRatio in the admin config.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	VerticalMaxStepPercent *int32 `json:"verticalMaxStepPercent,omitempty" protobuf:"varint,8,opt,name=verticalMaxStepPercent"`
 }
 
 // +kubebuilder:validation:Enum=DeleteAll;NoDelete
@@ -204,6 +367,20 @@ type TortoiseStatus struct {
 	// But, if .spec.autoscalingPolicy is empty, tortoise manages/generates
 	// the policies generated based on HPA and the target deployment.
 	AutoscalingPolicy []ContainerAutoscalingPolicy `json:"autoscalingPolicy,omitempty" protobuf:"bytes,6,opt,name=autoscalingPolicy"`
+	// VerticalRollout is the canary rollout of the resource requests in progress.
+	// It's nil when no canary rollout is in progress.
+	// +optional
+	VerticalRollout *VerticalRolloutStatus `json:"verticalRollout,omitempty" protobuf:"bytes,7,opt,name=verticalRollout"`
+}
+
+type VerticalRolloutStatus struct {
+	// ContainerResourceRequests is the new resource requests which only the canary Pods get.
+	// The other Pods keep getting .status.conditions.containerResourceRequests until the canary rollout is promoted.
+	ContainerResourceRequests []ContainerResourceRequests `json:"containerResourceRequests" protobuf:"bytes,1,name=containerResourceRequests"`
+	// StartedAt is the time when the canary rollout started.
+	StartedAt metav1.Time `json:"startedAt" protobuf:"bytes,2,name=startedAt"`
+	// CanaryReplicas is the number of the canary Pods.
+	CanaryReplicas int32 `json:"canaryReplicas" protobuf:"varint,3,name=canaryReplicas"`
 }
 
 type ContainerResourcePhases struct {
@@ -289,14 +466,112 @@ type Recommendations struct {
 	Horizontal HorizontalRecommendations `json:"horizontal,omitempty" protobuf:"bytes,1,opt,name=horizontal"`
 	// +optional
 	Vertical VerticalRecommendations `json:"vertical,omitempty" protobuf:"bytes,2,opt,name=vertical"`
+	// Constraints shows the constraints that this Tortoise has to take into account when generating the recommendation.
+	// It's generated by the recommender in .spec.recommenders, and it's removed when no recommender is responsible for it.
+	// When this field is empty, only the global constraints that are configured through the admin configuration are used.
+	// +optional
+	Constraints *Constraints `json:"constraints,omitempty" protobuf:"bytes,3,opt,name=constraints"`
+}
+
+type Constraints struct {
+	// MinimumMinReplicas is the minimum replica number that Tortoise gives to HPA.
+	// Note that it's not limited by the global MaximumMinReplicas, but limited by the maxReplicas (.spec.maxReplicas and the global MaximumMaxReplicas).
+	// +optional
+	MinimumMinReplicas *int32 `json:"minimumMinReplicas,omitempty" protobuf:"varint,1,opt,name=minimumMinReplicas"`
+	// MinAllocatedResources is the minimum resource request that Tortoise gives to the Pods.
+	// Note that the maximum resource request (.spec.resourcePolicy[*].maxAllocatedResources and the global one) is still prioritized.
+	// +optional
+	MinAllocatedResources []ContainerResourcePolicy `json:"minAllocatedResources,omitempty" protobuf:"bytes,2,opt,name=minAllocatedResources"`
 }
 
 type VerticalRecommendations struct {
 	// ContainerResourceRecommendation has the recommendation of container resource request.
 	// +optional
 	ContainerResourceRecommendation []RecommendedContainerResources `json:"containerResourceRecommendation" protobuf:"bytes,1,opt,name=containerResourceRecommendation"`
+	// Explanations explains how each recommendation in ContainerResourceRecommendation is generated.
+	// +optional
+	Explanations []RecommendationExplanation `json:"explanations,omitempty" protobuf:"bytes,2,opt,name=explanations"`
+}
+
+// RecommendationExplanation explains how tortoise generated the recommendation of the resource in the container.
+type RecommendationExplanation struct {
+	// ContainerName is the name of target container.
+	ContainerName string `json:"containerName" protobuf:"bytes,1,name=containerName"`
+	// ResourceName is the name of target resource.
+	ResourceName v1.ResourceName `json:"resourceName" protobuf:"bytes,2,name=resourceName"`
+	// ResourceRequest is the resource request of the container when the recommendation was generated.
+	ResourceRequest resource.Quantity `json:"resourceRequest" protobuf:"bytes,3,name=resourceRequest"`
+	// VPAMaxRecommendation is the max recommendation from VPA which the recommendation was based on.
+	VPAMaxRecommendation resource.Quantity `json:"vpaMaxRecommendation" protobuf:"bytes,4,name=vpaMaxRecommendation"`
+	// Replicas is the number of replicas when the recommendation was generated.
+	Replicas int32 `json:"replicas" protobuf:"varint,5,name=replicas"`
+	// HPATargetUtilization is the target utilization of this resource in HPA when the recommendation was generated.
+	// It's nil when this resource isn't scaled by HPA.
+	// +optional
+	HPATargetUtilization *int32 `json:"hpaTargetUtilization,omitempty" protobuf:"varint,6,opt,name=hpaTargetUtilization"`
+	// Branch is the logic which decided the recommendation.
+	Branch RecommendationBranch `json:"branch" protobuf:"bytes,7,name=branch"`
+	// Clamps are the limits which the recommendation was clamped by.
+	// +optional
+	Clamps []RecommendationClamp `json:"clamps,omitempty" protobuf:"bytes,8,opt,name=clamps"`
+	// Message is the human-readable explanation of the recommendation.
+	// +optional
+	Message string `json:"message,omitempty" protobuf:"bytes,9,opt,name=message"`
+	// GeneratedAt is the time when the recommendation was generated.
+	GeneratedAt metav1.Time `json:"generatedAt" protobuf:"bytes,10,name=generatedAt"`
 }
 
+type RecommendationBranch string
+
+const (
+	// RecommendationBranchPolicyOff means the autoscaling policy of the resource is Off, and the current resource request is kept.
+	RecommendationBranchPolicyOff RecommendationBranch = "PolicyOff"
+	// RecommendationBranchVerticalScaleUp means the resource request is scaled up based on the VPA recommendation and the buffer.
+	RecommendationBranchVerticalScaleUp RecommendationBranch = "VerticalScaleUp"
+	// RecommendationBranchVerticalScaleDown means the resource request is scaled down based on the VPA recommendation and the buffer.
+	RecommendationBranchVerticalScaleDown RecommendationBranch = "VerticalScaleDown"
+	// RecommendationBranchSmallChangeIgnored means the change from the current resource request is too small to apply.
+	RecommendationBranchSmallChangeIgnored RecommendationBranch = "SmallChangeIgnored"
+	// RecommendationBranchPreferredMaxReplicas means the resource request is scaled up
+	// because the number of replicas is bigger than the preferred max replica number.
+	RecommendationBranchPreferredMaxReplicas RecommendationBranch = "PreferredMaxReplicas"
+	// RecommendationBranchCloseToPreferredMaxReplicas means the current resource request is kept
+	// because the number of replicas is close to the preferred max replica number.
+	RecommendationBranchCloseToPreferredMaxReplicas RecommendationBranch = "CloseToPreferredMaxReplicas"
+	// RecommendationBranchMinimumMinReplicas means the resource request is scaled down based on the VPA recommendation
+	// because the number of replicas is equal or smaller than the minimum min replica number.
+	RecommendationBranchMinimumMinReplicas RecommendationBranch = "MinimumMinReplicas"
+	// RecommendationBranchUnbalancedContainer means the resource request is scaled down
+	// because the resource utilization is too small compared to the HPA target utilization due to the unbalanced container size.
+	RecommendationBranchUnbalancedContainer RecommendationBranch = "UnbalancedContainer"
+	// RecommendationBranchNoChange means tortoise found nothing to change, and the current value is kept.
+	RecommendationBranchNoChange RecommendationBranch = "NoChange"
+	// RecommendationBranchTargetUtilizationUpdated means the HPA target utilization is calculated from the VPA recommendation.
+	RecommendationBranchTargetUtilizationUpdated RecommendationBranch = "TargetUtilizationUpdated"
+	// RecommendationBranchInvalidTargetUtilization means the calculated HPA target utilization was invalid,
+	// and the current target utilization is kept.
+	RecommendationBranchInvalidTargetUtilization RecommendationBranch = "InvalidTargetUtilization"
+)
+
+type RecommendationClamp string
+
+const (
+	// RecommendationClampMinimumResource means the recommendation was raised to the minimum resource request,
+	// i.e., minAllocatedResources, the constraints, the floors and the global minimum.
+	RecommendationClampMinimumResource RecommendationClamp = "MinimumResource"
+	// RecommendationClampMaximumResource means the recommendation was lowered to the maximum resource request,
+	// i.e., maxAllocatedResources and the global maximum.
+	RecommendationClampMaximumResource RecommendationClamp = "MaximumResource"
+	// RecommendationClampMaxAllowedScalingDownRatio means the scale down was limited by the global MaxAllowedScalingDownRatio.
+	RecommendationClampMaxAllowedScalingDownRatio RecommendationClamp = "MaxAllowedScalingDownRatio"
+	// RecommendationClampVerticalMaxStep means the change was limited by the maximum step of the vertical scaling.
+	RecommendationClampVerticalMaxStep RecommendationClamp = "VerticalMaxStep"
+	// RecommendationClampMaximumTargetUtilization means the HPA target utilization was lowered to the global maximum.
+	RecommendationClampMaximumTargetUtilization RecommendationClamp = "MaximumTargetUtilization"
+	// RecommendationClampMinimumTargetUtilization means the HPA target utilization was raised to the global minimum.
+	RecommendationClampMinimumTargetUtilization RecommendationClamp = "MinimumTargetUtilization"
+)
+
 type RecommendedContainerResources struct {
 	// ContainerName is the name of target container.
 	ContainerName string `json:"containerName" protobuf:"bytes,1,name=containerName"`
@@ -321,6 +596,9 @@ type HorizontalRecommendations struct {
 	// It contains the recommendations for each time slot.
 	// +optional
 	MinReplicas []ReplicasRecommendation `json:"minReplicas,omitempty" protobuf:"bytes,3,opt,name=minReplicas"`
+	// TargetUtilizationExplanations explains how each recommendation in TargetUtilizations is generated.
+	// +optional
+	TargetUtilizationExplanations []RecommendationExplanation `json:"targetUtilizationExplanations,omitempty" protobuf:"bytes,4,opt,name=targetUtilizationExplanations"`
 }
 
 type ReplicasRecommendation struct {
@@ -364,6 +642,31 @@ type Conditions struct {
 	// (Tortoise sometimes doesn't immediately apply the recommendation value to the resource request for the sake of safety.)
 	// +optional
 	ContainerResourceRequests []ContainerResourceRequests `json:"containerResourceRequests,omitempty" protobuf:"bytes,3,opt,name=containerResourceRequests"`
+	// PreviousContainerResourceRequests is the resource request for each container before tortoise updated containerResourceRequests last time.
+	// Tortoise restores it when the Pods get OOMKilled or go into CrashLoopBackOff after the update.
+	// +optional
+	PreviousContainerResourceRequests []ContainerResourceRequests `json:"previousContainerResourceRequests,omitempty" protobuf:"bytes,4,opt,name=previousContainerResourceRequests"`
+	// ContainerResourceFloors is the lower bound of the resource request for each container.
+	// When tortoise rolls back containerResourceRequests, it raises the floors of the decreased resources to the restored values
+	// so that the same recommendation isn't applied again.
+	// Also, when the OOM-aware memory recommendation is enabled, each OOMKill raises the floor of the memory above the current request.
+	// Each floor is removed once the decay window has passed after it was raised.
+	// +optional
+	ContainerResourceFloors []ContainerResourceFloor `json:"containerResourceFloors,omitempty" protobuf:"bytes,5,opt,name=containerResourceFloors"`
+}
+
+type ContainerResourceFloor struct {
+	// ContainerName is the name of target container.
+	ContainerName string `json:"containerName" protobuf:"bytes,1,name=containerName"`
+	// Resource is the floor of each resource.
+	Resource map[v1.ResourceName]ResourceFloor `json:"resource" protobuf:"bytes,2,name=resource"`
+}
+
+type ResourceFloor struct {
+	// Quantity is the lower bound of the resource request.
+	Quantity resource.Quantity `json:"quantity" protobuf:"bytes,1,name=quantity"`
+	// RaisedAt is the time when the floor was raised last time.
+	RaisedAt metav1.Time `json:"raisedAt" protobuf:"bytes,2,name=raisedAt"`
 }
 
 type ContainerResourceRequests struct {
@@ -381,6 +684,26 @@ const (
 	TortoiseConditionTypeHPATargetUtilizationUpdated         TortoiseConditionType = "HPATargetUtilizationUpdated"
 	TortoiseConditionTypeVerticalRecommendationUpdated       TortoiseConditionType = "VerticalRecommendationUpdated"
 	TortoiseConditionTypeScaledUpBasedOnPreferredMaxReplicas TortoiseConditionType = "ScaledUpBasedOnPreferredMaxReplicas"
+	// TortoiseConditionTypeHistoryBackfilled means the recommendation was backfilled from the historical usage in Prometheus.
+	// It's False while the history isn't sufficient or cannot be fetched.
+	TortoiseConditionTypeHistoryBackfilled TortoiseConditionType = "HistoryBackfilled"
+	// TortoiseConditionTypeReplicasHistoryBackfilled means the min/max replicas recommendation was backfilled from the replicas history.
+	// It's False while the history isn't sufficient or cannot be fetched.
+	TortoiseConditionTypeReplicasHistoryBackfilled TortoiseConditionType = "ReplicasHistoryBackfilled"
+	// TortoiseConditionTypeEmergencyMode means tortoise is in Emergency mode.
+	// Its lastTransitionTime is the time when tortoise moved to Emergency mode.
+	TortoiseConditionTypeEmergencyMode TortoiseConditionType = "EmergencyMode"
+	// TortoiseConditionTypeVerticalCanary is the state of the canary rollout of the resource requests.
+	// It's True while the canary Pods are baking, and False with the reason Promoted or RolledBack once it's finished.
+	TortoiseConditionTypeVerticalCanary TortoiseConditionType = "VerticalCanary"
+	// TortoiseConditionTypeVerticalRolledBack means tortoise rolled back containerResourceRequests
+	// because the Pods got OOMKilled or went into CrashLoopBackOff after the update.
+	// Its lastTransitionTime is the time of the last rollback.
+	TortoiseConditionTypeVerticalRolledBack TortoiseConditionType = "VerticalRolledBack"
+	// TortoiseConditionTypeVerticalApplyDeferred means tortoise holds back the new resource requests
+	// because restarting the Pods now isn't safe, e.g., a rollout of the workload is in progress.
+	// It's True while the new resource requests are deferred, and tortoise tries to apply them again in the next reconciliation.
+	TortoiseConditionTypeVerticalApplyDeferred TortoiseConditionType = "VerticalApplyDeferred"
 )
 
 type TortoiseCondition struct {
@@ -29,6 +29,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -45,8 +46,10 @@ import (
 var tortoiselog = ctrl.Log.WithName("tortoise-resource")
 var ClientService *service
 
-func (r *Tortoise) SetupWebhookWithManager(mgr ctrl.Manager) error {
-	ClientService = newService(mgr.GetClient())
+// SetupWebhookWithManager sets up the webhook for Tortoise.
+// scaleSubresourceWorkloads are the workloads which tortoise can target in addition to Deployment, StatefulSet and DaemonSet.
+func (r *Tortoise) SetupWebhookWithManager(mgr ctrl.Manager, scaleSubresourceWorkloads []ScaleSubresourceWorkload) error {
+	ClientService = newService(mgr.GetClient(), scaleSubresourceWorkloads)
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -58,6 +61,9 @@ var _ webhook.Defaulter = &Tortoise{}
 
 const TortoiseDefaultHPANamePrefix = "tortoise-hpa-"
 
+// builtinScaleTargetKinds are the kinds of the workloads which tortoise can always target.
+var builtinScaleTargetKinds = sets.New("Deployment", "StatefulSet", "DaemonSet")
+
 func TortoiseDefaultHPAName(tortoiseName string) string {
 	return TortoiseDefaultHPANamePrefix + tortoiseName
 }
@@ -69,52 +75,54 @@ func (r *Tortoise) defaultAutoscalingPolicy() {
 		return
 	}
 
-	// TODO: support other resources.
-	if r.Spec.TargetRefs.ScaleTargetRef.Kind == "Deployment" {
-		d, err := ClientService.GetDeploymentOnTortoise(ctx, r)
-		if err != nil {
-			tortoiselog.Error(err, "failed to get deployment")
-			return
-		}
+	if !ClientService.supportedScaleTargetKinds().Has(r.Spec.TargetRefs.ScaleTargetRef.Kind) {
+		// validation webhook will reject it.
+		return
+	}
 
-		containers := d.Spec.Template.Spec.DeepCopy().Containers
-		if d.Spec.Template.Annotations != nil {
-			if v, ok := d.Spec.Template.Annotations[annotation.IstioSidecarInjectionAnnotation]; ok && v == "true" {
-				// If the deployment has the sidecar injection annotation, the Pods will have the sidecar container in addition.
-				containers = append(d.Spec.Template.Spec.Containers, v1.Container{
-					Name: "istio-proxy",
-				})
-			}
+	template, err := ClientService.GetPodTemplateOnTortoise(ctx, r)
+	if err != nil {
+		tortoiselog.Error(err, "failed to get the pod template of the workload")
+		return
+	}
+
+	containers := template.Spec.DeepCopy().Containers
+	if template.Annotations != nil {
+		if v, ok := template.Annotations[annotation.IstioSidecarInjectionAnnotation]; ok && v == "true" {
+			// If the workload has the sidecar injection annotation, the Pods will have the sidecar container in addition.
+			containers = append(template.Spec.Containers, v1.Container{
+				Name: "istio-proxy",
+			})
 		}
+	}
 
-		if len(containers) != len(r.Spec.AutoscalingPolicy) {
-			for _, c := range containers {
-				policyExist := false
-				for _, p := range r.Spec.AutoscalingPolicy {
-					if c.Name == p.ContainerName {
-						policyExist = true
-						break
-					}
-				}
-				if !policyExist {
-					r.Spec.AutoscalingPolicy = append(r.Spec.AutoscalingPolicy, ContainerAutoscalingPolicy{
-						ContainerName: c.Name,
-						Policy:        map[v1.ResourceName]AutoscalingType{},
-					})
+	if len(containers) != len(r.Spec.AutoscalingPolicy) {
+		for _, c := range containers {
+			policyExist := false
+			for _, p := range r.Spec.AutoscalingPolicy {
+				if c.Name == p.ContainerName {
+					policyExist = true
+					break
 				}
 			}
+			if !policyExist {
+				r.Spec.AutoscalingPolicy = append(r.Spec.AutoscalingPolicy, ContainerAutoscalingPolicy{
+					ContainerName: c.Name,
+					Policy:        map[v1.ResourceName]AutoscalingType{},
+				})
+			}
 		}
+	}
 
-		// the default policy is Off
-		for i := range r.Spec.AutoscalingPolicy {
-			_, ok := r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceCPU]
-			if !ok {
-				r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceCPU] = AutoscalingTypeOff
-			}
-			_, ok = r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceMemory]
-			if !ok {
-				r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceMemory] = AutoscalingTypeOff
-			}
+	// the default policy is Off
+	for i := range r.Spec.AutoscalingPolicy {
+		_, ok := r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceCPU]
+		if !ok {
+			r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceCPU] = AutoscalingTypeOff
+		}
+		_, ok = r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceMemory]
+		if !ok {
+			r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceMemory] = AutoscalingTypeOff
 		}
 	}
 }
@@ -129,6 +137,10 @@ func (r *Tortoise) Default() {
 	if r.Spec.DeletionPolicy == "" {
 		r.Spec.DeletionPolicy = DeletionPolicyNoDelete
 	}
+	if w, ok := ClientService.scaleSubresourceWorkloads[r.Spec.TargetRefs.ScaleTargetRef.Kind]; ok && r.Spec.TargetRefs.ScaleTargetRef.APIVersion == "" {
+		// HPA and VPA need the apiVersion to find the custom workload.
+		r.Spec.TargetRefs.ScaleTargetRef.APIVersion = w.APIVersion
+	}
 
 	r.defaultAutoscalingPolicy()
 }
@@ -148,25 +160,58 @@ func hasHorizontal(tortoise *Tortoise) bool {
 	return false
 }
 
+// IsEmergencyModeAllowed returns true if the tortoise in the phase can be changed to Emergency mode.
+func IsEmergencyModeAllowed(phase TortoisePhase) bool {
+	return phase == TortoisePhaseWorking || phase == TortoisePhaseEmergency || phase == TortoisePhaseBackToNormal
+}
+
 func validateTortoise(t *Tortoise) error {
 	fieldPath := field.NewPath("spec")
 	if t.Spec.TargetRefs.ScaleTargetRef.Kind == "" {
 		return fmt.Errorf("%s: shouldn't be empty", fieldPath.Child("targetRefs", "scaleTargetRef", "kind"))
 	}
 
-	if t.Spec.TargetRefs.ScaleTargetRef.Kind != "Deployment" {
-		return fmt.Errorf("%s: only Deployment is supported now", fieldPath.Child("targetRefs", "scaleTargetRef", "kind"))
+	if !ClientService.supportedScaleTargetKinds().Has(t.Spec.TargetRefs.ScaleTargetRef.Kind) {
+		return fmt.Errorf("%s: only %v are supported now", fieldPath.Child("targetRefs", "scaleTargetRef", "kind"), sets.List(ClientService.supportedScaleTargetKinds()))
 	}
 
 	if t.Spec.TargetRefs.ScaleTargetRef.Name == "" {
 		return fmt.Errorf("%s: shouldn't be empty", fieldPath.Child("targetRefs", "scaleTargetRef", "name"))
 	}
 
-	if t.Spec.UpdateMode == UpdateModeEmergency &&
-		t.Status.TortoisePhase != TortoisePhaseWorking && t.Status.TortoisePhase != TortoisePhaseEmergency && t.Status.TortoisePhase != TortoisePhaseBackToNormal {
+	if t.Spec.TargetRefs.ScaleTargetRef.Kind == "DaemonSet" {
+		// DaemonSet runs one Pod per node, and cannot be scaled horizontally.
+		if hasHorizontal(t) {
+			return fmt.Errorf("%s: DaemonSet cannot be scaled horizontally, only Vertical or Off is allowed", fieldPath.Child("autoscalingPolicy"))
+		}
+		if t.Spec.TargetRefs.HorizontalPodAutoscalerName != nil {
+			return fmt.Errorf("%s: DaemonSet cannot be scaled by HPA", fieldPath.Child("targetRefs", "horizontalPodAutoscalerName"))
+		}
+	}
+
+	if t.Spec.UpdateMode == UpdateModeEmergency && !IsEmergencyModeAllowed(t.Status.TortoisePhase) {
 		return fmt.Errorf("%s: emergency mode is only available for tortoises with Running phase", fieldPath.Child("updateMode"))
 	}
 
+	if s := t.Spec.ApplySchedule; s != nil {
+		for i, w := range s.Windows {
+			if w.From >= w.To {
+				return fmt.Errorf("%s: should be greater than from", fieldPath.Child("applySchedule", "windows").Index(i).Child("to"))
+			}
+		}
+		if s.TimeZone != "" {
+			if _, err := time.LoadLocation(s.TimeZone); err != nil {
+				return fmt.Errorf("%s: invalid time zone: %w", fieldPath.Child("applySchedule", "timeZone"), err)
+			}
+		}
+	}
+
+	if v, ok := t.Annotations[annotation.EmergencyUntilAnnotation]; ok {
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			return fmt.Errorf("metadata.annotations[%s]: should be RFC3339 format: %w", annotation.EmergencyUntilAnnotation, err)
+		}
+	}
+
 	return nil
 }
 
@@ -175,38 +220,35 @@ func (r *Tortoise) ValidateCreate() (admission.Warnings, error) {
 	ctx := context.Background()
 	tortoiselog.Info("validate create", "name", r.Name)
 	fieldPath := field.NewPath("spec")
-	if r.Spec.TargetRefs.ScaleTargetRef.Kind != "Deployment" {
-		return nil, fmt.Errorf("only deployment is supported in %s at the moment", fieldPath.Child("targetRefs", "scaleTargetRef", "kind"))
+	if !ClientService.supportedScaleTargetKinds().Has(r.Spec.TargetRefs.ScaleTargetRef.Kind) {
+		return nil, fmt.Errorf("only %v are supported in %s at the moment", sets.List(ClientService.supportedScaleTargetKinds()), fieldPath.Child("targetRefs", "scaleTargetRef", "kind"))
 	}
 
-	if r.Spec.TargetRefs.ScaleTargetRef.Kind == "Deployment" {
-		// TODO: do the same validation for other resources.
-		d, err := ClientService.GetDeploymentOnTortoise(ctx, r)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get the deployment defined in %s: %w", fieldPath.Child("targetRefs", "scaleTargetRef"), err)
-		}
+	template, err := ClientService.GetPodTemplateOnTortoise(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the %s defined in %s: %w", r.Spec.TargetRefs.ScaleTargetRef.Kind, fieldPath.Child("targetRefs", "scaleTargetRef"), err)
+	}
 
-		containersInDP := sets.New[string]()
-		for _, c := range d.Spec.Template.Spec.Containers {
-			containersInDP.Insert(c.Name)
-		}
+	containersInWorkload := sets.New[string]()
+	for _, c := range template.Spec.Containers {
+		containersInWorkload.Insert(c.Name)
+	}
 
-		if d.Spec.Template.Annotations != nil {
-			if v, ok := d.Spec.Template.Annotations[annotation.IstioSidecarInjectionAnnotation]; ok && v == "true" {
-				// If the deployment has the sidecar injection annotation, the Pods will have the sidecar container in addition.
-				containersInDP.Insert("istio-proxy")
-			}
+	if template.Annotations != nil {
+		if v, ok := template.Annotations[annotation.IstioSidecarInjectionAnnotation]; ok && v == "true" {
+			// If the workload has the sidecar injection annotation, the Pods will have the sidecar container in addition.
+			containersInWorkload.Insert("istio-proxy")
 		}
+	}
 
-		containerWithPolicy := sets.New[string]()
-		for _, p := range r.Spec.AutoscalingPolicy {
-			containerWithPolicy.Insert(p.ContainerName)
-		}
+	containerWithPolicy := sets.New[string]()
+	for _, p := range r.Spec.AutoscalingPolicy {
+		containerWithPolicy.Insert(p.ContainerName)
+	}
 
-		uselessPolicies := containerWithPolicy.Difference(containersInDP)
-		if uselessPolicies.Len() != 0 {
-			return nil, fmt.Errorf("%s: tortoise should not have the policies for the container(s) which isn't defined in the deployment, but, it have the policy for the container(s) %v", fieldPath.Child("resourcePolicy"), uselessPolicies)
-		}
+	uselessPolicies := containerWithPolicy.Difference(containersInWorkload)
+	if uselessPolicies.Len() != 0 {
+		return nil, fmt.Errorf("%s: tortoise should not have the policies for the container(s) which isn't defined in the %s, but, it have the policy for the container(s) %v", fieldPath.Child("resourcePolicy"), r.Spec.TargetRefs.ScaleTargetRef.Kind, uselessPolicies)
 	}
 
 	return nil, validateTortoise(r)
@@ -36,6 +36,7 @@ import (
 	v1 "k8s.io/api/apps/v1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/yaml"
 
@@ -43,21 +44,30 @@ import (
 	. "github.com/onsi/gomega"
 )
 
-func mutateTest(before, after, deployment, hpa string) {
-	ctx := context.Background()
-
-	y, err := os.ReadFile(deployment)
+// createWorkload creates the workload (e.g., Deployment, StatefulSet) defined in the file.
+func createWorkload(ctx context.Context, path string) *unstructured.Unstructured {
+	y, err := os.ReadFile(path)
 	Expect(err).NotTo(HaveOccurred())
-	deploy := &v1.Deployment{}
-	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(deploy)
+	workload := &unstructured.Unstructured{}
+	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(&workload.Object)
 	Expect(err).NotTo(HaveOccurred())
-	err = k8sClient.Create(ctx, deploy)
+	err = k8sClient.Create(ctx, workload)
 	Expect(err).NotTo(HaveOccurred())
+	return workload
+}
+
+func mutateTest(before, after, workload, hpa string) {
+	ctx := context.Background()
+
+	w := createWorkload(ctx, workload)
 	defer func() {
-		err = k8sClient.Delete(ctx, deploy)
+		err := k8sClient.Delete(ctx, w)
 		Expect(err).NotTo(HaveOccurred())
 	}()
 
+	var y []byte
+	var err error
+
 	if hpa != "" {
 		y, err = os.ReadFile(hpa)
 		Expect(err).NotTo(HaveOccurred())
@@ -97,21 +107,18 @@ func mutateTest(before, after, deployment, hpa string) {
 	Expect(ret.Spec).Should(Equal(afterTortoise.Spec))
 }
 
-func validateCreationTest(tortoise, hpa, deployment string, valid bool) {
+func validateCreationTest(tortoise, hpa, workload string, valid bool) {
 	ctx := context.Background()
 
-	y, err := os.ReadFile(deployment)
-	Expect(err).NotTo(HaveOccurred())
-	deploy := &v1.Deployment{}
-	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(deploy)
-	Expect(err).NotTo(HaveOccurred())
-	err = k8sClient.Create(ctx, deploy)
-	Expect(err).NotTo(HaveOccurred())
+	w := createWorkload(ctx, workload)
 	defer func() {
-		err = k8sClient.Delete(ctx, deploy)
+		err := k8sClient.Delete(ctx, w)
 		Expect(err).NotTo(HaveOccurred())
 	}()
 
+	var y []byte
+	var err error
+
 	if hpa != "" {
 		y, err = os.ReadFile(hpa)
 		Expect(err).NotTo(HaveOccurred())
@@ -231,6 +238,9 @@ var _ = Describe("Tortoise Webhook", func() {
 		It("should mutate a Tortoise which some autoscalingPolicy is specified, but not all", func() {
 			mutateTest(filepath.Join("testdata", "mutating", "some-specified-others-not", "before.yaml"), filepath.Join("testdata", "mutating", "some-specified-others-not", "after.yaml"), filepath.Join("testdata", "mutating", "some-specified-others-not", "deployment.yaml"), "")
 		})
+		It("should mutate a Tortoise targetting StatefulSet", func() {
+			mutateTest(filepath.Join("testdata", "mutating", "statefulset", "before.yaml"), filepath.Join("testdata", "mutating", "statefulset", "after.yaml"), filepath.Join("testdata", "mutating", "statefulset", "statefulset.yaml"), "")
+		})
 	})
 	Context("validating(creation)", func() {
 		It("should create a valid Tortoise", func() {
@@ -239,12 +249,24 @@ var _ = Describe("Tortoise Webhook", func() {
 		It("should create a valid Tortoise for the deployment with istio", func() {
 			validateCreationTest(filepath.Join("testdata", "validating", "success-with-istio", "tortoise.yaml"), filepath.Join("testdata", "validating", "success-with-istio", "hpa.yaml"), filepath.Join("testdata", "validating", "success-with-istio", "deployment.yaml"), true)
 		})
-		It("invalid: Tortoise is targetting the resource other than Deployment", func() {
+		It("should create a valid Tortoise for the statefulset", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "success-statefulset", "tortoise.yaml"), filepath.Join("testdata", "validating", "success-statefulset", "hpa.yaml"), filepath.Join("testdata", "validating", "success-statefulset", "statefulset.yaml"), true)
+		})
+		It("should create a valid Tortoise for the daemonset", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "success-daemonset", "tortoise.yaml"), "", filepath.Join("testdata", "validating", "success-daemonset", "daemonset.yaml"), true)
+		})
+		It("invalid: Tortoise is targetting the resource other than Deployment, StatefulSet or DaemonSet", func() {
 			validateCreationTest(filepath.Join("testdata", "validating", "not-targetting-deployment", "tortoise.yaml"), filepath.Join("testdata", "validating", "not-targetting-deployment", "hpa.yaml"), filepath.Join("testdata", "validating", "not-targetting-deployment", "deployment.yaml"), false)
 		})
+		It("invalid: Tortoise has Horizontal policy for the daemonset", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "daemonset-with-horizontal", "tortoise.yaml"), "", filepath.Join("testdata", "validating", "daemonset-with-horizontal", "daemonset.yaml"), false)
+		})
 		It("invalid: Tortoise has resource policy for non-existing container", func() {
 			validateCreationTest(filepath.Join("testdata", "validating", "useless-policy", "tortoise.yaml"), filepath.Join("testdata", "validating", "useless-policy", "hpa.yaml"), filepath.Join("testdata", "validating", "useless-policy", "deployment.yaml"), false)
 		})
+		It("invalid: Tortoise has the apply window which closes before it opens", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "invalid-apply-window", "tortoise.yaml"), filepath.Join("testdata", "validating", "invalid-apply-window", "hpa.yaml"), filepath.Join("testdata", "validating", "invalid-apply-window", "deployment.yaml"), false)
+		})
 	})
 	Context("validating(updating)", func() {
 		It("should update a valid Tortoise", func() {
@@ -31,10 +31,82 @@ package v1beta3
 
 import (
 	"k8s.io/api/autoscaling/v2"
-	"k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplySchedule) DeepCopyInto(out *ApplySchedule) {
+	*out = *in
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]ApplyWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FreezeUntil != nil {
+		in, out := &in.FreezeUntil, &out.FreezeUntil
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplySchedule.
+func (in *ApplySchedule) DeepCopy() *ApplySchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplySchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplyWindow) DeepCopyInto(out *ApplyWindow) {
+	*out = *in
+	if in.WeekDays != nil {
+		in, out := &in.WeekDays, &out.WeekDays
+		*out = make([]WeekDay, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplyWindow.
+func (in *ApplyWindow) DeepCopy() *ApplyWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplyWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryRollout) DeepCopyInto(out *CanaryRollout) {
+	*out = *in
+	if in.Percent != nil {
+		in, out := &in.Percent, &out.Percent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BakeDuration != nil {
+		in, out := &in.BakeDuration, &out.BakeDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryRollout.
+func (in *CanaryRollout) DeepCopy() *CanaryRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Conditions) DeepCopyInto(out *Conditions) {
 	*out = *in
@@ -59,6 +131,20 @@ func (in *Conditions) DeepCopyInto(out *Conditions) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PreviousContainerResourceRequests != nil {
+		in, out := &in.PreviousContainerResourceRequests, &out.PreviousContainerResourceRequests
+		*out = make([]ContainerResourceRequests, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ContainerResourceFloors != nil {
+		in, out := &in.ContainerResourceFloors, &out.ContainerResourceFloors
+		*out = make([]ContainerResourceFloor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Conditions.
@@ -71,12 +157,39 @@ func (in *Conditions) DeepCopy() *Conditions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Constraints) DeepCopyInto(out *Constraints) {
+	*out = *in
+	if in.MinimumMinReplicas != nil {
+		in, out := &in.MinimumMinReplicas, &out.MinimumMinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinAllocatedResources != nil {
+		in, out := &in.MinAllocatedResources, &out.MinAllocatedResources
+		*out = make([]ContainerResourcePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Constraints.
+func (in *Constraints) DeepCopy() *Constraints {
+	if in == nil {
+		return nil
+	}
+	out := new(Constraints)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerAutoscalingPolicy) DeepCopyInto(out *ContainerAutoscalingPolicy) {
 	*out = *in
 	if in.Policy != nil {
 		in, out := &in.Policy, &out.Policy
-		*out = make(map[v1.ResourceName]AutoscalingType, len(*in))
+		*out = make(map[corev1.ResourceName]AutoscalingType, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
@@ -98,14 +211,14 @@ func (in *ContainerRecommendationFromVPA) DeepCopyInto(out *ContainerRecommendat
 	*out = *in
 	if in.MaxRecommendation != nil {
 		in, out := &in.MaxRecommendation, &out.MaxRecommendation
-		*out = make(map[v1.ResourceName]ResourceQuantity, len(*in))
+		*out = make(map[corev1.ResourceName]ResourceQuantity, len(*in))
 		for key, val := range *in {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	if in.Recommendation != nil {
 		in, out := &in.Recommendation, &out.Recommendation
-		*out = make(map[v1.ResourceName]ResourceQuantity, len(*in))
+		*out = make(map[corev1.ResourceName]ResourceQuantity, len(*in))
 		for key, val := range *in {
 			(*out)[key] = *val.DeepCopy()
 		}
@@ -122,12 +235,34 @@ func (in *ContainerRecommendationFromVPA) DeepCopy() *ContainerRecommendationFro
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerResourceFloor) DeepCopyInto(out *ContainerResourceFloor) {
+	*out = *in
+	if in.Resource != nil {
+		in, out := &in.Resource, &out.Resource
+		*out = make(map[corev1.ResourceName]ResourceFloor, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerResourceFloor.
+func (in *ContainerResourceFloor) DeepCopy() *ContainerResourceFloor {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerResourceFloor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerResourcePhases) DeepCopyInto(out *ContainerResourcePhases) {
 	*out = *in
 	if in.ResourcePhases != nil {
 		in, out := &in.ResourcePhases, &out.ResourcePhases
-		*out = make(map[v1.ResourceName]ResourcePhase, len(*in))
+		*out = make(map[corev1.ResourceName]ResourcePhase, len(*in))
 		for key, val := range *in {
 			(*out)[key] = *val.DeepCopy()
 		}
@@ -149,18 +284,25 @@ func (in *ContainerResourcePolicy) DeepCopyInto(out *ContainerResourcePolicy) {
 	*out = *in
 	if in.MinAllocatedResources != nil {
 		in, out := &in.MinAllocatedResources, &out.MinAllocatedResources
-		*out = make(v1.ResourceList, len(*in))
+		*out = make(corev1.ResourceList, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
 	if in.MaxAllocatedResources != nil {
 		in, out := &in.MaxAllocatedResources, &out.MaxAllocatedResources
-		*out = make(v1.ResourceList, len(*in))
+		*out = make(corev1.ResourceList, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.Behavior != nil {
+		in, out := &in.Behavior, &out.Behavior
+		*out = make(map[corev1.ResourceName]ResourceBehavior, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerResourcePolicy.
@@ -178,7 +320,7 @@ func (in *ContainerResourceRequests) DeepCopyInto(out *ContainerResourceRequests
 	*out = *in
 	if in.Resource != nil {
 		in, out := &in.Resource, &out.Resource
-		*out = make(v1.ResourceList, len(*in))
+		*out = make(corev1.ResourceList, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val.DeepCopy()
 		}
@@ -215,7 +357,7 @@ func (in *HPATargetUtilizationRecommendationPerContainer) DeepCopyInto(out *HPAT
 	*out = *in
 	if in.TargetUtilization != nil {
 		in, out := &in.TargetUtilization, &out.TargetUtilization
-		*out = make(map[v1.ResourceName]int32, len(*in))
+		*out = make(map[corev1.ResourceName]int32, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
@@ -256,6 +398,13 @@ func (in *HorizontalRecommendations) DeepCopyInto(out *HorizontalRecommendations
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TargetUtilizationExplanations != nil {
+		in, out := &in.TargetUtilizationExplanations, &out.TargetUtilizationExplanations
+		*out = make([]RecommendationExplanation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HorizontalRecommendations.
@@ -268,11 +417,44 @@ func (in *HorizontalRecommendations) DeepCopy() *HorizontalRecommendations {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecommendationExplanation) DeepCopyInto(out *RecommendationExplanation) {
+	*out = *in
+	out.ResourceRequest = in.ResourceRequest.DeepCopy()
+	out.VPAMaxRecommendation = in.VPAMaxRecommendation.DeepCopy()
+	if in.HPATargetUtilization != nil {
+		in, out := &in.HPATargetUtilization, &out.HPATargetUtilization
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Clamps != nil {
+		in, out := &in.Clamps, &out.Clamps
+		*out = make([]RecommendationClamp, len(*in))
+		copy(*out, *in)
+	}
+	in.GeneratedAt.DeepCopyInto(&out.GeneratedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendationExplanation.
+func (in *RecommendationExplanation) DeepCopy() *RecommendationExplanation {
+	if in == nil {
+		return nil
+	}
+	out := new(RecommendationExplanation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Recommendations) DeepCopyInto(out *Recommendations) {
 	*out = *in
 	in.Horizontal.DeepCopyInto(&out.Horizontal)
 	in.Vertical.DeepCopyInto(&out.Vertical)
+	if in.Constraints != nil {
+		in, out := &in.Constraints, &out.Constraints
+		*out = new(Constraints)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Recommendations.
@@ -290,7 +472,7 @@ func (in *RecommendedContainerResources) DeepCopyInto(out *RecommendedContainerR
 	*out = *in
 	if in.RecommendedResource != nil {
 		in, out := &in.RecommendedResource, &out.RecommendedResource
-		*out = make(v1.ResourceList, len(*in))
+		*out = make(corev1.ResourceList, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val.DeepCopy()
 		}
@@ -307,6 +489,26 @@ func (in *RecommendedContainerResources) DeepCopy() *RecommendedContainerResourc
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Recommender) DeepCopyInto(out *Recommender) {
+	*out = *in
+	if in.ResponsibleFor != nil {
+		in, out := &in.ResponsibleFor, &out.ResponsibleFor
+		*out = make([]ResponsibleFor, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Recommender.
+func (in *Recommender) DeepCopy() *Recommender {
+	if in == nil {
+		return nil
+	}
+	out := new(Recommender)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicasRecommendation) DeepCopyInto(out *ReplicasRecommendation) {
 	*out = *in
@@ -328,6 +530,78 @@ func (in *ReplicasRecommendation) DeepCopy() *ReplicasRecommendation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceBehavior) DeepCopyInto(out *ResourceBehavior) {
+	*out = *in
+	if in.VerticalBufferPercent != nil {
+		in, out := &in.VerticalBufferPercent, &out.VerticalBufferPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.VerticalScaleDownCooldown != nil {
+		in, out := &in.VerticalScaleDownCooldown, &out.VerticalScaleDownCooldown
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MinReplicasRecommendationMultiplierPercent != nil {
+		in, out := &in.MinReplicasRecommendationMultiplierPercent, &out.MinReplicasRecommendationMultiplierPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxReplicasRecommendationMultiplierPercent != nil {
+		in, out := &in.MaxReplicasRecommendationMultiplierPercent, &out.MaxReplicasRecommendationMultiplierPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.VerticalScaleUpCooldown != nil {
+		in, out := &in.VerticalScaleUpCooldown, &out.VerticalScaleUpCooldown
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.VerticalMinimumChange != nil {
+		in, out := &in.VerticalMinimumChange, &out.VerticalMinimumChange
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.VerticalMinimumChangePercent != nil {
+		in, out := &in.VerticalMinimumChangePercent, &out.VerticalMinimumChangePercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.VerticalMaxStepPercent != nil {
+		in, out := &in.VerticalMaxStepPercent, &out.VerticalMaxStepPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceBehavior.
+func (in *ResourceBehavior) DeepCopy() *ResourceBehavior {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceBehavior)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceFloor) DeepCopyInto(out *ResourceFloor) {
+	*out = *in
+	out.Quantity = in.Quantity.DeepCopy()
+	in.RaisedAt.DeepCopyInto(&out.RaisedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceFloor.
+func (in *ResourceFloor) DeepCopy() *ResourceFloor {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceFloor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourcePhase) DeepCopyInto(out *ResourcePhase) {
 	*out = *in
@@ -522,6 +796,23 @@ func (in *TortoiseSpec) DeepCopyInto(out *TortoiseSpec) {
 		*out = new(v2.HorizontalPodAutoscalerBehavior)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Recommenders != nil {
+		in, out := &in.Recommenders, &out.Recommenders
+		*out = make([]Recommender, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VerticalRollout != nil {
+		in, out := &in.VerticalRollout, &out.VerticalRollout
+		*out = new(VerticalRollout)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ApplySchedule != nil {
+		in, out := &in.ApplySchedule, &out.ApplySchedule
+		*out = new(ApplySchedule)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TortoiseSpec.
@@ -554,6 +845,11 @@ func (in *TortoiseStatus) DeepCopyInto(out *TortoiseStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.VerticalRollout != nil {
+		in, out := &in.VerticalRollout, &out.VerticalRollout
+		*out = new(VerticalRolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TortoiseStatus.
@@ -576,6 +872,13 @@ func (in *VerticalRecommendations) DeepCopyInto(out *VerticalRecommendations) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Explanations != nil {
+		in, out := &in.Explanations, &out.Explanations
+		*out = make([]RecommendationExplanation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerticalRecommendations.
@@ -587,3 +890,46 @@ func (in *VerticalRecommendations) DeepCopy() *VerticalRecommendations {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerticalRollout) DeepCopyInto(out *VerticalRollout) {
+	*out = *in
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryRollout)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerticalRollout.
+func (in *VerticalRollout) DeepCopy() *VerticalRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(VerticalRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerticalRolloutStatus) DeepCopyInto(out *VerticalRolloutStatus) {
+	*out = *in
+	if in.ContainerResourceRequests != nil {
+		in, out := &in.ContainerResourceRequests, &out.ContainerResourceRequests
+		*out = make([]ContainerResourceRequests, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerticalRolloutStatus.
+func (in *VerticalRolloutStatus) DeepCopy() *VerticalRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VerticalRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -28,12 +28,15 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/zapr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
 	v2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -42,21 +45,33 @@ import (
 	"k8s.io/client-go/informers"
 	kube_client "k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	autoscalingv2 "github.com/mercari/tortoise/api/autoscaling/v2"
 	v1 "github.com/mercari/tortoise/api/core/v1"
+	autoscalingv1alpha1 "github.com/mercari/tortoise/api/v1alpha1"
 	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/internal/controller"
+	"github.com/mercari/tortoise/pkg/backfill"
+	"github.com/mercari/tortoise/pkg/canary"
 	"github.com/mercari/tortoise/pkg/config"
-	"github.com/mercari/tortoise/pkg/deployment"
+	"github.com/mercari/tortoise/pkg/configwatcher"
 	"github.com/mercari/tortoise/pkg/hpa"
 	"github.com/mercari/tortoise/pkg/metrics"
+	"github.com/mercari/tortoise/pkg/oom"
 	"github.com/mercari/tortoise/pkg/pod"
+	"github.com/mercari/tortoise/pkg/policy"
 	"github.com/mercari/tortoise/pkg/recommender"
+	"github.com/mercari/tortoise/pkg/resize"
+	"github.com/mercari/tortoise/pkg/rollback"
+	"github.com/mercari/tortoise/pkg/scheduledscaling"
 	"github.com/mercari/tortoise/pkg/tortoise"
+	"github.com/mercari/tortoise/pkg/usage"
 	"github.com/mercari/tortoise/pkg/vpa"
+	"github.com/mercari/tortoise/pkg/workload"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -72,6 +87,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(autoscalingv1beta3.AddToScheme(scheme))
+	utilruntime.Must(autoscalingv1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -144,11 +160,35 @@ func main() {
 		os.Exit(1)
 	}
 	eventRecorder := mgr.GetEventRecorderFor("tortoise-controller")
-	tortoiseService, err := tortoise.New(mgr.GetClient(), eventRecorder, config.RangeOfMinMaxReplicasRecommendationHours, config.TimeZone, config.TortoiseUpdateInterval, config.GatheringDataPeriodType, config.GlobalDisableMode)
+
+	const (
+		defaultResyncPeriod                        = 10 * time.Minute
+		statusUpdateInterval                       = 10 * time.Second
+		scaleCacheEntryLifetime      time.Duration = time.Hour
+		scaleCacheEntryFreshnessTime time.Duration = 10 * time.Minute
+		scaleCacheEntryJitterFactor  float64       = 1.
+	)
+
+	kubeClient := kube_client.NewForConfigOrDie(mgr.GetConfig())
+	factory := informers.NewSharedInformerFactory(kubeClient, defaultResyncPeriod)
+
+	controllerFetcher := controllerfetcher.NewControllerFetcher(mgr.GetConfig(), kubeClient, factory, scaleCacheEntryFreshnessTime, scaleCacheEntryLifetime, scaleCacheEntryJitterFactor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	controllerFetcher.Start(ctx, 1*time.Second)
+	defer cancel()
+
+	// The API reader reads directly from the API server.
+	// The services listing Pods (canary, rollback, oom and resize) use it so that the manager doesn't cache all the Pods in the cluster.
+	apiReader := mgr.GetAPIReader()
+
+	verticalApplyLimiter := tortoise.NewVerticalApplyLimiter(config.VerticalApplyRateLimit, config.VerticalApplyBurst)
+	services, err := newConfigServices(config, mgr.GetClient(), apiReader, eventRecorder, controllerFetcher, verticalApplyLimiter)
 	if err != nil {
-		setupLog.Error(err, "unable to start tortoise service")
+		setupLog.Error(err, "unable to start services")
 		os.Exit(1)
 	}
+	tortoiseService, recommenderService, hpaService, podService, rollbackService, oomService, resizeService := services.tortoise, services.recommender, services.hpa, services.pod, services.rollback, services.oom, services.resize
 
 	vpaClient, err := vpa.New(mgr.GetConfig(), eventRecorder)
 	if err != nil {
@@ -156,74 +196,95 @@ func main() {
 		os.Exit(1)
 	}
 
-	hpaService, err := hpa.New(mgr.GetClient(), eventRecorder, config.ReplicaReductionFactor, config.MaximumTargetResourceUtilization, config.HPATargetUtilizationMaxIncrease, config.HPATargetUtilizationUpdateInterval, config.DefaultHPABehavior, config.MaximumMinReplicas, config.MaximumMaxReplicas, int32(config.MinimumMinReplicas), config.HPAExternalMetricExclusionRegex, config.EmergencyModeGracePeriod, config.GlobalDisableMode)
-	if err != nil {
-		setupLog.Error(err, "unable to start hpa service")
-		os.Exit(1)
+	var usageService *usage.Service
+	if config.VerticalRecommender == "Tortoise" {
+		var source usage.Source
+		switch config.UsageSource {
+		case "Prometheus":
+			source, err = usage.NewPrometheusSource(config.PrometheusAddress, apiReader)
+			if err != nil {
+				setupLog.Error(err, "unable to start prometheus usage source")
+				os.Exit(1)
+			}
+		default:
+			source = usage.NewMetricsAPISource(apiReader)
+		}
+		usageService = usage.New(source, config.UsageHistogramDecayHalfLife, config.UsageMinimumObservationPeriod)
 	}
 
+	var backfillService *backfill.Service
+	if config.PrometheusHistoryBackfill || config.ReplicasHistoryBackfillSource != "" {
+		var usageHistorySource usage.HistorySource
+		if config.PrometheusHistoryBackfill {
+			usageHistorySource, err = usage.NewPrometheusSource(config.PrometheusAddress, apiReader)
+			if err != nil {
+				setupLog.Error(err, "unable to start prometheus history source")
+				os.Exit(1)
+			}
+		}
+		var replicasHistorySource backfill.ReplicasHistorySource
+		switch config.ReplicasHistoryBackfillSource {
+		case "Prometheus":
+			replicasHistorySource, err = backfill.NewPrometheusReplicasSource(config.PrometheusAddress)
+			if err != nil {
+				setupLog.Error(err, "unable to start prometheus replicas history source")
+				os.Exit(1)
+			}
+		case "CSV":
+			replicasHistorySource = backfill.NewCSVReplicasSource(config.ReplicasHistoryCSVPath)
+		}
+		backfillService = backfill.New(usageHistorySource, replicasHistorySource, recommenderService, eventRecorder, config.GatheringDataPeriodType)
+	}
+
+	policyService := policy.New(mgr.GetClient())
+	canaryService := canary.New(mgr.GetClient(), apiReader, eventRecorder)
+	reloadedServices := &atomic.Pointer[controller.ReloadableServices]{}
+
 	if err = (&controller.TortoiseReconciler{
-		Scheme:            mgr.GetScheme(),
-		HpaService:        hpaService,
-		VpaService:        vpaClient,
-		DeploymentService: deployment.New(mgr.GetClient(), config.IstioSidecarProxyDefaultCPU, config.IstioSidecarProxyDefaultMemory, eventRecorder),
-		RecommenderService: recommender.New(
-			config.MaxReplicasRecommendationMultiplier,
-			config.MinReplicasRecommendationMultiplier,
-			config.MaximumTargetResourceUtilization,
-			config.MinimumTargetResourceUtilization,
-			config.MinimumMinReplicas,
-			config.PreferredMaxReplicas,
-			config.MinimumCPURequest,
-			config.MinimumMemoryRequest,
-			config.MinimumCPURequestPerContainer,
-			config.MinimumMemoryRequestPerContainer,
-			config.MaximumCPURequest,
-			config.MaximumMemoryRequest,
-			config.MaximumMaxReplicas,
-			config.MaxAllowedScalingDownRatio,
-			config.BufferRatioOnVerticalResource,
-			config.FeatureFlags,
-			eventRecorder,
-		),
-		TortoiseService: tortoiseService,
-		Interval:        config.TortoiseUpdateInterval,
-		EventRecorder:   eventRecorder,
+		Scheme:             mgr.GetScheme(),
+		HpaService:         hpaService,
+		VpaService:         vpaClient,
+		UsageService:       usageService,
+		BackfillService:    backfillService,
+		PolicyService:      policyService,
+		CanaryService:      canaryService,
+		RollbackService:    rollbackService,
+		OOMService:         oomService,
+		ResizeService:      resizeService,
+		WorkloadService:    workload.New(mgr.GetClient(), eventRecorder, config.IstioSidecarProxyDefaultCPU, config.IstioSidecarProxyDefaultMemory, config.ScaleSubresourceWorkloads),
+		RecommenderService: recommenderService,
+		TortoiseService:    tortoiseService,
+		Interval:           config.TortoiseUpdateInterval,
+		EventRecorder:      eventRecorder,
+		ReloadedServices:   reloadedServices,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Tortoise")
 		os.Exit(1)
 	}
-	if err = (&autoscalingv1beta3.Tortoise{}).SetupWebhookWithManager(mgr); err != nil {
+	if err = (&controller.ScheduledScalingReconciler{
+		Scheme:                  mgr.GetScheme(),
+		Interval:                config.TortoiseUpdateInterval,
+		ScheduledScalingService: scheduledscaling.New(mgr.GetClient(), eventRecorder),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ScheduledScaling")
+		os.Exit(1)
+	}
+	scaleSubresourceWorkloads := make([]autoscalingv1beta3.ScaleSubresourceWorkload, 0, len(config.ScaleSubresourceWorkloads))
+	for _, w := range config.ScaleSubresourceWorkloads {
+		scaleSubresourceWorkloads = append(scaleSubresourceWorkloads, autoscalingv1beta3.ScaleSubresourceWorkload{
+			APIVersion:      w.APIVersion,
+			Kind:            w.Kind,
+			PodTemplatePath: w.PodTemplatePathFields(),
+		})
+	}
+	if err = (&autoscalingv1beta3.Tortoise{}).SetupWebhookWithManager(mgr, scaleSubresourceWorkloads); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Tortoise")
 		os.Exit(1)
 	}
 	//+kubebuilder:scaffold:builder
 
-	hpaWebhook := autoscalingv2.New(tortoiseService, hpaService)
-
-	const (
-		defaultResyncPeriod                        = 10 * time.Minute
-		statusUpdateInterval                       = 10 * time.Second
-		scaleCacheEntryLifetime      time.Duration = time.Hour
-		scaleCacheEntryFreshnessTime time.Duration = 10 * time.Minute
-		scaleCacheEntryJitterFactor  float64       = 1.
-	)
-
-	kubeClient := kube_client.NewForConfigOrDie(mgr.GetConfig())
-	factory := informers.NewSharedInformerFactory(kubeClient, defaultResyncPeriod)
-
-	controllerFetcher := controllerfetcher.NewControllerFetcher(mgr.GetConfig(), kubeClient, factory, scaleCacheEntryFreshnessTime, scaleCacheEntryLifetime, scaleCacheEntryJitterFactor)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	controllerFetcher.Start(ctx, 1*time.Second)
-	defer cancel()
-
-	podService, err := pod.New(config.ResourceLimitMultiplier, config.MinimumCPULimit, controllerFetcher, config.FeatureFlags)
-	if err != nil {
-		setupLog.Error(err, "unable to create pod service")
-		os.Exit(1)
-	}
-	podWebhook := v1.New(tortoiseService, podService)
+	hpaWebhook := autoscalingv2.New(tortoiseService, hpaService, policyService)
+	podWebhook := v1.New(tortoiseService, podService, canaryService)
 
 	if err = ctrl.NewWebhookManagedBy(mgr).
 		WithDefaulter(hpaWebhook).
@@ -242,6 +303,30 @@ func main() {
 		os.Exit(1)
 	}
 
+	if configPath != "" {
+		reloader := &configReloader{
+			client:               mgr.GetClient(),
+			reader:               apiReader,
+			recorder:             eventRecorder,
+			controllerFetcher:    controllerFetcher,
+			backfillService:      backfillService,
+			tortoiseService:      tortoiseService,
+			verticalApplyLimiter: verticalApplyLimiter,
+			reloadedServices:     reloadedServices,
+			hpaWebhook:           hpaWebhook,
+			podWebhook:           podWebhook,
+		}
+		watcher, err := configwatcher.New(configPath, configwatcher.DefaultInterval, reloader.reload, eventRecorder, controllerPodReference())
+		if err != nil {
+			setupLog.Error(err, "unable to create config watcher")
+			os.Exit(1)
+		}
+		if err := mgr.Add(watcher); err != nil {
+			setupLog.Error(err, "unable to set up config watcher")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -257,3 +342,140 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// configServices are the services built from the config file.
+// They're rebuilt when the config file is reloaded.
+type configServices struct {
+	tortoise    *tortoise.Service
+	recommender *recommender.Service
+	hpa         *hpa.Service
+	pod         *pod.Service
+	rollback    *rollback.Service
+	oom         *oom.Service
+	resize      *resize.Service
+}
+
+// The verticalApplyLimiter is shared by the services built on every reload.
+func newConfigServices(cfg *config.Config, c client.Client, reader client.Reader, recorder record.EventRecorder, controllerFetcher controllerfetcher.ControllerFetcher, verticalApplyLimiter *rate.Limiter) (*configServices, error) {
+	tortoiseService, err := tortoise.New(c, recorder, cfg.RangeOfMinMaxReplicasRecommendationHours, cfg.TimeZone, cfg.TortoiseUpdateInterval, cfg.GatheringDataPeriodType, cfg.GlobalDisableMode, cfg.MaximumEmergencyModeDuration, verticalApplyLimiter, cfg.VerticalMaintenanceWindows, cfg.VerticalScaleUpCooldown, cfg.VerticalScaleDownCooldown)
+	if err != nil {
+		return nil, fmt.Errorf("create tortoise service: %w", err)
+	}
+
+	recommenderService, err := recommender.New(
+		cfg.MaxReplicasRecommendationMultiplier,
+		cfg.MinReplicasRecommendationMultiplier,
+		cfg.MaximumTargetResourceUtilization,
+		cfg.MinimumTargetResourceUtilization,
+		cfg.MinimumMinReplicas,
+		cfg.PreferredMaxReplicas,
+		cfg.MinimumCPURequest,
+		cfg.MinimumMemoryRequest,
+		cfg.MinimumCPURequestPerContainer,
+		cfg.MinimumMemoryRequestPerContainer,
+		cfg.MaximumCPURequest,
+		cfg.MaximumMemoryRequest,
+		cfg.MaximumMaxReplicas,
+		cfg.MaxAllowedScalingDownRatio,
+		cfg.BufferRatioOnVerticalResource,
+		cfg.VerticalMinimumChangeRatio,
+		cfg.VerticalMinimumCPUChange,
+		cfg.VerticalMinimumMemoryChange,
+		cfg.VerticalMaxStepRatio,
+		cfg.FeatureFlags,
+		recorder,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create recommender service: %w", err)
+	}
+
+	hpaService, err := hpa.New(c, recorder, cfg.ReplicaReductionFactor, cfg.MaximumTargetResourceUtilization, cfg.HPATargetUtilizationMaxIncrease, cfg.HPATargetUtilizationUpdateInterval, cfg.DefaultHPABehavior, cfg.MaximumMinReplicas, cfg.MaximumMaxReplicas, int32(cfg.MinimumMinReplicas), cfg.HPAExternalMetricExclusionRegex, cfg.EmergencyModeGracePeriod, cfg.GlobalDisableMode)
+	if err != nil {
+		return nil, fmt.Errorf("create hpa service: %w", err)
+	}
+
+	podService, err := pod.New(cfg.ResourceLimitMultiplier, cfg.MinimumCPULimit, controllerFetcher, cfg.FeatureFlags, cfg.ScaleSubresourceWorkloads)
+	if err != nil {
+		return nil, fmt.Errorf("create pod service: %w", err)
+	}
+
+	return &configServices{
+		tortoise:    tortoiseService,
+		recommender: recommenderService,
+		hpa:         hpaService,
+		pod:         podService,
+		rollback:    rollback.New(reader, recorder, cfg.VerticalRollbackWindow, cfg.VerticalRollbackFloorDecayWindow),
+		oom:         oom.New(reader, recorder, cfg.OOMMemoryBumpRatio, cfg.VerticalRollbackFloorDecayWindow),
+		resize:      resize.New(c, reader, recorder, podService, cfg.VerticalRolloutStrategy),
+	}, nil
+}
+
+// configReloader swaps the services in the controller and the webhooks when the config file is reloaded.
+//
+// The configurations used to set up the manager, the usage sources and the backfill sources
+// (e.g., VerticalRecommender, PrometheusAddress) still need the restart to be applied.
+type configReloader struct {
+	client            client.Client
+	reader            client.Reader
+	recorder          record.EventRecorder
+	controllerFetcher controllerfetcher.ControllerFetcher
+	// backfillService is nil when the backfill is disabled.
+	backfillService *backfill.Service
+	// tortoiseService is the one built at the startup, which the state is carried over from on the first reload.
+	tortoiseService *tortoise.Service
+	// verticalApplyLimiter is updated in place so that the reload doesn't reset the consumed tokens.
+	verticalApplyLimiter *rate.Limiter
+	reloadedServices     *atomic.Pointer[controller.ReloadableServices]
+	hpaWebhook           *autoscalingv2.HPAWebhook
+	podWebhook           *v1.PodWebhook
+}
+
+func (r *configReloader) reload(cfg *config.Config) error {
+	services, err := newConfigServices(cfg, r.client, r.reader, r.recorder, r.controllerFetcher, r.verticalApplyLimiter)
+	if err != nil {
+		return err
+	}
+	tortoise.UpdateVerticalApplyLimiter(r.verticalApplyLimiter, cfg.VerticalApplyRateLimit, cfg.VerticalApplyBurst)
+
+	// Carry over the last update time of each Tortoise so that all the Tortoises aren't reconciled at once after the reload.
+	current := r.tortoiseService
+	if s := r.reloadedServices.Load(); s != nil {
+		current = s.TortoiseService
+	}
+	services.tortoise.InheritState(current)
+
+	var backfillService *backfill.Service
+	if r.backfillService != nil {
+		backfillService = r.backfillService.WithRecommender(services.recommender)
+	}
+
+	r.reloadedServices.Store(&controller.ReloadableServices{
+		HpaService:         services.hpa,
+		RecommenderService: services.recommender,
+		TortoiseService:    services.tortoise,
+		RollbackService:    services.rollback,
+		OOMService:         services.oom,
+		ResizeService:      services.resize,
+		BackfillService:    backfillService,
+		Interval:           cfg.TortoiseUpdateInterval,
+	})
+	r.hpaWebhook.Reload(services.tortoise, services.hpa)
+	r.podWebhook.Reload(services.tortoise, services.pod)
+
+	return nil
+}
+
+// controllerPodReference returns the reference to the Pod running this controller, which the config reload events are recorded on.
+// It returns nil when POD_NAME or POD_NAMESPACE isn't given via the downward API.
+func controllerPodReference() runtime.Object {
+	name, namespace := os.Getenv("POD_NAME"), os.Getenv("POD_NAMESPACE")
+	if name == "" || namespace == "" {
+		return nil
+	}
+	return &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       name,
+		Namespace:  namespace,
+	}
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/util/homedir"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe tortoise",
+	Short: "show the details of a tortoise",
+	Long: `describe is the command to show the details of a tortoise.
+
+Per container and resource, it shows
+- DECLARED: the resource request declared in the workload (Deployment, StatefulSet, DaemonSet,
+  or the custom workload in ScaleSubresourceWorkloads of the controller config given with --config).
+- APPLIED: the resource request that tortoise currently applies to the Pods.
+- VPA MAX RECOMMENDATION: the max recommendation from VPA in the past, which tortoise generates the recommendation from.
+- HPA TARGET: the target utilization that tortoise recommends to HPA.
+
+It also shows the recent events on the tortoise.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// validation
+		if describeNamespace == "" {
+			return fmt.Errorf("namespace must be specified")
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("one tortoise name must be specified")
+		}
+
+		d, err := newDescriber()
+		if err != nil {
+			return err
+		}
+
+		if err := d.Describe(cmd.Context(), args[0], describeNamespace, os.Stdout); err != nil {
+			return fmt.Errorf("failed to describe tortoise: %v", err)
+		}
+
+		return nil
+	},
+}
+
+var (
+	// namespace to describe tortoise in
+	describeNamespace string
+)
+
+func init() {
+	rootCmd.AddCommand(describeCmd)
+
+	if home := homedir.HomeDir(); home != "" {
+		describeCmd.Flags().StringVar(&kubeconfig, "kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		describeCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+
+	describeCmd.Flags().StringVarP(&describeNamespace, "namespace", "n", "", "namespace to describe tortoise in")
+	describeCmd.Flags().StringVar(&controllerConfigPath, "config", "", "(optional) path to the config file of the tortoise controller, which has ScaleSubresourceWorkloads for the custom workloads. The default values are used if it's not specified.")
+}
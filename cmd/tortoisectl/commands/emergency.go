@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/pkg/emergency"
+)
+
+var emergencyCmd = &cobra.Command{
+	Use:   "emergency on|off tortoise",
+	Short: "turn on/off Emergency mode of tortoise",
+	Long: `emergency is the command to turn on/off Emergency mode of tortoise.
+
+"on" changes the tortoise updateMode to "Emergency", and tortoise increases the number of replicas high enough.
+It's only available for the tortoise in Working phase, which has the horizontal autoscaling policy.
+With the --for flag, the controller turns off Emergency mode automatically after the duration.
+Otherwise, Emergency mode continues until you turn it off.
+
+"off" changes the tortoise updateMode back to "Auto", and the number of replicas is gradually reduced.
+
+See https://github.com/mercari/tortoise/blob/main/docs/emergency.md to know more about Emergency mode.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// validation
+		if emergencyNamespace == "" {
+			return fmt.Errorf("namespace must be specified")
+		}
+		if len(args) != 2 {
+			return fmt.Errorf("on or off, and one tortoise name must be specified")
+		}
+		if args[0] != "on" && args[0] != "off" {
+			return fmt.Errorf("the first argument should be on or off")
+		}
+		if emergencyFor < 0 {
+			return fmt.Errorf("--for shouldn't be negative")
+		}
+		if args[0] == "off" && emergencyFor != 0 {
+			return fmt.Errorf("--for is only available with on")
+		}
+
+		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to build config: %v", err)
+		}
+
+		client, err := client.New(config, client.Options{
+			Scheme: scheme,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create client: %v", err)
+		}
+
+		s := emergency.New(client)
+		if args[0] == "on" {
+			err = s.On(cmd.Context(), args[1], emergencyNamespace, emergencyFor, time.Now(), os.Stdout)
+		} else {
+			err = s.Off(cmd.Context(), args[1], emergencyNamespace, os.Stdout)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to turn %s emergency mode: %v", args[0], err)
+		}
+
+		return nil
+	},
+}
+
+var (
+	// namespace of the tortoise
+	emergencyNamespace string
+	// the duration of Emergency mode
+	emergencyFor time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(emergencyCmd)
+
+	if home := homedir.HomeDir(); home != "" {
+		emergencyCmd.Flags().StringVar(&kubeconfig, "kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		emergencyCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+
+	emergencyCmd.Flags().StringVarP(&emergencyNamespace, "namespace", "n", "", "namespace of the tortoise")
+	emergencyCmd.Flags().DurationVar(&emergencyFor, "for", 0, "(optional) the duration of Emergency mode, e.g., 2h. The controller turns off Emergency mode automatically after the duration.")
+}
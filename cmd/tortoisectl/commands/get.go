@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/describer"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get [tortoise1 tortoise2...]",
+	Short: "show the summary of tortoise(s)",
+	Long: `get is the command to show the summary of tortoise(s) in a table;
+the phase, the update mode, the recommended HPA target utilization and the applied resource requests per container.
+
+If no tortoise name is given, it shows all tortoises in the namespace, or in all namespaces with the --all flag.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// validation
+		if getAll {
+			if len(args) != 0 {
+				return fmt.Errorf("tortoise name shouldn't be specified because of --all flag")
+			}
+		} else if getNamespace == "" {
+			return fmt.Errorf("namespace must be specified")
+		}
+
+		d, err := newDescriber()
+		if err != nil {
+			return err
+		}
+
+		if err := d.Get(cmd.Context(), args, getNamespace, getAll, os.Stdout); err != nil {
+			return fmt.Errorf("failed to get tortoise(s): %v", err)
+		}
+
+		return nil
+	},
+}
+
+var (
+	// namespace to get tortoise(s) in
+	getNamespace string
+	// get all tortoises in all namespaces.
+	getAll bool
+)
+
+func newDescriber() (*describer.Describer, error) {
+	controllerConfig, err := config.ParseConfig(controllerConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the controller config: %v", err)
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build config: %v", err)
+	}
+
+	client, err := client.New(config, client.Options{
+		Scheme: scheme,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %v", err)
+	}
+
+	recorder := record.NewBroadcaster().NewRecorder(scheme, corev1.EventSource{Component: "tortoisectl"})
+	workloadService := workload.New(client, recorder, controllerConfig.IstioSidecarProxyDefaultCPU, controllerConfig.IstioSidecarProxyDefaultMemory, controllerConfig.ScaleSubresourceWorkloads)
+	return describer.New(client, workloadService), nil
+}
+
+func init() {
+	rootCmd.AddCommand(getCmd)
+
+	if home := homedir.HomeDir(); home != "" {
+		getCmd.Flags().StringVar(&kubeconfig, "kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		getCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+
+	getCmd.Flags().StringVarP(&getNamespace, "namespace", "n", "", "namespace to get tortoise(s) in")
+	getCmd.Flags().BoolVarP(&getAll, "all", "A", false, "get all tortoises in all namespaces.")
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kyokomi/emoji/v2"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/migrator"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate --deployment deployment",
+	Short: "generate the tortoise manifest for the existing deployment and its HPA",
+	Long: `migrate is the command to generate the tortoise manifest that takes over the existing deployment and its HPA.
+
+It reads the deployment and the HPA targeting it, and writes the tortoise manifest to stdout.
+.spec.targetRefs.horizontalPodAutoscalerName is set to the HPA, and .spec.autoscalingPolicy is left empty
+so that tortoise derives the autoscaling policy from the HPA;
+"Horizontal" for the resources that the HPA has the ContainerResource metrics for, and "Vertical" for the others.
+The derived policy is shown to stderr as the preview.
+The tortoise is "Off" so that you can check the recommendation before turning it on.
+
+It also warns about the HPA metrics that tortoise would remove or leave as they are, e.g., the External metrics matching HPAExternalMetricExclusionRegex.
+Pass the config file of the tortoise controller via --config to check HPAExternalMetricExclusionRegex.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// validation
+		if migrateNamespace == "" {
+			return fmt.Errorf("namespace must be specified")
+		}
+		if migrateDeployment == "" {
+			return fmt.Errorf("deployment must be specified")
+		}
+		if len(args) != 0 {
+			return fmt.Errorf("no argument is allowed, use --deployment to specify the deployment")
+		}
+		name := migrateTortoiseName
+		if name == "" {
+			name = migrateDeployment
+		}
+
+		controllerConfig, err := config.ParseConfig(controllerConfigPath)
+		if err != nil {
+			return fmt.Errorf("failed to load the controller config: %v", err)
+		}
+
+		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to build config: %v", err)
+		}
+
+		client, err := client.New(config, client.Options{
+			Scheme: scheme,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create client: %v", err)
+		}
+
+		m, err := migrator.New(client, controllerConfig.HPAExternalMetricExclusionRegex)
+		if err != nil {
+			return fmt.Errorf("failed to create migrator: %v", err)
+		}
+
+		manifest, policy, warnings, err := m.Migrate(cmd.Context(), migrateDeployment, migrateNamespace, name)
+		if err != nil {
+			return fmt.Errorf("failed to migrate deployment: %v", err)
+		}
+
+		// The preview and the warnings go to stderr so that stdout can be piped to kubectl apply.
+		if len(policy) != 0 {
+			fmt.Fprintf(os.Stderr, "%s tortoise will derive the autoscaling policy from the HPA %s:\n", emoji.Sprint(":turtle:"), *manifest.Spec.TargetRefs.HorizontalPodAutoscalerName)
+			for _, p := range policy {
+				fmt.Fprintf(os.Stderr, "  container %s, cpu: %s, memory: %s\n", p.ContainerName, p.Policy[corev1.ResourceCPU], p.Policy[corev1.ResourceMemory])
+			}
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "%s %s\n", emoji.Sprint(":warning:"), w)
+		}
+
+		y, err := manifest.YAML()
+		if err != nil {
+			return fmt.Errorf("failed to marshal tortoise: %v", err)
+		}
+		fmt.Fprint(os.Stdout, string(y))
+
+		return nil
+	},
+}
+
+var (
+	// namespace of the deployment to migrate
+	migrateNamespace string
+	// the deployment to migrate
+	migrateDeployment string
+	// the name of the generated tortoise
+	migrateTortoiseName string
+)
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	if home := homedir.HomeDir(); home != "" {
+		migrateCmd.Flags().StringVar(&kubeconfig, "kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		migrateCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+
+	migrateCmd.Flags().StringVarP(&migrateNamespace, "namespace", "n", "", "namespace of the deployment to migrate")
+	migrateCmd.Flags().StringVar(&migrateDeployment, "deployment", "", "the deployment to migrate")
+	migrateCmd.Flags().StringVar(&migrateTortoiseName, "name", "", "(optional) the name of the generated tortoise. The deployment name is used if it's not specified.")
+	migrateCmd.Flags().StringVar(&controllerConfigPath, "config", "", "(optional) path to the config file of the tortoise controller. The default values are used if it's not specified.")
+}
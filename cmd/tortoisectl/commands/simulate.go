@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/simulator"
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate tortoise",
+	Short: "simulate what tortoise would do, without changing anything",
+	Long: `simulate is the command to see what tortoise would do before you turn it on, e.g., change an "Off" tortoise to "Auto".
+
+It runs one recommendation cycle of the tortoise controller against the copies of the live objects,
+and shows the resulting HPA spec and the diff of the Pod resources.
+Nothing is written to the cluster.
+
+The recommendation is calculated with the configuration given via --config, which should be the same as the tortoise controller's one.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// validation
+		if simulateNamespace == "" {
+			return fmt.Errorf("namespace must be specified")
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("one tortoise name must be specified")
+		}
+		mode := v1beta3.UpdateMode(simulateUpdateMode)
+		if mode != v1beta3.UpdateModeAuto && mode != v1beta3.UpdateModeOff && mode != v1beta3.UpdateModeEmergency {
+			return fmt.Errorf("--update-mode should be one of Auto, Off or Emergency")
+		}
+
+		controllerConfig, err := config.ParseConfig(controllerConfigPath)
+		if err != nil {
+			return fmt.Errorf("failed to load the controller config: %v", err)
+		}
+
+		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to build config: %v", err)
+		}
+
+		client, err := client.New(config, client.Options{
+			Scheme: scheme,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create client: %v", err)
+		}
+
+		s, err := simulator.New(client, controllerConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create simulator: %v", err)
+		}
+
+		if err := s.Simulate(cmd.Context(), args[0], simulateNamespace, mode, time.Now(), os.Stdout); err != nil {
+			return fmt.Errorf("failed to simulate tortoise: %v", err)
+		}
+
+		return nil
+	},
+}
+
+var (
+	// namespace to simulate tortoise in
+	simulateNamespace string
+	// the updateMode which the tortoise is simulated with.
+	simulateUpdateMode string
+	// Path to the config file of the tortoise controller.
+	controllerConfigPath string
+)
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+
+	if home := homedir.HomeDir(); home != "" {
+		simulateCmd.Flags().StringVar(&kubeconfig, "kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		simulateCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+
+	simulateCmd.Flags().StringVarP(&simulateNamespace, "namespace", "n", "", "namespace to simulate tortoise in")
+	simulateCmd.Flags().StringVar(&simulateUpdateMode, "update-mode", string(v1beta3.UpdateModeAuto), "the updateMode which the tortoise is simulated with.")
+	simulateCmd.Flags().StringVar(&controllerConfigPath, "config", "", "(optional) path to the config file of the tortoise controller. The default values are used if it's not specified.")
+}
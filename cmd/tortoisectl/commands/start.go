@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/pod"
+	"github.com/mercari/tortoise/pkg/starter"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start tortoise1 tortoise2...",
+	Short: "start tortoise(s) safely",
+	Long: `start is the command to turn on tortoise(s), which are stopped by "tortoisectl stop" or manually, safely.
+
+It changes the tortoise updateMode to "Auto", and the tortoise controller restarts the deployment to apply the recommendation.
+Before that, it shows which containers' resource requests would be changed; you can only see the preview with the --dry-run flag.
+
+With the --only-increase flag, it patches the workload to apply only the increases of the resource requests, and keeps the tortoise "Off".
+The custom workloads with the scale subresource are supported when they're configured in the controller config given with --config.
+It's useful when the recommendation is much lower than what the Pods are running with now;
+you can increase the resources first, and run start again without --only-increase once the Pods get stable.
+
+When multiple tortoises are started (e.g., with --all), it waits for --restart-interval between the restarts of the deployments
+so that many Pods aren't restarted at once.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// validation
+		if startAll {
+			if len(args) != 0 {
+				return fmt.Errorf("tortoise name shouldn't be specified because of --all flag")
+			}
+		} else {
+			if startNamespace == "" {
+				return fmt.Errorf("namespace must be specified")
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("tortoise name must be specified")
+			}
+		}
+		if restartInterval < 0 {
+			return fmt.Errorf("--restart-interval shouldn't be negative")
+		}
+
+		controllerConfig, err := config.ParseConfig(controllerConfigPath)
+		if err != nil {
+			return fmt.Errorf("failed to load the controller config: %v", err)
+		}
+
+		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to build config: %v", err)
+		}
+
+		client, err := client.New(config, client.Options{
+			Scheme: scheme,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create client: %v", err)
+		}
+
+		recorder := record.NewBroadcaster().NewRecorder(scheme, corev1.EventSource{Component: "tortoisectl"})
+		workloadService := workload.New(client, recorder, controllerConfig.IstioSidecarProxyDefaultCPU, controllerConfig.IstioSidecarProxyDefaultMemory, controllerConfig.ScaleSubresourceWorkloads)
+		podService, err := pod.New(map[string]int64{}, "", nil, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create pod service: %v", err)
+		}
+
+		starterService := starter.New(client, workloadService, podService)
+
+		opts := []starter.StartrOption{}
+		if startDryRun {
+			opts = append(opts, starter.DryRun)
+		}
+		if onlyIncrease {
+			opts = append(opts, starter.OnlyIncrease)
+		}
+
+		err = starterService.Start(cmd.Context(), args, startNamespace, startAll, restartInterval, os.Stdout, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to start tortoise(s): %v", err)
+		}
+
+		return nil
+	},
+}
+
+var (
+	// namespace to start tortoise(s) in
+	startNamespace string
+	// start all tortoises in the specified namespace, or in all namespaces if no namespace is specified.
+	startAll bool
+	// only show the preview of the resource request changes.
+	startDryRun bool
+	// patch the workload to apply only the increases of the resource requests, and keep tortoise Off.
+	onlyIncrease bool
+	// the interval between the restarts of the deployments when multiple tortoises are started.
+	restartInterval time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+
+	if home := homedir.HomeDir(); home != "" {
+		startCmd.Flags().StringVar(&kubeconfig, "kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		startCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+
+	startCmd.Flags().StringVarP(&startNamespace, "namespace", "n", "", "namespace to start tortoise(s) in")
+	startCmd.Flags().BoolVarP(&startAll, "all", "A", false, "start all tortoises in the specified namespace, or in all namespaces if no namespace is specified.")
+	startCmd.Flags().BoolVar(&startDryRun, "dry-run", false, "only show which containers' resource requests would be changed, without changing anything.")
+	startCmd.Flags().BoolVar(&onlyIncrease, "only-increase", false, `Patch the workload to apply only the increases of the resource requests, and keep tortoise Off.
+Run start again without this flag once the Pods get stable with the increased resources.`)
+	startCmd.Flags().StringVar(&controllerConfigPath, "config", "", "(optional) path to the config file of the tortoise controller, which has ScaleSubresourceWorkloads for the custom workloads. The default values are used if it's not specified.")
+	startCmd.Flags().DurationVar(&restartInterval, "restart-interval", 30*time.Second, "the interval between the restarts of the deployments when multiple tortoises are started.")
+}
@@ -15,6 +15,7 @@ import (
 	"k8s.io/client-go/util/homedir"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	autoscalingv1alpha1 "github.com/mercari/tortoise/api/v1alpha1"
 	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/deployment"
 	"github.com/mercari/tortoise/pkg/pod"
@@ -62,8 +63,8 @@ it'd patch the deployment to 2 CPU request to prevent a possible negative impact
 		}
 
 		recorder := record.NewBroadcaster().NewRecorder(scheme, corev1.EventSource{Component: "tortoisectl"})
-		deploymentService := deployment.New(client, "", "", recorder)
-		podService, err := pod.New(map[string]int64{}, "", nil, nil)
+		deploymentService := deployment.New(client, recorder)
+		podService, err := pod.New(map[string]int64{}, "", nil, nil, nil)
 		if err != nil {
 			return fmt.Errorf("failed to create pod service: %v", err)
 		}
@@ -103,6 +104,7 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(autoscalingv1beta3.AddToScheme(scheme))
+	utilruntime.Must(autoscalingv1alpha1.AddToScheme(scheme))
 
 	rootCmd.AddCommand(stopCmd)
 
@@ -0,0 +1,114 @@
+/*
+MIT License
+
+Copyright (c) 2023 mercari
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	autoscalingv1alpha1 "github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/pkg/scheduledscaling"
+)
+
+// ScheduledScalingReconciler reconciles a ScheduledScaling object
+type ScheduledScalingReconciler struct {
+	Scheme *runtime.Scheme
+
+	// Interval is the interval to retry when the target Tortoise is not found.
+	Interval time.Duration
+
+	ScheduledScalingService *scheduledscaling.Service
+}
+
+//+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=scheduledscalings,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=scheduledscalings/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=scheduledscalings/finalizers,verbs=update
+
+func (r *ScheduledScalingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	now := time.Now()
+	if onlyTestNow != nil {
+		now = *onlyTestNow
+	}
+
+	ss, err := r.ScheduledScalingService.GetScheduledScaling(ctx, req.NamespacedName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("scheduled scaling is not found", "scheduledscaling", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !ss.DeletionTimestamp.IsZero() {
+		// Remove the constraints given by this ScheduledScaling, if any, before it's gone.
+		if err := r.ScheduledScalingService.SyncTortoise(ctx, ss.Namespace, ss.Spec.TargetRefs.TortoiseName, now); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("sync tortoise: %w", err)
+		}
+		if err := r.ScheduledScalingService.RemoveFinalizer(ctx, ss); err != nil {
+			return ctrl.Result{}, fmt.Errorf("remove finalizer: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.ScheduledScalingService.AddFinalizer(ctx, ss); err != nil {
+		return ctrl.Result{}, fmt.Errorf("add finalizer: %w", err)
+	}
+
+	phase, requeueAfter := scheduledscaling.Phase(ss, now)
+	reason := ""
+	if err := r.ScheduledScalingService.SyncTortoise(ctx, ss.Namespace, ss.Spec.TargetRefs.TortoiseName, now); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("sync tortoise: %w", err)
+		}
+		if phase != autoscalingv1alpha1.ScheduledScalingPhaseFinished {
+			// The tortoise may be created later.
+			logger.Info("the target tortoise is not found", "scheduledscaling", req.NamespacedName, "tortoise", ss.Spec.TargetRefs.TortoiseName)
+			phase = autoscalingv1alpha1.ScheduledScalingPhaseFailed
+			reason = fmt.Sprintf("the target tortoise %s is not found", ss.Spec.TargetRefs.TortoiseName)
+			requeueAfter = min(requeueAfter, r.Interval)
+		}
+	}
+
+	if err := r.ScheduledScalingService.UpdateScheduledScalingStatus(ctx, ss, phase, reason, now); err != nil {
+		return ctrl.Result{}, fmt.Errorf("update scheduled scaling status: %w", err)
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScheduledScalingReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&autoscalingv1alpha1.ScheduledScaling{}).
+		Complete(r)
+}
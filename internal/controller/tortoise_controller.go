@@ -29,23 +29,34 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sync/atomic"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/mercari/tortoise/api/v1alpha1"
 	"github.com/mercari/tortoise/api/v1beta3"
 	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
-	"github.com/mercari/tortoise/pkg/deployment"
+	"github.com/mercari/tortoise/pkg/backfill"
+	"github.com/mercari/tortoise/pkg/canary"
 	"github.com/mercari/tortoise/pkg/hpa"
 	"github.com/mercari/tortoise/pkg/metrics"
+	"github.com/mercari/tortoise/pkg/oom"
+	"github.com/mercari/tortoise/pkg/policy"
 	"github.com/mercari/tortoise/pkg/recommender"
+	"github.com/mercari/tortoise/pkg/resize"
+	"github.com/mercari/tortoise/pkg/rollback"
 	tortoiseService "github.com/mercari/tortoise/pkg/tortoise"
+	"github.com/mercari/tortoise/pkg/usage"
+	"github.com/mercari/tortoise/pkg/utils"
 	"github.com/mercari/tortoise/pkg/vpa"
+	"github.com/mercari/tortoise/pkg/workload"
 )
 
 // TortoiseReconciler reconciles a Tortoise object
@@ -54,12 +65,44 @@ type TortoiseReconciler struct {
 
 	Interval time.Duration
 
-	HpaService         *hpa.Service
-	VpaService         *vpa.Service
-	DeploymentService  *deployment.Service
+	HpaService *hpa.Service
+	VpaService *vpa.Service
+	// UsageService is the in-process recommender. When it's not nil, it's used instead of the monitor VPA.
+	UsageService *usage.Service
+	// BackfillService backfills the recommendation from the historical usage. It's nil when the backfill is disabled.
+	BackfillService *backfill.Service
+	// PolicyService resolves the TortoisePolicies overriding the global configurations for each tortoise.
+	PolicyService *policy.Service
+	// CanaryService rolls out the new resource requests via the canary Pods for the tortoises opting in to it.
+	CanaryService *canary.Service
+	// RollbackService rolls back the resource requests when the Pods get unhealthy after they're decreased.
+	RollbackService *rollback.Service
+	// OOMService records the OOMKills of the containers to raise their memory recommendation.
+	OOMService *oom.Service
+	// ResizeService resizes the Pods in place instead of restarting the workload for the tortoises with the InPlace strategy.
+	ResizeService      *resize.Service
+	WorkloadService    *workload.Service
 	TortoiseService    *tortoiseService.Service
 	RecommenderService *recommender.Service
 	EventRecorder      record.EventRecorder
+	// ReloadedServices holds the services rebuilt from the reloaded config file.
+	// When it holds the services, they're used instead of the above ones.
+	// It's nil when the config file isn't watched.
+	ReloadedServices *atomic.Pointer[ReloadableServices]
+}
+
+// ReloadableServices are the services built from the config file, which are swapped together when the config file is reloaded.
+type ReloadableServices struct {
+	HpaService         *hpa.Service
+	RecommenderService *recommender.Service
+	TortoiseService    *tortoiseService.Service
+	RollbackService    *rollback.Service
+	OOMService         *oom.Service
+	ResizeService      *resize.Service
+	// BackfillService is nil when the backfill is disabled.
+	BackfillService *backfill.Service
+	// Interval is the reloaded TortoiseUpdateInterval.
+	Interval time.Duration
 }
 
 var (
@@ -74,20 +117,63 @@ var (
 //+kubebuilder:rbac:groups=autoscaling.k8s.io,resources=verticalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=autoscaling.k8s.io,resources=verticalpodautoscalers/status,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;update;patch
-
-// Tortoise only supports the deployment at the moment though, will support them too in the future.
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;delete
+//+kubebuilder:rbac:groups=core,resources=pods/eviction,verbs=create
+//+kubebuilder:rbac:groups=core,resources=pods/resize,verbs=patch
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+//+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=tortoisepolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// Tortoise doesn't support the below resources as the scale target though, will support them too in the future.
 // At the moment, we only need a read permission for the below resources to run the controller fetcher.
 
-//+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch
-//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=replicationcontrollers,verbs=get;list;watch
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch
 //+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch
 
+// withOverrides returns a copy of the reconciler whose services use the configurations overridden by the TortoisePolicies.
+func (r *TortoiseReconciler) withOverrides(o v1alpha1.ConfigOverrides) *TortoiseReconciler {
+	copied := *r
+	copied.HpaService = r.HpaService.WithOverrides(o)
+	copied.RecommenderService = r.RecommenderService.WithOverrides(o)
+	if r.BackfillService != nil {
+		copied.BackfillService = r.BackfillService.WithRecommender(copied.RecommenderService)
+	}
+	return &copied
+}
+
+// withReloadedServices returns a copy of the reconciler using the services rebuilt from the reloaded config file.
+// It returns the reconciler as it is when the config file hasn't been reloaded.
+func (r *TortoiseReconciler) withReloadedServices() *TortoiseReconciler {
+	if r.ReloadedServices == nil {
+		return r
+	}
+	s := r.ReloadedServices.Load()
+	if s == nil {
+		return r
+	}
+
+	copied := *r
+	copied.HpaService = s.HpaService
+	copied.RecommenderService = s.RecommenderService
+	copied.TortoiseService = s.TortoiseService
+	copied.RollbackService = s.RollbackService
+	copied.OOMService = s.OOMService
+	copied.ResizeService = s.ResizeService
+	copied.BackfillService = s.BackfillService
+	copied.Interval = s.Interval
+	return &copied
+}
+
 func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	// Take the services at once so that one reconciliation isn't affected by the config reload in the middle of it.
+	r = r.withReloadedServices()
 	logger := log.FromContext(ctx)
 	now := time.Now()
 	if onlyTestNow != nil {
@@ -118,6 +204,7 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		}
 
 		metrics.RecordTortoise(tortoise, true)
+		metrics.RecordEmergencyModeDuration(tortoise, true, now)
 		return ctrl.Result{RequeueAfter: r.Interval}, nil
 	}
 
@@ -133,6 +220,7 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 			metrics.RecordTortoise(oldTortoise, true)
 		}
 		metrics.RecordTortoise(tortoise, false)
+		metrics.RecordEmergencyModeDuration(tortoise, false, now)
 
 		tortoise = r.TortoiseService.RecordReconciliationFailure(tortoise, reterr, now)
 		_, err = r.TortoiseService.UpdateTortoiseStatus(ctx, tortoise, now, false)
@@ -147,33 +235,38 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{RequeueAfter: requeueAfter}, nil
 	}
 
-	// TODO: stop depending on deployment.
-	// https://github.com/mercari/tortoise/issues/129
-	//
-	// Currently, we don't depend on the deployment on almost all cases,
-	// but we need to get the number of replicas from it + we need to take resource requests of each container when initializing tortoises.
-	// We should be able to eventually remove this dependency by using the number of replicas from scale subresource.
-	dm, err := r.DeploymentService.GetDeploymentOnTortoise(ctx, tortoise)
+	if r.PolicyService != nil {
+		overrides, err := r.PolicyService.Resolve(ctx, tortoise.Namespace)
+		if err != nil {
+			logger.Error(err, "failed to resolve the tortoise policies", "tortoise", req.NamespacedName)
+			return ctrl.Result{}, err
+		}
+		// The rest of this reconciliation uses the services with the configurations overridden by the TortoisePolicies.
+		r = r.withOverrides(overrides)
+	}
+
+	// We need to get the number of replicas from the workload + we need to take resource requests of each container when initializing tortoises.
+	w, err := r.WorkloadService.GetWorkloadOnTortoise(ctx, tortoise)
 	if err != nil {
-		logger.Error(err, "failed to get deployment", "tortoise", req.NamespacedName)
+		logger.Error(err, "failed to get the workload", "tortoise", req.NamespacedName)
 		return ctrl.Result{}, err
 	}
-	if dm.Spec.Replicas == nil {
-		logger.Error(nil, "the deployment doesn't have the number of replicas and tortoise cannot calculate the recommendation", "tortoise", req.NamespacedName, "deployment", klog.KObj(dm))
+	if w.Replicas == nil {
+		logger.Error(nil, "the workload doesn't have the number of replicas and tortoise cannot calculate the recommendation", "tortoise", req.NamespacedName, "kind", tortoise.Spec.TargetRefs.ScaleTargetRef.Kind, "workload", klog.KObj(w.Object))
 		return ctrl.Result{}, nil
 
 	}
 
-	currentDesiredReplicaNum := *dm.Spec.Replicas // Use the desired replica number.
+	currentDesiredReplicaNum := *w.Replicas // Use the desired replica number.
 
 	if tortoise.Spec.UpdateMode == autoscalingv1beta3.UpdateModeOff /* When Off, ContainerResourceRequests should be reset */ ||
 		tortoise.Status.Conditions.ContainerResourceRequests == nil /* The first reconciliation */ {
-		// If the update mode is off, we have to update ContainerResourceRequests from the deployment directly
+		// If the update mode is off, we have to update ContainerResourceRequests from the workload directly
 		// so that pods will get an original resource request.
 		// If it's not off, ContainerResourceRequests should be updated in UpdateVPAFromTortoiseRecommendation in the last reconciliation.
-		acr, err := r.DeploymentService.GetResourceRequests(dm)
+		acr, err := r.WorkloadService.GetResourceRequests(w)
 		if err != nil {
-			logger.Error(err, "failed to get resource requests in deployment", "tortoise", req.NamespacedName, "deployment", klog.KObj(dm))
+			logger.Error(err, "failed to get resource requests in the workload", "tortoise", req.NamespacedName, "kind", tortoise.Spec.TargetRefs.ScaleTargetRef.Kind, "workload", klog.KObj(w.Object))
 			return ctrl.Result{}, err
 		}
 		tortoise.Status.Conditions.ContainerResourceRequests = acr
@@ -186,6 +279,12 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 	}
 	tortoise = tortoiseService.UpdateTortoiseAutoscalingPolicyInStatus(tortoise, hpa, now)
 
+	tortoise, err = r.TortoiseService.ExpireEmergencyMode(ctx, tortoise, now)
+	if err != nil {
+		logger.Error(err, "failed to expire emergency mode", "tortoise", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
 	tortoise = r.TortoiseService.UpdateTortoisePhase(tortoise, now)
 	if tortoise.Status.TortoisePhase == autoscalingv1beta3.TortoisePhaseInitializing {
 		logger.Info("initializing tortoise", "tortoise", req.NamespacedName)
@@ -209,13 +308,13 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{}, err
 	}
 
-	monitorvpa, ready, err := r.VpaService.GetTortoiseMonitorVPA(ctx, tortoise)
+	verticalRecommendation, ready, err := r.getVerticalRecommendation(ctx, tortoise, w, now)
 	if err != nil {
-		logger.Error(err, "failed to get tortoise VPA", "tortoise", req.NamespacedName)
+		logger.Error(err, "failed to get the vertical recommendation", "tortoise", req.NamespacedName)
 		return ctrl.Result{}, err
 	}
 	if !ready {
-		logger.Info("VPA created by tortoise isn't ready yet", "tortoise", req.NamespacedName)
+		logger.Info("the vertical recommendation isn't ready yet", "tortoise", req.NamespacedName)
 		_, err = r.TortoiseService.UpdateTortoiseStatus(ctx, tortoise, now, true)
 		if err != nil {
 			logger.Error(err, "update Tortoise status", "tortoise", req.NamespacedName)
@@ -224,16 +323,10 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{RequeueAfter: r.Interval}, nil
 	}
 
-	_, err = r.VpaService.UpdateVPAContainerResourcePolicy(ctx, tortoise, monitorvpa)
-	if err != nil {
-		logger.Error(err, "update VPA Container Resource Policy", "tortoise", req.NamespacedName)
-		return ctrl.Result{}, err
-	}
-
-	// VPA is ready, we mark all Vertical scaling resources as Running.
+	// The vertical recommendation is ready, we mark all Vertical scaling resources as Running.
 	tortoise = vpa.SetAllVerticalContainerResourcePhaseWorking(tortoise, now)
 
-	logger.Info("VPA created by tortoise is ready, proceeding to generate the recommendation", "tortoise", req.NamespacedName)
+	logger.Info("the vertical recommendation is ready, proceeding to generate the recommendation", "tortoise", req.NamespacedName)
 	hpa, isReady, err := r.HpaService.GetHPAOnTortoise(ctx, tortoise)
 	if err != nil {
 		logger.Error(err, "failed to get HPA", "tortoise", req.NamespacedName)
@@ -253,7 +346,18 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{}, err
 	}
 
-	tortoise = r.TortoiseService.UpdateContainerRecommendationFromVPA(tortoise, monitorvpa, now)
+	tortoise = r.TortoiseService.UpdateContainerRecommendationFromVPA(tortoise, verticalRecommendation, now)
+	if r.BackfillService != nil {
+		tortoise = r.BackfillService.BackfillReplicasRecommendation(ctx, tortoise, now)
+		tortoise = r.BackfillService.BackfillContainerRecommendation(ctx, tortoise, now)
+	}
+
+	tortoise, err = r.OOMService.RaiseFloorsOnOOMKills(ctx, tortoise, w, now)
+	if err != nil {
+		logger.Error(err, "failed to raise the floors on OOMKills", "tortoise", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+	tortoise = r.RollbackService.ExpireFloors(tortoise, now)
 
 	tortoise, err = r.RecommenderService.UpdateRecommendations(ctx, tortoise, hpa, currentDesiredReplicaNum, now)
 	if err != nil {
@@ -278,7 +382,7 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{}, err
 	}
 
-	tortoise, err = r.TortoiseService.UpdateResourceRequest(ctx, tortoise, currentDesiredReplicaNum, now)
+	tortoise, err = r.updateResourceRequest(ctx, tortoise, w, currentDesiredReplicaNum, now)
 	if err != nil {
 		logger.Error(err, "update VPA based on the recommendation in tortoise", "tortoise", req.NamespacedName)
 		return ctrl.Result{}, err
@@ -292,16 +396,107 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 
 	if tortoise.Spec.UpdateMode != v1beta3.UpdateModeOff && !r.TortoiseService.IsGlobalDisableModeEnabled() && !reflect.DeepEqual(oldTortoise.Status.Conditions.ContainerResourceRequests, tortoise.Status.Conditions.ContainerResourceRequests) {
 		// The container resource requests are updated, so we need to update the Pods.
-		err = r.DeploymentService.RolloutRestart(ctx, dm, tortoise, now)
-		if err != nil {
-			logger.Error(err, "failed to rollout restart", "tortoise", req.NamespacedName)
-			return ctrl.Result{}, err
+		restart := true
+		if r.ResizeService.InPlace(tortoise) {
+			restart, err = r.ResizeService.Resize(ctx, tortoise, w)
+			if err != nil {
+				logger.Error(err, "failed to resize the Pods in place", "tortoise", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+		}
+		if restart {
+			err = r.WorkloadService.RolloutRestart(ctx, w, tortoise, now)
+			if err != nil {
+				logger.Error(err, "failed to rollout restart", "tortoise", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
 		}
 	}
 
 	return ctrl.Result{RequeueAfter: r.Interval}, nil
 }
 
+// updateResourceRequest updates the resource requests in the tortoise status based on the recommendation.
+// When the tortoise opts in to the canary rollout, the new resource requests are tried on the canary Pods first,
+// and .status.conditions.containerResourceRequests is updated only when the canary rollout is promoted.
+// When the Pods get unhealthy after the resource requests are decreased, they're rolled back to the previous ones.
+// The new resource requests are deferred while the Pods of the workload cannot be restarted safely, e.g., a rollout is in progress.
+func (r *TortoiseReconciler) updateResourceRequest(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, w *workload.Workload, replicaNum int32, now time.Time) (*autoscalingv1beta3.Tortoise, error) {
+	deferral, err := r.applyDeferral(ctx, tortoise, w, now)
+	if err != nil {
+		return tortoise, err
+	}
+	if canary.InProgress(tortoise) && !r.TortoiseService.IsGlobalDisableModeEnabled() {
+		// The recommendation isn't applied until the canary rollout in progress finishes.
+		// The canary rollout restarts the Pods only when the vertical applies are allowed.
+		return r.CanaryService.Bake(ctx, tortoise, w, now, func(t *autoscalingv1beta3.Tortoise) (*autoscalingv1beta3.Tortoise, bool) {
+			return r.TortoiseService.AllowCanaryStep(ctx, t, deferral, now)
+		})
+	}
+	if !r.TortoiseService.IsGlobalDisableModeEnabled() {
+		var rolledBack bool
+		tortoise, rolledBack, err = r.RollbackService.RollbackIfUnhealthy(ctx, tortoise, w, now)
+		if err != nil || rolledBack {
+			// The recommendation isn't applied right after the rollback
+			// so that the recommender takes the raised floor into account first.
+			return tortoise, err
+		}
+	}
+	if canary.RecentlyRolledBack(tortoise, now) && tortoise.Spec.UpdateMode != autoscalingv1beta3.UpdateModeOff {
+		log.FromContext(ctx).Info("skip applying the vertical recommendation because the last canary rollout was rolled back recently", "tortoise", klog.KObj(tortoise))
+		return tortoise, nil
+	}
+
+	var currentRequests []autoscalingv1beta3.ContainerResourceRequests
+	for _, req := range tortoise.Status.Conditions.ContainerResourceRequests {
+		currentRequests = append(currentRequests, *req.DeepCopy())
+	}
+	tortoise, err = r.TortoiseService.UpdateResourceRequest(ctx, tortoise, replicaNum, now, deferral)
+	if err != nil {
+		return tortoise, err
+	}
+	if currentRequests != nil && !reflect.DeepEqual(currentRequests, tortoise.Status.Conditions.ContainerResourceRequests) {
+		// Keep the current resource requests so that they can be restored if the Pods get unhealthy with the new ones.
+		tortoise.Status.Conditions.PreviousContainerResourceRequests = currentRequests
+	}
+
+	if canary.Enabled(tortoise) && tortoise.Spec.UpdateMode != autoscalingv1beta3.UpdateModeOff && !r.TortoiseService.IsGlobalDisableModeEnabled() &&
+		!reflect.DeepEqual(currentRequests, tortoise.Status.Conditions.ContainerResourceRequests) {
+		tortoise = r.CanaryService.Start(ctx, tortoise, currentRequests, replicaNum, now)
+	}
+	return tortoise, nil
+}
+
+// applyDeferral returns the deferral of the new resource requests when the Pods of the workload cannot be restarted now, or nil.
+func (r *TortoiseReconciler) applyDeferral(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, w *workload.Workload, now time.Time) (*tortoiseService.ApplyDeferral, error) {
+	if tortoise.Spec.UpdateMode == autoscalingv1beta3.UpdateModeOff || r.TortoiseService.IsGlobalDisableModeEnabled() {
+		return nil, nil
+	}
+	if reason, msg := utils.ApplyBlockedBySchedule(tortoise, now); reason != "" {
+		// The users don't want the restart now, e.g., during the business peak or the release freeze.
+		return &tortoiseService.ApplyDeferral{Reason: reason, Message: msg}, nil
+	}
+	// Resizing the Pods in place doesn't disrupt them, so PodDisruptionBudgets don't matter.
+	// But the canary rollout always evicts the Pods.
+	pdbCheck := workload.PDBCheckNone
+	switch {
+	case canary.InProgress(tortoise):
+		pdbCheck = workload.PDBCheckEviction
+	case !r.ResizeService.InPlace(tortoise):
+		pdbCheck = workload.PDBCheckHealthy
+	}
+	reason, msg, err := r.WorkloadService.RestartBlocker(ctx, w, tortoise, pdbCheck)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		// Restarting the Pods now would stack on the ongoing rollout or disrupt too many Pods.
+		// The new resource requests are applied in the next reconciliation once it's resolved.
+		return &tortoiseService.ApplyDeferral{Reason: reason, Message: msg}, nil
+	}
+	return nil, nil
+}
+
 func (r *TortoiseReconciler) deleteVPAAndHPA(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
 	if tortoise.Spec.DeletionPolicy == autoscalingv1beta3.DeletionPolicyNoDelete {
 		// don't delete anything.
@@ -317,6 +512,12 @@ func (r *TortoiseReconciler) deleteVPAAndHPA(ctx context.Context, tortoise *auto
 		}
 	}
 
+	if r.UsageService != nil {
+		// No monitor VPA is created when the in-process recommender is used.
+		r.UsageService.Forget(tortoise)
+		return nil
+	}
+
 	err = r.VpaService.DeleteTortoiseMonitorVPA(ctx, tortoise)
 	if err != nil {
 		return fmt.Errorf("delete monitor VPA created by tortoise: %w", err)
@@ -331,9 +532,11 @@ func (r *TortoiseReconciler) initializeVPAAndHPA(ctx context.Context, tortoise *
 		return err
 	}
 
-	_, tortoise, err = r.VpaService.CreateTortoiseMonitorVPA(ctx, tortoise)
-	if err != nil {
-		return fmt.Errorf("create tortoise monitor VPA: %w", err)
+	if r.UsageService == nil {
+		_, tortoise, err = r.VpaService.CreateTortoiseMonitorVPA(ctx, tortoise)
+		if err != nil {
+			return fmt.Errorf("create tortoise monitor VPA: %w", err)
+		}
 	}
 	_, err = r.TortoiseService.UpdateTortoiseStatus(ctx, tortoise, now, true)
 	if err != nil {
@@ -342,6 +545,32 @@ func (r *TortoiseReconciler) initializeVPAAndHPA(ctx context.Context, tortoise *
 	return nil
 }
 
+// getVerticalRecommendation returns the recommendation from the in-process recommender if it's enabled, or from the monitor VPA otherwise.
+// The second return value is false when the recommendation isn't ready yet.
+func (r *TortoiseReconciler) getVerticalRecommendation(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, w *workload.Workload, now time.Time) (*vpav1.RecommendedPodResources, bool, error) {
+	if r.UsageService != nil {
+		if err := r.UsageService.RecordUsage(ctx, tortoise, w.PodTemplate.Labels, now); err != nil {
+			return nil, false, fmt.Errorf("record the resource usage: %w", err)
+		}
+		recommendation, ready := r.UsageService.GetRecommendation(tortoise, now)
+		return recommendation, ready, nil
+	}
+
+	monitorvpa, ready, err := r.VpaService.GetTortoiseMonitorVPA(ctx, tortoise)
+	if err != nil {
+		return nil, false, fmt.Errorf("get tortoise VPA: %w", err)
+	}
+	if !ready {
+		return nil, false, nil
+	}
+
+	_, err = r.VpaService.UpdateVPAContainerResourcePolicy(ctx, tortoise, monitorvpa)
+	if err != nil {
+		return nil, false, fmt.Errorf("update VPA Container Resource Policy: %w", err)
+	}
+	return monitorvpa.Status.Recommendation, true, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *TortoiseReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -13,8 +13,11 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	v1 "k8s.io/api/apps/v1"
 	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	autoscalingv1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
@@ -24,12 +27,19 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/mercari/tortoise/api/v1beta3"
-	"github.com/mercari/tortoise/pkg/deployment"
+	"github.com/mercari/tortoise/pkg/backfill"
+	"github.com/mercari/tortoise/pkg/canary"
 	"github.com/mercari/tortoise/pkg/features"
 	"github.com/mercari/tortoise/pkg/hpa"
+	"github.com/mercari/tortoise/pkg/oom"
 	"github.com/mercari/tortoise/pkg/recommender"
+	"github.com/mercari/tortoise/pkg/resize"
+	"github.com/mercari/tortoise/pkg/rollback"
 	"github.com/mercari/tortoise/pkg/tortoise"
+	"github.com/mercari/tortoise/pkg/usage"
+	"github.com/mercari/tortoise/pkg/utils"
 	"github.com/mercari/tortoise/pkg/vpa"
+	"github.com/mercari/tortoise/pkg/workload"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -244,6 +254,15 @@ func removeUnnecessaryFields(rawdata []byte) ([]byte, error) {
 }
 
 func startController(ctx context.Context) func() {
+	mgr := newManager()
+	reconciler := newReconciler(mgr)
+	err := reconciler.SetupWithManager(mgr)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	return startManager(ctx, mgr)
+}
+
+func newManager() ctrl.Manager {
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:         scheme,
 		LeaderElection: false,
@@ -252,36 +271,54 @@ func startController(ctx context.Context) func() {
 		},
 	})
 	Expect(err).ShouldNot(HaveOccurred())
+	return mgr
+}
+
+func startManager(ctx context.Context, mgr ctrl.Manager) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		err := mgr.Start(ctx)
+		if err != nil {
+			panic(err)
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+	return cancel
+}
 
+func newReconciler(mgr ctrl.Manager) *TortoiseReconciler {
 	// We only reconcile once.
 	recorder := mgr.GetEventRecorderFor("tortoise-controller")
-	tortoiseService, err := tortoise.New(mgr.GetClient(), recorder, 24, "Asia/Tokyo", 1000*time.Minute, "daily", false)
+	tortoiseService, err := tortoise.New(mgr.GetClient(), recorder, 24, "Asia/Tokyo", 1000*time.Minute, "daily", false, 0, nil, nil, 0, time.Hour)
 	Expect(err).ShouldNot(HaveOccurred())
 	cli, err := vpa.New(mgr.GetConfig(), recorder)
 	Expect(err).ShouldNot(HaveOccurred())
 	hpaS, err := hpa.New(mgr.GetClient(), recorder, 0.95, 90, 25, time.Hour, nil, 1000, 10000, 3, ".*-exclude-metric", 5*time.Minute, false)
 	Expect(err).ShouldNot(HaveOccurred())
+	recommenderService, err := recommender.New(2.0, 0.5, 90, 40, 3, 30, "10m", "10Mi", map[string]string{"istio-proxy": "11m"}, map[string]string{"istio-proxy": "11Mi"}, "10", "10Gi", 10000, 0, 0, 0, "", "", 0, []features.FeatureFlag{features.VerticalScalingBasedOnPreferredMaxReplicas}, recorder)
+	Expect(err).ShouldNot(HaveOccurred())
 	reconciler := &TortoiseReconciler{
 		Scheme:             scheme,
 		HpaService:         hpaS,
 		EventRecorder:      record.NewFakeRecorder(10),
 		VpaService:         cli,
-		DeploymentService:  deployment.New(mgr.GetClient(), "100m", "100Mi", recorder),
+		WorkloadService:    workload.New(mgr.GetClient(), recorder, "100m", "100Mi", nil),
+		CanaryService:      canary.New(mgr.GetClient(), mgr.GetAPIReader(), recorder),
+		RollbackService:    rollback.New(mgr.GetAPIReader(), recorder, 30*time.Minute, 24*time.Hour),
+		OOMService:         oom.New(mgr.GetAPIReader(), recorder, 0.2, 24*time.Hour),
+		ResizeService:      resize.New(mgr.GetClient(), mgr.GetAPIReader(), recorder, nil, "Restart"),
 		TortoiseService:    tortoiseService,
-		RecommenderService: recommender.New(2.0, 0.5, 90, 40, 3, 30, "10m", "10Mi", map[string]string{"istio-proxy": "11m"}, map[string]string{"istio-proxy": "11Mi"}, "10", "10Gi", 10000, 0, 0, []features.FeatureFlag{features.VerticalScalingBasedOnPreferredMaxReplicas}, recorder),
+		RecommenderService: recommenderService,
 	}
-	err = reconciler.SetupWithManager(mgr)
-	Expect(err).ShouldNot(HaveOccurred())
+	return reconciler
+}
 
-	ctx, cancel := context.WithCancel(ctx)
-	go func() {
-		err := mgr.Start(ctx)
-		if err != nil {
-			panic(err)
-		}
-	}()
-	time.Sleep(100 * time.Millisecond)
-	return cancel
+type fakeUsageHistorySource struct {
+	histories []usage.ContainerHistory
+}
+
+func (f *fakeUsageHistorySource) ContainerUsageHistory(_ context.Context, _ string, _ v1beta3.CrossVersionObjectReference, _ time.Duration, _ time.Time) ([]usage.ContainerHistory, error) {
+	return f.histories, nil
 }
 
 var _ = Describe("Test TortoiseController", func() {
@@ -510,6 +547,60 @@ var _ = Describe("Test TortoiseController", func() {
 			runTest(filepath.Join("testdata", "reconcile-automatic-emergency-mode-mixed-policies-healthy"))
 		})
 	})
+	Context("history backfill", func() {
+		It("the backfilled recommendation isn't overwritten by the VPA recommendation in the next reconciliation", func() {
+			initializeResourcesFromFiles(ctx, k8sClient, "testdata/reconcile-for-the-single-container-pod-gathering-data/before")
+			mgr := newManager()
+			stopFunc = startManager(ctx, mgr)
+
+			source := &fakeUsageHistorySource{
+				histories: []usage.ContainerHistory{
+					{
+						ContainerName: "app",
+						P99:           corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+						Max:           corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("6"), corev1.ResourceMemory: resource.MustParse("6Gi")},
+						Since:         onlyTestNow.Add(-48 * time.Hour),
+					},
+				},
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "mercari"}}
+			for i := 0; i < 2; i++ {
+				// Use a new reconciler every time because the tortoise service skips the Tortoise reconciled recently.
+				reconciler := newReconciler(mgr)
+				reconciler.BackfillService = backfill.New(source, nil, reconciler.RecommenderService, record.NewFakeRecorder(10), "daily")
+				_, err := reconciler.Reconcile(ctx, req)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				// Wait for the cache to catch up with the backfilled status before the next reconciliation.
+				Eventually(func(g Gomega) {
+					got := &v1beta3.Tortoise{}
+					err := mgr.GetClient().Get(ctx, req.NamespacedName, got)
+					g.Expect(err).ShouldNot(HaveOccurred())
+					cond := utils.GetTortoiseCondition(got, v1beta3.TortoiseConditionTypeHistoryBackfilled)
+					g.Expect(cond).ShouldNot(BeNil())
+					g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+				}).Should(Succeed())
+			}
+
+			got := &v1beta3.Tortoise{}
+			err := k8sClient.Get(ctx, req.NamespacedName, got)
+			Expect(err).ShouldNot(HaveOccurred())
+			want := []v1beta3.ContainerRecommendationFromVPA{
+				{
+					ContainerName: "app",
+					Recommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+						corev1.ResourceCPU:    {Quantity: resource.MustParse("4")},
+						corev1.ResourceMemory: {Quantity: resource.MustParse("4Gi")},
+					},
+					MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+						corev1.ResourceCPU:    {Quantity: resource.MustParse("6")},
+						corev1.ResourceMemory: {Quantity: resource.MustParse("6Gi")},
+					},
+				},
+			}
+			Expect(cmp.Diff(want, got.Status.Conditions.ContainerRecommendationFromVPA, cmpopts.IgnoreTypes(metav1.Time{}))).To(BeEmpty())
+		})
+	})
 	Context("DeletionPolicy is handled correctly", func() {
 		It("[DeletionPolicy = DeleteAll] delete HPA and VPA when Tortoise is deleted", func() {
 			resource := initializeResourcesFromFiles(ctx, k8sClient, "testdata/deletion-policy-all/before")
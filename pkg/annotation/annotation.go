@@ -3,6 +3,10 @@ package annotation
 // annotation on Pod, HPA and VPA resource.
 const (
 	PodMutationAnnotation = "tortoise.autoscaling.mercari.com/pod-mutation"
+
+	// CanaryStartedAtAnnotation is the time (RFC3339) when the canary rollout started, which is put on the canary Pods.
+	// The canary Pods from the past canary rollouts are distinguished by this annotation.
+	CanaryStartedAtAnnotation = "tortoise.autoscaling.mercari.com/canary-started-at"
 )
 
 // annotation on Tortoise resource.
@@ -19,4 +23,16 @@ const (
 	// But, DryRun Tortoise is not allowed to modify HPAs, and if users manually add/remove metrics in HPAs,
 	// it could result in being inconsistent with the autoscaling policy in DryRun Tortoise.
 	ModifyDryRunTortoiseWhenHPAIsChangedAnnotation = "tortoise.autoscaling.mercari.com/modify-dryrun-tortoise-when-hpa-is-changed"
+
+	// EmergencyUntilAnnotation is the time (RFC3339) until when the tortoise stays in Emergency mode.
+	// Once the time passes, the controller changes .spec.updateMode from Emergency to Auto,
+	// and the tortoise goes back to normal gradually (TortoisePhaseBackToNormal).
+	// `tortoisectl emergency on --for` sets this annotation.
+	EmergencyUntilAnnotation = "tortoise.autoscaling.mercari.com/emergency-until"
+
+	// CanaryReservationAnnotation is the time (RFC3339) when the canary rollout in progress started,
+	// which the controller puts on the tortoise before evicting a Pod to be replaced with the canary Pod.
+	// The Pod mutating webhook makes the Pod the canary Pod only when it removes this annotation,
+	// so that only the Pod replacing the evicted one becomes the canary Pod, not the ones created by scaling up at the same time.
+	CanaryReservationAnnotation = "tortoise.autoscaling.mercari.com/canary-reservation"
 )
@@ -0,0 +1,144 @@
+package backfill
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+// replicasStep is the resolution of the replicas history.
+// Each sample is the max number of replicas in the hour, which fits the time slots of the min/max replicas recommendation.
+const replicasStep = time.Hour
+
+// ReplicasSample is the number of replicas at some point in time.
+type ReplicasSample struct {
+	Time     time.Time
+	Replicas int32
+}
+
+// ReplicasHistorySource provides the historical number of replicas of workloads.
+type ReplicasHistorySource interface {
+	// ReplicasHistory returns the number of replicas of the workload over the window until now, sorted by time.
+	ReplicasHistory(ctx context.Context, namespace string, ref v1beta3.CrossVersionObjectReference, window time.Duration, now time.Time) ([]ReplicasSample, error)
+}
+
+type prometheusReplicasSource struct {
+	api promv1.API
+}
+
+// NewPrometheusReplicasSource returns the ReplicasHistorySource which gets the number of replicas from kube-state-metrics in Prometheus.
+// Only Deployment and StatefulSet are supported.
+func NewPrometheusReplicasSource(address string) (ReplicasHistorySource, error) {
+	cli, err := api.NewClient(api.Config{Address: address})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prometheus client: %w", err)
+	}
+	return &prometheusReplicasSource{api: promv1.NewAPI(cli)}, nil
+}
+
+func (s *prometheusReplicasSource) ReplicasHistory(ctx context.Context, namespace string, ref v1beta3.CrossVersionObjectReference, window time.Duration, now time.Time) ([]ReplicasSample, error) {
+	var selector string
+	switch ref.Kind {
+	case "Deployment":
+		selector = fmt.Sprintf(`kube_deployment_spec_replicas{namespace=%q, deployment=%q}`, namespace, ref.Name)
+	case "StatefulSet":
+		selector = fmt.Sprintf(`kube_statefulset_replicas{namespace=%q, statefulset=%q}`, namespace, ref.Name)
+	default:
+		return nil, fmt.Errorf("the replicas history of %s isn't supported", ref.Kind)
+	}
+
+	// Each value is the max in the last hour, so that it can be attributed to the hour before the timestamp.
+	end := now.Truncate(replicasStep)
+	result, _, err := s.api.QueryRange(ctx, fmt.Sprintf(`max(max_over_time(%s[%s]))`, selector, model.Duration(replicasStep)), promv1.Range{
+		Start: end.Add(-window).Add(replicasStep),
+		End:   end,
+		Step:  replicasStep,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the replicas history to prometheus: %w", err)
+	}
+	matrix, ok := result.(model.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type from prometheus: %s", result.Type())
+	}
+
+	samples := []ReplicasSample{}
+	for _, stream := range matrix {
+		for _, v := range stream.Values {
+			samples = append(samples, ReplicasSample{Time: v.Timestamp.Time().Add(-replicasStep), Replicas: int32(v.Value)})
+		}
+	}
+	sortSamples(samples)
+	return samples, nil
+}
+
+type csvReplicasSource struct {
+	path string
+}
+
+// NewCSVReplicasSource returns the ReplicasHistorySource which reads the number of replicas from the CSV file.
+// Each row of the file has to be "<timestamp in RFC3339>,<namespace>,<kind>,<name>,<replicas>".
+// The file is read every time the history is requested so that it can be updated without restarting the controller.
+func NewCSVReplicasSource(path string) ReplicasHistorySource {
+	return &csvReplicasSource{path: path}
+}
+
+func (s *csvReplicasSource) ReplicasHistory(_ context.Context, namespace string, ref v1beta3.CrossVersionObjectReference, window time.Duration, now time.Time) ([]ReplicasSample, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the replicas history file: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 5
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	samples := []ReplicasSample{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the replicas history file: %w", err)
+		}
+		if record[1] != namespace || record[2] != ref.Kind || record[3] != ref.Name {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the timestamp in the replicas history file: %w", err)
+		}
+		if t.Before(now.Add(-window)) || t.After(now) {
+			continue
+		}
+		replicas, err := strconv.ParseInt(record[4], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the replicas in the replicas history file: %w", err)
+		}
+		samples = append(samples, ReplicasSample{Time: t, Replicas: int32(replicas)})
+	}
+	sortSamples(samples)
+	return samples, nil
+}
+
+func sortSamples(samples []ReplicasSample) {
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Time.Before(samples[j].Time)
+	})
+}
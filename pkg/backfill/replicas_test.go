@@ -0,0 +1,99 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+func TestPrometheusReplicasSource_ReplicasHistory(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 30, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse the form: %v", err)
+		}
+		if r.URL.Path != "/api/v1/query_range" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if query := r.Form.Get("query"); query != `max(max_over_time(kube_deployment_spec_replicas{namespace="default", deployment="app"}[1h]))` {
+			t.Errorf("unexpected query: %s", query)
+		}
+		// 2023-09-30T01:00:00Z ~ 2023-10-01T00:00:00Z
+		if start, end := r.Form.Get("start"), r.Form.Get("end"); start != "1696035600" || end != "1696118400" {
+			t.Errorf("unexpected range: %s ~ %s", start, end)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data": json.RawMessage(`{"resultType":"matrix","result":[
+				{"metric":{},"values":[[1696046400,"10"],[1696050000,"12"],[1696093200,"20"]]}
+			]}`),
+		})
+	}))
+	defer server.Close()
+
+	s, err := NewPrometheusReplicasSource(server.URL)
+	if err != nil {
+		t.Fatalf("NewPrometheusReplicasSource() error = %v", err)
+	}
+	got, err := s.ReplicasHistory(context.Background(), "default", v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app"}, 24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("ReplicasHistory() error = %v", err)
+	}
+	// Each sample is attributed to the hour before the timestamp.
+	want := []ReplicasSample{
+		{Time: time.Date(2023, 9, 30, 3, 0, 0, 0, time.UTC), Replicas: 10},
+		{Time: time.Date(2023, 9, 30, 4, 0, 0, 0, time.UTC), Replicas: 12},
+		{Time: time.Date(2023, 9, 30, 16, 0, 0, 0, time.UTC), Replicas: 20},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("ReplicasHistory() diff = %v", d)
+	}
+
+	if _, err := s.ReplicasHistory(context.Background(), "default", v1beta3.CrossVersionObjectReference{Kind: "Rollout", Name: "app"}, 24*time.Hour, now); err == nil {
+		t.Errorf("ReplicasHistory() should return the error for the unsupported kind")
+	}
+}
+
+func TestCSVReplicasSource_ReplicasHistory(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 30, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "replicas.csv")
+	content := strings.Join([]string{
+		"# timestamp,namespace,kind,name,replicas",
+		"2023-09-30T15:00:00Z,default,Deployment,app,20",
+		"2023-09-30T03:00:00Z, default, Deployment, app, 10",
+		"2023-09-29T03:00:00Z,default,Deployment,app,30",
+		"2023-09-30T03:00:00Z,default,Deployment,other,40",
+		"2023-09-30T03:00:00Z,other,Deployment,app,50",
+		"2023-09-30T03:00:00Z,default,StatefulSet,app,60",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write the file: %v", err)
+	}
+
+	got, err := NewCSVReplicasSource(path).ReplicasHistory(context.Background(), "default", v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app"}, 24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("ReplicasHistory() error = %v", err)
+	}
+	want := []ReplicasSample{
+		{Time: time.Date(2023, 9, 30, 3, 0, 0, 0, time.UTC), Replicas: 10},
+		{Time: time.Date(2023, 9, 30, 15, 0, 0, 0, time.UTC), Replicas: 20},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("ReplicasHistory() diff = %v", d)
+	}
+
+	if _, err := NewCSVReplicasSource(filepath.Join(t.TempDir(), "not-found.csv")).ReplicasHistory(context.Background(), "default", v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app"}, 24*time.Hour, now); err == nil {
+		t.Errorf("ReplicasHistory() should return the error when the file doesn't exist")
+	}
+}
@@ -0,0 +1,234 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/recommender"
+	"github.com/mercari/tortoise/pkg/usage"
+	"github.com/mercari/tortoise/pkg/utils"
+)
+
+// retryInterval is the interval to retry the backfill when the history wasn't sufficient or couldn't be fetched.
+const retryInterval = time.Hour
+
+// Service backfills the recommendation of a new tortoise from the history
+// so that the tortoise doesn't have to wait for the whole gathering data period when the workload has been running already.
+type Service struct {
+	// usageSource is the source of the container usage history. It's nil when the backfill of the container recommendation is disabled.
+	usageSource usage.HistorySource
+	// replicasSource is the source of the replicas history. It's nil when the backfill of the replicas recommendation is disabled.
+	replicasSource ReplicasHistorySource
+	recommender    *recommender.Service
+	recorder       record.EventRecorder
+	// window is the period of the history we need, which is the same as the gathering data period.
+	window time.Duration
+}
+
+func New(usageSource usage.HistorySource, replicasSource ReplicasHistorySource, recommender *recommender.Service, recorder record.EventRecorder, gatheringDataPeriodType string) *Service {
+	window := 7 * 24 * time.Hour
+	if gatheringDataPeriodType == "daily" {
+		window = 24 * time.Hour
+	}
+	return &Service{
+		usageSource:    usageSource,
+		replicasSource: replicasSource,
+		recommender:    recommender,
+		recorder:       recorder,
+		window:         window,
+	}
+}
+
+// WithRecommender returns a copy of the service which replays the replicas history with the recommender.
+func (s *Service) WithRecommender(recommender *recommender.Service) *Service {
+	copied := *s
+	copied.recommender = recommender
+	return &copied
+}
+
+// shouldBackfill checks if the tortoise is gathering data and the backfill of the condition type hasn't succeeded nor been tried recently.
+func shouldBackfill(tortoise *v1beta3.Tortoise, conditionType v1beta3.TortoiseConditionType, now time.Time) bool {
+	if tortoise.Status.TortoisePhase != v1beta3.TortoisePhaseGatheringData && tortoise.Status.TortoisePhase != v1beta3.TortoisePhasePartlyWorking {
+		return false
+	}
+	cond := utils.GetTortoiseCondition(tortoise, conditionType)
+	if cond != nil && (cond.Status == corev1.ConditionTrue || cond.LastUpdateTime.Add(retryInterval).After(now)) {
+		// Already backfilled, or tried recently.
+		return false
+	}
+	return true
+}
+
+// BackfillReplicasRecommendation fills the min/max replicas recommendation by replaying the replicas history,
+// and marks the horizontal resources gathering data as Working, when the history covers all the time slots.
+func (s *Service) BackfillReplicasRecommendation(ctx context.Context, tortoise *v1beta3.Tortoise, now time.Time) *v1beta3.Tortoise {
+	if s.replicasSource == nil || !shouldBackfill(tortoise, v1beta3.TortoiseConditionTypeReplicasHistoryBackfilled, now) {
+		return tortoise
+	}
+	if isReplicasRecommendationReady(tortoise) || !hasGatheringDataResource(tortoise, v1beta3.AutoscalingTypeHorizontal) {
+		return tortoise
+	}
+
+	samples, err := s.replicasSource.ReplicasHistory(ctx, tortoise.Namespace, tortoise.Spec.TargetRefs.ScaleTargetRef, s.window, now)
+	if err != nil {
+		return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeReplicasHistoryBackfilled, corev1.ConditionFalse, "FailedToFetchHistory", err.Error(), now)
+	}
+
+	// Replay the history in the same way as the recommender does in every reconciliation.
+	backfilled := tortoise.DeepCopy()
+	for _, sample := range samples {
+		backfilled, err = s.recommender.UpdateHPAMinMaxReplicasRecommendations(backfilled, sample.Replicas, sample.Time)
+		if err != nil {
+			return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeReplicasHistoryBackfilled, corev1.ConditionFalse, "FailedToBackfill", err.Error(), now)
+		}
+	}
+	if !isReplicasRecommendationReady(backfilled) {
+		return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeReplicasHistoryBackfilled, corev1.ConditionFalse, "InsufficientHistory",
+			fmt.Sprintf("the replicas history in the last %s doesn't cover all the time slots", s.window), now)
+	}
+
+	tortoise = backfilled
+	for _, c := range tortoise.Status.AutoscalingPolicy {
+		for rn, p := range c.Policy {
+			if p != v1beta3.AutoscalingTypeHorizontal {
+				continue
+			}
+			if phase, ok := resourcePhase(tortoise, c.ContainerName, rn); !ok || phase != v1beta3.ContainerResourcePhaseGatheringData {
+				continue
+			}
+			utils.ChangeTortoiseContainerResourcePhase(tortoise, c.ContainerName, rn, now, v1beta3.ContainerResourcePhaseWorking)
+		}
+	}
+
+	s.recorder.Event(tortoise, corev1.EventTypeNormal, event.HistoryBackfilled, fmt.Sprintf("Tortoise backfilled the min/max replicas recommendation from the replicas in the last %s, and doesn't need to wait for gathering data", s.window))
+	return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeReplicasHistoryBackfilled, corev1.ConditionTrue, "HistoryBackfilled",
+		fmt.Sprintf("the min/max replicas recommendation is backfilled from the replicas in the last %s", s.window), now)
+}
+
+// BackfillContainerRecommendation fills ContainerRecommendationFromVPA with the p99 and max usage in the history,
+// and marks the resources gathering data as Working, when all the containers have the history covering the whole gathering data period.
+// It should be called after ContainerRecommendationFromVPA is synced with the autoscaling policy.
+//
+// Horizontal resources are marked as Working only when the min/max replicas recommendation is filled as well,
+// otherwise the tortoise service puts them back to GatheringData anyway.
+func (s *Service) BackfillContainerRecommendation(ctx context.Context, tortoise *v1beta3.Tortoise, now time.Time) *v1beta3.Tortoise {
+	if s.usageSource == nil || !shouldBackfill(tortoise, v1beta3.TortoiseConditionTypeHistoryBackfilled, now) {
+		return tortoise
+	}
+	if !hasGatheringDataResource(tortoise, "") {
+		return tortoise
+	}
+
+	histories, err := s.usageSource.ContainerUsageHistory(ctx, tortoise.Namespace, tortoise.Spec.TargetRefs.ScaleTargetRef, s.window, now)
+	if err != nil {
+		return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeHistoryBackfilled, corev1.ConditionFalse, "FailedToFetchHistory", err.Error(), now)
+	}
+
+	historyMap := make(map[string]usage.ContainerHistory, len(histories))
+	for _, h := range histories {
+		historyMap[h.ContainerName] = h
+	}
+	insufficient := []string{}
+	for _, p := range tortoise.Status.AutoscalingPolicy {
+		if !s.isSufficient(historyMap[p.ContainerName], now) {
+			insufficient = append(insufficient, p.ContainerName)
+		}
+	}
+	if len(insufficient) != 0 {
+		return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeHistoryBackfilled, corev1.ConditionFalse, "InsufficientHistory",
+			fmt.Sprintf("the history doesn't cover the last %s for the container(s): %s", s.window, strings.Join(insufficient, ", ")), now)
+	}
+
+	for k, r := range tortoise.Status.Conditions.ContainerRecommendationFromVPA {
+		h := historyMap[r.ContainerName]
+		for _, rn := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			tortoise.Status.Conditions.ContainerRecommendationFromVPA[k].Recommendation[rn] = v1beta3.ResourceQuantity{
+				Quantity:  h.P99[rn],
+				UpdatedAt: metav1.NewTime(now),
+			}
+			tortoise.Status.Conditions.ContainerRecommendationFromVPA[k].MaxRecommendation[rn] = v1beta3.ResourceQuantity{
+				Quantity:  h.Max[rn],
+				UpdatedAt: metav1.NewTime(now),
+			}
+		}
+	}
+
+	horizontalReady := isReplicasRecommendationReady(tortoise)
+	for _, c := range tortoise.Status.AutoscalingPolicy {
+		for rn, p := range c.Policy {
+			if p == v1beta3.AutoscalingTypeHorizontal && !horizontalReady {
+				continue
+			}
+			if phase, ok := resourcePhase(tortoise, c.ContainerName, rn); !ok || phase != v1beta3.ContainerResourcePhaseGatheringData {
+				continue
+			}
+			utils.ChangeTortoiseContainerResourcePhase(tortoise, c.ContainerName, rn, now, v1beta3.ContainerResourcePhaseWorking)
+		}
+	}
+
+	s.recorder.Event(tortoise, corev1.EventTypeNormal, event.HistoryBackfilled, fmt.Sprintf("Tortoise backfilled the recommendation from the usage in the last %s, and doesn't need to wait for gathering data", s.window))
+	return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeHistoryBackfilled, corev1.ConditionTrue, "HistoryBackfilled",
+		fmt.Sprintf("the recommendation is backfilled from the usage in the last %s", s.window), now)
+}
+
+// isSufficient checks if the history has both CPU and memory usage, and covers the whole window.
+func (s *Service) isSufficient(h usage.ContainerHistory, now time.Time) bool {
+	for _, rn := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if _, ok := h.P99[rn]; !ok {
+			return false
+		}
+		if _, ok := h.Max[rn]; !ok {
+			return false
+		}
+	}
+	return !h.Since.IsZero() && !h.Since.After(now.Add(-s.window))
+}
+
+// hasGatheringDataResource checks if any resource with the autoscaling type is gathering data.
+// The empty autoscaling type means any type.
+func hasGatheringDataResource(tortoise *v1beta3.Tortoise, autoscalingType v1beta3.AutoscalingType) bool {
+	for _, c := range tortoise.Status.AutoscalingPolicy {
+		for rn, p := range c.Policy {
+			if autoscalingType != "" && p != autoscalingType {
+				continue
+			}
+			if phase, ok := resourcePhase(tortoise, c.ContainerName, rn); ok && phase == v1beta3.ContainerResourcePhaseGatheringData {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func resourcePhase(tortoise *v1beta3.Tortoise, containerName string, rn corev1.ResourceName) (v1beta3.ContainerResourcePhase, bool) {
+	for _, c := range tortoise.Status.ContainerResourcePhases {
+		if c.ContainerName == containerName {
+			p, ok := c.ResourcePhases[rn]
+			return p.Phase, ok
+		}
+	}
+	return "", false
+}
+
+// isReplicasRecommendationReady checks if all the time slots of the min/max replicas recommendation have the value.
+func isReplicasRecommendationReady(tortoise *v1beta3.Tortoise) bool {
+	for _, r := range tortoise.Status.Recommendations.Horizontal.MinReplicas {
+		if r.Value == 0 {
+			return false
+		}
+	}
+	for _, r := range tortoise.Status.Recommendations.Horizontal.MaxReplicas {
+		if r.Value == 0 {
+			return false
+		}
+	}
+	return true
+}
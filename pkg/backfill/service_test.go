@@ -0,0 +1,454 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/recommender"
+	"github.com/mercari/tortoise/pkg/usage"
+)
+
+type fakeHistorySource struct {
+	histories []usage.ContainerHistory
+	err       error
+	called    bool
+}
+
+func (f *fakeHistorySource) ContainerUsageHistory(_ context.Context, _ string, _ v1beta3.CrossVersionObjectReference, _ time.Duration, _ time.Time) ([]usage.ContainerHistory, error) {
+	f.called = true
+	return f.histories, f.err
+}
+
+type fakeReplicasSource struct {
+	samples []ReplicasSample
+	err     error
+	called  bool
+}
+
+func (f *fakeReplicasSource) ReplicasHistory(_ context.Context, _ string, _ v1beta3.CrossVersionObjectReference, _ time.Duration, _ time.Time) ([]ReplicasSample, error) {
+	f.called = true
+	return f.samples, f.err
+}
+
+func history(container, p99CPU, p99Memory, maxCPU, maxMemory string, since time.Time) usage.ContainerHistory {
+	return usage.ContainerHistory{
+		ContainerName: container,
+		P99:           corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(p99CPU), corev1.ResourceMemory: resource.MustParse(p99Memory)},
+		Max:           corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(maxCPU), corev1.ResourceMemory: resource.MustParse(maxMemory)},
+		Since:         since,
+	}
+}
+
+func emptyRecommendation(container string) v1beta3.ContainerRecommendationFromVPA {
+	return v1beta3.ContainerRecommendationFromVPA{
+		ContainerName:     container,
+		Recommendation:    map[corev1.ResourceName]v1beta3.ResourceQuantity{corev1.ResourceCPU: {}, corev1.ResourceMemory: {}},
+		MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{corev1.ResourceCPU: {}, corev1.ResourceMemory: {}},
+	}
+}
+
+func recommendation(container, cpu, memory, maxCPU, maxMemory string, now time.Time) v1beta3.ContainerRecommendationFromVPA {
+	return v1beta3.ContainerRecommendationFromVPA{
+		ContainerName: container,
+		Recommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+			corev1.ResourceCPU:    {Quantity: resource.MustParse(cpu), UpdatedAt: metav1.NewTime(now)},
+			corev1.ResourceMemory: {Quantity: resource.MustParse(memory), UpdatedAt: metav1.NewTime(now)},
+		},
+		MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+			corev1.ResourceCPU:    {Quantity: resource.MustParse(maxCPU), UpdatedAt: metav1.NewTime(now)},
+			corev1.ResourceMemory: {Quantity: resource.MustParse(maxMemory), UpdatedAt: metav1.NewTime(now)},
+		},
+	}
+}
+
+func phases(container string, cpu, memory v1beta3.ContainerResourcePhase, at time.Time) v1beta3.ContainerResourcePhases {
+	return v1beta3.ContainerResourcePhases{
+		ContainerName: container,
+		ResourcePhases: map[corev1.ResourceName]v1beta3.ResourcePhase{
+			corev1.ResourceCPU:    {Phase: cpu, LastTransitionTime: metav1.NewTime(at)},
+			corev1.ResourceMemory: {Phase: memory, LastTransitionTime: metav1.NewTime(at)},
+		},
+	}
+}
+
+func condition(status corev1.ConditionStatus, reason, message string, at time.Time) v1beta3.TortoiseCondition {
+	return v1beta3.TortoiseCondition{
+		Type:               v1beta3.TortoiseConditionTypeHistoryBackfilled,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(at),
+		LastUpdateTime:     metav1.NewTime(at),
+	}
+}
+
+// baseTortoise returns the tortoise which was just created for the Deployment "app" with two containers.
+// "app" container is scaled horizontally on CPU and vertically on memory, and "istio-proxy" is scaled vertically on both.
+func baseTortoise(created time.Time, replicasReady bool) *v1beta3.Tortoise {
+	replicas := int32(0)
+	if replicasReady {
+		replicas = 3
+	}
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef: v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app", APIVersion: "apps/v1"},
+			},
+		},
+		Status: v1beta3.TortoiseStatus{
+			TortoisePhase: v1beta3.TortoisePhaseGatheringData,
+			AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+				{
+					ContainerName: "app",
+					Policy:        map[corev1.ResourceName]v1beta3.AutoscalingType{corev1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal, corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical},
+				},
+				{
+					ContainerName: "istio-proxy",
+					Policy:        map[corev1.ResourceName]v1beta3.AutoscalingType{corev1.ResourceCPU: v1beta3.AutoscalingTypeVertical, corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical},
+				},
+			},
+			ContainerResourcePhases: []v1beta3.ContainerResourcePhases{
+				phases("app", v1beta3.ContainerResourcePhaseGatheringData, v1beta3.ContainerResourcePhaseGatheringData, created),
+				phases("istio-proxy", v1beta3.ContainerResourcePhaseGatheringData, v1beta3.ContainerResourcePhaseGatheringData, created),
+			},
+			Recommendations: v1beta3.Recommendations{
+				Horizontal: v1beta3.HorizontalRecommendations{
+					MinReplicas: []v1beta3.ReplicasRecommendation{{From: 0, To: 1, Value: replicas}},
+					MaxReplicas: []v1beta3.ReplicasRecommendation{{From: 0, To: 1, Value: replicas}},
+				},
+			},
+			Conditions: v1beta3.Conditions{
+				ContainerRecommendationFromVPA: []v1beta3.ContainerRecommendationFromVPA{
+					emptyRecommendation("app"),
+					emptyRecommendation("istio-proxy"),
+				},
+			},
+		},
+	}
+}
+
+func TestService_BackfillContainerRecommendation(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	created := now.Add(-time.Minute)
+	oldEnough := now.Add(-8 * 24 * time.Hour)
+	sufficientHistories := []usage.ContainerHistory{
+		history("app", "500m", "500Mi", "800m", "600Mi", oldEnough),
+		history("istio-proxy", "50m", "50Mi", "100m", "60Mi", oldEnough),
+	}
+
+	tests := []struct {
+		name       string
+		tortoise   *v1beta3.Tortoise
+		source     *fakeHistorySource
+		want       *v1beta3.Tortoise
+		wantCalled bool
+	}{
+		{
+			name:     "backfill all the resources",
+			tortoise: baseTortoise(created, true),
+			source:   &fakeHistorySource{histories: sufficientHistories},
+			want: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, true)
+				t.Status.Conditions.ContainerRecommendationFromVPA = []v1beta3.ContainerRecommendationFromVPA{
+					recommendation("app", "500m", "500Mi", "800m", "600Mi", now),
+					recommendation("istio-proxy", "50m", "50Mi", "100m", "60Mi", now),
+				}
+				t.Status.ContainerResourcePhases = []v1beta3.ContainerResourcePhases{
+					phases("app", v1beta3.ContainerResourcePhaseWorking, v1beta3.ContainerResourcePhaseWorking, now),
+					phases("istio-proxy", v1beta3.ContainerResourcePhaseWorking, v1beta3.ContainerResourcePhaseWorking, now),
+				}
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					condition(corev1.ConditionTrue, "HistoryBackfilled", "the recommendation is backfilled from the usage in the last 168h0m0s", now),
+				}
+				return t
+			}(),
+			wantCalled: true,
+		},
+		{
+			name:     "horizontal resources keep gathering data when the replicas recommendation isn't ready",
+			tortoise: baseTortoise(created, false),
+			source:   &fakeHistorySource{histories: sufficientHistories},
+			want: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, false)
+				t.Status.Conditions.ContainerRecommendationFromVPA = []v1beta3.ContainerRecommendationFromVPA{
+					recommendation("app", "500m", "500Mi", "800m", "600Mi", now),
+					recommendation("istio-proxy", "50m", "50Mi", "100m", "60Mi", now),
+				}
+				t.Status.ContainerResourcePhases = []v1beta3.ContainerResourcePhases{
+					{
+						ContainerName: "app",
+						ResourcePhases: map[corev1.ResourceName]v1beta3.ResourcePhase{
+							corev1.ResourceCPU:    {Phase: v1beta3.ContainerResourcePhaseGatheringData, LastTransitionTime: metav1.NewTime(created)},
+							corev1.ResourceMemory: {Phase: v1beta3.ContainerResourcePhaseWorking, LastTransitionTime: metav1.NewTime(now)},
+						},
+					},
+					phases("istio-proxy", v1beta3.ContainerResourcePhaseWorking, v1beta3.ContainerResourcePhaseWorking, now),
+				}
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					condition(corev1.ConditionTrue, "HistoryBackfilled", "the recommendation is backfilled from the usage in the last 168h0m0s", now),
+				}
+				return t
+			}(),
+			wantCalled: true,
+		},
+		{
+			name:     "history doesn't cover the whole period",
+			tortoise: baseTortoise(created, true),
+			source: &fakeHistorySource{histories: []usage.ContainerHistory{
+				history("app", "500m", "500Mi", "800m", "600Mi", oldEnough),
+				history("istio-proxy", "50m", "50Mi", "100m", "60Mi", now.Add(-24*time.Hour)),
+			}},
+			want: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, true)
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					condition(corev1.ConditionFalse, "InsufficientHistory", "the history doesn't cover the last 168h0m0s for the container(s): istio-proxy", now),
+				}
+				return t
+			}(),
+			wantCalled: true,
+		},
+		{
+			name:     "history of some container is missing",
+			tortoise: baseTortoise(created, true),
+			source: &fakeHistorySource{histories: []usage.ContainerHistory{
+				history("app", "500m", "500Mi", "800m", "600Mi", oldEnough),
+			}},
+			want: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, true)
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					condition(corev1.ConditionFalse, "InsufficientHistory", "the history doesn't cover the last 168h0m0s for the container(s): istio-proxy", now),
+				}
+				return t
+			}(),
+			wantCalled: true,
+		},
+		{
+			name:     "failed to fetch the history",
+			tortoise: baseTortoise(created, true),
+			source:   &fakeHistorySource{err: errors.New("connection refused")},
+			want: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, true)
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					condition(corev1.ConditionFalse, "FailedToFetchHistory", "connection refused", now),
+				}
+				return t
+			}(),
+			wantCalled: true,
+		},
+		{
+			name: "don't retry within the retry interval",
+			tortoise: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, true)
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					condition(corev1.ConditionFalse, "InsufficientHistory", "", now.Add(-30*time.Minute)),
+				}
+				return t
+			}(),
+			source: &fakeHistorySource{histories: sufficientHistories},
+			want: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, true)
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					condition(corev1.ConditionFalse, "InsufficientHistory", "", now.Add(-30*time.Minute)),
+				}
+				return t
+			}(),
+		},
+		{
+			name: "don't backfill twice",
+			tortoise: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, false)
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					condition(corev1.ConditionTrue, "HistoryBackfilled", "", now.Add(-2*time.Hour)),
+				}
+				return t
+			}(),
+			source: &fakeHistorySource{histories: sufficientHistories},
+			want: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, false)
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					condition(corev1.ConditionTrue, "HistoryBackfilled", "", now.Add(-2*time.Hour)),
+				}
+				return t
+			}(),
+		},
+		{
+			name: "don't backfill the working tortoise",
+			tortoise: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, true)
+				t.Status.TortoisePhase = v1beta3.TortoisePhaseWorking
+				return t
+			}(),
+			source: &fakeHistorySource{histories: sufficientHistories},
+			want: func() *v1beta3.Tortoise {
+				t := baseTortoise(created, true)
+				t.Status.TortoisePhase = v1beta3.TortoisePhaseWorking
+				return t
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.source, nil, nil, record.NewFakeRecorder(10), "weekly")
+			got := s.BackfillContainerRecommendation(context.Background(), tt.tortoise, now)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("BackfillContainerRecommendation() diff = %v", d)
+			}
+			if tt.source.called != tt.wantCalled {
+				t.Errorf("the history source is called = %v, want %v", tt.source.called, tt.wantCalled)
+			}
+		})
+	}
+}
+
+// replicasTortoise returns the tortoise gathering data, which has two time slots of the min/max replicas recommendation.
+func replicasTortoise(created time.Time, min, max [2]int32, updatedAt time.Time) *v1beta3.Tortoise {
+	t := baseTortoise(created, false)
+	slots := func(values [2]int32) []v1beta3.ReplicasRecommendation {
+		rs := []v1beta3.ReplicasRecommendation{
+			{From: 0, To: 12, TimeZone: "UTC", Value: values[0]},
+			{From: 12, To: 24, TimeZone: "UTC", Value: values[1]},
+		}
+		for i := range rs {
+			if rs[i].Value != 0 {
+				rs[i].UpdatedAt = metav1.NewTime(updatedAt)
+			}
+		}
+		return rs
+	}
+	t.Status.Recommendations.Horizontal.MinReplicas = slots(min)
+	t.Status.Recommendations.Horizontal.MaxReplicas = slots(max)
+	return t
+}
+
+func TestService_BackfillReplicasRecommendation(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 30, 0, 0, time.UTC)
+	created := now.Add(-time.Minute)
+	morning := time.Date(2023, 9, 30, 3, 0, 0, 0, time.UTC)
+	evening := time.Date(2023, 9, 30, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		tortoise   *v1beta3.Tortoise
+		source     *fakeReplicasSource
+		want       *v1beta3.Tortoise
+		wantCalled bool
+	}{
+		{
+			name:     "backfill all the time slots",
+			tortoise: replicasTortoise(created, [2]int32{}, [2]int32{}, time.Time{}),
+			source: &fakeReplicasSource{samples: []ReplicasSample{
+				{Time: morning, Replicas: 10},
+				{Time: morning.Add(time.Hour), Replicas: 8},
+				{Time: evening, Replicas: 20},
+			}},
+			want: func() *v1beta3.Tortoise {
+				t := replicasTortoise(created, [2]int32{}, [2]int32{}, time.Time{})
+				t.Status.Recommendations.Horizontal.MinReplicas[0].Value = 5
+				t.Status.Recommendations.Horizontal.MinReplicas[0].UpdatedAt = metav1.NewTime(morning.Add(time.Hour))
+				t.Status.Recommendations.Horizontal.MinReplicas[1].Value = 10
+				t.Status.Recommendations.Horizontal.MinReplicas[1].UpdatedAt = metav1.NewTime(evening)
+				t.Status.Recommendations.Horizontal.MaxReplicas[0].Value = 20
+				t.Status.Recommendations.Horizontal.MaxReplicas[0].UpdatedAt = metav1.NewTime(morning.Add(time.Hour))
+				t.Status.Recommendations.Horizontal.MaxReplicas[1].Value = 40
+				t.Status.Recommendations.Horizontal.MaxReplicas[1].UpdatedAt = metav1.NewTime(evening)
+				t.Status.ContainerResourcePhases = []v1beta3.ContainerResourcePhases{
+					{
+						ContainerName: "app",
+						ResourcePhases: map[corev1.ResourceName]v1beta3.ResourcePhase{
+							corev1.ResourceCPU:    {Phase: v1beta3.ContainerResourcePhaseWorking, LastTransitionTime: metav1.NewTime(now)},
+							corev1.ResourceMemory: {Phase: v1beta3.ContainerResourcePhaseGatheringData, LastTransitionTime: metav1.NewTime(created)},
+						},
+					},
+					phases("istio-proxy", v1beta3.ContainerResourcePhaseGatheringData, v1beta3.ContainerResourcePhaseGatheringData, created),
+				}
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					{
+						Type:               v1beta3.TortoiseConditionTypeReplicasHistoryBackfilled,
+						Status:             corev1.ConditionTrue,
+						Reason:             "HistoryBackfilled",
+						Message:            "the min/max replicas recommendation is backfilled from the replicas in the last 24h0m0s",
+						LastTransitionTime: metav1.NewTime(now),
+						LastUpdateTime:     metav1.NewTime(now),
+					},
+				}
+				return t
+			}(),
+			wantCalled: true,
+		},
+		{
+			name:     "history doesn't cover all the time slots",
+			tortoise: replicasTortoise(created, [2]int32{}, [2]int32{}, time.Time{}),
+			source: &fakeReplicasSource{samples: []ReplicasSample{
+				{Time: morning, Replicas: 10},
+			}},
+			want: func() *v1beta3.Tortoise {
+				t := replicasTortoise(created, [2]int32{}, [2]int32{}, time.Time{})
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					{
+						Type:               v1beta3.TortoiseConditionTypeReplicasHistoryBackfilled,
+						Status:             corev1.ConditionFalse,
+						Reason:             "InsufficientHistory",
+						Message:            "the replicas history in the last 24h0m0s doesn't cover all the time slots",
+						LastTransitionTime: metav1.NewTime(now),
+						LastUpdateTime:     metav1.NewTime(now),
+					},
+				}
+				return t
+			}(),
+			wantCalled: true,
+		},
+		{
+			name:     "failed to fetch the history",
+			tortoise: replicasTortoise(created, [2]int32{}, [2]int32{}, time.Time{}),
+			source:   &fakeReplicasSource{err: errors.New("the replicas history of Rollout isn't supported")},
+			want: func() *v1beta3.Tortoise {
+				t := replicasTortoise(created, [2]int32{}, [2]int32{}, time.Time{})
+				t.Status.Conditions.TortoiseConditions = []v1beta3.TortoiseCondition{
+					{
+						Type:               v1beta3.TortoiseConditionTypeReplicasHistoryBackfilled,
+						Status:             corev1.ConditionFalse,
+						Reason:             "FailedToFetchHistory",
+						Message:            "the replicas history of Rollout isn't supported",
+						LastTransitionTime: metav1.NewTime(now),
+						LastUpdateTime:     metav1.NewTime(now),
+					},
+				}
+				return t
+			}(),
+			wantCalled: true,
+		},
+		{
+			name:     "nothing to do when the recommendation is ready already",
+			tortoise: replicasTortoise(created, [2]int32{3, 3}, [2]int32{6, 6}, created),
+			source:   &fakeReplicasSource{samples: []ReplicasSample{{Time: morning, Replicas: 10}}},
+			want:     replicasTortoise(created, [2]int32{3, 3}, [2]int32{6, 6}, created),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r, err := recommender.New(2.0, 0.5, 90, 40, 3, 30, "10m", "10Mi", map[string]string{}, map[string]string{}, "10", "10Gi", 10000, 0, 0, 0, "", "", 0, nil, recorder)
+			if err != nil {
+				t.Fatalf("recommender.New() error = %v", err)
+			}
+			s := New(nil, tt.source, r, recorder, "daily")
+			got := s.BackfillReplicasRecommendation(context.Background(), tt.tortoise, now)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("BackfillReplicasRecommendation() diff = %v", d)
+			}
+			if tt.source.called != tt.wantCalled {
+				t.Errorf("the replicas source is called = %v, want %v", tt.source.called, tt.wantCalled)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/utils"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+const (
+	// Label is the label on the canary Pods, whose value is the UID of the tortoise.
+	Label = "tortoise.autoscaling.mercari.com/canary"
+
+	defaultPercent      = 10
+	defaultBakeDuration = 30 * time.Minute
+
+	// The reasons of TortoiseConditionTypeVerticalCanary.
+	ReasonBaking     = "Baking"
+	ReasonPromoted   = "Promoted"
+	ReasonRolledBack = "RolledBack"
+	ReasonCanceled   = "Canceled"
+)
+
+// Service rolls out the new resource requests to a part of the Pods (canary Pods) first,
+// and applies them to all the Pods only when the canary Pods stay healthy during the bake duration.
+//
+// The canary Pods are created by evicting the existing Pods one by one;
+// before each eviction, the controller reserves the canary Pod on the tortoise (CanaryReservationAnnotation),
+// and the Pod mutating webhook gives the new resource requests to the recreated Pod which takes the reservation.
+type Service struct {
+	c        client.Client
+	reader   client.Reader
+	recorder record.EventRecorder
+}
+
+func New(c client.Client, reader client.Reader, recorder record.EventRecorder) *Service {
+	return &Service{c: c, reader: reader, recorder: recorder}
+}
+
+// Enabled returns true when the tortoise rolls out the new resource requests via the canary Pods.
+func Enabled(t *v1beta3.Tortoise) bool {
+	return t.Spec.VerticalRollout != nil && t.Spec.VerticalRollout.Canary != nil
+}
+
+// InProgress returns true when the canary Pods of the tortoise are baking.
+func InProgress(t *v1beta3.Tortoise) bool {
+	return t.Status.VerticalRollout != nil
+}
+
+// RecentlyRolledBack returns true when the last canary rollout was rolled back within the bake duration.
+// Tortoise doesn't start a new canary rollout during that time so that the same problem doesn't happen again and again.
+func RecentlyRolledBack(t *v1beta3.Tortoise, now time.Time) bool {
+	if !Enabled(t) {
+		return false
+	}
+	c := utils.GetTortoiseCondition(t, v1beta3.TortoiseConditionTypeVerticalCanary)
+	if c == nil || c.Status != corev1.ConditionFalse || c.Reason != ReasonRolledBack {
+		return false
+	}
+	return c.LastTransitionTime.Add(bakeDuration(t)).After(now)
+}
+
+// Gate decides whether the canary rollout can restart the Pods now,
+// that is, evict the Pods to create the canary Pods or promote the new resource requests to all the Pods.
+// It returns the tortoise with the updated conditions, and false when the restart has to wait.
+type Gate func(t *v1beta3.Tortoise) (*v1beta3.Tortoise, bool)
+
+// Start starts the canary rollout of the new resource requests in .status.conditions.containerResourceRequests.
+// The current resource requests are restored to .status.conditions.containerResourceRequests so that only the canary Pods get the new ones.
+// The canary Pods are created in Bake after the status is persisted.
+func (s *Service) Start(ctx context.Context, t *v1beta3.Tortoise, currentRequests []v1beta3.ContainerResourceRequests, replicas int32, now time.Time) *v1beta3.Tortoise {
+	canaryReplicas := canaryReplicas(t, replicas)
+	t.Status.VerticalRollout = &v1beta3.VerticalRolloutStatus{
+		ContainerResourceRequests: t.Status.Conditions.ContainerResourceRequests,
+		StartedAt:                 metav1.NewTime(now),
+		CanaryReplicas:            canaryReplicas,
+	}
+	t.Status.Conditions.ContainerResourceRequests = currentRequests
+
+	msg := fmt.Sprintf("The new resource requests are being tried on %d canary Pod(s) for %s", canaryReplicas, bakeDuration(t))
+	t = utils.ChangeTortoiseCondition(t, v1beta3.TortoiseConditionTypeVerticalCanary, corev1.ConditionTrue, ReasonBaking, msg, now)
+	s.recorder.Event(t, corev1.EventTypeNormal, event.VerticalCanaryStarted, msg)
+	log.FromContext(ctx).Info(msg, "tortoise", klog.KObj(t))
+
+	return t
+}
+
+// Bake checks the canary Pods.
+//   - If any canary Pod is OOMKilled or restarted, it rolls back: the canary Pods are deleted so that they're recreated with the current resource requests.
+//   - If the bake duration has passed and all the canary Pods are ready, it promotes the new resource requests to .status.conditions.containerResourceRequests,
+//     and the caller is supposed to restart the workload so that all the Pods get them.
+//   - Otherwise, it evicts the Pods to be replaced with the canary Pods if the canary Pods are fewer than the desired number.
+//
+// The promotion and the evictions wait while the gate doesn't allow restarting the Pods.
+func (s *Service) Bake(ctx context.Context, t *v1beta3.Tortoise, w *workload.Workload, now time.Time, gate Gate) (*v1beta3.Tortoise, error) {
+	if t.Spec.UpdateMode == v1beta3.UpdateModeOff || !Enabled(t) {
+		return s.finish(ctx, t, ReasonCanceled, "The canary rollout is canceled because the canary rollout or the tortoise is turned off", now)
+	}
+
+	pods := &corev1.PodList{}
+	if err := s.reader.List(ctx, pods, client.InNamespace(t.Namespace), client.MatchingLabels(w.PodTemplate.Labels)); err != nil {
+		return t, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var canaries, others []corev1.Pod
+	terminating := 0
+	for _, p := range pods.Items {
+		if !p.DeletionTimestamp.IsZero() {
+			terminating++
+			continue
+		}
+		if IsCanaryPod(&p, t) {
+			canaries = append(canaries, p)
+		} else {
+			others = append(others, p)
+		}
+	}
+
+	for _, p := range canaries {
+		if reason := unhealthyReason(&p); reason != "" {
+			return s.finish(ctx, t, ReasonRolledBack, fmt.Sprintf("The canary rollout is rolled back because the canary Pod %s is unhealthy: %s", p.Name, reason), now)
+		}
+	}
+
+	r := t.Status.VerticalRollout
+	if !r.StartedAt.Add(bakeDuration(t)).After(now) && countReady(canaries) >= int(r.CanaryReplicas) {
+		var allowed bool
+		if t, allowed = gate(t); !allowed {
+			log.FromContext(ctx).Info("the promotion of the canary rollout is deferred", "tortoise", klog.KObj(t))
+			return t, nil
+		}
+		if _, err := s.setReservation(ctx, t, rolloutStartedAt(t), ""); err != nil {
+			return t, fmt.Errorf("failed to release the canary reservation: %w", err)
+		}
+		// Keep the current resource requests so that they can be restored if the Pods get unhealthy with the promoted ones.
+		t.Status.Conditions.PreviousContainerResourceRequests = t.Status.Conditions.ContainerResourceRequests
+		t.Status.Conditions.ContainerResourceRequests = r.ContainerResourceRequests
+		t.Status.VerticalRollout = nil
+
+		msg := fmt.Sprintf("The new resource requests are promoted to all the Pods because %d canary Pod(s) stayed healthy for %s", len(canaries), bakeDuration(t))
+		t = utils.ChangeTortoiseCondition(t, v1beta3.TortoiseConditionTypeVerticalCanary, corev1.ConditionFalse, ReasonPromoted, msg, now)
+		s.recorder.Event(t, corev1.EventTypeNormal, event.VerticalCanaryPromoted, msg)
+		log.FromContext(ctx).Info(msg, "tortoise", klog.KObj(t))
+		return t, nil
+	}
+
+	recreating := (w.Replicas != nil && len(others)+len(canaries) < int(*w.Replicas)) || terminating != 0 || countReady(others) != len(others) || countReady(canaries) != len(canaries)
+	reservation, err := s.reservation(ctx, t)
+	if err != nil {
+		return t, fmt.Errorf("failed to get the canary reservation: %w", err)
+	}
+	if reservation == rolloutStartedAt(t) {
+		if recreating {
+			// The Pod replacing the evicted one isn't created or ready yet.
+			log.FromContext(ctx).Info("waiting for the pod replacing the evicted one to be created as the canary pod", "tortoise", klog.KObj(t))
+			return t, nil
+		}
+		// All the Pods are there, but none of them took the reservation, e.g., the eviction failed.
+		if _, err := s.setReservation(ctx, t, reservation, ""); err != nil {
+			return t, fmt.Errorf("failed to release the canary reservation: %w", err)
+		}
+		reservation = ""
+	}
+
+	if int(r.CanaryReplicas) <= len(canaries) {
+		return t, nil
+	}
+	if recreating {
+		// Some Pods are being recreated. Wait for them not to evict too many Pods at once.
+		log.FromContext(ctx).Info("waiting for all the Pods to be ready before creating the canary Pods", "tortoise", klog.KObj(t))
+		return t, nil
+	}
+	if len(others) == 0 {
+		return t, nil
+	}
+
+	var allowed bool
+	if t, allowed = gate(t); !allowed {
+		log.FromContext(ctx).Info("the evictions to create the canary Pods are deferred", "tortoise", klog.KObj(t))
+		return t, nil
+	}
+
+	// Evict the oldest Pod. Only one Pod is evicted in each reconciliation,
+	// and the next one is evicted after the canary Pod replacing it is ready.
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].CreationTimestamp.Before(&others[j].CreationTimestamp)
+	})
+	p := &others[0]
+	reserved, err := s.setReservation(ctx, t, reservation, rolloutStartedAt(t))
+	if err != nil {
+		return t, fmt.Errorf("failed to reserve the canary pod: %w", err)
+	}
+	if !reserved {
+		// The reservation was changed in the meantime. Try it again in the next reconciliation.
+		log.FromContext(ctx).Info("the canary reservation was changed in the meantime", "tortoise", klog.KObj(t))
+		return t, nil
+	}
+	if err := s.c.SubResource("eviction").Create(ctx, p, &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: p.Name, Namespace: p.Namespace}}); err != nil {
+		if _, rerr := s.setReservation(ctx, t, rolloutStartedAt(t), ""); rerr != nil {
+			log.FromContext(ctx).Error(rerr, "failed to release the canary reservation", "tortoise", klog.KObj(t))
+		}
+		if apierrors.IsTooManyRequests(err) {
+			// The PodDisruptionBudget doesn't allow the eviction now. Try it again in the next reconciliation.
+			log.FromContext(ctx).Info("the eviction of the pod is blocked by the PodDisruptionBudget", "tortoise", klog.KObj(t), "pod", klog.KObj(p))
+			return t, nil
+		}
+		return t, fmt.Errorf("failed to evict pod %s to create the canary pod: %w", p.Name, err)
+	}
+	log.FromContext(ctx).Info("the pod is evicted to be replaced with the canary pod", "tortoise", klog.KObj(t), "pod", klog.KObj(p))
+
+	return t, nil
+}
+
+// finish finishes the canary rollout without promoting the new resource requests.
+// The canary Pods are deleted so that they're recreated with the current resource requests.
+func (s *Service) finish(ctx context.Context, t *v1beta3.Tortoise, reason, msg string, now time.Time) (*v1beta3.Tortoise, error) {
+	current := rolloutStartedAt(t)
+	if _, err := s.setReservation(ctx, t, current, ""); err != nil {
+		return t, fmt.Errorf("failed to release the canary reservation: %w", err)
+	}
+	t.Status.VerticalRollout = nil
+	t = utils.ChangeTortoiseCondition(t, v1beta3.TortoiseConditionTypeVerticalCanary, corev1.ConditionFalse, reason, msg, now)
+	eventType, eventReason := corev1.EventTypeNormal, event.VerticalCanaryCanceled
+	if reason == ReasonRolledBack {
+		eventType, eventReason = corev1.EventTypeWarning, event.VerticalCanaryRolledBack
+	}
+	s.recorder.Event(t, eventType, eventReason, msg)
+	log.FromContext(ctx).Info(msg, "tortoise", klog.KObj(t))
+
+	pods := &corev1.PodList{}
+	if err := s.reader.List(ctx, pods, client.InNamespace(t.Namespace), client.MatchingLabels{Label: string(t.UID)}); err != nil {
+		return t, fmt.Errorf("failed to list canary pods: %w", err)
+	}
+	for _, p := range pods.Items {
+		if p.Annotations[annotation.CanaryStartedAtAnnotation] != current {
+			continue
+		}
+		if err := s.c.Delete(ctx, &p); client.IgnoreNotFound(err) != nil {
+			return t, fmt.Errorf("failed to delete canary pod %s: %w", p.Name, err)
+		}
+	}
+
+	return t, nil
+}
+
+// MutatePod makes the Pod the canary Pod if the canary rollout is in progress and the Pod takes the canary reservation,
+// that is, the Pod replaces the Pod evicted to create the canary Pod.
+// It returns the tortoise whose .status.conditions.containerResourceRequests is the resource requests which the Pod should get.
+func (s *Service) MutatePod(ctx context.Context, pod *corev1.Pod, t *v1beta3.Tortoise) (*v1beta3.Tortoise, bool, error) {
+	if !InProgress(t) || t.Spec.UpdateMode == v1beta3.UpdateModeOff {
+		return t, false, nil
+	}
+
+	// Only one of the Pods created at the same time can take the reservation.
+	taken, err := s.setReservation(ctx, t, rolloutStartedAt(t), "")
+	if err != nil {
+		return t, false, fmt.Errorf("failed to take the canary reservation: %w", err)
+	}
+	if !taken {
+		return t, false, nil
+	}
+
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	pod.Labels[Label] = string(t.UID)
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[annotation.CanaryStartedAtAnnotation] = rolloutStartedAt(t)
+
+	t = t.DeepCopy()
+	t.Status.Conditions.ContainerResourceRequests = t.Status.VerticalRollout.ContainerResourceRequests
+	return t, true, nil
+}
+
+// reservation returns the value of CanaryReservationAnnotation on the latest tortoise.
+// The tortoise is read from the API server because the cache may not have the latest reservation yet.
+func (s *Service) reservation(ctx context.Context, t *v1beta3.Tortoise) (string, error) {
+	latest := &v1beta3.Tortoise{}
+	if err := s.reader.Get(ctx, client.ObjectKeyFromObject(t), latest); err != nil {
+		return "", err
+	}
+	return latest.Annotations[annotation.CanaryReservationAnnotation], nil
+}
+
+// setReservation changes CanaryReservationAnnotation on the tortoise from `from` to `to`; the empty value means no reservation.
+// It returns false without changing it if the current value isn't `from`.
+// The tortoise is patched with the optimistic lock so that only one of the concurrent callers succeeds in changing it.
+func (s *Service) setReservation(ctx context.Context, t *v1beta3.Tortoise, from, to string) (bool, error) {
+	changed := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1beta3.Tortoise{}
+		if err := s.reader.Get(ctx, client.ObjectKeyFromObject(t), latest); err != nil {
+			return err
+		}
+		if latest.Annotations[annotation.CanaryReservationAnnotation] != from {
+			return nil
+		}
+		if from == to {
+			changed = true
+			return nil
+		}
+		patch := client.MergeFromWithOptions(latest.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		if to == "" {
+			delete(latest.Annotations, annotation.CanaryReservationAnnotation)
+		} else {
+			if latest.Annotations == nil {
+				latest.Annotations = map[string]string{}
+			}
+			latest.Annotations[annotation.CanaryReservationAnnotation] = to
+		}
+		if err := s.c.Patch(ctx, latest, patch); err != nil {
+			return err
+		}
+		changed = true
+		return nil
+	})
+	return changed, err
+}
+
+// IsCanaryPod returns true when the Pod is the canary Pod of the canary rollout in progress.
+func IsCanaryPod(pod *corev1.Pod, t *v1beta3.Tortoise) bool {
+	if !InProgress(t) || pod.Labels[Label] != string(t.UID) {
+		return false
+	}
+	return pod.Annotations[annotation.CanaryStartedAtAnnotation] == rolloutStartedAt(t)
+}
+
+// rolloutStartedAt returns the time (RFC3339) when the canary rollout in progress started, which identifies the canary rollout.
+func rolloutStartedAt(t *v1beta3.Tortoise) string {
+	return t.Status.VerticalRollout.StartedAt.UTC().Format(time.RFC3339)
+}
+
+func canaryReplicas(t *v1beta3.Tortoise, replicas int32) int32 {
+	percent := int32(defaultPercent)
+	if p := t.Spec.VerticalRollout.Canary.Percent; p != nil {
+		percent = *p
+	}
+	// round up so that at least one Pod is the canary.
+	n := (replicas*percent + 99) / 100
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func bakeDuration(t *v1beta3.Tortoise) time.Duration {
+	if d := t.Spec.VerticalRollout.Canary.BakeDuration; d != nil {
+		return d.Duration
+	}
+	return defaultBakeDuration
+}
+
+// unhealthyReason returns why the Pod looks unhealthy, or the empty string if it's healthy.
+// The canary Pods are new, and thus any restart means something is wrong with the new resource requests.
+func unhealthyReason(pod *corev1.Pod) string {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if t := cs.LastTerminationState.Terminated; t != nil && t.Reason == "OOMKilled" {
+			return fmt.Sprintf("the container %s was OOMKilled", cs.Name)
+		}
+		if t := cs.State.Terminated; t != nil && t.Reason == "OOMKilled" {
+			return fmt.Sprintf("the container %s was OOMKilled", cs.Name)
+		}
+		if w := cs.State.Waiting; w != nil && w.Reason == "CrashLoopBackOff" {
+			return fmt.Sprintf("the container %s is in CrashLoopBackOff", cs.Name)
+		}
+		if cs.RestartCount > 0 {
+			return fmt.Sprintf("the container %s was restarted %d time(s)", cs.Name, cs.RestartCount)
+		}
+	}
+	return ""
+}
+
+func countReady(pods []corev1.Pod) int {
+	n := 0
+	for _, p := range pods {
+		for _, c := range p.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,481 @@
+package canary
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/utils"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+var (
+	now       = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	startedAt = now.Add(-10 * time.Minute)
+
+	scheme = func() *runtime.Scheme {
+		s := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(s))
+		utilruntime.Must(v1beta3.AddToScheme(s))
+		return s
+	}()
+
+	oomKilled = corev1.ContainerStatus{
+		Name:                 "app",
+		RestartCount:         1,
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"}},
+	}
+)
+
+func requests(memory string) []v1beta3.ContainerResourceRequests {
+	return []v1beta3.ContainerResourceRequests{
+		{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)}},
+	}
+}
+
+func TestService_Start(t *testing.T) {
+	tests := []struct {
+		name               string
+		percent            *int32
+		replicas           int32
+		wantCanaryReplicas int32
+	}{
+		{
+			name:               "default percent",
+			replicas:           30,
+			wantCanaryReplicas: 3,
+		},
+		{
+			name:               "rounded up",
+			percent:            ptr.To[int32](50),
+			replicas:           5,
+			wantCanaryReplicas: 3,
+		},
+		{
+			name:               "at least one pod is the canary",
+			replicas:           1,
+			wantCanaryReplicas: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tortoise := utils.NewTortoiseBuilder().SetName("t").SetNamespace("default").SetUpdateMode(v1beta3.UpdateModeAuto).
+				SetVerticalRollout(&v1beta3.VerticalRollout{Canary: &v1beta3.CanaryRollout{Percent: tt.percent}}).
+				// UpdateResourceRequest has put the new resource requests.
+				AddContainerResourceRequests(requests("800Mi")[0]).
+				Build()
+
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+			s := New(c, c, record.NewFakeRecorder(10))
+			got := s.Start(context.Background(), tortoise, requests("1Gi"), tt.replicas, now)
+
+			want := &v1beta3.VerticalRolloutStatus{
+				ContainerResourceRequests: requests("800Mi"),
+				StartedAt:                 metav1.NewTime(now),
+				CanaryReplicas:            tt.wantCanaryReplicas,
+			}
+			if d := cmp.Diff(want, got.Status.VerticalRollout); d != "" {
+				t.Errorf("Start() verticalRollout diff = %s", d)
+			}
+			if d := cmp.Diff(requests("1Gi"), got.Status.Conditions.ContainerResourceRequests); d != "" {
+				t.Errorf("Start() should keep the current resource requests, diff = %s", d)
+			}
+			if c := got.Status.Conditions.TortoiseConditions; len(c) != 1 || c[0].Status != corev1.ConditionTrue || c[0].Reason != ReasonBaking {
+				t.Errorf("Start() conditions = %v", c)
+			}
+		})
+	}
+}
+
+func TestService_Bake(t *testing.T) {
+	tests := []struct {
+		name string
+		// The tortoise is Auto, and bakes the canary Pods for 30 minutes by default.
+		updateMode     v1beta3.UpdateMode
+		bakeDuration   time.Duration
+		canaryReplicas int32
+		reservation    string
+		pods           []*corev1.Pod
+		replicas       int32
+		gateClosed     bool
+
+		wantRequests         []v1beta3.ContainerResourceRequests
+		wantPreviousRequests []v1beta3.ContainerResourceRequests
+		wantInProgress       bool
+		wantConditionReason  string
+		wantRemainingPods    []string
+		wantReservation      string
+		wantNoConditionAdded bool
+	}{
+		{
+			name: "reserve the canary pod and evict the oldest pod to create it",
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+			},
+			replicas:             3,
+			wantRequests:         requests("1Gi"),
+			wantInProgress:       true,
+			wantRemainingPods:    []string{"pod-1", "pod-3"},
+			wantReservation:      startedAt.Format(time.RFC3339),
+			wantNoConditionAdded: true,
+		},
+		{
+			name:           "evict only one pod in a reconciliation",
+			canaryReplicas: 2,
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+			},
+			replicas:             3,
+			wantRequests:         requests("1Gi"),
+			wantInProgress:       true,
+			wantRemainingPods:    []string{"pod-1", "pod-3"},
+			wantReservation:      startedAt.Format(time.RFC3339),
+			wantNoConditionAdded: true,
+		},
+		{
+			name:        "wait for the pod replacing the evicted one",
+			reservation: startedAt.Format(time.RFC3339),
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+			},
+			replicas:             3,
+			wantRequests:         requests("1Gi"),
+			wantInProgress:       true,
+			wantRemainingPods:    []string{"pod-1", "pod-3"},
+			wantReservation:      startedAt.Format(time.RFC3339),
+			wantNoConditionAdded: true,
+		},
+		{
+			name:        "release the reservation which no pod took",
+			reservation: startedAt.Format(time.RFC3339),
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+			},
+			replicas:             3,
+			gateClosed:           true,
+			wantRequests:         requests("1Gi"),
+			wantInProgress:       true,
+			wantRemainingPods:    []string{"pod-1", "pod-2", "pod-3"},
+			wantNoConditionAdded: true,
+		},
+		{
+			name: "don't evict the pod while the gate is closed",
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+			},
+			replicas:             3,
+			gateClosed:           true,
+			wantRequests:         requests("1Gi"),
+			wantInProgress:       true,
+			wantRemainingPods:    []string{"pod-1", "pod-2", "pod-3"},
+			wantNoConditionAdded: true,
+		},
+		{
+			name: "wait for the pods being recreated",
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				// not ready
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+			},
+			replicas:             3,
+			wantRequests:         requests("1Gi"),
+			wantInProgress:       true,
+			wantRemainingPods:    []string{"pod-1", "pod-2", "pod-3"},
+			wantNoConditionAdded: true,
+		},
+		{
+			name: "keep baking",
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-5*time.Minute)).SetReady().
+					AddLabel(Label, "tortoise-uid").AddAnnotation(annotation.CanaryStartedAtAnnotation, startedAt.Format(time.RFC3339)).Build(),
+			},
+			replicas:             3,
+			wantRequests:         requests("1Gi"),
+			wantInProgress:       true,
+			wantRemainingPods:    []string{"pod-1", "pod-2", "pod-3"},
+			wantNoConditionAdded: true,
+		},
+		{
+			name:         "promote after the bake duration",
+			bakeDuration: 5 * time.Minute,
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-5*time.Minute)).SetReady().
+					AddLabel(Label, "tortoise-uid").AddAnnotation(annotation.CanaryStartedAtAnnotation, startedAt.Format(time.RFC3339)).Build(),
+			},
+			replicas:             3,
+			wantRequests:         requests("800Mi"),
+			wantPreviousRequests: requests("1Gi"),
+			wantConditionReason:  ReasonPromoted,
+			wantRemainingPods:    []string{"pod-1", "pod-2", "pod-3"},
+		},
+		{
+			name:         "don't promote while the gate is closed",
+			bakeDuration: 5 * time.Minute,
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-5*time.Minute)).SetReady().
+					AddLabel(Label, "tortoise-uid").AddAnnotation(annotation.CanaryStartedAtAnnotation, startedAt.Format(time.RFC3339)).Build(),
+			},
+			replicas:             3,
+			gateClosed:           true,
+			wantRequests:         requests("1Gi"),
+			wantInProgress:       true,
+			wantRemainingPods:    []string{"pod-1", "pod-2", "pod-3"},
+			wantNoConditionAdded: true,
+		},
+		{
+			name:         "don't promote without the canary pod",
+			bakeDuration: 5 * time.Minute,
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				// not ready
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).Build(),
+			},
+			replicas:             2,
+			wantRequests:         requests("1Gi"),
+			wantInProgress:       true,
+			wantRemainingPods:    []string{"pod-1", "pod-2"},
+			wantNoConditionAdded: true,
+		},
+		{
+			name:        "roll back when the canary pod is OOMKilled",
+			reservation: startedAt.Format(time.RFC3339),
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-5*time.Minute)).SetReady().
+					AddLabel(Label, "tortoise-uid").AddAnnotation(annotation.CanaryStartedAtAnnotation, startedAt.Format(time.RFC3339)).
+					AddContainerStatus(oomKilled).Build(),
+			},
+			replicas:            3,
+			wantRequests:        requests("1Gi"),
+			wantConditionReason: ReasonRolledBack,
+			wantRemainingPods:   []string{"pod-1", "pod-2"},
+		},
+		{
+			name:       "cancel when the tortoise is turned off",
+			updateMode: v1beta3.UpdateModeOff,
+			pods: []*corev1.Pod{
+				utils.NewPodBuilder().SetName("pod-1").SetNamespace("default").SetCreationTimestamp(now.Add(-time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-2").SetNamespace("default").SetCreationTimestamp(now.Add(-2 * time.Hour)).SetReady().Build(),
+				utils.NewPodBuilder().SetName("pod-3").SetNamespace("default").SetCreationTimestamp(now.Add(-5*time.Minute)).SetReady().
+					AddLabel(Label, "tortoise-uid").AddAnnotation(annotation.CanaryStartedAtAnnotation, startedAt.Format(time.RFC3339)).Build(),
+			},
+			replicas:            3,
+			wantRequests:        requests("1Gi"),
+			wantConditionReason: ReasonCanceled,
+			wantRemainingPods:   []string{"pod-1", "pod-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateMode, bakeDuration, canaryReplicas := v1beta3.UpdateModeAuto, 30*time.Minute, int32(1)
+			if tt.updateMode != "" {
+				updateMode = tt.updateMode
+			}
+			if tt.bakeDuration != 0 {
+				bakeDuration = tt.bakeDuration
+			}
+			if tt.canaryReplicas != 0 {
+				canaryReplicas = tt.canaryReplicas
+			}
+			b := utils.NewTortoiseBuilder().SetName("t").SetNamespace("default").SetUID("tortoise-uid").SetUpdateMode(updateMode).
+				SetVerticalRollout(&v1beta3.VerticalRollout{Canary: &v1beta3.CanaryRollout{BakeDuration: &metav1.Duration{Duration: bakeDuration}}}).
+				AddContainerResourceRequests(requests("1Gi")[0]).
+				SetVerticalRolloutStatus(&v1beta3.VerticalRolloutStatus{
+					ContainerResourceRequests: requests("800Mi"),
+					StartedAt:                 metav1.NewTime(startedAt),
+					CanaryReplicas:            canaryReplicas,
+				})
+			if tt.reservation != "" {
+				b = b.AddAnnotation(annotation.CanaryReservationAnnotation, tt.reservation)
+			}
+			tortoise := b.Build()
+
+			objs := []client.Object{tortoise.DeepCopy()}
+			for _, p := range tt.pods {
+				objs = append(objs, p)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+			s := New(c, c, record.NewFakeRecorder(10))
+
+			gate := func(t *v1beta3.Tortoise) (*v1beta3.Tortoise, bool) { return t, !tt.gateClosed }
+			w := &workload.Workload{Replicas: ptr.To(tt.replicas), PodTemplate: &corev1.PodTemplateSpec{}}
+			got, err := s.Bake(context.Background(), tortoise, w, now, gate)
+			if err != nil {
+				t.Fatalf("Bake() error = %v", err)
+			}
+			if d := cmp.Diff(tt.wantRequests, got.Status.Conditions.ContainerResourceRequests); d != "" {
+				t.Errorf("Bake() containerResourceRequests diff = %s", d)
+			}
+			if d := cmp.Diff(tt.wantPreviousRequests, got.Status.Conditions.PreviousContainerResourceRequests); d != "" {
+				t.Errorf("Bake() previousContainerResourceRequests diff = %s", d)
+			}
+			if InProgress(got) != tt.wantInProgress {
+				t.Errorf("Bake() in progress = %v, want %v", InProgress(got), tt.wantInProgress)
+			}
+			if tt.wantNoConditionAdded {
+				if len(got.Status.Conditions.TortoiseConditions) != 0 {
+					t.Errorf("Bake() shouldn't change the conditions, got %v", got.Status.Conditions.TortoiseConditions)
+				}
+			} else {
+				if c := got.Status.Conditions.TortoiseConditions; len(c) != 1 || c[0].Status != corev1.ConditionFalse || c[0].Reason != tt.wantConditionReason {
+					t.Errorf("Bake() conditions = %v, want the reason %s", c, tt.wantConditionReason)
+				}
+			}
+
+			pods := &corev1.PodList{}
+			if err := c.List(context.Background(), pods); err != nil {
+				t.Fatalf("failed to list pods: %v", err)
+			}
+			names := []string{}
+			for _, p := range pods.Items {
+				names = append(names, p.Name)
+			}
+			if d := cmp.Diff(tt.wantRemainingPods, names, cmpopts.SortSlices(func(a, b string) bool { return a < b })); d != "" {
+				t.Errorf("Bake() remaining pods diff = %s", d)
+			}
+
+			latest := &v1beta3.Tortoise{}
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(tortoise), latest); err != nil {
+				t.Fatalf("failed to get tortoise: %v", err)
+			}
+			if got := latest.Annotations[annotation.CanaryReservationAnnotation]; got != tt.wantReservation {
+				t.Errorf("Bake() reservation = %q, want %q", got, tt.wantReservation)
+			}
+		})
+	}
+}
+
+func TestService_MutatePod(t *testing.T) {
+	tests := []struct {
+		name         string
+		inProgress   bool
+		reservation  string
+		wantCanary   bool
+		wantRequests []v1beta3.ContainerResourceRequests
+	}{
+		{
+			name:         "no canary rollout in progress",
+			wantRequests: requests("1Gi"),
+		},
+		{
+			name:         "the pod taking the reservation becomes the canary",
+			inProgress:   true,
+			reservation:  startedAt.Format(time.RFC3339),
+			wantCanary:   true,
+			wantRequests: requests("800Mi"),
+		},
+		{
+			name:         "the pod without the reservation, e.g., created by scaling up, doesn't become the canary",
+			inProgress:   true,
+			wantRequests: requests("1Gi"),
+		},
+		{
+			name:         "the reservation from the past canary rollout isn't taken",
+			inProgress:   true,
+			reservation:  now.Add(-time.Hour).Format(time.RFC3339),
+			wantRequests: requests("1Gi"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := utils.NewTortoiseBuilder().SetName("t").SetNamespace("default").SetUID("tortoise-uid").SetUpdateMode(v1beta3.UpdateModeAuto).
+				SetVerticalRollout(&v1beta3.VerticalRollout{Canary: &v1beta3.CanaryRollout{}}).
+				AddContainerResourceRequests(requests("1Gi")[0])
+			if tt.inProgress {
+				b = b.SetVerticalRolloutStatus(&v1beta3.VerticalRolloutStatus{
+					ContainerResourceRequests: requests("800Mi"),
+					StartedAt:                 metav1.NewTime(startedAt),
+					CanaryReplicas:            1,
+				})
+			}
+			if tt.reservation != "" {
+				b = b.AddAnnotation(annotation.CanaryReservationAnnotation, tt.reservation)
+			}
+			tortoise := b.Build()
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tortoise.DeepCopy()).Build()
+			s := New(c, c, record.NewFakeRecorder(10))
+
+			pod := utils.NewPodBuilder().SetName("new-pod").SetNamespace("default").Build()
+			got, isCanary, err := s.MutatePod(context.Background(), pod, tortoise)
+			if err != nil {
+				t.Fatalf("MutatePod() error = %v", err)
+			}
+			if isCanary != tt.wantCanary {
+				t.Errorf("MutatePod() isCanary = %v, want %v", isCanary, tt.wantCanary)
+			}
+			if IsCanaryPod(pod, tortoise) != tt.wantCanary {
+				t.Errorf("MutatePod() the pod is labeled as the canary = %v, want %v", IsCanaryPod(pod, tortoise), tt.wantCanary)
+			}
+			if d := cmp.Diff(tt.wantRequests, got.Status.Conditions.ContainerResourceRequests); d != "" {
+				t.Errorf("MutatePod() containerResourceRequests diff = %s", d)
+			}
+			if d := cmp.Diff(requests("1Gi"), tortoise.Status.Conditions.ContainerResourceRequests); d != "" {
+				t.Errorf("MutatePod() shouldn't modify the given tortoise, diff = %s", d)
+			}
+
+			// The reservation is taken only once.
+			_, isCanary, err = s.MutatePod(context.Background(), utils.NewPodBuilder().SetName("another-pod").SetNamespace("default").Build(), tortoise)
+			if err != nil {
+				t.Fatalf("MutatePod() error = %v", err)
+			}
+			if isCanary {
+				t.Errorf("MutatePod() another pod shouldn't become the canary")
+			}
+		})
+	}
+}
+
+func TestRecentlyRolledBack(t *testing.T) {
+	rolledBack := func(at time.Time) *v1beta3.Tortoise {
+		return utils.NewTortoiseBuilder().
+			SetVerticalRollout(&v1beta3.VerticalRollout{Canary: &v1beta3.CanaryRollout{BakeDuration: &metav1.Duration{Duration: 30 * time.Minute}}}).
+			AddTortoiseConditions(v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeVerticalCanary,
+				Status:             corev1.ConditionFalse,
+				Reason:             ReasonRolledBack,
+				LastTransitionTime: metav1.NewTime(at),
+			}).Build()
+	}
+	if !RecentlyRolledBack(rolledBack(now.Add(-10*time.Minute)), now) {
+		t.Errorf("RecentlyRolledBack() should be true within the bake duration")
+	}
+	if RecentlyRolledBack(rolledBack(now.Add(-time.Hour)), now) {
+		t.Errorf("RecentlyRolledBack() should be false after the bake duration")
+	}
+	notRolledBack := utils.NewTortoiseBuilder().SetVerticalRollout(&v1beta3.VerticalRollout{Canary: &v1beta3.CanaryRollout{}}).Build()
+	if RecentlyRolledBack(notRolledBack, now) {
+		t.Errorf("RecentlyRolledBack() should be false without the rollback")
+	}
+}
@@ -3,11 +3,15 @@ package config
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
 
+	"github.com/mercari/tortoise/pkg/cron"
 	"github.com/mercari/tortoise/pkg/features"
 )
 
@@ -195,6 +199,65 @@ type Config struct {
 	// For example, if the recommendation from VPA is 100m, and BufferOnVerticalResource is 0.1,
 	// the tortoise will set the resource request to 110m.
 	BufferRatioOnVerticalResource float64 `yaml:"BufferRatioOnVerticalResource"`
+	// VerticalScaleUpCooldown is the minimum interval between increasing the resource requests (default: 0)
+	// It can be overridden per resource via .spec.resourcePolicy[*].behavior[*].verticalScaleUpCooldown in Tortoise.
+	VerticalScaleUpCooldown time.Duration `yaml:"VerticalScaleUpCooldown"`
+	// VerticalScaleDownCooldown is the minimum interval between decreasing the resource requests (default: 1h)
+	// It can be overridden per resource via .spec.resourcePolicy[*].behavior[*].verticalScaleDownCooldown in Tortoise.
+	VerticalScaleDownCooldown time.Duration `yaml:"VerticalScaleDownCooldown"`
+	// VerticalMinimumChangeRatio is the minimum change of the resource request to apply, relative to the current resource request (default: 0)
+	// For example, if it's 0.1 and the current resource request is 100m, tortoise ignores the new recommendation between 91m and 109m.
+	// The increases are ignored only when the current resource request still covers the recommendation from VPA without the buffer.
+	// 0 means the decreases smaller than BufferRatioOnVerticalResource are ignored, and the increases aren't ignored.
+	// It can be overridden per resource via .spec.resourcePolicy[*].behavior[*].verticalMinimumChangePercent in Tortoise.
+	VerticalMinimumChangeRatio float64 `yaml:"VerticalMinimumChangeRatio"`
+	// VerticalMinimumCPUChange is the minimum change of the CPU request to apply (default: 0)
+	// It can be overridden per resource via .spec.resourcePolicy[*].behavior[*].verticalMinimumChange in Tortoise.
+	VerticalMinimumCPUChange string `yaml:"VerticalMinimumCPUChange"`
+	// VerticalMinimumMemoryChange is the minimum change of the memory request to apply (default: 0)
+	// It can be overridden per resource via .spec.resourcePolicy[*].behavior[*].verticalMinimumChange in Tortoise.
+	VerticalMinimumMemoryChange string `yaml:"VerticalMinimumMemoryChange"`
+	// VerticalMaxStepRatio is the maximum change of the resource request per apply, relative to the current resource request (default: 0)
+	// For example, if it's 0.5 and the current resource request is 100m, tortoise changes it to between 50m and 150m at once.
+	// 0 means no limit. Note that the decrease is also limited by MaxAllowedScalingDownRatio.
+	// It can be overridden per resource via .spec.resourcePolicy[*].behavior[*].verticalMaxStepPercent in Tortoise.
+	VerticalMaxStepRatio float64 `yaml:"VerticalMaxStepRatio"`
+	// VerticalRollbackWindow is the duration after tortoise updates the resource requests,
+	// during which tortoise watches the Pods and rolls back the resource requests
+	// if the Pods get OOMKilled or go into CrashLoopBackOff with the decreased resource requests (default: 30m)
+	// 0 disables the rollback.
+	VerticalRollbackWindow time.Duration `yaml:"VerticalRollbackWindow"`
+	// VerticalRollbackFloorDecayWindow is how long tortoise keeps the floor raised by the rollback or OOMKills after it's raised last time (default: 24h)
+	VerticalRollbackFloorDecayWindow time.Duration `yaml:"VerticalRollbackFloorDecayWindow"`
+	// OOMMemoryBumpRatio is how much tortoise raises the memory recommendation of the container on each OOMKill (default: 0)
+	// For example, if the memory request is 1Gi and OOMMemoryBumpRatio is 0.2,
+	// the memory recommendation doesn't go below 1.2Gi after the container is OOMKilled, even if VPA suggests less.
+	// 0 disables the bump. Note that tortoise lists the Pods of the workload from the API server in every reconciliation when it's enabled.
+	OOMMemoryBumpRatio float64 `yaml:"OOMMemoryBumpRatio"`
+	// VerticalRolloutStrategy is how tortoise applies the new resource requests to the running Pods by default.
+	// "Restart" and "InPlace" are only valid value. (default: Restart)
+	// It can be overridden per tortoise via .spec.verticalRollout.strategy.
+	//
+	// If "Restart", tortoise restarts the workload, and the Pod mutating webhook gives the new resource requests to the recreated Pods.
+	// If "InPlace", tortoise resizes the containers of the running Pods via the resize subresource (InPlacePodVerticalScaling) without recreating them,
+	// and falls back to "Restart" when a container needs to be restarted for the resize.
+	VerticalRolloutStrategy string `yaml:"VerticalRolloutStrategy"`
+	// VerticalApplyRateLimit is the maximum number of the vertical applies per minute in the whole cluster (default: 0)
+	// Each vertical apply, i.e., the change of the resource requests in a tortoise, usually restarts the Pods of the workload.
+	// The tortoises which exceed the limit wait for the next reconciliation to apply the new resource requests.
+	// 0 means no limit.
+	VerticalApplyRateLimit float64 `yaml:"VerticalApplyRateLimit"`
+	// VerticalApplyBurst is the maximum number of the vertical applies at once when VerticalApplyRateLimit is set (default: 10)
+	VerticalApplyBurst int `yaml:"VerticalApplyBurst"`
+	// VerticalMaintenanceWindows are the windows when tortoise can decrease the resource requests (default: empty)
+	// Outside the windows, only the increases of the resource requests are applied, and the decreases wait for the next window.
+	// If empty, the decreases are applied anytime.
+	//
+	// VerticalMaintenanceWindows:
+	//   # Every day from 2:00 to 5:00 in TimeZone.
+	//   - Schedule: "0 2 * * *"
+	//     Duration: 3h
+	VerticalMaintenanceWindows []MaintenanceWindow `yaml:"VerticalMaintenanceWindows"`
 	// MinimumMemoryRequestPerContainer is the minimum memory bytes per container that the tortoise can give to the container (default: nil)
 	// If you specify both, the tortoise uses MinimumMemoryRequestPerContainer basically, but if the container name is not found in this map, the tortoise uses MinimumMemoryRequest.
 	//
@@ -240,6 +303,12 @@ type Config struct {
 	// This prevents false emergency mode triggers during temporary HPA metric unavailability during HPA updates, deployments, scheduled scaling, etc.
 	// During this grace period, the system will continue normal operation even if HPA metrics are temporarily unavailable.
 	EmergencyModeGracePeriod time.Duration `yaml:"EmergencyModeGracePeriod"`
+	// MaximumEmergencyModeDuration is the maximum duration of Emergency mode (default: 0, no limit)
+	// Once a tortoise has been in Emergency mode (.spec.updateMode: Emergency) longer than this duration,
+	// the controller changes .spec.updateMode to Auto, and the tortoise goes back to normal gradually.
+	// It's a safety guard for the case where people forget to turn off Emergency mode.
+	// Each tortoise can have the shorter expiry via the tortoise.autoscaling.mercari.com/emergency-until annotation.
+	MaximumEmergencyModeDuration time.Duration `yaml:"MaximumEmergencyModeDuration"`
 
 	// DefaultHPABehavior defines the default behavior for HPAs created and managed by Tortoise.
 	// If not specified, Tortoise will use built-in default values that scale up aggressively and scale down conservatively.
@@ -283,6 +352,24 @@ type Config struct {
 	// The default value is nil; Tortoise doesn't change the resource limit itself.
 	ResourceLimitMultiplier map[string]int64 `yaml:"ResourceLimitMultiplier"`
 
+	// ScaleSubresourceWorkloads is the list of the additional workloads that tortoise can target (default: empty)
+	// Deployment, StatefulSet and DaemonSet are always supported, and you don't need to put them here.
+	//
+	// The workload has to expose the scale subresource (`/scale`), which tortoise reads the number of replicas from,
+	// and the pod template at `PodTemplatePath`, which tortoise reads the resource requests from,
+	// and updates the annotation on to restart the Pods.
+	// Also, you have to grant the permissions (get, list, watch, update, patch) on the workload
+	// and (get, patch) on its scale subresource to the tortoise controller. See docs/user-guide.md for the example ClusterRole.
+	//
+	// For example, you can make tortoise support Argo Rollouts like this:
+	// ```yaml
+	// ScaleSubresourceWorkloads:
+	//   - APIVersion: argoproj.io/v1alpha1
+	//     Kind: Rollout
+	//     PodTemplatePath: spec.template # (default: spec.template)
+	// ```
+	ScaleSubresourceWorkloads []ScaleSubresourceWorkload `yaml:"ScaleSubresourceWorkloads"`
+
 	// TODO: the following fields should be removed after we stop depending on deployment.
 	// So, we don't put them in the documentation.
 	// IstioSidecarProxyDefaultCPU is the default CPU resource request of the istio sidecar proxy (default: 100m)
@@ -301,6 +388,92 @@ type Config struct {
 	// without modifying individual Tortoise resources.
 	// Default: false (Tortoise operates normally)
 	GlobalDisableMode bool `yaml:"GlobalDisableMode"`
+
+	// VerticalRecommender is the recommender that tortoise uses to get the resource recommendation for the vertical scaling.
+	// "VPA" and "Tortoise" are only valid value. (default: VPA)
+	//
+	// If "VPA", tortoise creates a monitor VPA (tortoise-monitor-<tortoise name>) for each tortoise,
+	// and uses the recommendation from it. The VPA recommender has to be running in the cluster.
+	// If "Tortoise", tortoise calculates the recommendation by itself from the resource usage given by UsageSource,
+	// and you don't need to run VPA in the cluster.
+	// The in-process recommender builds the histograms of the resource usage per container,
+	// whose weights decay by UsageHistogramDecayHalfLife, and uses the percentiles of them as the recommendation.
+	// Note that the histograms are kept in memory, and they're lost when the controller restarts.
+	VerticalRecommender string `yaml:"VerticalRecommender"`
+	// UsageSource is where the in-process recommender gets the resource usage of the containers from.
+	// "MetricsAPI" and "Prometheus" are only valid value. (default: MetricsAPI)
+	// It's used only when VerticalRecommender is "Tortoise".
+	//
+	// If "MetricsAPI", tortoise gets the usage from the metrics.k8s.io API, which is usually served by metrics-server.
+	// If "Prometheus", tortoise gets the usage from the cAdvisor metrics (container_cpu_usage_seconds_total and container_memory_working_set_bytes)
+	// in the Prometheus at PrometheusAddress.
+	UsageSource string `yaml:"UsageSource"`
+	// PrometheusAddress is the address of the Prometheus server, e.g., http://prometheus.monitoring:9090 (default: "")
+	// It's required when UsageSource is "Prometheus".
+	PrometheusAddress string `yaml:"PrometheusAddress"`
+	// UsageHistogramDecayHalfLife is the half-life of the weights of the usage samples in the in-process recommender (default: 24h)
+	// The bigger value makes the recommendation more stable, and the smaller value makes it follow the recent usage faster.
+	UsageHistogramDecayHalfLife time.Duration `yaml:"UsageHistogramDecayHalfLife"`
+	// UsageMinimumObservationPeriod is the minimum period that the in-process recommender observes the usage
+	// before its recommendation is used (default: 24h)
+	// The histograms are lost when the controller restarts,
+	// and this prevents the recommendation from only a few samples from replacing the established one after the restart.
+	// Until then, tortoise keeps the current resource requests.
+	UsageMinimumObservationPeriod time.Duration `yaml:"UsageMinimumObservationPeriod"`
+	// PrometheusHistoryBackfill enables the backfill of the recommendation from the historical usage in the Prometheus at PrometheusAddress (default: false)
+	// It works with both VerticalRecommender, "VPA" and "Tortoise".
+	//
+	// When a tortoise is created for the workload which has been running for a while,
+	// tortoise queries the p99 and max usage of each container over the period of GatheringDataPeriodType (1 week or 1 day),
+	// and if the history covers the whole period, tortoise uses them as the recommendation
+	// and starts to work without waiting for gathering data.
+	// Horizontal resources still need the min/max replicas recommendation to start working.
+	// The Pods of the workload are found by kube_pod_owner and kube_replicaset_owner from kube-state-metrics in the Prometheus.
+	PrometheusHistoryBackfill bool `yaml:"PrometheusHistoryBackfill"`
+	// ReplicasHistoryBackfillSource is where tortoise gets the history of the number of replicas
+	// to backfill the min/max replicas recommendation of a new tortoise.
+	// "", "Prometheus" and "CSV" are only valid value. (default: "", which disables the backfill)
+	//
+	// When a tortoise is created for the workload which has been running for a while,
+	// tortoise replays the number of replicas in the period of GatheringDataPeriodType (1 week or 1 day)
+	// to calculate the min/max replicas recommendation, in the same way as it does in every reconciliation.
+	// If all the time slots of the recommendation get the value, the horizontal resources start to work without waiting for gathering data.
+	//
+	// If "Prometheus", tortoise gets the number of replicas from kube-state-metrics (kube_deployment_spec_replicas and kube_statefulset_replicas)
+	// in the Prometheus at PrometheusAddress. Only Deployment and StatefulSet are supported.
+	// If "CSV", tortoise reads the number of replicas from the CSV file at ReplicasHistoryCSVPath.
+	ReplicasHistoryBackfillSource string `yaml:"ReplicasHistoryBackfillSource"`
+	// ReplicasHistoryCSVPath is the path to the CSV file which has the history of the number of replicas (default: "")
+	// It's required when ReplicasHistoryBackfillSource is "CSV".
+	// Each row has to be "<timestamp in RFC3339>,<namespace>,<kind>,<name>,<replicas>", e.g., "2023-10-01T00:00:00Z,default,Deployment,app,3".
+	// The lines starting with "#" are ignored.
+	ReplicasHistoryCSVPath string `yaml:"ReplicasHistoryCSVPath"`
+}
+
+// ScaleSubresourceWorkload is the workload that exposes the scale subresource and has a pod template.
+type ScaleSubresourceWorkload struct {
+	// APIVersion is the API version of the workload. e.g., argoproj.io/v1alpha1
+	APIVersion string `yaml:"APIVersion"`
+	// Kind is the kind of the workload. e.g., Rollout
+	Kind string `yaml:"Kind"`
+	// PodTemplatePath is the dot-separated path to the pod template in the workload object (default: spec.template)
+	PodTemplatePath string `yaml:"PodTemplatePath"`
+}
+
+// MaintenanceWindow is the window which starts on the cron schedule and lasts for the duration.
+type MaintenanceWindow struct {
+	// Schedule is the cron expression (minute, hour, day of month, month and day of week) of the start of the window in TimeZone.
+	Schedule string `yaml:"Schedule"`
+	// Duration is how long the window lasts.
+	Duration time.Duration `yaml:"Duration"`
+}
+
+// PodTemplatePathFields returns the path to the pod template as the list of fields.
+func (w ScaleSubresourceWorkload) PodTemplatePathFields() []string {
+	if w.PodTemplatePath == "" {
+		return []string{"spec", "template"}
+	}
+	return strings.Split(w.PodTemplatePath, ".")
 }
 
 func defaultConfig() *Config {
@@ -332,16 +505,27 @@ func defaultConfig() *Config {
 		MinimumCPULimit:                          "0",
 		ResourceLimitMultiplier:                  map[string]int64{},
 		BufferRatioOnVerticalResource:            0.1,
+		VerticalScaleDownCooldown:                time.Hour,
+		VerticalMinimumCPUChange:                 "0",
+		VerticalMinimumMemoryChange:              "0",
+		VerticalRollbackWindow:                   30 * time.Minute,
+		VerticalRollbackFloorDecayWindow:         24 * time.Hour,
+		OOMMemoryBumpRatio:                       0,
+		VerticalRolloutStrategy:                  "Restart",
+		VerticalApplyBurst:                       10,
 		EmergencyModeGracePeriod:                 5 * time.Minute,
 		GlobalDisableMode:                        false,
+		VerticalRecommender:                      "VPA",
+		UsageSource:                              "MetricsAPI",
+		UsageHistogramDecayHalfLife:              24 * time.Hour,
+		UsageMinimumObservationPeriod:            24 * time.Hour,
 	}
 }
 
 // ParseConfig parses the config file (yaml) and returns Config.
 func ParseConfig(path string) (*Config, error) {
-	config := defaultConfig()
 	if path == "" {
-		return config, nil
+		return defaultConfig(), nil
 	}
 
 	// read file from path
@@ -350,6 +534,12 @@ func ParseConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	return Parse(b)
+}
+
+// Parse parses the content of the config file and validates it.
+func Parse(b []byte) (*Config, error) {
+	config := defaultConfig()
 	if err := yaml.Unmarshal(b, config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config file: %w", err)
 	}
@@ -412,6 +602,110 @@ func validateDefaultHPA(behavior *v2.HorizontalPodAutoscalerBehavior) error {
 	return nil
 }
 
+// validateScaleSubresourceWorkloads validates the additional workloads.
+func validateScaleSubresourceWorkloads(workloads []ScaleSubresourceWorkload) error {
+	kinds := map[string]bool{"Deployment": true, "StatefulSet": true, "DaemonSet": true}
+	for _, w := range workloads {
+		if w.APIVersion == "" || w.Kind == "" {
+			return fmt.Errorf("ScaleSubresourceWorkloads.APIVersion and ScaleSubresourceWorkloads.Kind should not be empty")
+		}
+		if kinds[w.Kind] {
+			return fmt.Errorf("ScaleSubresourceWorkloads.Kind should be unique and should not be Deployment, StatefulSet or DaemonSet: %s", w.Kind)
+		}
+		kinds[w.Kind] = true
+		for _, f := range w.PodTemplatePathFields() {
+			if f == "" {
+				return fmt.Errorf("ScaleSubresourceWorkloads.PodTemplatePath is invalid: %s", w.PodTemplatePath)
+			}
+		}
+	}
+	return nil
+}
+
+// validateVerticalRecommender validates the configuration of the vertical recommender.
+func validateVerticalRecommender(config *Config) error {
+	switch config.VerticalRecommender {
+	case "Tortoise":
+	case "", "VPA":
+		// The usage source isn't used.
+		return nil
+	default:
+		return fmt.Errorf("VerticalRecommender should be either \"VPA\" or \"Tortoise\"")
+	}
+
+	if config.UsageSource != "MetricsAPI" && config.UsageSource != "Prometheus" {
+		return fmt.Errorf("UsageSource should be either \"MetricsAPI\" or \"Prometheus\"")
+	}
+	if config.UsageSource == "Prometheus" && config.PrometheusAddress == "" {
+		return fmt.Errorf("PrometheusAddress should be set when UsageSource is \"Prometheus\"")
+	}
+	if config.UsageHistogramDecayHalfLife <= 0 {
+		return fmt.Errorf("UsageHistogramDecayHalfLife should be greater than 0")
+	}
+	if config.UsageMinimumObservationPeriod < 0 {
+		return fmt.Errorf("UsageMinimumObservationPeriod should be greater than or equal to 0")
+	}
+	return nil
+}
+
+// validateReplicasHistoryBackfill validates the configuration of the backfill of the min/max replicas recommendation.
+func validateReplicasHistoryBackfill(config *Config) error {
+	switch config.ReplicasHistoryBackfillSource {
+	case "":
+	case "Prometheus":
+		if config.PrometheusAddress == "" {
+			return fmt.Errorf("PrometheusAddress should be set when ReplicasHistoryBackfillSource is \"Prometheus\"")
+		}
+	case "CSV":
+		if config.ReplicasHistoryCSVPath == "" {
+			return fmt.Errorf("ReplicasHistoryCSVPath should be set when ReplicasHistoryBackfillSource is \"CSV\"")
+		}
+	default:
+		return fmt.Errorf("ReplicasHistoryBackfillSource should be either \"Prometheus\" or \"CSV\" when it's set")
+	}
+	return nil
+}
+
+// validateQuantities validates the fields which are parsed as resource.Quantity by the services
+// so that an invalid config is rejected here rather than breaking the services.
+func validateQuantities(config *Config) error {
+	quantities := map[string]string{
+		"MinimumCPURequest":              config.MinimumCPURequest,
+		"MinimumMemoryRequest":           config.MinimumMemoryRequest,
+		"MaximumCPURequest":              config.MaximumCPURequest,
+		"MaximumMemoryRequest":           config.MaximumMemoryRequest,
+		"MinimumCPULimit":                config.MinimumCPULimit,
+		"VerticalMinimumCPUChange":       config.VerticalMinimumCPUChange,
+		"VerticalMinimumMemoryChange":    config.VerticalMinimumMemoryChange,
+		"IstioSidecarProxyDefaultCPU":    config.IstioSidecarProxyDefaultCPU,
+		"IstioSidecarProxyDefaultMemory": config.IstioSidecarProxyDefaultMemory,
+	}
+	for container, q := range config.MinimumCPURequestPerContainer {
+		quantities[fmt.Sprintf("MinimumCPURequestPerContainer[%s]", container)] = q
+	}
+	for container, q := range config.MinimumMemoryRequestPerContainer {
+		quantities[fmt.Sprintf("MinimumMemoryRequestPerContainer[%s]", container)] = q
+	}
+
+	names := make([]string, 0, len(quantities))
+	for name := range quantities {
+		names = append(names, name)
+	}
+	// Sort to return the same error for the same config.
+	sort.Strings(names)
+	for _, name := range names {
+		q := quantities[name]
+		if q == "" {
+			// The empty value means "not set" in the optional fields, and is rejected by the services in the others.
+			continue
+		}
+		if _, err := resource.ParseQuantity(q); err != nil {
+			return fmt.Errorf("%s is invalid: %w", name, err)
+		}
+	}
+	return nil
+}
+
 func validate(config *Config) error {
 	if config.RangeOfMinMaxReplicasRecommendationHours > 24 || config.RangeOfMinMaxReplicasRecommendationHours < 1 {
 		return fmt.Errorf("RangeOfMinMaxReplicasRecommendationHours should be between 1 and 24")
@@ -443,6 +737,57 @@ func validate(config *Config) error {
 		return fmt.Errorf("MaxAllowedScalingDownRatio should be between 0 and 1")
 	}
 
+	if config.MaximumEmergencyModeDuration < 0 {
+		return fmt.Errorf("MaximumEmergencyModeDuration should be greater than or equal to 0")
+	}
+
+	if config.VerticalScaleUpCooldown < 0 || config.VerticalScaleDownCooldown < 0 {
+		return fmt.Errorf("VerticalScaleUpCooldown and VerticalScaleDownCooldown should be greater than or equal to 0")
+	}
+	if config.VerticalMinimumChangeRatio < 0 || config.VerticalMinimumChangeRatio >= 1 {
+		return fmt.Errorf("VerticalMinimumChangeRatio should be greater than or equal to 0 and less than 1")
+	}
+	if config.VerticalMaxStepRatio < 0 {
+		return fmt.Errorf("VerticalMaxStepRatio should be greater than or equal to 0")
+	}
+	if err := validateQuantities(config); err != nil {
+		return err
+	}
+
+	if config.VerticalRollbackWindow < 0 {
+		return fmt.Errorf("VerticalRollbackWindow should be greater than or equal to 0")
+	}
+	if config.VerticalRollbackWindow > 0 && config.VerticalRollbackFloorDecayWindow <= 0 {
+		return fmt.Errorf("VerticalRollbackFloorDecayWindow should be greater than 0 when VerticalRollbackWindow is set")
+	}
+
+	if config.VerticalRolloutStrategy != "" && config.VerticalRolloutStrategy != "Restart" && config.VerticalRolloutStrategy != "InPlace" {
+		return fmt.Errorf("VerticalRolloutStrategy should be either \"Restart\" or \"InPlace\"")
+	}
+
+	if config.VerticalApplyRateLimit < 0 {
+		return fmt.Errorf("VerticalApplyRateLimit should be greater than or equal to 0")
+	}
+	if config.VerticalApplyRateLimit > 0 && config.VerticalApplyBurst < 1 {
+		return fmt.Errorf("VerticalApplyBurst should be greater than 0 when VerticalApplyRateLimit is set")
+	}
+
+	for _, w := range config.VerticalMaintenanceWindows {
+		if _, err := cron.Parse(w.Schedule); err != nil {
+			return fmt.Errorf("VerticalMaintenanceWindows.Schedule is invalid: %w", err)
+		}
+		if w.Duration <= 0 {
+			return fmt.Errorf("VerticalMaintenanceWindows.Duration should be greater than 0")
+		}
+	}
+
+	if config.OOMMemoryBumpRatio < 0 {
+		return fmt.Errorf("OOMMemoryBumpRatio should be greater than or equal to 0")
+	}
+	if config.OOMMemoryBumpRatio > 0 && config.VerticalRollbackFloorDecayWindow <= 0 {
+		return fmt.Errorf("VerticalRollbackFloorDecayWindow should be greater than 0 when OOMMemoryBumpRatio is set")
+	}
+
 	for _, ratio := range config.ResourceLimitMultiplier {
 		if ratio < 1 {
 			// ResourceLimitMultiplier should be greater than or equal to 1.
@@ -456,5 +801,21 @@ func validate(config *Config) error {
 		return err
 	}
 
+	if err := validateScaleSubresourceWorkloads(config.ScaleSubresourceWorkloads); err != nil {
+		return err
+	}
+
+	if err := validateVerticalRecommender(config); err != nil {
+		return err
+	}
+
+	if config.PrometheusHistoryBackfill && config.PrometheusAddress == "" {
+		return fmt.Errorf("PrometheusAddress should be set when PrometheusHistoryBackfill is true")
+	}
+
+	if err := validateReplicasHistoryBackfill(config); err != nil {
+		return err
+	}
+
 	return nil
 }
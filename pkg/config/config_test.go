@@ -60,8 +60,34 @@ func TestParseConfig(t *testing.T) {
 					"cpu":    3,
 					"memory": 1,
 				},
-				BufferRatioOnVerticalResource: 0.2,
-				EmergencyModeGracePeriod:      5 * time.Minute,
+				BufferRatioOnVerticalResource:    0.2,
+				VerticalScaleUpCooldown:          10 * time.Minute,
+				VerticalScaleDownCooldown:        3 * time.Hour,
+				VerticalMinimumChangeRatio:       0.05,
+				VerticalMinimumCPUChange:         "10m",
+				VerticalMinimumMemoryChange:      "16Mi",
+				VerticalMaxStepRatio:             0.5,
+				VerticalRollbackWindow:           time.Hour,
+				VerticalRollbackFloorDecayWindow: 6 * time.Hour,
+				OOMMemoryBumpRatio:               0.5,
+				VerticalRolloutStrategy:          "InPlace",
+				VerticalApplyRateLimit:           5,
+				VerticalApplyBurst:               20,
+				VerticalMaintenanceWindows:       []MaintenanceWindow{{Schedule: "0 2 * * 1-5", Duration: 3 * time.Hour}},
+				EmergencyModeGracePeriod:         5 * time.Minute,
+				VerticalRecommender:              "Tortoise",
+				UsageSource:                      "Prometheus",
+				PrometheusAddress:                "http://prometheus.monitoring:9090",
+				UsageHistogramDecayHalfLife:      12 * time.Hour,
+				UsageMinimumObservationPeriod:    6 * time.Hour,
+				PrometheusHistoryBackfill:        true,
+				ReplicasHistoryBackfillSource:    "Prometheus",
+				ScaleSubresourceWorkloads: []ScaleSubresourceWorkload{
+					{
+						APIVersion: "argoproj.io/v1alpha1",
+						Kind:       "Rollout",
+					},
+				},
 			},
 		},
 		{
@@ -97,7 +123,19 @@ func TestParseConfig(t *testing.T) {
 				MinimumMemoryRequestPerContainer:         map[string]string{},
 				ResourceLimitMultiplier:                  map[string]int64{},
 				BufferRatioOnVerticalResource:            0.1,
+				VerticalScaleDownCooldown:                time.Hour,
+				VerticalMinimumCPUChange:                 "0",
+				VerticalMinimumMemoryChange:              "0",
+				VerticalRollbackWindow:                   30 * time.Minute,
+				VerticalRollbackFloorDecayWindow:         24 * time.Hour,
+				OOMMemoryBumpRatio:                       0,
+				VerticalRolloutStrategy:                  "Restart",
+				VerticalApplyBurst:                       10,
 				EmergencyModeGracePeriod:                 5 * time.Minute,
+				VerticalRecommender:                      "VPA",
+				UsageSource:                              "MetricsAPI",
+				UsageHistogramDecayHalfLife:              24 * time.Hour,
+				UsageMinimumObservationPeriod:            24 * time.Hour,
 			},
 		},
 		{
@@ -140,7 +178,19 @@ func TestParseConfig(t *testing.T) {
 				MinimumMemoryRequestPerContainer:         map[string]string{},
 				ResourceLimitMultiplier:                  map[string]int64{},
 				BufferRatioOnVerticalResource:            0.1,
+				VerticalScaleDownCooldown:                time.Hour,
+				VerticalMinimumCPUChange:                 "0",
+				VerticalMinimumMemoryChange:              "0",
+				VerticalRollbackWindow:                   30 * time.Minute,
+				VerticalRollbackFloorDecayWindow:         24 * time.Hour,
+				OOMMemoryBumpRatio:                       0,
+				VerticalRolloutStrategy:                  "Restart",
+				VerticalApplyBurst:                       10,
 				EmergencyModeGracePeriod:                 5 * time.Minute,
+				VerticalRecommender:                      "VPA",
+				UsageSource:                              "MetricsAPI",
+				UsageHistogramDecayHalfLife:              24 * time.Hour,
+				UsageMinimumObservationPeriod:            24 * time.Hour,
 			},
 		},
 	}
@@ -255,6 +305,158 @@ func Test_validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid MaximumEmergencyModeDuration",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 2,
+				GatheringDataPeriodType:                  "daily",
+				HPATargetUtilizationMaxIncrease:          99,
+				MinimumMinReplicas:                       5,
+				MaximumMinReplicas:                       20,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     6,
+				MaxAllowedScalingDownRatio:               0.8,
+				MaximumEmergencyModeDuration:             -time.Hour,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid VerticalRollbackWindow",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 2,
+				GatheringDataPeriodType:                  "daily",
+				HPATargetUtilizationMaxIncrease:          99,
+				MinimumMinReplicas:                       5,
+				MaximumMinReplicas:                       20,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     6,
+				MaxAllowedScalingDownRatio:               0.8,
+				VerticalRollbackWindow:                   -time.Minute,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid VerticalRollbackFloorDecayWindow",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalRollbackFloorDecayWindow = 0
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid OOMMemoryBumpRatio",
+			config: func() *Config {
+				c := defaultConfig()
+				c.OOMMemoryBumpRatio = -0.1
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid VerticalRollbackFloorDecayWindow - the OOM bump is enabled",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalRollbackWindow = 0
+				c.OOMMemoryBumpRatio = 0.2
+				c.VerticalRollbackFloorDecayWindow = 0
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "valid VerticalRollbackFloorDecayWindow - neither the rollback nor the OOM bump is enabled",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalRollbackWindow = 0
+				c.OOMMemoryBumpRatio = 0
+				c.VerticalRollbackFloorDecayWindow = 0
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "invalid VerticalRolloutStrategy",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalRolloutStrategy = "Evict"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid VerticalMinimumChangeRatio",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalMinimumChangeRatio = 1
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid VerticalMinimumCPUChange",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalMinimumCPUChange = "hoge"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid MaximumMemoryRequest",
+			config: func() *Config {
+				c := defaultConfig()
+				c.MaximumMemoryRequest = "10Gb"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid MinimumCPURequestPerContainer",
+			config: func() *Config {
+				c := defaultConfig()
+				c.MinimumCPURequestPerContainer = map[string]string{"istio-proxy": "hoge"}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid MinimumCPULimit",
+			config: func() *Config {
+				c := defaultConfig()
+				c.MinimumCPULimit = "100 m"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid VerticalApplyBurst",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalApplyRateLimit = 1
+				c.VerticalApplyBurst = 0
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid VerticalMaintenanceWindows - invalid schedule",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalMaintenanceWindows = []MaintenanceWindow{{Schedule: "0 25 * * *", Duration: time.Hour}}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid VerticalMaintenanceWindows - no duration",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalMaintenanceWindows = []MaintenanceWindow{{Schedule: "0 2 * * *"}}
+				return c
+			}(),
+			wantErr: true,
+		},
 		{
 			name: "invalid ResourceLimitMultiplier",
 			config: &Config{
@@ -592,6 +794,175 @@ func Test_validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid ScaleSubresourceWorkloads",
+			config: func() *Config {
+				c := defaultConfig()
+				c.ScaleSubresourceWorkloads = []ScaleSubresourceWorkload{
+					{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout"},
+					{APIVersion: "example.com/v1", Kind: "Custom", PodTemplatePath: "spec.workload.template"},
+				}
+				return c
+			}(),
+		},
+		{
+			name: "invalid ScaleSubresourceWorkloads - empty kind",
+			config: func() *Config {
+				c := defaultConfig()
+				c.ScaleSubresourceWorkloads = []ScaleSubresourceWorkload{
+					{APIVersion: "argoproj.io/v1alpha1"},
+				}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid ScaleSubresourceWorkloads - builtin kind",
+			config: func() *Config {
+				c := defaultConfig()
+				c.ScaleSubresourceWorkloads = []ScaleSubresourceWorkload{
+					{APIVersion: "apps/v1", Kind: "Deployment"},
+				}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid ScaleSubresourceWorkloads - duplicated kind",
+			config: func() *Config {
+				c := defaultConfig()
+				c.ScaleSubresourceWorkloads = []ScaleSubresourceWorkload{
+					{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout"},
+					{APIVersion: "argoproj.io/v1alpha2", Kind: "Rollout"},
+				}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid ScaleSubresourceWorkloads - invalid pod template path",
+			config: func() *Config {
+				c := defaultConfig()
+				c.ScaleSubresourceWorkloads = []ScaleSubresourceWorkload{
+					{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", PodTemplatePath: "spec..template"},
+				}
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "valid in-process vertical recommender with Prometheus",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalRecommender = "Tortoise"
+				c.UsageSource = "Prometheus"
+				c.PrometheusAddress = "http://prometheus.monitoring:9090"
+				return c
+			}(),
+		},
+		{
+			name: "invalid VerticalRecommender",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalRecommender = "Unknown"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid UsageSource",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalRecommender = "Tortoise"
+				c.UsageSource = "Unknown"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid UsageSource - PrometheusAddress is empty",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalRecommender = "Tortoise"
+				c.UsageSource = "Prometheus"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid UsageHistogramDecayHalfLife",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalRecommender = "Tortoise"
+				c.UsageHistogramDecayHalfLife = 0
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid UsageMinimumObservationPeriod",
+			config: func() *Config {
+				c := defaultConfig()
+				c.VerticalRecommender = "Tortoise"
+				c.UsageMinimumObservationPeriod = -time.Hour
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "valid PrometheusHistoryBackfill",
+			config: func() *Config {
+				c := defaultConfig()
+				c.PrometheusHistoryBackfill = true
+				c.PrometheusAddress = "http://prometheus.monitoring:9090"
+				return c
+			}(),
+		},
+		{
+			name: "invalid PrometheusHistoryBackfill - PrometheusAddress is empty",
+			config: func() *Config {
+				c := defaultConfig()
+				c.PrometheusHistoryBackfill = true
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "valid ReplicasHistoryBackfillSource - CSV",
+			config: func() *Config {
+				c := defaultConfig()
+				c.ReplicasHistoryBackfillSource = "CSV"
+				c.ReplicasHistoryCSVPath = "/etc/tortoise/replicas.csv"
+				return c
+			}(),
+		},
+		{
+			name: "invalid ReplicasHistoryBackfillSource",
+			config: func() *Config {
+				c := defaultConfig()
+				c.ReplicasHistoryBackfillSource = "Unknown"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid ReplicasHistoryBackfillSource - PrometheusAddress is empty",
+			config: func() *Config {
+				c := defaultConfig()
+				c.ReplicasHistoryBackfillSource = "Prometheus"
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid ReplicasHistoryBackfillSource - ReplicasHistoryCSVPath is empty",
+			config: func() *Config {
+				c := defaultConfig()
+				c.ReplicasHistoryBackfillSource = "CSV"
+				return c
+			}(),
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
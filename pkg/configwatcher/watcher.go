@@ -0,0 +1,135 @@
+package configwatcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/metrics"
+)
+
+// DefaultInterval is the interval to check whether the config file is changed.
+const DefaultInterval = 10 * time.Second
+
+// Watcher watches the config file and calls the callback with the new config when the file is changed.
+//
+// The config file is checked by polling (not by inotify) because the ConfigMap volume is updated by swapping the symlink,
+// which the file watch cannot follow easily.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	onReload func(*config.Config) error
+
+	recorder record.EventRecorder
+	// object is the object that the events are recorded on. The events aren't recorded when it's nil.
+	object runtime.Object
+
+	mu      sync.Mutex
+	content []byte
+	// rejected is the content of the last config which failed to be applied.
+	// It's kept so that the same invalid config isn't reported again at every check.
+	rejected []byte
+}
+
+// New returns a Watcher of the config file at path.
+// The current content of the file is regarded as already loaded.
+func New(path string, interval time.Duration, onReload func(*config.Config) error, recorder record.EventRecorder, object runtime.Object) (*Watcher, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	return &Watcher{
+		path:     path,
+		interval: interval,
+		onReload: onReload,
+		recorder: recorder,
+		object:   object,
+		content:  b,
+	}, nil
+}
+
+// Start implements manager.Runnable.
+// It checks the config file periodically until ctx is canceled.
+func (w *Watcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := w.Reload(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "failed to reload the config file", "path", w.path)
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// The config has to be reloaded in all replicas because the webhooks are served by all of them.
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+// Reload reads the config file, and calls the callback if the content is changed from the last successful reload.
+// It returns true when the new config is applied.
+// When the new config is invalid, it returns an error and the callback isn't called
+// so that the services keep running with the last valid config.
+// Each invalid content is reported only once; it's ignored until the file is changed again.
+func (w *Watcher) Reload(ctx context.Context) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b, err := os.ReadFile(w.path)
+	if err != nil {
+		// The file may be temporarily missing while the ConfigMap volume is being updated.
+		return false, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if bytes.Equal(b, w.content) {
+		// The file may be reverted to the last valid config.
+		w.rejected = nil
+		return false, nil
+	}
+	if w.rejected != nil && bytes.Equal(b, w.rejected) {
+		return false, nil
+	}
+
+	c, err := config.Parse(b)
+	if err == nil {
+		err = w.onReload(c)
+	}
+	if err != nil {
+		w.rejected = b
+		metrics.ConfigReloadCounter.WithLabelValues("failure").Inc()
+		w.event(corev1.EventTypeWarning, event.ConfigReloadFailed, fmt.Sprintf("The config file is changed, but not applied: %v", err))
+		return false, err
+	}
+
+	w.content = b
+	w.rejected = nil
+	metrics.ConfigReloadCounter.WithLabelValues("success").Inc()
+	metrics.SetGlobalDisableMode(c.GlobalDisableMode)
+	w.event(corev1.EventTypeNormal, event.ConfigReloaded, "The config file is reloaded")
+	log.FromContext(ctx).Info("the config file is reloaded", "config", *c)
+
+	return true, nil
+}
+
+func (w *Watcher) event(eventtype, reason, message string) {
+	if w.object == nil || w.recorder == nil {
+		return
+	}
+	w.recorder.Event(w.object, eventtype, reason, message)
+}
@@ -0,0 +1,147 @@
+package configwatcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/metrics"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	initial := "GlobalDisableMode: false\n"
+	tests := []struct {
+		name           string
+		newContent     string
+		onReloadErr    error
+		want           bool
+		wantErr        bool
+		wantGlobalMode bool
+		wantEvent      string
+		wantResult     string
+	}{
+		{
+			name:       "the file isn't changed",
+			newContent: initial,
+			want:       false,
+		},
+		{
+			name:           "the file is changed",
+			newContent:     "GlobalDisableMode: true\n",
+			want:           true,
+			wantGlobalMode: true,
+			wantEvent:      "Normal ConfigReloaded The config file is reloaded",
+			wantResult:     "success",
+		},
+		{
+			name:       "the new config is invalid",
+			newContent: "GlobalDisableMode: true\nGatheringDataPeriodType: monthly\n",
+			want:       false,
+			wantErr:    true,
+			wantEvent:  `Warning ConfigReloadFailed The config file is changed, but not applied: invalid config: GatheringDataPeriodType should be either "daily" or "weekly"`,
+			wantResult: "failure",
+		},
+		{
+			name:       "the new config has an invalid quantity",
+			newContent: "GlobalDisableMode: true\nMinimumCPURequestPerContainer:\n  istio-proxy: 100mm\n",
+			want:       false,
+			wantErr:    true,
+			wantEvent:  `Warning ConfigReloadFailed The config file is changed, but not applied: invalid config: MinimumCPURequestPerContainer[istio-proxy] is invalid: unable to parse quantity's suffix`,
+			wantResult: "failure",
+		},
+		{
+			name:        "the callback fails",
+			newContent:  "GlobalDisableMode: true\n",
+			onReloadErr: errors.New("boom"),
+			want:        false,
+			wantErr:     true,
+			wantEvent:   "Warning ConfigReloadFailed The config file is changed, but not applied: boom",
+			wantResult:  "failure",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(initial), 0o600); err != nil {
+				t.Fatalf("failed to write the config file: %v", err)
+			}
+
+			var reloaded *config.Config
+			recorder := record.NewFakeRecorder(10)
+			w, err := New(path, DefaultInterval, func(c *config.Config) error {
+				if tt.onReloadErr != nil {
+					return tt.onReloadErr
+				}
+				reloaded = c
+				return nil
+			}, recorder, &corev1.ObjectReference{Kind: "Pod", Namespace: "tortoise-system", Name: "tortoise-controller-manager"})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if err := os.WriteFile(path, []byte(tt.newContent), 0o600); err != nil {
+				t.Fatalf("failed to write the config file: %v", err)
+			}
+
+			var before float64
+			if tt.wantResult != "" {
+				before = testutil.ToFloat64(metrics.ConfigReloadCounter.WithLabelValues(tt.wantResult))
+			}
+
+			got, err := w.Reload(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Reload() = %v, want %v", got, tt.want)
+			}
+			if got && reloaded.GlobalDisableMode != tt.wantGlobalMode {
+				t.Errorf("Reload() reloaded GlobalDisableMode = %v, want %v", reloaded.GlobalDisableMode, tt.wantGlobalMode)
+			}
+			if !got && reloaded != nil {
+				t.Errorf("Reload() applied the config unexpectedly")
+			}
+
+			if tt.wantResult != "" {
+				if after := testutil.ToFloat64(metrics.ConfigReloadCounter.WithLabelValues(tt.wantResult)); after != before+1 {
+					t.Errorf("Reload() should increment the %s counter, before = %v, after = %v", tt.wantResult, before, after)
+				}
+			}
+
+			select {
+			case e := <-recorder.Events:
+				if e != tt.wantEvent {
+					t.Errorf("Reload() recorded event = %q, want %q", e, tt.wantEvent)
+				}
+			default:
+				if tt.wantEvent != "" {
+					t.Errorf("Reload() should record the event %q", tt.wantEvent)
+				}
+			}
+
+			// The same invalid content isn't reported again at the next check.
+			if tt.wantErr {
+				before := testutil.ToFloat64(metrics.ConfigReloadCounter.WithLabelValues("failure"))
+				got, err := w.Reload(context.Background())
+				if got || err != nil {
+					t.Errorf("Reload() with the same invalid content = %v, %v, want false, nil", got, err)
+				}
+				if after := testutil.ToFloat64(metrics.ConfigReloadCounter.WithLabelValues("failure")); after != before {
+					t.Errorf("Reload() shouldn't increment the failure counter again, before = %v, after = %v", before, after)
+				}
+				select {
+				case e := <-recorder.Events:
+					t.Errorf("Reload() shouldn't record the event again, got %q", e)
+				default:
+				}
+			}
+		})
+	}
+}
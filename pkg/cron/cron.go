@@ -0,0 +1,128 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is the standard cron schedule with 5 fields: minute, hour, day of month, month and day of week.
+// Each field accepts "*", a number, a range ("1-5"), a step ("*/15" or "0-30/10") and a comma-separated list of them.
+// Like the standard cron, when both day of month and day of week are restricted, the time matches either of them.
+type Schedule struct {
+	minute, hour, dom, month, dow map[int]bool
+	// domStar and dowStar are true when day of month and day of week are "*" respectively.
+	domStar, dowStar bool
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	// 7 is also Sunday.
+	{name: "day of week", min: 0, max: 7},
+}
+
+// Parse parses the cron expression.
+func Parse(spec string) (*Schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("the cron expression should have %d fields: %q", len(fields), spec)
+	}
+
+	values := make([]map[int]bool, len(fields))
+	for i, f := range fields {
+		v, err := parseField(parts[i], f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in %q: %w", f.name, spec, err)
+		}
+		values[i] = v
+	}
+	if values[4][7] {
+		values[4][0] = true
+	}
+
+	return &Schedule{
+		minute:  values[0],
+		hour:    values[1],
+		dom:     values[2],
+		month:   values[3],
+		dow:     values[4],
+		domStar: parts[2] == "*",
+		dowStar: parts[4] == "*",
+	}, nil
+}
+
+func parseField(s string, f field) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, item := range strings.Split(s, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		start, end := f.min, f.max
+		if rangePart != "*" {
+			first, last, isRange := strings.Cut(rangePart, "-")
+			var err error
+			start, err = strconv.Atoi(first)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", first)
+			}
+			end = start
+			if isRange {
+				end, err = strconv.Atoi(last)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q", last)
+				}
+			} else if hasStep {
+				// "5/15" means from 5 to the max every 15.
+				end = f.max
+			}
+		}
+		if start < f.min || end > f.max || start > end {
+			return nil, fmt.Errorf("%q is out of the range %d-%d", item, f.min, f.max)
+		}
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Matches returns true when the minute of t matches the schedule.
+// t is supposed to be in the timezone the schedule is written for.
+func (s *Schedule) Matches(t time.Time) bool {
+	if !s.minute[t.Minute()] || !s.hour[t.Hour()] || !s.month[int(t.Month())] {
+		return false
+	}
+	domMatch, dowMatch := s.dom[t.Day()], s.dow[int(t.Weekday())]
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// ActiveWithin returns true when the schedule is fired within the duration before t (inclusive of t),
+// that is, t is in the window which starts at the schedule and lasts for the duration.
+func (s *Schedule) ActiveWithin(t time.Time, d time.Duration) bool {
+	t = t.Truncate(time.Minute)
+	for since := time.Duration(0); since < d; since += time.Minute {
+		if s.Matches(t.Add(-since)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "0 2 * * *"},
+		{spec: "*/15 1-5 1,15 * 1-5"},
+		{spec: "30 22 * 1-12/2 0,7"},
+		{spec: "0 2 * *", wantErr: true},
+		{spec: "60 2 * * *", wantErr: true},
+		{spec: "0 2 0 * *", wantErr: true},
+		{spec: "0 5-1 * * *", wantErr: true},
+		{spec: "*/0 2 * * *", wantErr: true},
+		{spec: "a 2 * * *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchedule_ActiveWithin(t *testing.T) {
+	// 2023-01-02 is Monday.
+	monday := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		spec     string
+		t        time.Time
+		duration time.Duration
+		want     bool
+	}{
+		{
+			name:     "at the start of the window",
+			spec:     "0 2 * * *",
+			t:        monday.Add(2 * time.Hour),
+			duration: 3 * time.Hour,
+			want:     true,
+		},
+		{
+			name:     "in the window",
+			spec:     "0 2 * * *",
+			t:        monday.Add(4*time.Hour + 59*time.Minute + 30*time.Second),
+			duration: 3 * time.Hour,
+			want:     true,
+		},
+		{
+			name:     "at the end of the window",
+			spec:     "0 2 * * *",
+			t:        monday.Add(5 * time.Hour),
+			duration: 3 * time.Hour,
+			want:     false,
+		},
+		{
+			name:     "before the window",
+			spec:     "0 2 * * *",
+			t:        monday.Add(time.Hour + 59*time.Minute),
+			duration: 3 * time.Hour,
+			want:     false,
+		},
+		{
+			name:     "the window started on the previous day",
+			spec:     "0 22 * * 0",
+			t:        monday.Add(time.Hour),
+			duration: 4 * time.Hour,
+			want:     true,
+		},
+		{
+			name:     "the window isn't on the day of week",
+			spec:     "0 2 * * 6",
+			t:        monday.Add(3 * time.Hour),
+			duration: 3 * time.Hour,
+			want:     false,
+		},
+		{
+			name:     "either day of month or day of week matches",
+			spec:     "0 2 15 * 1",
+			t:        monday.Add(3 * time.Hour),
+			duration: 3 * time.Hour,
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := s.ActiveWithin(tt.t, tt.duration); got != tt.want {
+				t.Errorf("ActiveWithin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
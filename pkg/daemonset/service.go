@@ -0,0 +1,60 @@
+package daemonset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/event"
+)
+
+type Service struct {
+	c        client.Client
+	recorder record.EventRecorder
+}
+
+func New(c client.Client, recorder record.EventRecorder) *Service {
+	return &Service{c: c, recorder: recorder}
+}
+
+func (c *Service) GetDaemonSetOnTortoise(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*v1.DaemonSet, error) {
+	ds := &v1.DaemonSet{}
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Spec.TargetRefs.ScaleTargetRef.Name}, ds); err != nil {
+		return nil, fmt.Errorf("failed to get daemonset on tortoise: %w", err)
+	}
+	return ds, nil
+}
+
+// RolloutRestart restarts the Pods in the DaemonSet by updating the annotation in the pod template.
+// When the update strategy is OnDelete, the Pods aren't restarted until they're deleted by someone else.
+func (c *Service) RolloutRestart(ctx context.Context, ds *v1.DaemonSet, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	if ds.Spec.Template.ObjectMeta.Annotations == nil {
+		ds.Spec.Template.ObjectMeta.Annotations = make(map[string]string)
+	}
+	ds.Spec.Template.ObjectMeta.Annotations[annotation.UpdatedAtAnnotation] = now.Format(time.RFC3339)
+
+	if err := c.c.Update(ctx, ds); err != nil {
+		return fmt.Errorf("failed to update daemonset: %w", err)
+	}
+
+	msg := "DaemonSet is restarted to apply the recommendation from Tortoise"
+	eventType := corev1.EventTypeNormal
+	if ds.Spec.UpdateStrategy.Type == v1.OnDeleteDaemonSetStrategyType {
+		eventType = corev1.EventTypeWarning
+		msg = "DaemonSet uses the OnDelete update strategy; the recommendation from Tortoise is applied only when Pods are deleted"
+	}
+
+	c.recorder.Event(tortoise, eventType, event.RestartDaemonSet, msg)
+	log.FromContext(ctx).Info(msg, "tortoise", tortoise)
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package daemonset
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+)
+
+func TestService_RolloutRestart(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	tortoise := &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef: v1beta3.CrossVersionObjectReference{
+					Kind: "DaemonSet",
+					Name: "ds",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		updateStrategy v1.DaemonSetUpdateStrategy
+		wantEvent      string
+	}{
+		{
+			name:           "rolling update",
+			updateStrategy: v1.DaemonSetUpdateStrategy{Type: v1.RollingUpdateDaemonSetStrategyType},
+			wantEvent:      "Normal RestartDaemonSet DaemonSet is restarted to apply the recommendation from Tortoise",
+		},
+		{
+			name:           "on delete",
+			updateStrategy: v1.DaemonSetUpdateStrategy{Type: v1.OnDeleteDaemonSetStrategyType},
+			wantEvent:      "Warning RestartDaemonSet DaemonSet uses the OnDelete update strategy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &v1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "ds", Namespace: "default"},
+				Spec: v1.DaemonSetSpec{
+					UpdateStrategy: tt.updateStrategy,
+				},
+			}
+			c := fake.NewClientBuilder().WithRuntimeObjects(ds).Build()
+			recorder := record.NewFakeRecorder(10)
+			s := New(c, recorder)
+
+			got, err := s.GetDaemonSetOnTortoise(context.Background(), tortoise)
+			if err != nil {
+				t.Fatalf("GetDaemonSetOnTortoise() error = %v", err)
+			}
+			if err := s.RolloutRestart(context.Background(), got, tortoise, now); err != nil {
+				t.Fatalf("RolloutRestart() error = %v", err)
+			}
+
+			updated := &v1.DaemonSet{}
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "ds"}, updated); err != nil {
+				t.Fatalf("failed to get daemonset: %v", err)
+			}
+			if updated.Spec.Template.Annotations[annotation.UpdatedAtAnnotation] != now.Format(time.RFC3339) {
+				t.Errorf("RolloutRestart() didn't update the annotation: %v", updated.Spec.Template.Annotations)
+			}
+
+			select {
+			case e := <-recorder.Events:
+				if !strings.HasPrefix(e, tt.wantEvent) {
+					t.Errorf("RolloutRestart() event = %q, want prefix %q", e, tt.wantEvent)
+				}
+			default:
+				t.Errorf("RolloutRestart() didn't record any event")
+			}
+		})
+	}
+}
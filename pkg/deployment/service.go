@@ -7,13 +7,11 @@ import (
 
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	"github.com/mercari/tortoise/api/v1beta3"
 	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/annotation"
 	"github.com/mercari/tortoise/pkg/event"
@@ -22,15 +20,10 @@ import (
 type Service struct {
 	c        client.Client
 	recorder record.EventRecorder
-
-	// IstioSidecarProxyDefaultCPU is the default CPU resource request of the istio sidecar proxy.
-	istioSidecarProxyDefaultCPU string
-	// IstioSidecarProxyDefaultMemory is the default Memory resource request of the istio sidecar proxy.
-	istioSidecarProxyDefaultMemory string
 }
 
-func New(c client.Client, istioSidecarProxyDefaultCPU, istioSidecarProxyDefaultMemory string, recorder record.EventRecorder) *Service {
-	return &Service{c: c, istioSidecarProxyDefaultCPU: istioSidecarProxyDefaultCPU, istioSidecarProxyDefaultMemory: istioSidecarProxyDefaultMemory, recorder: recorder}
+func New(c client.Client, recorder record.EventRecorder) *Service {
+	return &Service{c: c, recorder: recorder}
 }
 
 func (c *Service) GetDeploymentOnTortoise(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*v1.Deployment, error) {
@@ -56,66 +49,3 @@ func (c *Service) RolloutRestart(ctx context.Context, dm *v1.Deployment, tortois
 
 	return nil
 }
-
-// GetResourceRequests returns the resource requests of the containers in the deployment.
-func (c *Service) GetResourceRequests(dm *v1.Deployment) ([]autoscalingv1beta3.ContainerResourceRequests, error) {
-	actualContainerResource := []autoscalingv1beta3.ContainerResourceRequests{}
-
-	istioProxyIndex := -1
-	for i, c := range dm.Spec.Template.Spec.Containers {
-		rcr := autoscalingv1beta3.ContainerResourceRequests{
-			ContainerName: c.Name,
-			Resource:      corev1.ResourceList{},
-		}
-		for name, r := range c.Resources.Requests {
-			rcr.Resource[name] = r
-		}
-		actualContainerResource = append(actualContainerResource, rcr)
-		if c.Name == "istio-proxy" {
-			istioProxyIndex = i
-		}
-	}
-
-	if dm.Spec.Template.Annotations != nil {
-		if v, ok := dm.Spec.Template.Annotations[annotation.IstioSidecarInjectionAnnotation]; ok && v == "true" {
-			// Istio sidecar injection is enabled.
-			// Because the istio container spec is not in the deployment spec, we need to get it from the deployment's annotation.
-
-			cpuReq, ok := dm.Spec.Template.Annotations[annotation.IstioSidecarProxyCPUAnnotation]
-			if !ok {
-				cpuReq = c.istioSidecarProxyDefaultCPU
-			}
-			cpu, err := resource.ParseQuantity(cpuReq)
-			if err != nil {
-				return nil, fmt.Errorf("parse CPU request of istio sidecar: %w", err)
-			}
-
-			memoryReq, ok := dm.Spec.Template.Annotations[annotation.IstioSidecarProxyMemoryAnnotation]
-			if !ok {
-				memoryReq = c.istioSidecarProxyDefaultMemory
-			}
-			memory, err := resource.ParseQuantity(memoryReq)
-			if err != nil {
-				return nil, fmt.Errorf("parse Memory request of istio sidecar: %w", err)
-			}
-
-			if istioProxyIndex == -1 {
-				// If the deployment has the sidecar injection annotation, the Pods will have the sidecar container in addition.
-				actualContainerResource = append(actualContainerResource, v1beta3.ContainerResourceRequests{
-					ContainerName: "istio-proxy",
-					Resource: corev1.ResourceList{
-						corev1.ResourceCPU:    cpu,
-						corev1.ResourceMemory: memory,
-					},
-				})
-			} else {
-				// the deployment has the sidecar injection annotation and it's using the custom injection:
-				// https://istio.io/latest/docs/setup/additional-setup/sidecar-injection/#customizing-injection
-				actualContainerResource[istioProxyIndex].Resource[corev1.ResourceCPU] = cpu
-				actualContainerResource[istioProxyIndex].Resource[corev1.ResourceMemory] = memory
-			}
-		}
-	}
-
-	return actualContainerResource, nil
-}
@@ -0,0 +1,313 @@
+package describer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+// maxEvents is the number of the recent events shown in Describe.
+const maxEvents = 10
+
+// Describer is the struct for showing tortoise(s) in a human readable way.
+type Describer struct {
+	c client.Client
+
+	workloadService *workload.Service
+
+	// now returns the current time. It's replaced in the test.
+	now func() time.Time
+}
+
+func New(c client.Client, ws *workload.Service) *Describer {
+	return &Describer{
+		c:               c,
+		workloadService: ws,
+		now:             time.Now,
+	}
+}
+
+// Get writes the table of the tortoise(s).
+// When no tortoise name is given, it shows all tortoises in the namespace, or in all namespaces if all is true.
+func (d *Describer) Get(ctx context.Context, tortoiseNames []string, namespace string, all bool, writer io.Writer) error {
+	// It assumes the validation is already done in the CLI layer.
+
+	tortoises := []v1beta3.Tortoise{}
+	if len(tortoiseNames) == 0 {
+		tl := &v1beta3.TortoiseList{}
+		opt := &client.ListOptions{}
+		if !all {
+			opt.Namespace = namespace
+		}
+		if err := d.c.List(ctx, tl, opt); err != nil {
+			return fmt.Errorf("failed to list tortoises: %w", err)
+		}
+		tortoises = tl.Items
+	} else {
+		for _, name := range tortoiseNames {
+			t := &v1beta3.Tortoise{}
+			if err := d.c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, t); err != nil {
+				return fmt.Errorf("failed to get tortoise: %w", err)
+			}
+			tortoises = append(tortoises, *t)
+		}
+	}
+
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	if all {
+		fmt.Fprint(w, "NAMESPACE\t")
+	}
+	fmt.Fprintln(w, "NAME\tPHASE\tMODE\tHPA TARGET\tREQUESTS")
+	for _, t := range tortoises {
+		if all {
+			fmt.Fprintf(w, "%s\t", t.Namespace)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.Name, orNone(string(t.Status.TortoisePhase)), orNone(string(t.Spec.UpdateMode)), orNone(hpaTargetSummary(&t)), orNone(requestsSummary(&t)))
+	}
+
+	return w.Flush()
+}
+
+// Describe writes the details of the tortoise;
+// the declared request in the workload, the applied request, the VPA max recommendation and the HPA target
+// per container and resource, and the recent events of the tortoise.
+func (d *Describer) Describe(ctx context.Context, tortoiseName, namespace string, writer io.Writer) error {
+	t := &v1beta3.Tortoise{}
+	if err := d.c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: tortoiseName}, t); err != nil {
+		return fmt.Errorf("failed to get tortoise: %w", err)
+	}
+
+	// Still show the other information even if it fails to get the declared requests.
+	declared, declaredErr := d.declaredRequests(ctx, t)
+
+	events, err := d.recentEvents(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", t.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", t.Namespace)
+	fmt.Fprintf(w, "Target:\t%s/%s\n", t.Spec.TargetRefs.ScaleTargetRef.Kind, t.Spec.TargetRefs.ScaleTargetRef.Name)
+	fmt.Fprintf(w, "Update Mode:\t%s\n", orNone(string(t.Spec.UpdateMode)))
+	fmt.Fprintf(w, "Phase:\t%s\n", orNone(string(t.Status.TortoisePhase)))
+	fmt.Fprintf(w, "HPA:\t%s\n", orNone(t.Status.Targets.HorizontalPodAutoscaler))
+	if declaredErr != nil {
+		fmt.Fprintf(w, "Warning:\tfailed to get the declared resource requests: %v\n", declaredErr)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(writer, "\nResources:")
+	w = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  CONTAINER\tRESOURCE\tDECLARED\tAPPLIED\tVPA MAX RECOMMENDATION\tHPA TARGET")
+	for _, r := range resourceRows(t, declared) {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", r.containerName, r.resourceName, r.declared, r.applied, r.vpaMaxRecommendation, r.hpaTarget)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(writer, "\nEvents:")
+	if len(events) == 0 {
+		fmt.Fprintln(writer, "  <none>")
+		return nil
+	}
+	w = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  LAST SEEN\tTYPE\tREASON\tMESSAGE")
+	for _, e := range events {
+		lastSeen := "<unknown>"
+		if ts := eventTime(e); !ts.IsZero() {
+			lastSeen = duration.HumanDuration(d.now().Sub(ts)) + " ago"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", lastSeen, e.Type, e.Reason, strings.TrimSpace(e.Message))
+	}
+	return w.Flush()
+}
+
+type resourceRow struct {
+	containerName        string
+	resourceName         corev1.ResourceName
+	declared             string
+	applied              string
+	vpaMaxRecommendation string
+	hpaTarget            string
+}
+
+// resourceRows returns the rows per container and resource which appear in any of the sources, sorted by the names.
+func resourceRows(t *v1beta3.Tortoise, declared map[string]corev1.ResourceList) []resourceRow {
+	keys := map[string]map[corev1.ResourceName]struct{}{}
+	add := func(containerName string, rn corev1.ResourceName) {
+		if keys[containerName] == nil {
+			keys[containerName] = map[corev1.ResourceName]struct{}{}
+		}
+		keys[containerName][rn] = struct{}{}
+	}
+	for containerName, rl := range declared {
+		for rn := range rl {
+			add(containerName, rn)
+		}
+	}
+	for _, r := range t.Status.Conditions.ContainerResourceRequests {
+		for rn := range r.Resource {
+			add(r.ContainerName, rn)
+		}
+	}
+	for _, r := range t.Status.Conditions.ContainerRecommendationFromVPA {
+		for rn := range r.MaxRecommendation {
+			add(r.ContainerName, rn)
+		}
+	}
+
+	rows := []resourceRow{}
+	for containerName, rns := range keys {
+		for rn := range rns {
+			row := resourceRow{
+				containerName:        containerName,
+				resourceName:         rn,
+				declared:             "-",
+				applied:              "-",
+				vpaMaxRecommendation: "-",
+				hpaTarget:            "-",
+			}
+			if q, ok := declared[containerName][rn]; ok {
+				row.declared = q.String()
+			}
+			for _, r := range t.Status.Conditions.ContainerResourceRequests {
+				if q, ok := r.Resource[rn]; ok && r.ContainerName == containerName {
+					row.applied = q.String()
+				}
+			}
+			for _, r := range t.Status.Conditions.ContainerRecommendationFromVPA {
+				if q, ok := r.MaxRecommendation[rn]; ok && r.ContainerName == containerName {
+					row.vpaMaxRecommendation = q.Quantity.String()
+				}
+			}
+			for _, r := range t.Status.Recommendations.Horizontal.TargetUtilizations {
+				if u, ok := r.TargetUtilization[rn]; ok && r.ContainerName == containerName {
+					row.hpaTarget = fmt.Sprintf("%d%%", u)
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].containerName != rows[j].containerName {
+			return rows[i].containerName < rows[j].containerName
+		}
+		return rows[i].resourceName < rows[j].resourceName
+	})
+	return rows
+}
+
+// declaredRequests returns the resource requests declared in the workload per container,
+// including the istio sidecar injected to the Pods, the same as the controller sees.
+func (d *Describer) declaredRequests(ctx context.Context, t *v1beta3.Tortoise) (map[string]corev1.ResourceList, error) {
+	w, err := d.workloadService.GetWorkloadOnTortoise(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	reqs, err := d.workloadService.GetResourceRequests(w)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := map[string]corev1.ResourceList{}
+	for _, r := range reqs {
+		requests[r.ContainerName] = r.Resource
+	}
+	return requests, nil
+}
+
+// recentEvents returns the recent events on the tortoise, sorted from the oldest.
+func (d *Describer) recentEvents(ctx context.Context, t *v1beta3.Tortoise) ([]corev1.Event, error) {
+	el := &corev1.EventList{}
+	if err := d.c.List(ctx, el, client.InNamespace(t.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	events := []corev1.Event{}
+	for _, e := range el.Items {
+		if e.InvolvedObject.Kind == "Tortoise" && e.InvolvedObject.Name == t.Name {
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+	if len(events) > maxEvents {
+		events = events[len(events)-maxEvents:]
+	}
+	return events, nil
+}
+
+func eventTime(e corev1.Event) time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
+	}
+	return e.FirstTimestamp.Time
+}
+
+// hpaTargetSummary returns the recommended target utilizations like "app:cpu=70%".
+func hpaTargetSummary(t *v1beta3.Tortoise) string {
+	targets := []string{}
+	for _, r := range t.Status.Recommendations.Horizontal.TargetUtilizations {
+		rns := make([]string, 0, len(r.TargetUtilization))
+		for rn := range r.TargetUtilization {
+			rns = append(rns, string(rn))
+		}
+		sort.Strings(rns)
+		for _, rn := range rns {
+			targets = append(targets, fmt.Sprintf("%s:%s=%d%%", r.ContainerName, rn, r.TargetUtilization[corev1.ResourceName(rn)]))
+		}
+	}
+	return strings.Join(targets, ",")
+}
+
+// requestsSummary returns the applied resource requests like "app:cpu=500m,memory=1Gi".
+func requestsSummary(t *v1beta3.Tortoise) string {
+	containers := []string{}
+	for _, r := range t.Status.Conditions.ContainerResourceRequests {
+		containers = append(containers, r.ContainerName+":"+resourceListString(r.Resource))
+	}
+	return strings.Join(containers, " ")
+}
+
+func resourceListString(rl corev1.ResourceList) string {
+	rns := make([]string, 0, len(rl))
+	for rn := range rl {
+		rns = append(rns, string(rn))
+	}
+	sort.Strings(rns)
+
+	values := make([]string, 0, len(rns))
+	for _, rn := range rns {
+		q := rl[corev1.ResourceName(rn)]
+		values = append(values, rn+"="+q.String())
+	}
+	return strings.Join(values, ",")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
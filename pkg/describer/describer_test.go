@@ -0,0 +1,284 @@
+package describer
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+var now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func testTortoise(name, namespace string) *v1beta3.Tortoise {
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1beta3.TortoiseSpec{
+			UpdateMode: v1beta3.UpdateModeAuto,
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef: v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app", APIVersion: "apps/v1"},
+			},
+		},
+		Status: v1beta3.TortoiseStatus{
+			TortoisePhase: v1beta3.TortoisePhaseWorking,
+			Targets:       v1beta3.TargetsStatus{HorizontalPodAutoscaler: "tortoise-hpa-" + name},
+			Conditions: v1beta3.Conditions{
+				ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+					{
+						ContainerName: "app",
+						Resource: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("500m"),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				},
+				ContainerRecommendationFromVPA: []v1beta3.ContainerRecommendationFromVPA{
+					{
+						ContainerName: "app",
+						MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+							corev1.ResourceCPU:    {Quantity: resource.MustParse("400m")},
+							corev1.ResourceMemory: {Quantity: resource.MustParse("800Mi")},
+						},
+					},
+				},
+			},
+			Recommendations: v1beta3.Recommendations{
+				Horizontal: v1beta3.HorizontalRecommendations{
+					TargetUtilizations: []v1beta3.HPATargetUtilizationRecommendationPerContainer{
+						{ContainerName: "app", TargetUtilization: map[corev1.ResourceName]int32{corev1.ResourceCPU: 70}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newTestDescriber(t *testing.T, objs ...client.Object) *Describer {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1beta3.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	d := New(c, workload.New(c, record.NewFakeRecorder(10), "100m", "100Mi", nil))
+	d.now = func() time.Time { return now }
+	return d
+}
+
+func TestDescriber_Get(t *testing.T) {
+	gathering := testTortoise("b", "other")
+	gathering.Spec.UpdateMode = v1beta3.UpdateModeOff
+	gathering.Status = v1beta3.TortoiseStatus{TortoisePhase: v1beta3.TortoisePhaseGatheringData}
+
+	tests := []struct {
+		name          string
+		tortoiseNames []string
+		namespace     string
+		all           bool
+		want          string
+	}{
+		{
+			name:      "all tortoises in the namespace",
+			namespace: "default",
+			want: `NAME  PHASE    MODE  HPA TARGET   REQUESTS
+a     Working  Auto  app:cpu=70%  app:cpu=500m,memory=1Gi
+`,
+		},
+		{
+			name:          "the specified tortoise",
+			tortoiseNames: []string{"b"},
+			namespace:     "other",
+			want: `NAME  PHASE          MODE  HPA TARGET  REQUESTS
+b     GatheringData  Off   <none>      <none>
+`,
+		},
+		{
+			name: "all tortoises in all namespaces",
+			all:  true,
+			want: `NAMESPACE  NAME  PHASE          MODE  HPA TARGET   REQUESTS
+default    a     Working        Auto  app:cpu=70%  app:cpu=500m,memory=1Gi
+other      b     GatheringData  Off   <none>       <none>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDescriber(t, testTortoise("a", "default"), gathering)
+			out := &bytes.Buffer{}
+			if err := d.Get(context.Background(), tt.tortoiseNames, tt.namespace, tt.all, out); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if d := cmp.Diff(tt.want, out.String()); d != "" {
+				t.Errorf("Get() output mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestDescriber_Describe(t *testing.T) {
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("1"),
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+								},
+							},
+						},
+						{
+							Name: "sidecar",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{annotation.IstioSidecarInjectionAnnotation: "true"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("2"),
+									corev1.ResourceMemory: resource.MustParse("2Gi"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	statefulSetTortoise := testTortoise("a", "default")
+	statefulSetTortoise.Spec.TargetRefs.ScaleTargetRef = v1beta3.CrossVersionObjectReference{Kind: "StatefulSet", Name: "app", APIVersion: "apps/v1"}
+	event := func(name, tortoiseName, reason string, ago time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Tortoise", Name: tortoiseName, Namespace: "default"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         reason,
+			Message:        reason + " message",
+			LastTimestamp:  metav1.NewTime(now.Add(-ago)),
+		}
+	}
+
+	tests := []struct {
+		name string
+		objs []client.Object
+		want string
+	}{
+		{
+			name: "describe a tortoise",
+			objs: []client.Object{
+				testTortoise("a", "default"), dp,
+				event("e1", "a", "Working", 10*time.Minute),
+				event("e2", "a", "RecommendationUpdated", time.Minute),
+				event("e3", "another", "Initialized", time.Minute),
+			},
+			want: `Name:         a
+Namespace:    default
+Target:       Deployment/app
+Update Mode:  Auto
+Phase:        Working
+HPA:          tortoise-hpa-a
+
+Resources:
+  CONTAINER  RESOURCE  DECLARED  APPLIED  VPA MAX RECOMMENDATION  HPA TARGET
+  app        cpu       1         500m     400m                    70%
+  app        memory    1Gi       1Gi      800Mi                   -
+  sidecar    cpu       100m      -        -                       -
+
+Events:
+  LAST SEEN  TYPE    REASON                 MESSAGE
+  10m ago    Normal  Working                Working message
+  60s ago    Normal  RecommendationUpdated  RecommendationUpdated message
+`,
+		},
+		{
+			name: "describe a tortoise targeting a StatefulSet with the istio sidecar",
+			objs: []client.Object{statefulSetTortoise, sts},
+			want: `Name:         a
+Namespace:    default
+Target:       StatefulSet/app
+Update Mode:  Auto
+Phase:        Working
+HPA:          tortoise-hpa-a
+
+Resources:
+  CONTAINER    RESOURCE  DECLARED  APPLIED  VPA MAX RECOMMENDATION  HPA TARGET
+  app          cpu       2         500m     400m                    70%
+  app          memory    2Gi       1Gi      800Mi                   -
+  istio-proxy  cpu       100m      -        -                       -
+  istio-proxy  memory    100Mi     -        -                       -
+
+Events:
+  <none>
+`,
+		},
+		{
+			name: "the deployment is not found",
+			objs: []client.Object{testTortoise("a", "default")},
+			want: `Name:         a
+Namespace:    default
+Target:       Deployment/app
+Update Mode:  Auto
+Phase:        Working
+HPA:          tortoise-hpa-a
+Warning:      failed to get the declared resource requests: failed to get deployment on tortoise: deployments.apps "app" not found
+
+Resources:
+  CONTAINER  RESOURCE  DECLARED  APPLIED  VPA MAX RECOMMENDATION  HPA TARGET
+  app        cpu       -         500m     400m                    70%
+  app        memory    -         1Gi      800Mi                   -
+
+Events:
+  <none>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDescriber(t, tt.objs...)
+			out := &bytes.Buffer{}
+			if err := d.Describe(context.Background(), "a", "default", out); err != nil {
+				t.Fatalf("Describe() error = %v", err)
+			}
+			if d := cmp.Diff(tt.want, out.String()); d != "" {
+				t.Errorf("Describe() output mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package emergency
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kyokomi/emoji/v2"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/hpa"
+)
+
+// Switcher is the struct for turning on/off Emergency mode of tortoise.
+type Switcher struct {
+	c client.Client
+}
+
+func New(c client.Client) *Switcher {
+	return &Switcher{c: c}
+}
+
+// On changes the tortoise to Emergency mode.
+// If duration is non-zero, it sets the emergency-until annotation so that the controller turns off Emergency mode automatically after the duration.
+// If duration is zero, Emergency mode continues until someone turns it off.
+func (s *Switcher) On(ctx context.Context, tortoiseName, namespace string, duration time.Duration, now time.Time, writer io.Writer) error {
+	t := &v1beta3.Tortoise{}
+	if err := s.c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: tortoiseName}, t); err != nil {
+		return fmt.Errorf("failed to get tortoise: %w", err)
+	}
+
+	// The same check as the webhook and the controller so that we can tell the reason to the user.
+	if !v1beta3.IsEmergencyModeAllowed(t.Status.TortoisePhase) {
+		return fmt.Errorf("emergency mode is only available for tortoises with Working phase (current phase: %s)", t.Status.TortoisePhase)
+	}
+	if !hpa.HasHorizontal(t) {
+		return fmt.Errorf("emergency mode is only available for tortoises with the horizontal autoscaling policy")
+	}
+
+	write(writer, fmt.Sprintf("%s turning on Emergency mode of your tortoise %s/%s ... ", emoji.Sprint(":rotating_light:"), namespace, tortoiseName))
+
+	t.Spec.UpdateMode = v1beta3.UpdateModeEmergency
+	if duration != 0 {
+		if t.Annotations == nil {
+			t.Annotations = map[string]string{}
+		}
+		t.Annotations[annotation.EmergencyUntilAnnotation] = now.Add(duration).UTC().Format(time.RFC3339)
+	} else {
+		// The previous expiry shouldn't turn off this Emergency mode.
+		delete(t.Annotations, annotation.EmergencyUntilAnnotation)
+	}
+
+	if err := s.c.Update(ctx, t); err != nil {
+		return fmt.Errorf("failed to update tortoise: %w", err)
+	}
+
+	if duration != 0 {
+		write(writer, fmt.Sprintf("Done, Emergency mode will be turned off automatically at %s %s\n", t.Annotations[annotation.EmergencyUntilAnnotation], emoji.Sprint(":alarm_clock:")))
+	} else {
+		write(writer, fmt.Sprintf("Done, don't forget to turn it off by `tortoisectl emergency off` %s\n", emoji.Sprint(":warning:")))
+	}
+	return nil
+}
+
+// Off turns off Emergency mode of the tortoise by changing the updateMode to Auto.
+// The tortoise goes back to normal gradually (TortoisePhaseBackToNormal).
+func (s *Switcher) Off(ctx context.Context, tortoiseName, namespace string, writer io.Writer) error {
+	t := &v1beta3.Tortoise{}
+	if err := s.c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: tortoiseName}, t); err != nil {
+		return fmt.Errorf("failed to get tortoise: %w", err)
+	}
+
+	if t.Spec.UpdateMode != v1beta3.UpdateModeEmergency {
+		write(writer, fmt.Sprintf("your tortoise %s/%s is not in Emergency mode (updateMode: %s) %s\n", namespace, tortoiseName, t.Spec.UpdateMode, emoji.Sprint(":turtle:")))
+		return nil
+	}
+
+	write(writer, fmt.Sprintf("%s turning off Emergency mode of your tortoise %s/%s ... ", emoji.Sprint(":turtle:"), namespace, tortoiseName))
+
+	t.Spec.UpdateMode = v1beta3.UpdateModeAuto
+	delete(t.Annotations, annotation.EmergencyUntilAnnotation)
+
+	if err := s.c.Update(ctx, t); err != nil {
+		return fmt.Errorf("failed to update tortoise: %w", err)
+	}
+
+	write(writer, fmt.Sprintf("Done, your tortoise is Auto now and the number of replicas will gradually be reduced %s\n", emoji.Sprint(":running:")))
+	return nil
+}
+
+func write(writer io.Writer, msg string) {
+	//nolint:errcheck // intentionally ignore the error because it's not critical
+	writer.Write([]byte(msg))
+}
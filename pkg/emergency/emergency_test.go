@@ -0,0 +1,119 @@
+package emergency
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+)
+
+var now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func testTortoise(mode v1beta3.UpdateMode, phase v1beta3.TortoisePhase, annotations map[string]string) *v1beta3.Tortoise {
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "t", Namespace: "default", Annotations: annotations},
+		Spec:       v1beta3.TortoiseSpec{UpdateMode: mode},
+		Status: v1beta3.TortoiseStatus{
+			TortoisePhase: phase,
+			AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+				{
+					ContainerName: "app",
+					Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+						corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+						corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSwitcher(t *testing.T) {
+	tests := []struct {
+		name            string
+		tortoise        *v1beta3.Tortoise
+		on              bool
+		duration        time.Duration
+		wantUpdateMode  v1beta3.UpdateMode
+		wantAnnotations map[string]string
+		wantErr         bool
+	}{
+		{
+			name:           "turn on without duration",
+			tortoise:       testTortoise(v1beta3.UpdateModeAuto, v1beta3.TortoisePhaseWorking, nil),
+			on:             true,
+			wantUpdateMode: v1beta3.UpdateModeEmergency,
+		},
+		{
+			name:            "turn on with duration",
+			tortoise:        testTortoise(v1beta3.UpdateModeAuto, v1beta3.TortoisePhaseWorking, nil),
+			on:              true,
+			duration:        2 * time.Hour,
+			wantUpdateMode:  v1beta3.UpdateModeEmergency,
+			wantAnnotations: map[string]string{annotation.EmergencyUntilAnnotation: "2024-01-01T14:00:00Z"},
+		},
+		{
+			name:           "turn on without duration removes the previous expiry",
+			tortoise:       testTortoise(v1beta3.UpdateModeEmergency, v1beta3.TortoisePhaseEmergency, map[string]string{annotation.EmergencyUntilAnnotation: "2024-01-01T13:00:00Z"}),
+			on:             true,
+			wantUpdateMode: v1beta3.UpdateModeEmergency,
+		},
+		{
+			name:           "cannot turn on while gathering data",
+			tortoise:       testTortoise(v1beta3.UpdateModeAuto, v1beta3.TortoisePhaseGatheringData, nil),
+			on:             true,
+			wantUpdateMode: v1beta3.UpdateModeAuto,
+			wantErr:        true,
+		},
+		{
+			name:           "turn off",
+			tortoise:       testTortoise(v1beta3.UpdateModeEmergency, v1beta3.TortoisePhaseEmergency, map[string]string{annotation.EmergencyUntilAnnotation: "2024-01-01T13:00:00Z"}),
+			wantUpdateMode: v1beta3.UpdateModeAuto,
+		},
+		{
+			name:           "turn off the tortoise which is not in emergency mode",
+			tortoise:       testTortoise(v1beta3.UpdateModeOff, v1beta3.TortoisePhaseWorking, nil),
+			wantUpdateMode: v1beta3.UpdateModeOff,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := v1beta3.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.tortoise).Build()
+			s := New(c)
+
+			var err error
+			if tt.on {
+				err = s.On(context.Background(), "t", "default", tt.duration, now, &bytes.Buffer{})
+			} else {
+				err = s.Off(context.Background(), "t", "default", &bytes.Buffer{})
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got := &v1beta3.Tortoise{}
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "t"}, got); err != nil {
+				t.Fatalf("failed to get tortoise: %v", err)
+			}
+			if got.Spec.UpdateMode != tt.wantUpdateMode {
+				t.Errorf("updateMode = %v, want %v", got.Spec.UpdateMode, tt.wantUpdateMode)
+			}
+			if got.Annotations[annotation.EmergencyUntilAnnotation] != tt.wantAnnotations[annotation.EmergencyUntilAnnotation] {
+				t.Errorf("emergency-until annotation = %q, want %q", got.Annotations[annotation.EmergencyUntilAnnotation], tt.wantAnnotations[annotation.EmergencyUntilAnnotation])
+			}
+		})
+	}
+}
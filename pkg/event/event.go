@@ -17,7 +17,28 @@ const (
 	PartlyWorking        = "PartlyWorking"
 	EmergencyModeEnabled = "EmergencyModeEnabled"
 	EmergencyModeFailed  = "EmergencyModeFailed"
+	EmergencyModeExpired = "EmergencyModeExpired"
 	RestartDeployment    = "RestartDeployment"
+	RestartStatefulSet   = "RestartStatefulSet"
+	RestartDaemonSet     = "RestartDaemonSet"
+	RestartWorkload      = "RestartWorkload"
+	ResizedInPlace       = "ResizedInPlace"
+
+	VerticalCanaryStarted    = "VerticalCanaryStarted"
+	VerticalCanaryPromoted   = "VerticalCanaryPromoted"
+	VerticalCanaryRolledBack = "VerticalCanaryRolledBack"
+	VerticalCanaryCanceled   = "VerticalCanaryCanceled"
+
+	VerticalRolledBack = "VerticalRolledBack"
+	OOMKillDetected    = "OOMKillDetected"
 
 	WarningHittingHardMaxReplicaLimit = "HitHardMaxReplicaLimit"
+
+	ScheduledScalingUp       = "ScheduledScalingUp"
+	ScheduledScalingFinished = "ScheduledScalingFinished"
+
+	HistoryBackfilled = "HistoryBackfilled"
+
+	ConfigReloaded     = "ConfigReloaded"
+	ConfigReloadFailed = "ConfigReloadFailed"
 )
@@ -23,6 +23,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/mercari/tortoise/api/v1alpha1"
 	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/event"
 	"github.com/mercari/tortoise/pkg/metrics"
@@ -115,8 +116,32 @@ func New(
 	}, nil
 }
 
+// WithOverrides returns a copy of the service with the configurations overridden by the TortoisePolicy.
+func (c *Service) WithOverrides(o v1alpha1.ConfigOverrides) *Service {
+	copied := *c
+	if o.MinimumMinReplicas != nil {
+		copied.minimumMinReplicas = *o.MinimumMinReplicas
+	}
+	if o.MaximumMinReplicas != nil {
+		copied.maximumMinReplica = *o.MaximumMinReplicas
+	}
+	if o.MaximumMaxReplicas != nil {
+		copied.maximumMaxReplica = *o.MaximumMaxReplicas
+	}
+	if o.MaximumTargetResourceUtilization != nil {
+		copied.maximumTargetResourceUtilization = *o.MaximumTargetResourceUtilization
+	}
+	return &copied
+}
+
 func (c *Service) InitializeHPA(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, replicaNum int32, now time.Time) (*autoscalingv1beta3.Tortoise, error) {
 	logger := log.FromContext(ctx)
+	if tortoise.Spec.TargetRefs.ScaleTargetRef.Kind == "DaemonSet" {
+		// The validation webhook rejects Horizontal policies for DaemonSet, but, we double-check it here.
+		logger.Info("DaemonSet cannot scale horizontally, no need to create HPA")
+		return tortoise, nil
+	}
+
 	// if all policy is off or Vertical, we don't need HPA.
 	if !HasHorizontal(tortoise) && tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName == nil {
 		logger.Info("no horizontal policy, no need to create HPA")
@@ -393,6 +418,10 @@ func (c *Service) ChangeHPAFromTortoiseRecommendation(tortoise *autoscalingv1bet
 
 	var allowed bool
 	tortoise, allowed = c.UpdatingHPATargetUtilizationAllowed(tortoise, now)
+	if reason, _ := utils.ApplyBlockedBySchedule(tortoise, now); reason != "" {
+		// .spec.applySchedule doesn't allow changing the target utilization now.
+		allowed = false
+	}
 	for _, t := range tortoise.Status.Recommendations.Horizontal.TargetUtilizations {
 		for resourcename, proposedTarget := range t.TargetUtilization {
 			if !readyHorizontalResourceAndContainer.Has(resourceNameAndContainerName{resourcename, t.ContainerName}) {
@@ -426,6 +455,11 @@ func (c *Service) ChangeHPAFromTortoiseRecommendation(tortoise *autoscalingv1bet
 		return nil, tortoise, fmt.Errorf("get maxReplicas recommendation: %w", err)
 	}
 
+	if minimumMin := minimumMinReplicasConstraint(tortoise); recommendMax < minimumMin {
+		// maxReplicas has to be bigger than or equal to minReplicas.
+		recommendMax = minimumMin
+	}
+
 	if tortoise.Spec.MaxReplicas != nil && recommendMax > *tortoise.Spec.MaxReplicas {
 		recommendMax = *tortoise.Spec.MaxReplicas
 	}
@@ -445,6 +479,11 @@ func (c *Service) ChangeHPAFromTortoiseRecommendation(tortoise *autoscalingv1bet
 		recommendMin = c.maximumMinReplica
 		// We don't change the maxReplica because it's dangerous to limit.
 	}
+	if minimumMin := minimumMinReplicasConstraint(tortoise); recommendMin < minimumMin {
+		// The constraint is explicitly given by the recommender in .spec.recommenders, e.g., ScheduledScaling.
+		// So, it's not limited by maximumMinReplica, but by maxReplicas.
+		recommendMin = min(minimumMin, recommendMax)
+	}
 
 	if recordMetrics {
 		metrics.ProposedHPAMinReplicas.WithLabelValues(tortoise.Name, tortoise.Namespace, hpa.Name).Set(float64(recommendMin))
@@ -493,6 +532,15 @@ func (c *Service) ChangeHPAFromTortoiseRecommendation(tortoise *autoscalingv1bet
 	return hpa, tortoise, nil
 }
 
+// minimumMinReplicasConstraint returns the minimum minReplicas in .status.recommendations.constraints, or 0 if it's not set.
+func minimumMinReplicasConstraint(tortoise *autoscalingv1beta3.Tortoise) int32 {
+	constraints := tortoise.Status.Recommendations.Constraints
+	if constraints == nil || constraints.MinimumMinReplicas == nil {
+		return 0
+	}
+	return *constraints.MinimumMinReplicas
+}
+
 // disableHPA disables the HPA created by users without removing it, by removing all metrics and setting the minReplicas to the specified value.
 func (c *Service) disableHPA(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, replicaNum int32) error {
 	if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName == nil {
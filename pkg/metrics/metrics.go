@@ -106,10 +106,20 @@ var (
 		Help: "the number of tortoise",
 	}, []string{"tortoise_name", "namespace", "controller_name", "controller_kind", "update_mode", "tortoise_phase"})
 
+	EmergencyModeDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tortoise_emergency_mode_duration_seconds",
+		Help: "how long (seconds) the tortoise has been in Emergency mode",
+	}, []string{"tortoise_name", "namespace"})
+
 	GlobalDisableMode = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "tortoise_global_disable_mode",
 		Help: "indicates if global disable mode is enabled (1=enabled, 0=disabled)",
 	})
+
+	ConfigReloadCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tortoise_config_reload_total",
+		Help: "the number of the config file reloads (result=success|failure)",
+	}, []string{"result"})
 )
 
 func init() {
@@ -134,7 +144,9 @@ func init() {
 		ProposedCPURequest,
 		ProposedMemoryRequest,
 		TortoiseNumber,
+		EmergencyModeDuration,
 		GlobalDisableMode,
+		ConfigReloadCounter,
 	)
 }
 
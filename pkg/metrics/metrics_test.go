@@ -2,8 +2,13 @@ package metrics
 
 import (
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mercari/tortoise/api/v1beta3"
 )
 
 func TestSetGlobalDisableMode(t *testing.T) {
@@ -34,3 +39,34 @@ func TestSetGlobalDisableMode(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordEmergencyModeDuration(t *testing.T) {
+	now := time.Date(2023, 3, 19, 0, 0, 0, 0, time.UTC)
+	tortoise := func(phase v1beta3.TortoisePhase) *v1beta3.Tortoise {
+		return &v1beta3.Tortoise{
+			ObjectMeta: metav1.ObjectMeta{Name: "t", Namespace: "default"},
+			Status: v1beta3.TortoiseStatus{
+				TortoisePhase: phase,
+				Conditions: v1beta3.Conditions{
+					TortoiseConditions: []v1beta3.TortoiseCondition{
+						{
+							Type:               v1beta3.TortoiseConditionTypeEmergencyMode,
+							Status:             corev1.ConditionTrue,
+							LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+						},
+					},
+				},
+			},
+		}
+	}
+
+	RecordEmergencyModeDuration(tortoise(v1beta3.TortoisePhaseEmergency), false, now)
+	if got := testutil.ToFloat64(EmergencyModeDuration.WithLabelValues("t", "default")); got != 3600 {
+		t.Errorf("RecordEmergencyModeDuration() = %v, want 3600", got)
+	}
+
+	RecordEmergencyModeDuration(tortoise(v1beta3.TortoisePhaseBackToNormal), false, now)
+	if got := testutil.CollectAndCount(EmergencyModeDuration); got != 0 {
+		t.Errorf("RecordEmergencyModeDuration() should remove the metric when the tortoise isn't in Emergency mode, got %v metrics", got)
+	}
+}
@@ -1,6 +1,10 @@
 package metrics
 
 import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
 	"github.com/mercari/tortoise/api/v1beta3"
 )
 
@@ -39,3 +43,18 @@ func ShouldRerecordTortoise(old, new *v1beta3.Tortoise) bool {
 	return old.Status.TortoisePhase != new.Status.TortoisePhase ||
 		old.Spec.UpdateMode != new.Spec.UpdateMode
 }
+
+// RecordEmergencyModeDuration records how long the tortoise has been in Emergency mode,
+// based on the EmergencyMode condition. The metric is removed when the tortoise isn't in Emergency mode.
+func RecordEmergencyModeDuration(t *v1beta3.Tortoise, deleted bool, now time.Time) {
+	if !deleted && t.Status.TortoisePhase == v1beta3.TortoisePhaseEmergency {
+		for _, c := range t.Status.Conditions.TortoiseConditions {
+			if c.Type == v1beta3.TortoiseConditionTypeEmergencyMode && c.Status == corev1.ConditionTrue {
+				EmergencyModeDuration.WithLabelValues(t.Name, t.Namespace).Set(now.Sub(c.LastTransitionTime.Time).Seconds())
+				return
+			}
+		}
+	}
+
+	EmergencyModeDuration.DeleteLabelValues(t.Name, t.Namespace)
+}
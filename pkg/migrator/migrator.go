@@ -0,0 +1,177 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/tortoise"
+)
+
+// Migrator is the struct for generating the tortoise for the existing deployment and its HPA.
+type Migrator struct {
+	c client.Client
+
+	// externalMetricExclusionRegex is the same as HPAExternalMetricExclusionRegex in the controller config.
+	externalMetricExclusionRegex *regexp.Regexp
+}
+
+func New(c client.Client, externalMetricExclusionRegex string) (*Migrator, error) {
+	var regex *regexp.Regexp
+	if externalMetricExclusionRegex != "" {
+		var err error
+		regex, err = regexp.Compile(externalMetricExclusionRegex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile the external metric exclusion regex: %w", err)
+		}
+	}
+
+	return &Migrator{
+		c:                            c,
+		externalMetricExclusionRegex: regex,
+	}, nil
+}
+
+// Manifest is the tortoise manifest which Migrate generates.
+// It only has the fields that users are supposed to write.
+type Manifest struct {
+	metav1.TypeMeta `json:",inline"`
+	Metadata        ManifestMetadata     `json:"metadata"`
+	Spec            v1beta3.TortoiseSpec `json:"spec"`
+}
+
+type ManifestMetadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// YAML returns the manifest in YAML.
+func (m *Manifest) YAML() ([]byte, error) {
+	return yaml.Marshal(m)
+}
+
+// Migrate generates the tortoise manifest which takes over the deployment and its HPA.
+//
+// The manifest leaves .spec.autoscalingPolicy empty with .spec.targetRefs.horizontalPodAutoscalerName
+// so that the tortoise controller derives the autoscaling policy from the HPA.
+// Migrate returns the policy that the controller would derive as the preview,
+// and the warnings about the HPA metrics that the controller would remove or leave as they are.
+func (m *Migrator) Migrate(ctx context.Context, deploymentName, namespace, tortoiseName string) (*Manifest, []v1beta3.ContainerAutoscalingPolicy, []string, error) {
+	dp := &appsv1.Deployment{}
+	if err := m.c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: deploymentName}, dp); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	hpa, err := m.findHPA(ctx, dp)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	manifest := &Manifest{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1beta3.GroupVersion.String(),
+			Kind:       "Tortoise",
+		},
+		Metadata: ManifestMetadata{
+			Name:      tortoiseName,
+			Namespace: namespace,
+		},
+		Spec: v1beta3.TortoiseSpec{
+			// The user should turn it on after checking the recommendation.
+			UpdateMode: v1beta3.UpdateModeOff,
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef: v1beta3.CrossVersionObjectReference{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       dp.Name,
+				},
+			},
+		},
+	}
+
+	if hpa == nil {
+		// tortoise uses the default policy and creates the HPA.
+		return manifest, nil, []string{fmt.Sprintf("no HPA is found for the deployment %s; tortoise will create a new HPA with the default autoscaling policy", dp.Name)}, nil
+	}
+
+	manifest.Spec.TargetRefs.HorizontalPodAutoscalerName = ptr.To(hpa.Name)
+
+	return manifest, autoscalingPolicy(manifest.Spec, dp.Spec.Template.Spec.Containers, hpa), m.metricWarnings(hpa), nil
+}
+
+// findHPA returns the HPA targeting the deployment, or nil if there's no such HPA.
+func (m *Migrator) findHPA(ctx context.Context, dp *appsv1.Deployment) (*v2.HorizontalPodAutoscaler, error) {
+	hpas := &v2.HorizontalPodAutoscalerList{}
+	if err := m.c.List(ctx, hpas, client.InNamespace(dp.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list HPAs: %w", err)
+	}
+
+	var found *v2.HorizontalPodAutoscaler
+	for i, h := range hpas.Items {
+		if h.Spec.ScaleTargetRef.Kind != "Deployment" || h.Spec.ScaleTargetRef.Name != dp.Name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("multiple HPAs (%s, %s) target the deployment %s", found.Name, h.Name, dp.Name)
+		}
+		found = &hpas.Items[i]
+	}
+	return found, nil
+}
+
+// autoscalingPolicy returns the policy that the tortoise controller derives from the HPA for the tortoise with spec.
+func autoscalingPolicy(spec v1beta3.TortoiseSpec, containers []corev1.Container, hpa *v2.HorizontalPodAutoscaler) []v1beta3.ContainerAutoscalingPolicy {
+	t := &v1beta3.Tortoise{Spec: spec}
+	for _, c := range containers {
+		t.Status.Conditions.ContainerResourceRequests = append(t.Status.Conditions.ContainerResourceRequests, v1beta3.ContainerResourceRequests{
+			ContainerName: c.Name,
+			Resource:      c.Resources.Requests,
+		})
+	}
+	return tortoise.UpdateTortoiseAutoscalingPolicyInStatus(t, hpa, time.Now()).Status.AutoscalingPolicy
+}
+
+// metricWarnings returns the warnings about the metrics in the HPA that tortoise doesn't manage.
+func (m *Migrator) metricWarnings(hpa *v2.HorizontalPodAutoscaler) []string {
+	warnings := []string{}
+	for _, metric := range hpa.Spec.Metrics {
+		switch metric.Type {
+		case v2.ResourceMetricSourceType:
+			if metric.Resource == nil {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("the Resource metric (%s) will be removed from the HPA %s; tortoise scales %s vertically unless the HPA has the ContainerResource metrics for it", metric.Resource.Name, hpa.Name, metric.Resource.Name))
+		case v2.ExternalMetricSourceType:
+			if metric.External == nil {
+				continue
+			}
+			if m.externalMetricExclusionRegex != nil && m.externalMetricExclusionRegex.MatchString(metric.External.Metric.Name) {
+				warnings = append(warnings, fmt.Sprintf("the External metric %s will be removed from the HPA %s because it matches HPAExternalMetricExclusionRegex", metric.External.Metric.Name, hpa.Name))
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("the External metric %s in the HPA %s is kept as it is; tortoise doesn't adjust it", metric.External.Metric.Name, hpa.Name))
+		case v2.ObjectMetricSourceType:
+			if metric.Object == nil {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("the Object metric %s in the HPA %s is kept as it is; tortoise doesn't adjust it", metric.Object.Metric.Name, hpa.Name))
+		case v2.PodsMetricSourceType:
+			if metric.Pods == nil {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("the Pods metric %s in the HPA %s is kept as it is; tortoise doesn't adjust it", metric.Pods.Metric.Name, hpa.Name))
+		}
+	}
+	return warnings
+}
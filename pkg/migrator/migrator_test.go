@@ -0,0 +1,218 @@
+package migrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+func testDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("1"),
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+								},
+							},
+						},
+						{
+							Name: "sidecar",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testHPA(name, namespace string, metrics ...v2.MetricSpec) *v2.HorizontalPodAutoscaler {
+	return &v2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: v2.CrossVersionObjectReference{Kind: "Deployment", Name: "app", APIVersion: "apps/v1"},
+			MaxReplicas:    10,
+			Metrics:        metrics,
+		},
+	}
+}
+
+func containerResourceMetric(container string, rn corev1.ResourceName) v2.MetricSpec {
+	return v2.MetricSpec{
+		Type: v2.ContainerResourceMetricSourceType,
+		ContainerResource: &v2.ContainerResourceMetricSource{
+			Name:      rn,
+			Container: container,
+			Target:    v2.MetricTarget{Type: v2.UtilizationMetricType},
+		},
+	}
+}
+
+func externalMetric(name string) v2.MetricSpec {
+	return v2.MetricSpec{
+		Type: v2.ExternalMetricSourceType,
+		External: &v2.ExternalMetricSource{
+			Metric: v2.MetricIdentifier{Name: name},
+			Target: v2.MetricTarget{Type: v2.ValueMetricType},
+		},
+	}
+}
+
+func TestMigrator_Migrate(t *testing.T) {
+	tests := []struct {
+		name         string
+		objs         []client.Object
+		regex        string
+		want         string
+		wantPolicy   []v1beta3.ContainerAutoscalingPolicy
+		wantWarnings []string
+		wantErr      bool
+	}{
+		{
+			name: "the HPA with the ContainerResource metric",
+			objs: []client.Object{
+				testDeployment(),
+				testHPA("app-hpa", "default", containerResourceMetric("app", corev1.ResourceCPU)),
+				testHPA("another-hpa", "other"), // targets the deployment with the same name in another namespace.
+			},
+			want: `apiVersion: autoscaling.mercari.com/v1beta3
+kind: Tortoise
+metadata:
+  name: app-tortoise
+  namespace: default
+spec:
+  targetRefs:
+    horizontalPodAutoscalerName: app-hpa
+    scaleTargetRef:
+      apiVersion: apps/v1
+      kind: Deployment
+      name: app
+  updateMode: "Off"
+`,
+			wantPolicy: []v1beta3.ContainerAutoscalingPolicy{
+				{ContainerName: "app", Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{corev1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal, corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical}},
+				{ContainerName: "sidecar", Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{corev1.ResourceCPU: v1beta3.AutoscalingTypeVertical, corev1.ResourceMemory: v1beta3.AutoscalingTypeOff}},
+			},
+			wantWarnings: []string{},
+		},
+		{
+			name: "the HPA with the Resource and External metrics",
+			objs: []client.Object{
+				testDeployment(),
+				testHPA("app-hpa", "default",
+					v2.MetricSpec{Type: v2.ResourceMetricSourceType, Resource: &v2.ResourceMetricSource{Name: corev1.ResourceCPU}},
+					externalMetric("datadogmetric@default:queue-length"),
+					externalMetric("pubsub-subscription"),
+				),
+			},
+			regex: "datadogmetric.*",
+			want: `apiVersion: autoscaling.mercari.com/v1beta3
+kind: Tortoise
+metadata:
+  name: app-tortoise
+  namespace: default
+spec:
+  targetRefs:
+    horizontalPodAutoscalerName: app-hpa
+    scaleTargetRef:
+      apiVersion: apps/v1
+      kind: Deployment
+      name: app
+  updateMode: "Off"
+`,
+			// The Resource metric isn't taken into account.
+			wantPolicy: []v1beta3.ContainerAutoscalingPolicy{
+				{ContainerName: "app", Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{corev1.ResourceCPU: v1beta3.AutoscalingTypeVertical, corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical}},
+				{ContainerName: "sidecar", Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{corev1.ResourceCPU: v1beta3.AutoscalingTypeVertical, corev1.ResourceMemory: v1beta3.AutoscalingTypeOff}},
+			},
+			wantWarnings: []string{
+				"the Resource metric (cpu) will be removed from the HPA app-hpa; tortoise scales cpu vertically unless the HPA has the ContainerResource metrics for it",
+				"the External metric datadogmetric@default:queue-length will be removed from the HPA app-hpa because it matches HPAExternalMetricExclusionRegex",
+				"the External metric pubsub-subscription in the HPA app-hpa is kept as it is; tortoise doesn't adjust it",
+			},
+		},
+		{
+			name: "no HPA",
+			objs: []client.Object{testDeployment()},
+			want: `apiVersion: autoscaling.mercari.com/v1beta3
+kind: Tortoise
+metadata:
+  name: app-tortoise
+  namespace: default
+spec:
+  targetRefs:
+    scaleTargetRef:
+      apiVersion: apps/v1
+      kind: Deployment
+      name: app
+  updateMode: "Off"
+`,
+			wantWarnings: []string{"no HPA is found for the deployment app; tortoise will create a new HPA with the default autoscaling policy"},
+		},
+		{
+			name: "multiple HPAs",
+			objs: []client.Object{
+				testDeployment(),
+				testHPA("app-hpa", "default", containerResourceMetric("app", corev1.ResourceCPU)),
+				testHPA("app-hpa2", "default", containerResourceMetric("app", corev1.ResourceMemory)),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.objs...).Build()
+
+			m, err := New(c, tt.regex)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			manifest, policy, warnings, err := m.Migrate(context.Background(), "app", "default", "app-tortoise")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Migrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			y, err := manifest.YAML()
+			if err != nil {
+				t.Fatalf("YAML() error = %v", err)
+			}
+			if d := cmp.Diff(tt.want, string(y)); d != "" {
+				t.Errorf("Migrate() manifest mismatch (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(tt.wantPolicy, policy); d != "" {
+				t.Errorf("Migrate() policy mismatch (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(tt.wantWarnings, warnings); d != "" {
+				t.Errorf("Migrate() warnings mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package oom
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/utils"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+// Service raises the memory floor of the container in .status.conditions.containerResourceFloors on each OOMKill,
+// which the recommender doesn't go below.
+//
+// VPA's recommendation takes time to catch up with OOMKills,
+// so the memory floor makes the memory recommendation bigger than the request which was OOMKilled right away.
+// The floor is removed once the decay window has passed after it was raised, the same as the one raised by the rollback.
+type Service struct {
+	reader   client.Reader
+	recorder record.EventRecorder
+	// bumpRatio is how much the memory floor is raised from the current memory request on each OOMKill. 0 disables the bump.
+	bumpRatio float64
+	// floorDecayWindow is how long the floor is kept after it's raised.
+	floorDecayWindow time.Duration
+}
+
+func New(reader client.Reader, recorder record.EventRecorder, bumpRatio float64, floorDecayWindow time.Duration) *Service {
+	return &Service{reader: reader, recorder: recorder, bumpRatio: bumpRatio, floorDecayWindow: floorDecayWindow}
+}
+
+// RaiseFloorsOnOOMKills finds the OOMKills of the containers from .status.containerStatuses[*].lastState.terminated of the Pods,
+// and when a container has new OOMKills, raises its memory floor to the current memory request multiplied by (1 + bumpRatio).
+// The OOMKills finished before the memory floor was raised last time are regarded as already taken into account,
+// and the multiple OOMKills found at once (e.g., in the multiple Pods) raise the floor only once.
+func (s *Service) RaiseFloorsOnOOMKills(ctx context.Context, t *v1beta3.Tortoise, w *workload.Workload, now time.Time) (*v1beta3.Tortoise, error) {
+	if s.bumpRatio == 0 {
+		return t, nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := s.reader.List(ctx, pods, client.InNamespace(t.Namespace), client.MatchingLabels(w.PodTemplate.Labels)); err != nil {
+		return t, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	// container name → the number of the OOMKills which haven't been taken into account yet.
+	newOOMKills := map[string]int{}
+	for _, p := range pods.Items {
+		for _, cs := range p.Status.ContainerStatuses {
+			terminated := cs.LastTerminationState.Terminated
+			if terminated == nil || terminated.Reason != "OOMKilled" {
+				continue
+			}
+			if !terminated.FinishedAt.Add(s.floorDecayWindow).After(now) {
+				// Too old to take into account.
+				continue
+			}
+			if f, ok := utils.GetTortoiseResourceFloor(t, cs.Name, corev1.ResourceMemory); ok && !terminated.FinishedAt.After(f.RaisedAt.Time) {
+				// Already taken into account.
+				continue
+			}
+			newOOMKills[cs.Name]++
+		}
+	}
+
+	containerNames := make([]string, 0, len(newOOMKills))
+	for name := range newOOMKills {
+		containerNames = append(containerNames, name)
+	}
+	sort.Strings(containerNames)
+	for _, containerName := range containerNames {
+		request, ok := utils.GetRequestFromTortoise(t, containerName, corev1.ResourceMemory)
+		if !ok {
+			log.FromContext(ctx).Info("the container was OOMKilled, but its memory request isn't found in the tortoise", "tortoise", klog.KObj(t), "container", containerName)
+			continue
+		}
+		floor := resource.NewQuantity(int64(math.Ceil(float64(request.Value())*(1+s.bumpRatio))), request.Format)
+		t = utils.RaiseTortoiseResourceFloor(t, containerName, corev1.ResourceMemory, *floor, now)
+
+		raised, _ := utils.GetTortoiseResourceFloor(t, containerName, corev1.ResourceMemory)
+		msg := fmt.Sprintf("The container %s was OOMKilled %d time(s), the memory recommendation doesn't go below %s until %s", containerName, newOOMKills[containerName], raised.Quantity.String(), now.Add(s.floorDecayWindow).UTC().Format(time.RFC3339))
+		s.recorder.Event(t, corev1.EventTypeWarning, event.OOMKillDetected, msg)
+		log.FromContext(ctx).Info(msg, "tortoise", klog.KObj(t))
+	}
+
+	return t, nil
+}
@@ -0,0 +1,150 @@
+package oom
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/utils"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+var now = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// memoryFloor returns the floor of the app container's memory raised at raisedAt.
+func memoryFloor(q string, raisedAt time.Time) v1beta3.ContainerResourceFloor {
+	return v1beta3.ContainerResourceFloor{
+		ContainerName: "app",
+		Resource: map[corev1.ResourceName]v1beta3.ResourceFloor{
+			corev1.ResourceMemory: {Quantity: resource.MustParse(q), RaisedAt: metav1.NewTime(raisedAt)},
+		},
+	}
+}
+
+func TestService_RaiseFloorsOnOOMKills(t *testing.T) {
+	tests := []struct {
+		name      string
+		bumpRatio float64
+		// memory is the current memory request of the app container, 1Gi by default.
+		memory     string
+		floors     []v1beta3.ContainerResourceFloor
+		pods       []client.Object
+		wantFloors []v1beta3.ContainerResourceFloor
+	}{
+		{
+			name:      "raise the memory floor once on the new OOMKills",
+			bumpRatio: 0.5,
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-2 * time.Minute))},
+					}}).Build(),
+				utils.NewPodBuilder().SetName("pod2").SetNamespace("default").AddLabel("app", "app").
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-time.Minute))},
+					}}).Build(),
+				utils.NewPodBuilder().SetName("pod3").SetNamespace("default").AddLabel("app", "app").
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "Error", FinishedAt: metav1.NewTime(now.Add(-time.Minute))},
+					}}).Build(),
+			},
+			wantFloors: []v1beta3.ContainerResourceFloor{memoryFloor("1536Mi", now)},
+		},
+		{
+			name:      "raise the memory floor again on the next OOMKill",
+			bumpRatio: 0.5,
+			memory:    "2Gi",
+			floors:    []v1beta3.ContainerResourceFloor{memoryFloor("1Gi", now.Add(-time.Hour))},
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-time.Minute))},
+					}}).Build(),
+			},
+			wantFloors: []v1beta3.ContainerResourceFloor{memoryFloor("3Gi", now)},
+		},
+		{
+			name:      "the OOMKill before the floor was raised isn't taken into account again",
+			bumpRatio: 0.5,
+			floors:    []v1beta3.ContainerResourceFloor{memoryFloor("1536Mi", now.Add(-time.Minute))},
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-2 * time.Minute))},
+					}}).Build(),
+			},
+			wantFloors: []v1beta3.ContainerResourceFloor{memoryFloor("1536Mi", now.Add(-time.Minute))},
+		},
+		{
+			name:      "the higher floor (e.g., raised by the rollback) is kept, but renewed",
+			bumpRatio: 0.5,
+			floors:    []v1beta3.ContainerResourceFloor{memoryFloor("4Gi", now.Add(-time.Hour))},
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-time.Minute))},
+					}}).Build(),
+			},
+			wantFloors: []v1beta3.ContainerResourceFloor{memoryFloor("4Gi", now)},
+		},
+		{
+			name:      "the OOMKill older than the decay window is ignored",
+			bumpRatio: 0.5,
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-25 * time.Hour))},
+					}}).Build(),
+			},
+			wantFloors: nil,
+		},
+		{
+			name:      "disabled",
+			bumpRatio: 0,
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-time.Minute))},
+					}}).Build(),
+			},
+			wantFloors: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := "1Gi"
+			if tt.memory != "" {
+				memory = tt.memory
+			}
+			b := utils.NewTortoiseBuilder().SetName("t").SetNamespace("default").
+				AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
+					ContainerName: "app",
+					Resource:      corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)},
+				})
+			for _, f := range tt.floors {
+				b = b.AddContainerResourceFloors(f)
+			}
+			w := &workload.Workload{
+				PodTemplate: &corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "app"}}},
+			}
+
+			s := New(fake.NewClientBuilder().WithObjects(tt.pods...).Build(), record.NewFakeRecorder(10), tt.bumpRatio, 24*time.Hour)
+			got, err := s.RaiseFloorsOnOOMKills(context.Background(), b.Build(), w, now)
+			if err != nil {
+				t.Fatalf("RaiseFloorsOnOOMKills() error = %v", err)
+			}
+			if d := cmp.Diff(tt.wantFloors, got.Status.Conditions.ContainerResourceFloors); d != "" {
+				t.Errorf("RaiseFloorsOnOOMKills() floors mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/features"
 	"github.com/mercari/tortoise/pkg/utils"
 )
@@ -23,6 +24,10 @@ type Service struct {
 	minimumCPULimit               resource.Quantity
 	controllerFetcher             controllerfetcher.ControllerFetcher
 	goMemLimitModificationEnabled bool
+	// workloadKinds are the kinds of the workloads which Tortoise can target.
+	workloadKinds map[string]bool
+	// podOwnerKinds are the kinds of the controllers which can directly own a Pod under Tortoise.
+	podOwnerKinds map[string]bool
 }
 
 func New(
@@ -30,16 +35,32 @@ func New(
 	minimumCPULimit string,
 	cf controllerfetcher.ControllerFetcher,
 	featureFlags []features.FeatureFlag,
+	scaleSubresourceWorkloads []config.ScaleSubresourceWorkload,
 ) (*Service, error) {
 	if minimumCPULimit == "" {
 		minimumCPULimit = "0"
 	}
-	minCPULim := resource.MustParse(minimumCPULimit)
+	minCPULim, err := resource.ParseQuantity(minimumCPULimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minimum CPU limit: %w", err)
+	}
+
+	// Deployment owns Pods through ReplicaSet.
+	workloadKinds := map[string]bool{"Deployment": true, "StatefulSet": true, "DaemonSet": true}
+	podOwnerKinds := map[string]bool{"ReplicaSet": true, "StatefulSet": true, "DaemonSet": true}
+	for _, w := range scaleSubresourceWorkloads {
+		// The custom workloads may own Pods directly, or through ReplicaSet like Argo Rollouts.
+		workloadKinds[w.Kind] = true
+		podOwnerKinds[w.Kind] = true
+	}
+
 	return &Service{
 		resourceLimitMultiplier:       resourceLimitMultiplier,
 		minimumCPULimit:               minCPULim,
 		controllerFetcher:             cf,
 		goMemLimitModificationEnabled: features.Contains(featureFlags, features.GoMemLimitModificationEnabled),
+		workloadKinds:                 workloadKinds,
+		podOwnerKinds:                 podOwnerKinds,
 	}, nil
 }
 
@@ -254,7 +275,9 @@ func (s *Service) ModifyPodSpecResource(podSpec *v1.PodSpec, t *v1beta3.Tortoise
 	}
 }
 
-func (s *Service) GetDeploymentForPod(pod *v1.Pod) (string, error) {
+// GetWorkloadForPod returns the kind and the name of the top most workload managing the Pod.
+// It returns empty strings when the Pod isn't managed by any workload which Tortoise supports.
+func (s *Service) GetWorkloadForPod(pod *v1.Pod) (string, string, error) {
 	var ownerRefrence *metav1.OwnerReference
 	for i := range pod.OwnerReferences {
 		r := pod.OwnerReferences[i]
@@ -264,12 +287,11 @@ func (s *Service) GetDeploymentForPod(pod *v1.Pod) (string, error) {
 	}
 	if ownerRefrence == nil {
 		// If the pod has no ownerReference, it cannot be under Tortoise.
-		return "", nil
+		return "", "", nil
 	}
 
-	if ownerRefrence.Kind != "ReplicaSet" {
-		// Tortoise only supports Deployment for now, and ReplicaSet is the only controller that can own a pod in this case.
-		return "", nil
+	if !s.podOwnerKinds[ownerRefrence.Kind] {
+		return "", "", nil
 	}
 
 	k := &controllerfetcher.ControllerKeyWithAPIVersion{
@@ -283,15 +305,14 @@ func (s *Service) GetDeploymentForPod(pod *v1.Pod) (string, error) {
 
 	topController, err := s.controllerFetcher.FindTopMostWellKnownOrScalable(k)
 	if err != nil {
-		return "", fmt.Errorf("failed to find top most well known or scalable controller: %v", err)
+		return "", "", fmt.Errorf("failed to find top most well known or scalable controller: %v", err)
 	}
 
-	if topController.Kind != "Deployment" {
-		// Tortoise only supports Deployment for now.
-		return "", nil
+	if !s.workloadKinds[topController.Kind] {
+		return "", "", nil
 	}
 
-	return topController.Name, nil
+	return topController.Kind, topController.Name, nil
 }
 
 type containerNameAndResource struct {
@@ -8,10 +8,12 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
 	"k8s.io/utils/ptr"
 
 	"github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/features"
 )
 
@@ -205,7 +207,7 @@ func TestService_ModifyPodTemplateResource(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s, err := New(nil, "", nil, nil)
+			s, err := New(nil, "", nil, nil, nil)
 			if err != nil {
 				t.Fatalf("New() error = %v", err)
 			}
@@ -1160,7 +1162,7 @@ func TestService_ModifyPodSpecResource(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s, err := New(tt.fields.resourceLimitMultiplier, tt.fields.minimumCPULimit, nil, tt.fields.featureFlags)
+			s, err := New(tt.fields.resourceLimitMultiplier, tt.fields.minimumCPULimit, nil, tt.fields.featureFlags, nil)
 			if err != nil {
 				t.Fatalf("New() error = %v", err)
 			}
@@ -1172,3 +1174,99 @@ func TestService_ModifyPodSpecResource(t *testing.T) {
 		})
 	}
 }
+
+// fakeControllerFetcher resolves the top most controller from the given map.
+type fakeControllerFetcher struct {
+	// key is "kind/name" of the controller owning the Pod.
+	topControllers map[string]controllerfetcher.ControllerKeyWithAPIVersion
+}
+
+func (f *fakeControllerFetcher) FindTopMostWellKnownOrScalable(k *controllerfetcher.ControllerKeyWithAPIVersion) (*controllerfetcher.ControllerKeyWithAPIVersion, error) {
+	top, ok := f.topControllers[k.Kind+"/"+k.Name]
+	if !ok {
+		return k, nil
+	}
+	return &top, nil
+}
+
+func TestService_GetWorkloadForPod(t *testing.T) {
+	cf := &fakeControllerFetcher{
+		topControllers: map[string]controllerfetcher.ControllerKeyWithAPIVersion{
+			"ReplicaSet/app-12345": {
+				ControllerKey: controllerfetcher.ControllerKey{Namespace: "default", Kind: "Deployment", Name: "app"},
+				ApiVersion:    "apps/v1",
+			},
+			"ReplicaSet/rollout-12345": {
+				ControllerKey: controllerfetcher.ControllerKey{Namespace: "default", Kind: "Rollout", Name: "rollout"},
+				ApiVersion:    "argoproj.io/v1alpha1",
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		owner    *metav1.OwnerReference
+		wantKind string
+		wantName string
+	}{
+		{
+			name:     "Pod managed by Deployment",
+			owner:    &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "app-12345", Controller: ptr.To(true)},
+			wantKind: "Deployment",
+			wantName: "app",
+		},
+		{
+			name:     "Pod managed by StatefulSet",
+			owner:    &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db", Controller: ptr.To(true)},
+			wantKind: "StatefulSet",
+			wantName: "db",
+		},
+		{
+			name:     "Pod managed by DaemonSet",
+			owner:    &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "agent", Controller: ptr.To(true)},
+			wantKind: "DaemonSet",
+			wantName: "agent",
+		},
+		{
+			name:     "Pod managed by Argo Rollout",
+			owner:    &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rollout-12345", Controller: ptr.To(true)},
+			wantKind: "Rollout",
+			wantName: "rollout",
+		},
+		{
+			name:     "Pod managed by the custom workload directly",
+			owner:    &metav1.OwnerReference{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "rollout", Controller: ptr.To(true)},
+			wantKind: "Rollout",
+			wantName: "rollout",
+		},
+		{
+			name:  "Pod managed by ReplicaSet only",
+			owner: &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "standalone", Controller: ptr.To(true)},
+		},
+		{
+			name:  "Pod managed by Job",
+			owner: &metav1.OwnerReference{APIVersion: "batch/v1", Kind: "Job", Name: "job", Controller: ptr.To(true)},
+		},
+		{
+			name: "Pod without owner",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(map[string]int64{}, "", cf, nil, []config.ScaleSubresourceWorkload{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout"}})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+			if tt.owner != nil {
+				pod.OwnerReferences = []metav1.OwnerReference{*tt.owner}
+			}
+			gotKind, gotName, err := s.GetWorkloadForPod(pod)
+			if err != nil {
+				t.Fatalf("GetWorkloadForPod() error = %v", err)
+			}
+			if gotKind != tt.wantKind || gotName != tt.wantName {
+				t.Errorf("GetWorkloadForPod() = (%v, %v), want (%v, %v)", gotKind, gotName, tt.wantKind, tt.wantName)
+			}
+		})
+	}
+}
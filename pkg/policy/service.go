@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+)
+
+type Service struct {
+	c client.Client
+}
+
+func New(c client.Client) *Service {
+	return &Service{c: c}
+}
+
+// Resolve returns the overrides for the Tortoises in the namespace, merged from all the TortoisePolicies selecting the namespace.
+// Each field is taken from the TortoisePolicy with the highest priority among the ones setting the field.
+// The empty overrides is returned when no TortoisePolicy selects the namespace.
+func (s *Service) Resolve(ctx context.Context, namespace string) (v1alpha1.ConfigOverrides, error) {
+	policies := &v1alpha1.TortoisePolicyList{}
+	if err := s.c.List(ctx, policies); err != nil {
+		if meta.IsNoMatchError(err) {
+			// The TortoisePolicy CRD isn't installed.
+			return v1alpha1.ConfigOverrides{}, nil
+		}
+		return v1alpha1.ConfigOverrides{}, fmt.Errorf("failed to list tortoise policies: %w", err)
+	}
+	if len(policies.Items) == 0 {
+		return v1alpha1.ConfigOverrides{}, nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := s.c.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return v1alpha1.ConfigOverrides{}, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+
+	matched := []v1alpha1.TortoisePolicy{}
+	for _, p := range policies.Items {
+		selector, err := metav1.LabelSelectorAsSelector(&p.Spec.NamespaceSelector)
+		if err != nil {
+			return v1alpha1.ConfigOverrides{}, fmt.Errorf("invalid namespace selector in tortoise policy %s: %w", p.Name, err)
+		}
+		if selector.Matches(labels.Set(ns.Labels)) {
+			matched = append(matched, p)
+		}
+	}
+
+	// Apply from the lowest priority so that the higher priority ones overwrite them.
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Spec.Priority != matched[j].Spec.Priority {
+			return matched[i].Spec.Priority < matched[j].Spec.Priority
+		}
+		return matched[i].Name > matched[j].Name
+	})
+	overrides := v1alpha1.ConfigOverrides{}
+	for _, p := range matched {
+		overrides = merge(overrides, p.Spec.Overrides)
+	}
+	return overrides, nil
+}
+
+// merge returns base overwritten by the fields set in o.
+func merge(base, o v1alpha1.ConfigOverrides) v1alpha1.ConfigOverrides {
+	if o.MinimumMinReplicas != nil {
+		base.MinimumMinReplicas = o.MinimumMinReplicas
+	}
+	if o.MaximumMinReplicas != nil {
+		base.MaximumMinReplicas = o.MaximumMinReplicas
+	}
+	if o.PreferredMaxReplicas != nil {
+		base.PreferredMaxReplicas = o.PreferredMaxReplicas
+	}
+	if o.MaximumMaxReplicas != nil {
+		base.MaximumMaxReplicas = o.MaximumMaxReplicas
+	}
+	if o.MinimumCPURequest != nil {
+		base.MinimumCPURequest = o.MinimumCPURequest
+	}
+	if o.MinimumMemoryRequest != nil {
+		base.MinimumMemoryRequest = o.MinimumMemoryRequest
+	}
+	if o.MaximumCPURequest != nil {
+		base.MaximumCPURequest = o.MaximumCPURequest
+	}
+	if o.MaximumMemoryRequest != nil {
+		base.MaximumMemoryRequest = o.MaximumMemoryRequest
+	}
+	if o.MinimumTargetResourceUtilization != nil {
+		base.MinimumTargetResourceUtilization = o.MinimumTargetResourceUtilization
+	}
+	if o.MaximumTargetResourceUtilization != nil {
+		base.MaximumTargetResourceUtilization = o.MaximumTargetResourceUtilization
+	}
+	return base
+}
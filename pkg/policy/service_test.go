@@ -0,0 +1,132 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+)
+
+func tortoisePolicy(name string, priority int32, selector metav1.LabelSelector, overrides v1alpha1.ConfigOverrides) *v1alpha1.TortoisePolicy {
+	return &v1alpha1.TortoisePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1alpha1.TortoisePolicySpec{
+			NamespaceSelector: selector,
+			Priority:          priority,
+			Overrides:         overrides,
+		},
+	}
+}
+
+func TestService_Resolve(t *testing.T) {
+	batch := metav1.LabelSelector{MatchLabels: map[string]string{"workload-type": "batch"}}
+	frontend := metav1.LabelSelector{MatchLabels: map[string]string{"workload-type": "frontend"}}
+
+	tests := []struct {
+		name     string
+		policies []client.Object
+		want     v1alpha1.ConfigOverrides
+		wantErr  bool
+	}{
+		{
+			name: "no policy",
+			want: v1alpha1.ConfigOverrides{},
+		},
+		{
+			name: "no policy selects the namespace",
+			policies: []client.Object{
+				tortoisePolicy("frontend", 0, frontend, v1alpha1.ConfigOverrides{MinimumMinReplicas: ptr.To[int32](5)}),
+			},
+			want: v1alpha1.ConfigOverrides{},
+		},
+		{
+			name: "the policy selecting the namespace is used",
+			policies: []client.Object{
+				tortoisePolicy("frontend", 0, frontend, v1alpha1.ConfigOverrides{MinimumMinReplicas: ptr.To[int32](5)}),
+				tortoisePolicy("batch", 0, batch, v1alpha1.ConfigOverrides{
+					MinimumMinReplicas: ptr.To[int32](1),
+					MaximumCPURequest:  ptr.To(resource.MustParse("20")),
+				}),
+			},
+			want: v1alpha1.ConfigOverrides{
+				MinimumMinReplicas: ptr.To[int32](1),
+				MaximumCPURequest:  ptr.To(resource.MustParse("20")),
+			},
+		},
+		{
+			name: "the empty selector selects all the namespaces",
+			policies: []client.Object{
+				tortoisePolicy("all", 0, metav1.LabelSelector{}, v1alpha1.ConfigOverrides{MaximumMaxReplicas: ptr.To[int32](50)}),
+			},
+			want: v1alpha1.ConfigOverrides{MaximumMaxReplicas: ptr.To[int32](50)},
+		},
+		{
+			name: "each field is taken from the policy with the highest priority setting it",
+			policies: []client.Object{
+				tortoisePolicy("all", 0, metav1.LabelSelector{}, v1alpha1.ConfigOverrides{
+					MinimumMinReplicas: ptr.To[int32](3),
+					MaximumMaxReplicas: ptr.To[int32](50),
+				}),
+				tortoisePolicy("batch", 10, batch, v1alpha1.ConfigOverrides{
+					MinimumMinReplicas: ptr.To[int32](1),
+				}),
+			},
+			want: v1alpha1.ConfigOverrides{
+				MinimumMinReplicas: ptr.To[int32](1),
+				MaximumMaxReplicas: ptr.To[int32](50),
+			},
+		},
+		{
+			name: "the policy with the smaller name wins when the priorities are the same",
+			policies: []client.Object{
+				tortoisePolicy("b", 0, batch, v1alpha1.ConfigOverrides{MinimumMinReplicas: ptr.To[int32](2)}),
+				tortoisePolicy("a", 0, batch, v1alpha1.ConfigOverrides{MinimumMinReplicas: ptr.To[int32](1)}),
+				tortoisePolicy("c", 0, batch, v1alpha1.ConfigOverrides{MinimumMinReplicas: ptr.To[int32](3)}),
+			},
+			want: v1alpha1.ConfigOverrides{MinimumMinReplicas: ptr.To[int32](1)},
+		},
+		{
+			name: "invalid selector",
+			policies: []client.Object{
+				tortoisePolicy("invalid", 0, metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "workload-type", Operator: "Unknown"}},
+				}, v1alpha1.ConfigOverrides{}),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			if err := v1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "batch-jobs", Labels: map[string]string{"workload-type": "batch"}}}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(tt.policies, ns)...).Build()
+
+			got, err := New(c).Resolve(context.Background(), "batch-jobs")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("Resolve() diff = %v", d)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"time"
 
 	v2 "k8s.io/api/autoscaling/v2"
@@ -16,6 +17,7 @@ import (
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/mercari/tortoise/api/v1alpha1"
 	"github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/event"
 	"github.com/mercari/tortoise/pkg/features"
@@ -44,6 +46,13 @@ type Service struct {
 	maxAllowedScalingDownRatio float64
 
 	bufferRatioOnVerticalResource float64
+	// verticalMinimumChangeRatio is the minimum relative change of the resource request to apply.
+	// 0 means the buffer is used as the threshold of the decreases.
+	verticalMinimumChangeRatio float64
+	// verticalMinimumChange is the minimum change of the resource request to apply.
+	verticalMinimumChange corev1.ResourceList
+	// verticalMaxStepRatio is the maximum relative change of the resource request per apply. 0 means no limit.
+	verticalMaxStepRatio float64
 }
 
 func New(
@@ -62,23 +71,67 @@ func New(
 	maximumMaxReplica int32,
 	maxAllowedScalingDownRatio float64,
 	bufferRatioOnVerticalResourceRecommendation float64,
+	verticalMinimumChangeRatio float64,
+	verticalMinimumCPUChange string,
+	verticalMinimumMemoryChange string,
+	verticalMaxStepRatio float64,
 	featureFlags []features.FeatureFlag,
 	eventRecorder record.EventRecorder,
-) *Service {
-	minimumCPUPerContainer["*"] = minCPU
-	minimumMemoryPerContainer["*"] = minMemory
+) (*Service, error) {
+	// Copy not to modify the maps in the config.
+	minimumCPUs := map[string]string{"*": minCPU}
+	for containerName, v := range minimumCPUPerContainer {
+		minimumCPUs[containerName] = v
+	}
+	minimumMemories := map[string]string{"*": minMemory}
+	for containerName, v := range minimumMemoryPerContainer {
+		minimumMemories[containerName] = v
+	}
 
 	minResourceSizePerContainer := map[string]corev1.ResourceList{}
-	for containerName, v := range minimumCPUPerContainer {
+	for containerName, v := range minimumCPUs {
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum CPU request for the container %s: %w", containerName, err)
+		}
 		minResourceSizePerContainer[containerName] = corev1.ResourceList{
-			corev1.ResourceCPU: resource.MustParse(v),
+			corev1.ResourceCPU: q,
 		}
 	}
-	for containerName, v := range minimumMemoryPerContainer {
+	for containerName, v := range minimumMemories {
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum memory request for the container %s: %w", containerName, err)
+		}
 		if _, ok := minResourceSizePerContainer[containerName]; !ok {
 			minResourceSizePerContainer[containerName] = corev1.ResourceList{}
 		}
-		minResourceSizePerContainer[containerName][corev1.ResourceMemory] = resource.MustParse(v)
+		minResourceSizePerContainer[containerName][corev1.ResourceMemory] = q
+	}
+
+	maxCPUQuantity, err := resource.ParseQuantity(maxCPU)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maximum CPU request: %w", err)
+	}
+	maxMemoryQuantity, err := resource.ParseQuantity(maxMemory)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maximum memory request: %w", err)
+	}
+
+	verticalMinimumChange := corev1.ResourceList{}
+	if verticalMinimumCPUChange != "" {
+		q, err := resource.ParseQuantity(verticalMinimumCPUChange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vertical minimum CPU change: %w", err)
+		}
+		verticalMinimumChange[corev1.ResourceCPU] = q
+	}
+	if verticalMinimumMemoryChange != "" {
+		q, err := resource.ParseQuantity(verticalMinimumMemoryChange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vertical minimum memory change: %w", err)
+		}
+		verticalMinimumChange[corev1.ResourceMemory] = q
 	}
 
 	return &Service{
@@ -91,14 +144,62 @@ func New(
 		preferredMaxReplicas:                int32(preferredMaxReplicas),
 		minResourceSizePerContainer:         minResourceSizePerContainer,
 		maxResourceSize: corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse(maxCPU),
-			corev1.ResourceMemory: resource.MustParse(maxMemory),
+			corev1.ResourceCPU:    maxCPUQuantity,
+			corev1.ResourceMemory: maxMemoryQuantity,
 		},
 		maximumMaxReplica:             maximumMaxReplica,
 		featureFlags:                  featureFlags,
 		maxAllowedScalingDownRatio:    maxAllowedScalingDownRatio,
 		bufferRatioOnVerticalResource: bufferRatioOnVerticalResourceRecommendation,
+		verticalMinimumChangeRatio:    verticalMinimumChangeRatio,
+		verticalMinimumChange:         verticalMinimumChange,
+		verticalMaxStepRatio:          verticalMaxStepRatio,
+	}, nil
+}
+
+// WithOverrides returns a copy of the service with the configurations overridden by the TortoisePolicy.
+func (s *Service) WithOverrides(o v1alpha1.ConfigOverrides) *Service {
+	copied := *s
+	if o.MinimumMinReplicas != nil {
+		copied.minimumMinReplicas = *o.MinimumMinReplicas
 	}
+	if o.PreferredMaxReplicas != nil {
+		copied.preferredMaxReplicas = *o.PreferredMaxReplicas
+	}
+	if o.MaximumMaxReplicas != nil {
+		copied.maximumMaxReplica = *o.MaximumMaxReplicas
+	}
+	if o.MinimumTargetResourceUtilization != nil {
+		copied.minimumTargetResourceUtilization = *o.MinimumTargetResourceUtilization
+	}
+	if o.MaximumTargetResourceUtilization != nil {
+		copied.maximumTargetResourceUtilization = *o.MaximumTargetResourceUtilization
+	}
+	if o.MaximumCPURequest != nil || o.MaximumMemoryRequest != nil {
+		copied.maxResourceSize = s.maxResourceSize.DeepCopy()
+		if o.MaximumCPURequest != nil {
+			copied.maxResourceSize[corev1.ResourceCPU] = *o.MaximumCPURequest
+		}
+		if o.MaximumMemoryRequest != nil {
+			copied.maxResourceSize[corev1.ResourceMemory] = *o.MaximumMemoryRequest
+		}
+	}
+	if o.MinimumCPURequest != nil || o.MinimumMemoryRequest != nil {
+		// Only the default for all containers is overridden, and the per-container ones are kept.
+		copied.minResourceSizePerContainer = make(map[string]corev1.ResourceList, len(s.minResourceSizePerContainer))
+		for k, v := range s.minResourceSizePerContainer {
+			copied.minResourceSizePerContainer[k] = v
+		}
+		defaults := s.minResourceSizePerContainer["*"].DeepCopy()
+		if o.MinimumCPURequest != nil {
+			defaults[corev1.ResourceCPU] = *o.MinimumCPURequest
+		}
+		if o.MinimumMemoryRequest != nil {
+			defaults[corev1.ResourceMemory] = *o.MinimumMemoryRequest
+		}
+		copied.minResourceSizePerContainer["*"] = defaults
+	}
+	return &copied
 }
 
 func (s *Service) updateVPARecommendation(ctx context.Context, tortoise *v1beta3.Tortoise, hpa *v2.HorizontalPodAutoscaler, replicaNum int32, now time.Time) (*v1beta3.Tortoise, error) {
@@ -157,6 +258,20 @@ func (s *Service) updateVPARecommendation(ctx context.Context, tortoise *v1beta3
 	for _, r := range tortoise.Spec.ResourcePolicy {
 		minAllocatedResourcesMap[r.ContainerName] = r.MinAllocatedResources
 	}
+	if tortoise.Status.Recommendations.Constraints != nil {
+		// Bigger min requirement is used.
+		for _, r := range tortoise.Status.Recommendations.Constraints.MinAllocatedResources {
+			minAllocatedResourcesMap[r.ContainerName] = mergeMinAllocatedResources(minAllocatedResourcesMap[r.ContainerName], r.MinAllocatedResources)
+		}
+	}
+	// The floors raised by the rollback of the resource requests or OOMKills are also respected.
+	for _, r := range tortoise.Status.Conditions.ContainerResourceFloors {
+		floors := v1.ResourceList{}
+		for rn, f := range r.Resource {
+			floors[rn] = f.Quantity
+		}
+		minAllocatedResourcesMap[r.ContainerName] = mergeMinAllocatedResources(minAllocatedResourcesMap[r.ContainerName], floors)
+	}
 
 	// containerName → MaxAllocatedResources
 	maxAllocatedResourcesMap := map[string]v1.ResourceList{}
@@ -165,6 +280,7 @@ func (s *Service) updateVPARecommendation(ctx context.Context, tortoise *v1beta3
 	}
 
 	newRecommendations := []v1beta3.RecommendedContainerResources{}
+	explanations := []v1beta3.RecommendationExplanation{}
 	for _, r := range tortoise.Status.AutoscalingPolicy {
 		recommendation := v1beta3.RecommendedContainerResources{
 			ContainerName:       r.ContainerName,
@@ -195,13 +311,24 @@ func (s *Service) updateVPARecommendation(ctx context.Context, tortoise *v1beta3
 			if !ok {
 				return tortoise, fmt.Errorf("no %s recommendation from VPA for the container %s", k, r.ContainerName)
 			}
-			var newSize int64
-			var reason string
-			var err error
-			newSize, reason, err = s.calculateBestNewSize(ctx, tortoise, p, r.ContainerName, recom, k, hpa, replicaNum, req, minAllocatedResourcesMap[r.ContainerName], maxAllocatedResourcesMap[r.ContainerName], scaledUpBasedOnPreferredMaxReplicas, closeToPreferredMaxReplicas)
+			newSize, explanation, err := s.calculateBestNewSize(ctx, tortoise, p, r.ContainerName, recom, k, hpa, replicaNum, req, minAllocatedResourcesMap[r.ContainerName], maxAllocatedResourcesMap[r.ContainerName], scaledUpBasedOnPreferredMaxReplicas, closeToPreferredMaxReplicas)
 			if err != nil {
 				return tortoise, err
 			}
+			reason := explanation.Message
+
+			explanation.ContainerName = r.ContainerName
+			explanation.ResourceName = k
+			explanation.ResourceRequest = req
+			explanation.VPAMaxRecommendation = recom
+			explanation.Replicas = replicaNum
+			explanation.GeneratedAt = metav1.NewTime(now)
+			if p == v1beta3.AutoscalingTypeHorizontal && hpa != nil {
+				if target, err := hpaservice.GetHPATargetValue(ctx, hpa, r.ContainerName, k); err == nil {
+					explanation.HPATargetUtilization = ptr.To(target)
+				}
+			}
+			explanations = append(explanations, explanation)
 
 			if newSize != req.MilliValue() {
 				logger.Info("The recommendation of resource request in Tortoise is updated", "container name", r.ContainerName, "resource name", k, "reason", reason)
@@ -217,10 +344,37 @@ func (s *Service) updateVPARecommendation(ctx context.Context, tortoise *v1beta3
 	}
 
 	tortoise.Status.Recommendations.Vertical.ContainerResourceRecommendation = newRecommendations
+	tortoise.Status.Recommendations.Vertical.Explanations = sortExplanations(explanations)
 
 	return tortoise, nil
 }
 
+// sortExplanations sorts the explanations by the container and the resource
+// so that the order doesn't change in every reconciliation because of the map iteration.
+func sortExplanations(explanations []v1beta3.RecommendationExplanation) []v1beta3.RecommendationExplanation {
+	sort.Slice(explanations, func(i, j int) bool {
+		if explanations[i].ContainerName != explanations[j].ContainerName {
+			return explanations[i].ContainerName < explanations[j].ContainerName
+		}
+		return explanations[i].ResourceName < explanations[j].ResourceName
+	})
+	return explanations
+}
+
+// mergeMinAllocatedResources returns the bigger min requirement of each resource.
+func mergeMinAllocatedResources(current, additional v1.ResourceList) v1.ResourceList {
+	merged := current.DeepCopy()
+	if merged == nil {
+		merged = v1.ResourceList{}
+	}
+	for k, q := range additional {
+		if c, ok := merged[k]; !ok || c.Cmp(q) < 0 {
+			merged[k] = q
+		}
+	}
+	return merged
+}
+
 func allowVerticalScalingBasedOnPreferredMaxReplicas(tortoise *v1beta3.Tortoise, now time.Time) bool {
 	for _, c := range tortoise.Status.Conditions.TortoiseConditions {
 		if c.Type == v1beta3.TortoiseConditionTypeScaledUpBasedOnPreferredMaxReplicas && c.Status == v1.ConditionTrue {
@@ -249,40 +403,80 @@ func (s *Service) calculateBestNewSize(
 	resourceRequest resource.Quantity,
 	minAllocatedResources, maxAllocatedResources corev1.ResourceList,
 	scaledUpBasedOnPreferredMaxReplicas, closeToPreferredMaxReplicas bool,
-) (int64, string, error) {
+) (int64, v1beta3.RecommendationExplanation, error) {
 	if p == v1beta3.AutoscalingTypeOff {
 		// Just keep the current resource request.
-		return resourceRequest.MilliValue(), "The autoscaling policy for this resource is Off", nil
+		return resourceRequest.MilliValue(), explain(v1beta3.RecommendationBranchPolicyOff, "The autoscaling policy for this resource is Off"), nil
 	}
 
 	if p == v1beta3.AutoscalingTypeVertical {
 		// The user configures Vertical on this container's resource. This is just vertical scaling.
 		// Basically we want to reduce the frequency of scaling up/down because vertical scaling has to restart deployment.
 
+		behavior := utils.GetResourceBehavior(tortoise, containerName, k)
+		buffer := s.bufferRatioOnVerticalResource
+		if b := behavior.VerticalBufferPercent; b != nil {
+			buffer = float64(*b) / 100
+		}
+		minimumChangeRatio := s.verticalMinimumChangeRatio
+		if r := behavior.VerticalMinimumChangePercent; r != nil {
+			minimumChangeRatio = float64(*r) / 100
+		}
+		minimumChange := s.verticalMinimumChange[k]
+		if c := behavior.VerticalMinimumChange; c != nil {
+			minimumChange = *c
+		}
+		maxStepRatio := s.verticalMaxStepRatio
+		if r := behavior.VerticalMaxStepPercent; r != nil {
+			maxStepRatio = float64(*r) / 100
+		}
+
+		// resize applies the max step and justifies the new size.
+		resize := func(newSize int64) (int64, []v1beta3.RecommendationClamp) {
+			stepped := limitVerticalStep(resourceRequest.MilliValue(), newSize, maxStepRatio)
+			jastified, clamp := s.justifyNewSize(resourceRequest.MilliValue(), stepped, k, minAllocatedResources, maxAllocatedResources, containerName)
+			var clamps []v1beta3.RecommendationClamp
+			if stepped != newSize {
+				clamps = append(clamps, v1beta3.RecommendationClampVerticalMaxStep)
+			}
+			return jastified, append(clamps, clamp...)
+		}
+
 		// The ideal size is {VPA recommendation} * (1+buffer).
-		idealSize := float64(recommendedResourceRequest.MilliValue()) * (1 + s.bufferRatioOnVerticalResource)
+		idealSize := float64(recommendedResourceRequest.MilliValue()) * (1 + buffer)
 		if idealSize > float64(resourceRequest.MilliValue()) {
 			// Scale up always happens when idealSize goes higher than the current resource request.
 			// In this case, we don't just apply idealSize, but apply idealSize * (1+buffer)
 			// so that we increase the resource request more than actually needed,
 			// which reduces the need of scaling up in the future.
-			idealSize = idealSize * (1 + s.bufferRatioOnVerticalResource)
-			jastified := s.justifyNewSize(resourceRequest.MilliValue(), int64(idealSize), k, minAllocatedResources, maxAllocatedResources, containerName)
-			return jastified, fmt.Sprintf("change %v request (%v) (%v → %v) based on VPA suggestion", k, containerName, resourceRequest.MilliValue(), jastified), nil
+			idealSize = idealSize * (1 + buffer)
+			if recommendedResourceRequest.MilliValue() <= resourceRequest.MilliValue() && smallVerticalChange(resourceRequest.MilliValue(), int64(idealSize), minimumChangeRatio, minimumChange) {
+				// The current resource request still covers the recommendation, and only the buffer is a bit short.
+				return resourceRequest.MilliValue(),
+					explain(v1beta3.RecommendationBranchSmallChangeIgnored, fmt.Sprintf("Tortoise recommends %v as a new %v request (%v), but it's very small scale up change, so tortoise just ignores it", idealSize, k, containerName)),
+					nil
+			}
+			jastified, clamps := resize(int64(idealSize))
+			return jastified, explain(v1beta3.RecommendationBranchVerticalScaleUp, fmt.Sprintf("change %v request (%v) (%v → %v) based on VPA suggestion", k, containerName, resourceRequest.MilliValue(), jastified), clamps...), nil
 		}
 
 		// Scale down - we ignore too small scale down to reduce the frequency of restarts.
 
-		// previousIdealSize was the ideal size which was calculated when this resource request was applied.
-		previousIdealSize := float64(resourceRequest.MilliValue()) / (1 + s.bufferRatioOnVerticalResource)
-		if previousIdealSize*(1-s.bufferRatioOnVerticalResource) > idealSize {
-			// The current ideal size is too small campared to the previous ideal size.
-			jastified := s.justifyNewSize(resourceRequest.MilliValue(), int64(idealSize), k, minAllocatedResources, maxAllocatedResources, containerName)
-			return jastified, fmt.Sprintf("change %v request (%v) (%v → %v) based on VPA suggestion", k, containerName, resourceRequest.MilliValue(), jastified), nil
+		small := smallVerticalChange(resourceRequest.MilliValue(), int64(idealSize), minimumChangeRatio, minimumChange)
+		if minimumChangeRatio == 0 {
+			// Without the minimum change ratio, the buffer is used as the threshold.
+			// previousIdealSize was the ideal size which was calculated when this resource request was applied.
+			previousIdealSize := float64(resourceRequest.MilliValue()) / (1 + buffer)
+			small = small || previousIdealSize*(1-buffer) <= idealSize
+		}
+		if !small {
+			// The current ideal size is small enough compared to the current resource request.
+			jastified, clamps := resize(int64(idealSize))
+			return jastified, explain(v1beta3.RecommendationBranchVerticalScaleDown, fmt.Sprintf("change %v request (%v) (%v → %v) based on VPA suggestion", k, containerName, resourceRequest.MilliValue(), jastified), clamps...), nil
 		}
 
 		return resourceRequest.MilliValue(),
-			fmt.Sprintf("Tortoise recommends %v as a new %v request (%v), but it's very small scale down change, so tortoise just ignores it", idealSize, k, containerName),
+			explain(v1beta3.RecommendationBranchSmallChangeIgnored, fmt.Sprintf("Tortoise recommends %v as a new %v request (%v), but it's very small scale down change, so tortoise just ignores it", idealSize, k, containerName)),
 			nil
 	}
 
@@ -295,9 +489,9 @@ func (s *Service) calculateBestNewSize(
 	if scaledUpBasedOnPreferredMaxReplicas {
 		// We keep increasing the size until we hit the maxResourceSize.
 		newSize := int64(float64(resourceRequest.MilliValue()) * 1.3)
-		jastifiedNewSize := s.justifyNewSize(resourceRequest.MilliValue(), newSize, k, minAllocatedResources, maxAllocatedResources, containerName)
+		jastifiedNewSize, clamps := s.justifyNewSize(resourceRequest.MilliValue(), newSize, k, minAllocatedResources, maxAllocatedResources, containerName)
 		msg := fmt.Sprintf("the current number of replicas (%v) is bigger than the preferred max replica number in this cluster (%v), so make %v request (%s) bigger (%v → %v)", replicaNum, s.preferredMaxReplicas, k, containerName, resourceRequest.MilliValue(), jastifiedNewSize)
-		return jastifiedNewSize, msg, nil
+		return jastifiedNewSize, explain(v1beta3.RecommendationBranchPreferredMaxReplicas, msg, clamps...), nil
 	}
 
 	if closeToPreferredMaxReplicas {
@@ -305,7 +499,7 @@ func (s *Service) calculateBestNewSize(
 		// So, we just keep the current resource request
 		// until the replica number goes lower
 		// because scaling down the resource request might increase the replica number further more.
-		return resourceRequest.MilliValue(), fmt.Sprintf("the current number of replicas is close to the preferred max replica number in this cluster, so keep the current resource request in %s in %s", k, containerName), nil
+		return resourceRequest.MilliValue(), explain(v1beta3.RecommendationBranchCloseToPreferredMaxReplicas, fmt.Sprintf("the current number of replicas is close to the preferred max replica number in this cluster, so keep the current resource request in %s in %s", k, containerName)), nil
 	}
 
 	if replicaNum <= s.minimumMinReplicas {
@@ -320,9 +514,9 @@ func (s *Service) calculateBestNewSize(
 			// We use the recommended resource request if it's smaller than the current resource request.
 			newSize = recommendedResourceRequest.MilliValue()
 		}
-		jastified := s.justifyNewSize(resourceRequest.MilliValue(), newSize, k, minAllocatedResources, maxAllocatedResources, containerName)
+		jastified, clamps := s.justifyNewSize(resourceRequest.MilliValue(), newSize, k, minAllocatedResources, maxAllocatedResources, containerName)
 
-		return jastified, fmt.Sprintf("the current number of replicas is equal or smaller than the minimum min replica number in this cluster (%v), so make %v request (%v) smaller (%v → %v) based on VPA suggestion", s.minimumMinReplicas, k, containerName, resourceRequest.MilliValue(), jastified), nil
+		return jastified, explain(v1beta3.RecommendationBranchMinimumMinReplicas, fmt.Sprintf("the current number of replicas is equal or smaller than the minimum min replica number in this cluster (%v), so make %v request (%v) smaller (%v → %v) based on VPA suggestion", s.minimumMinReplicas, k, containerName, resourceRequest.MilliValue(), jastified), clamps...), nil
 	}
 
 	// The replica number is OK based on minimumMinReplicas and preferredMaxReplicas.
@@ -332,12 +526,13 @@ func (s *Service) calculateBestNewSize(
 		// Also, if the current replica number is equal to the minReplicas,
 		// we don't change the resource request based on the current resource utilization
 		// because even if the resource utilization is low, it's due to the minReplicas.
-		return s.justifyNewSize(resourceRequest.MilliValue(), resourceRequest.MilliValue(), k, minAllocatedResources, maxAllocatedResources, containerName), "nothing to do", nil
+		jastified, clamps := s.justifyNewSize(resourceRequest.MilliValue(), resourceRequest.MilliValue(), k, minAllocatedResources, maxAllocatedResources, containerName)
+		return jastified, explain(v1beta3.RecommendationBranchNoChange, "nothing to do", clamps...), nil
 	}
 
 	targetUtilizationValue, err := hpaservice.GetHPATargetValue(ctx, hpa, containerName, k)
 	if err != nil {
-		return 0, "", fmt.Errorf("get the target value from HPA: %w", err)
+		return 0, v1beta3.RecommendationExplanation{}, fmt.Errorf("get the target value from HPA: %w", err)
 	}
 
 	upperUtilization := (float64(recommendedResourceRequest.MilliValue()) / float64(resourceRequest.MilliValue())) * 100
@@ -351,13 +546,27 @@ func (s *Service) calculateBestNewSize(
 		// And this case, reducing the resource request of container in this kind of weird situation
 		// so that the upper usage will be the target usage.
 		newSize := int64(float64(recommendedResourceRequest.MilliValue()) * 100.0 / float64(targetUtilizationValue))
-		jastified := s.justifyNewSize(resourceRequest.MilliValue(), newSize, k, minAllocatedResources, maxAllocatedResources, containerName)
-		return jastified, fmt.Sprintf("the current resource usage (%v, %v%%) is too small and it's due to unbalanced container size, so make %v request (%v) smaller (%v → %v) based on VPA's recommendation and HPA target utilization %v%%", recommendedResourceRequest.MilliValue(), int(upperUtilization), k, containerName, resourceRequest.MilliValue(), jastified, targetUtilizationValue), nil
+		jastified, clamps := s.justifyNewSize(resourceRequest.MilliValue(), newSize, k, minAllocatedResources, maxAllocatedResources, containerName)
+		return jastified, explain(v1beta3.RecommendationBranchUnbalancedContainer, fmt.Sprintf("the current resource usage (%v, %v%%) is too small and it's due to unbalanced container size, so make %v request (%v) smaller (%v → %v) based on VPA's recommendation and HPA target utilization %v%%", recommendedResourceRequest.MilliValue(), int(upperUtilization), k, containerName, resourceRequest.MilliValue(), jastified, targetUtilizationValue), clamps...), nil
 	}
 
 	// Just keep the current resource request.
 	// Only do justification.
-	return s.justifyNewSize(resourceRequest.MilliValue(), resourceRequest.MilliValue(), k, minAllocatedResources, maxAllocatedResources, containerName), "nothing to do", nil
+	jastified, clamps := s.justifyNewSize(resourceRequest.MilliValue(), resourceRequest.MilliValue(), k, minAllocatedResources, maxAllocatedResources, containerName)
+	return jastified, explain(v1beta3.RecommendationBranchNoChange, "nothing to do", clamps...), nil
+}
+
+// explain returns the explanation of the recommendation generated by the branch.
+// The inputs of the recommendation are filled by the caller.
+func explain(branch v1beta3.RecommendationBranch, message string, clamps ...v1beta3.RecommendationClamp) v1beta3.RecommendationExplanation {
+	e := v1beta3.RecommendationExplanation{
+		Branch:  branch,
+		Message: message,
+	}
+	if len(clamps) != 0 {
+		e.Clamps = clamps
+	}
+	return e
 }
 
 func hasHorizontal(tortoise *v1beta3.Tortoise) bool {
@@ -394,7 +603,33 @@ func (s *Service) getGlobalMinResourceSize(k corev1.ResourceName, containerName
 	return s.minResourceSizePerContainer["*"][k]
 }
 
-func (s *Service) justifyNewSize(oldSizeMilli, newSizeMilli int64, k corev1.ResourceName, minAllocatedResources, maxAllocatedResources corev1.ResourceList, containerName string) int64 {
+// smallVerticalChange returns true when the change from the current size is smaller than the minimum change.
+func smallVerticalChange(currentMilli, newMilli int64, minimumChangeRatio float64, minimumChange resource.Quantity) bool {
+	diff := newMilli - currentMilli
+	if diff < 0 {
+		diff = -diff
+	}
+	return float64(diff) < float64(currentMilli)*minimumChangeRatio || diff < minimumChange.MilliValue()
+}
+
+// limitVerticalStep limits the change from the current size to maxStepRatio of the current size.
+// 0 maxStepRatio means no limit. It doesn't limit the change from 0, i.e., the resource request isn't set yet.
+func limitVerticalStep(currentMilli, newMilli int64, maxStepRatio float64) int64 {
+	if maxStepRatio <= 0 || currentMilli == 0 {
+		return newMilli
+	}
+	step := int64(float64(currentMilli) * maxStepRatio)
+	if newMilli > currentMilli+step {
+		return currentMilli + step
+	}
+	if newMilli < currentMilli-step {
+		return currentMilli - step
+	}
+	return newMilli
+}
+
+// justifyNewSize clamps the new size into the allowed range, and returns the clamp if the new size is clamped.
+func (s *Service) justifyNewSize(oldSizeMilli, newSizeMilli int64, k corev1.ResourceName, minAllocatedResources, maxAllocatedResources corev1.ResourceList, containerName string) (int64, []v1beta3.RecommendationClamp) {
 	max := maxAllocatedResources[k]
 	min := minAllocatedResources[k]
 
@@ -416,27 +651,29 @@ func (s *Service) justifyNewSize(oldSizeMilli, newSizeMilli int64, k corev1.Reso
 	//
 	// So, here if min is smaller than oldSizeMilli * s.maxAllowedScalingDownRatio,
 	// we use oldSizeMilli * s.maxAllowedScalingDownRatio as min.
+	minClamp := v1beta3.RecommendationClampMinimumResource
 	if min.MilliValue() < int64(float64(oldSizeMilli)*s.maxAllowedScalingDownRatio) {
 		min = ptr.Deref(resource.NewMilliQuantity(int64(float64(oldSizeMilli)*s.maxAllowedScalingDownRatio), min.Format), min)
+		minClamp = v1beta3.RecommendationClampMaxAllowedScalingDownRatio
 	}
 
 	if newSizeMilli > max.MilliValue() {
-		return max.MilliValue()
+		return max.MilliValue(), []v1beta3.RecommendationClamp{v1beta3.RecommendationClampMaximumResource}
 	} else if newSizeMilli < min.MilliValue() {
-		return min.MilliValue()
+		return min.MilliValue(), []v1beta3.RecommendationClamp{minClamp}
 	}
 
-	return newSizeMilli
+	return newSizeMilli, nil
 }
 
 func (s *Service) updateHPARecommendation(ctx context.Context, tortoise *v1beta3.Tortoise, hpa *v2.HorizontalPodAutoscaler, replicaNum int32, now time.Time) (*v1beta3.Tortoise, error) {
 	var err error
-	tortoise, err = s.updateHPATargetUtilizationRecommendations(ctx, tortoise, hpa, replicaNum)
+	tortoise, err = s.updateHPATargetUtilizationRecommendations(ctx, tortoise, hpa, replicaNum, now)
 	if err != nil {
 		return tortoise, fmt.Errorf("update HPA target utilization recommendations: %w", err)
 	}
 
-	tortoise, err = s.updateHPAMinMaxReplicasRecommendations(tortoise, replicaNum, now)
+	tortoise, err = s.UpdateHPAMinMaxReplicasRecommendations(tortoise, replicaNum, now)
 	if err != nil {
 		return tortoise, err
 	}
@@ -469,14 +706,17 @@ func (s *Service) UpdateRecommendations(ctx context.Context, tortoise *v1beta3.T
 	return tortoise, nil
 }
 
-func (s *Service) updateHPAMinMaxReplicasRecommendations(tortoise *v1beta3.Tortoise, replicaNum int32, now time.Time) (*v1beta3.Tortoise, error) {
+// UpdateHPAMinMaxReplicasRecommendations updates the min/max replicas recommendation in the time slot of now
+// based on the number of replicas at that time.
+func (s *Service) UpdateHPAMinMaxReplicasRecommendations(tortoise *v1beta3.Tortoise, replicaNum int32, now time.Time) (*v1beta3.Tortoise, error) {
 	currentReplica := float64(replicaNum)
-	min, err := s.updateReplicasRecommendation(int32(math.Ceil(currentReplica*s.MinReplicasRecommendationMultiplier)), tortoise.Status.Recommendations.Horizontal.MinReplicas, now, s.minimumMinReplicas)
+	minMultiplier, maxMultiplier := s.replicasRecommendationMultipliers(tortoise)
+	min, err := s.updateReplicasRecommendation(int32(math.Ceil(currentReplica*minMultiplier)), tortoise.Status.Recommendations.Horizontal.MinReplicas, now, s.minimumMinReplicas)
 	if err != nil {
 		return tortoise, fmt.Errorf("update MinReplicas recommendation: %w", err)
 	}
 	tortoise.Status.Recommendations.Horizontal.MinReplicas = min
-	max, err := s.updateReplicasRecommendation(int32(math.Ceil(currentReplica*s.MaxReplicasRecommendationMultiplier)), tortoise.Status.Recommendations.Horizontal.MaxReplicas, now, int32(float64(s.minimumMinReplicas)*s.MaxReplicasRecommendationMultiplier/s.MinReplicasRecommendationMultiplier))
+	max, err := s.updateReplicasRecommendation(int32(math.Ceil(currentReplica*maxMultiplier)), tortoise.Status.Recommendations.Horizontal.MaxReplicas, now, int32(float64(s.minimumMinReplicas)*maxMultiplier/minMultiplier))
 	if err != nil {
 		return tortoise, fmt.Errorf("update MaxReplicas recommendation: %w", err)
 	}
@@ -485,6 +725,36 @@ func (s *Service) updateHPAMinMaxReplicasRecommendations(tortoise *v1beta3.Torto
 	return tortoise, nil
 }
 
+// replicasRecommendationMultipliers returns the multipliers to calculate the min/max replicas recommendation.
+// If the horizontal resources have the multipliers in their behavior, the biggest ones are used,
+// otherwise the cluster wide ones are used.
+func (s *Service) replicasRecommendationMultipliers(tortoise *v1beta3.Tortoise) (float64, float64) {
+	var minPercent, maxPercent int32
+	for _, c := range tortoise.Status.AutoscalingPolicy {
+		for rn, p := range c.Policy {
+			if p != v1beta3.AutoscalingTypeHorizontal {
+				continue
+			}
+			b := utils.GetResourceBehavior(tortoise, c.ContainerName, rn)
+			if b.MinReplicasRecommendationMultiplierPercent != nil && *b.MinReplicasRecommendationMultiplierPercent > minPercent {
+				minPercent = *b.MinReplicasRecommendationMultiplierPercent
+			}
+			if b.MaxReplicasRecommendationMultiplierPercent != nil && *b.MaxReplicasRecommendationMultiplierPercent > maxPercent {
+				maxPercent = *b.MaxReplicasRecommendationMultiplierPercent
+			}
+		}
+	}
+
+	minMultiplier, maxMultiplier := s.MinReplicasRecommendationMultiplier, s.MaxReplicasRecommendationMultiplier
+	if minPercent != 0 {
+		minMultiplier = float64(minPercent) / 100
+	}
+	if maxPercent != 0 {
+		maxMultiplier = float64(maxPercent) / 100
+	}
+	return minMultiplier, maxMultiplier
+}
+
 func findSlotInReplicasRecommendation(recommendations []v1beta3.ReplicasRecommendation, now time.Time) (int, error) {
 	index := -1
 	for i, r := range recommendations {
@@ -536,7 +806,7 @@ func (s *Service) updateReplicasRecommendation(value int32, recommendations []v1
 	return recommendations, nil
 }
 
-func (s *Service) updateHPATargetUtilizationRecommendations(ctx context.Context, tortoise *v1beta3.Tortoise, hpa *v2.HorizontalPodAutoscaler, replicaNum int32) (*v1beta3.Tortoise, error) {
+func (s *Service) updateHPATargetUtilizationRecommendations(ctx context.Context, tortoise *v1beta3.Tortoise, hpa *v2.HorizontalPodAutoscaler, replicaNum int32, now time.Time) (*v1beta3.Tortoise, error) {
 	logger := log.FromContext(ctx)
 	if replicaNum == s.maximumMaxReplica {
 		// We skip generating HPA recommendations if the current replica number is equal to the maximumMaxReplica
@@ -568,6 +838,7 @@ func (s *Service) updateHPATargetUtilizationRecommendations(ctx context.Context,
 	}
 
 	newHPATargetUtilizationRecommendationPerContainer := []v1beta3.HPATargetUtilizationRecommendationPerContainer{}
+	explanations := []v1beta3.RecommendationExplanation{}
 	for _, r := range tortoise.Status.AutoscalingPolicy {
 		recommendedTargetUtilization := map[corev1.ResourceName]int32{}
 		reqmap, ok := requestMap[r.ContainerName]
@@ -603,6 +874,8 @@ func (s *Service) updateHPATargetUtilizationRecommendations(ctx context.Context,
 
 			upperUsage := math.Ceil((float64(recom.MilliValue()) / float64(req.MilliValue())) * 100)
 			reason := ""
+			branch := v1beta3.RecommendationBranchTargetUtilizationUpdated
+			var clamps []v1beta3.RecommendationClamp
 			if currentTargetValue > int32(upperUsage) {
 				// upperUsage is less than targetValue.
 				// This case, there're some scenarios:
@@ -612,12 +885,14 @@ func (s *Service) updateHPATargetUtilizationRecommendations(ctx context.Context,
 				// And this case, rather than changing the target value, we'd like to change the container size.
 				recommendedTargetUtilization[k] = currentTargetValue // no change (except the current value exceeds maximumTargetResourceUtilization)
 				reason = "the current resource utilization is too small and it's due to unbalanced container size or minReplicas, so keep the current target utilization"
+				branch = v1beta3.RecommendationBranchUnbalancedContainer
 			} else {
 				newRecom := updateRecommendedContainerBasedMetric(int32(upperUsage), currentTargetValue)
 				if newRecom <= 0 || newRecom > 100 {
 					logger.Error(nil, "generated recommended HPA target utilization is invalid, fallback to the current target value", "current target utilization", currentTargetValue, "recommended target utilization", newRecom, "upper usage", upperUsage, "container name", r.ContainerName, "resource name", k)
 					newRecom = currentTargetValue
 					reason = "the generated recommended HPA target utilization is invalid, fallback to the current target value"
+					branch = v1beta3.RecommendationBranchInvalidTargetUtilization
 				} else {
 					reason = "generated recommendation is valid"
 				}
@@ -627,10 +902,12 @@ func (s *Service) updateHPATargetUtilizationRecommendations(ctx context.Context,
 			if recommendedTargetUtilization[k] > s.maximumTargetResourceUtilization {
 				reason = "the generated recommended HPA target utilization is too high, fallback to the upper target utilization"
 				recommendedTargetUtilization[k] = s.maximumTargetResourceUtilization
+				clamps = append(clamps, v1beta3.RecommendationClampMaximumTargetUtilization)
 			}
 			if recommendedTargetUtilization[k] < s.minimumTargetResourceUtilization {
 				reason = "the generated recommended HPA target utilization is too low, fallback to the lower target utilization"
 				recommendedTargetUtilization[k] = s.minimumTargetResourceUtilization
+				clamps = append(clamps, v1beta3.RecommendationClampMinimumTargetUtilization)
 			}
 
 			if currentTargetValue != recommendedTargetUtilization[k] {
@@ -640,6 +917,19 @@ func (s *Service) updateHPATargetUtilizationRecommendations(ctx context.Context,
 			}
 
 			logger.Info("HPA target utilization recommendation is generated", "current target utilization", currentTargetValue, "recommended target utilization", recommendedTargetUtilization[k], "upper usage", upperUsage, "container name", r.ContainerName, "resource name", k, "reason", reason)
+
+			explanations = append(explanations, v1beta3.RecommendationExplanation{
+				ContainerName:        r.ContainerName,
+				ResourceName:         k,
+				ResourceRequest:      req,
+				VPAMaxRecommendation: recom,
+				Replicas:             replicaNum,
+				HPATargetUtilization: ptr.To(currentTargetValue),
+				Branch:               branch,
+				Clamps:               clamps,
+				Message:              fmt.Sprintf("%s (HPA target utilization %v%% → %v%%, upper usage %v%%)", reason, currentTargetValue, recommendedTargetUtilization[k], upperUsage),
+				GeneratedAt:          metav1.NewTime(now),
+			})
 		}
 		newHPATargetUtilizationRecommendationPerContainer = append(newHPATargetUtilizationRecommendationPerContainer, v1beta3.HPATargetUtilizationRecommendationPerContainer{
 			ContainerName:     r.ContainerName,
@@ -648,6 +938,7 @@ func (s *Service) updateHPATargetUtilizationRecommendations(ctx context.Context,
 	}
 
 	tortoise.Status.Recommendations.Horizontal.TargetUtilizations = newHPATargetUtilizationRecommendationPerContainer
+	tortoise.Status.Recommendations.Horizontal.TargetUtilizationExplanations = sortExplanations(explanations)
 
 	return tortoise, nil
 }
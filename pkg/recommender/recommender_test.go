@@ -14,6 +14,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 
+	"github.com/mercari/tortoise/api/v1alpha1"
 	"github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/features"
 	"github.com/mercari/tortoise/pkg/utils"
@@ -873,8 +874,11 @@ func TestUpdateRecommendation(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := New(2.0, 0.5, 90, 40, 3, 30, "50m", "50Mi", map[string]string{"istio-proxy": "100m"}, map[string]string{"istio-proxy": "100m"}, "10", "10Gi", 1000, 0.5, 0, nil, record.NewFakeRecorder(10))
-			got, err := s.updateHPATargetUtilizationRecommendations(context.Background(), tt.args.tortoise, tt.args.hpa, tt.args.currentReplicaNum)
+			s, err := New(2.0, 0.5, 90, 40, 3, 30, "50m", "50Mi", map[string]string{"istio-proxy": "100m"}, map[string]string{"istio-proxy": "100m"}, "10", "10Gi", 1000, 0.5, 0, 0, "", "", 0, nil, record.NewFakeRecorder(10))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			got, err := s.updateHPATargetUtilizationRecommendations(context.Background(), tt.args.tortoise, tt.args.hpa, tt.args.currentReplicaNum, time.Now())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("updateHPATargetUtilizationRecommendations() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -882,7 +886,8 @@ func TestUpdateRecommendation(t *testing.T) {
 			if tt.wantErr {
 				return
 			}
-			if d := cmp.Diff(tt.want, got); d != "" {
+			// The explanations are tested in TestService_RecommendationExplanations.
+			if d := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(v1beta3.HorizontalRecommendations{}, "TargetUtilizationExplanations")); d != "" {
 				t.Errorf("unexpected result from updateHPARecommendation; diff = %s", d)
 			}
 		})
@@ -893,7 +898,7 @@ func resourceQuantityPtr(quantity resource.Quantity) *resource.Quantity {
 	return &quantity
 }
 
-func Test_updateHPAMinMaxReplicasRecommendations(t *testing.T) {
+func TestService_UpdateHPAMinMaxReplicasRecommendations(t *testing.T) {
 	timeZone := "Asia/Tokyo"
 	jst, err := time.LoadLocation(timeZone)
 	if err != nil {
@@ -1038,6 +1043,216 @@ func Test_updateHPAMinMaxReplicasRecommendations(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "replica recommendation is replaced with the multipliers in the behavior of the horizontal resources",
+			args: args{
+				tortoise: &v1beta3.Tortoise{
+					Spec: v1beta3.TortoiseSpec{
+						ResourcePolicy: []v1beta3.ContainerResourcePolicy{
+							{
+								ContainerName: "app",
+								Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+									corev1.ResourceCPU: {
+										MinReplicasRecommendationMultiplierPercent: ptr.To[int32](60),
+										MaxReplicasRecommendationMultiplierPercent: ptr.To[int32](300),
+									},
+									// ignored because memory isn't scaled horizontally.
+									corev1.ResourceMemory: {
+										MinReplicasRecommendationMultiplierPercent: ptr.To[int32](100),
+									},
+								},
+							},
+							{
+								ContainerName: "istio-proxy",
+								Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+									corev1.ResourceCPU: {
+										MinReplicasRecommendationMultiplierPercent: ptr.To[int32](80),
+									},
+								},
+							},
+						},
+					},
+					Status: v1beta3.TortoiseStatus{
+						AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+							{
+								ContainerName: "app",
+								Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+									corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+									corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								},
+							},
+							{
+								ContainerName: "istio-proxy",
+								Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+									corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+									corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								},
+							},
+						},
+						Recommendations: v1beta3.Recommendations{
+							Horizontal: v1beta3.HorizontalRecommendations{
+								MinReplicas: []v1beta3.ReplicasRecommendation{
+									{
+										From:      0,
+										To:        1,
+										UpdatedAt: metav1.NewTime(time.Date(2023, 3, 12, 0, 0, 0, 0, jst)),
+										TimeZone:  timeZone,
+										Value:     3,
+										WeekDay:   ptr.To(time.Sunday.String()),
+									},
+									{
+										From:      2,
+										To:        3,
+										UpdatedAt: metav1.NewTime(time.Date(2023, 3, 12, 0, 0, 0, 0, jst)),
+										TimeZone:  timeZone,
+										Value:     1,
+										WeekDay:   ptr.To(time.Sunday.String()),
+									},
+									{
+										From:      3,
+										To:        4,
+										UpdatedAt: metav1.NewTime(time.Date(2023, 3, 12, 0, 0, 0, 0, jst)),
+										TimeZone:  timeZone,
+										Value:     1,
+										WeekDay:   ptr.To(time.Sunday.String()),
+									},
+								},
+								MaxReplicas: []v1beta3.ReplicasRecommendation{
+									{
+										From:      0,
+										To:        1,
+										UpdatedAt: metav1.NewTime(time.Date(2023, 3, 12, 0, 0, 0, 0, jst)),
+										TimeZone:  timeZone,
+										Value:     9,
+										WeekDay:   ptr.To(time.Sunday.String()),
+									},
+									{
+										From:      2,
+										To:        3,
+										UpdatedAt: metav1.NewTime(time.Date(2023, 3, 12, 0, 0, 0, 0, jst)),
+										TimeZone:  timeZone,
+										Value:     7,
+										WeekDay:   ptr.To(time.Sunday.String()),
+									},
+									{
+										From:      3,
+										To:        4,
+										UpdatedAt: metav1.NewTime(time.Date(2023, 3, 12, 0, 0, 0, 0, jst)),
+										TimeZone:  timeZone,
+										Value:     7,
+										WeekDay:   ptr.To(time.Sunday.String()),
+									},
+								},
+							},
+						},
+					},
+				},
+				replicaNum: 10,
+				now:        time.Date(2023, 3, 19, 0, 0, 0, 0, jst),
+			},
+			want: &v1beta3.Tortoise{
+				Spec: v1beta3.TortoiseSpec{
+					ResourcePolicy: []v1beta3.ContainerResourcePolicy{
+						{
+							ContainerName: "app",
+							Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+								corev1.ResourceCPU: {
+									MinReplicasRecommendationMultiplierPercent: ptr.To[int32](60),
+									MaxReplicasRecommendationMultiplierPercent: ptr.To[int32](300),
+								},
+								// ignored because memory isn't scaled horizontally.
+								corev1.ResourceMemory: {
+									MinReplicasRecommendationMultiplierPercent: ptr.To[int32](100),
+								},
+							},
+						},
+						{
+							ContainerName: "istio-proxy",
+							Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+								corev1.ResourceCPU: {
+									MinReplicasRecommendationMultiplierPercent: ptr.To[int32](80),
+								},
+							},
+						},
+					},
+				},
+				Status: v1beta3.TortoiseStatus{
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{
+							ContainerName: "app",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+							},
+						},
+						{
+							ContainerName: "istio-proxy",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+							},
+						},
+					},
+					Recommendations: v1beta3.Recommendations{
+						Horizontal: v1beta3.HorizontalRecommendations{
+							MinReplicas: []v1beta3.ReplicasRecommendation{
+								{
+									From:      0,
+									To:        1,
+									UpdatedAt: metav1.NewTime(time.Date(2023, 3, 19, 0, 0, 0, 0, jst)),
+									TimeZone:  timeZone,
+									Value:     8,
+									WeekDay:   ptr.To(time.Sunday.String()),
+								},
+								{
+									From:      2,
+									To:        3,
+									UpdatedAt: metav1.NewTime(time.Date(2023, 3, 12, 0, 0, 0, 0, jst)),
+									TimeZone:  timeZone,
+									Value:     1,
+									WeekDay:   ptr.To(time.Sunday.String()),
+								},
+								{
+									From:      3,
+									To:        4,
+									UpdatedAt: metav1.NewTime(time.Date(2023, 3, 12, 0, 0, 0, 0, jst)),
+									TimeZone:  timeZone,
+									Value:     1,
+									WeekDay:   ptr.To(time.Sunday.String()),
+								},
+							},
+							MaxReplicas: []v1beta3.ReplicasRecommendation{
+								{
+									From:      0,
+									To:        1,
+									UpdatedAt: metav1.NewTime(time.Date(2023, 3, 19, 0, 0, 0, 0, jst)),
+									TimeZone:  timeZone,
+									Value:     30,
+									WeekDay:   ptr.To(time.Sunday.String()),
+								},
+								{
+									From:      2,
+									To:        3,
+									UpdatedAt: metav1.NewTime(time.Date(2023, 3, 12, 0, 0, 0, 0, jst)),
+									TimeZone:  timeZone,
+									Value:     7,
+									WeekDay:   ptr.To(time.Sunday.String()),
+								},
+								{
+									From:      3,
+									To:        4,
+									UpdatedAt: metav1.NewTime(time.Date(2023, 3, 12, 0, 0, 0, 0, jst)),
+									TimeZone:  timeZone,
+									Value:     7,
+									WeekDay:   ptr.To(time.Sunday.String()),
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "replica recommendation is not replaced",
 			args: args{
@@ -1553,14 +1768,17 @@ func Test_updateHPAMinMaxReplicasRecommendations(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := New(2.0, 0.5, 90, 40, 3, 30, "50m", "50Mi", map[string]string{"istio-proxy": "100m"}, map[string]string{"istio-proxy": "100m"}, "10", "10Gi", 1000, 0.5, 0, nil, record.NewFakeRecorder(10))
-			got, err := s.updateHPAMinMaxReplicasRecommendations(tt.args.tortoise, tt.args.replicaNum, tt.args.now)
+			s, err := New(2.0, 0.5, 90, 40, 3, 30, "50m", "50Mi", map[string]string{"istio-proxy": "100m"}, map[string]string{"istio-proxy": "100m"}, "10", "10Gi", 1000, 0.5, 0, 0, "", "", 0, nil, record.NewFakeRecorder(10))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			got, err := s.UpdateHPAMinMaxReplicasRecommendations(tt.args.tortoise, tt.args.replicaNum, tt.args.now)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("updateHPAMinMaxReplicasRecommendations() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("UpdateHPAMinMaxReplicasRecommendations() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if d := cmp.Diff(got, tt.want); d != "" {
-				t.Errorf("updateHPAMinMaxReplicasRecommendations() diff = %v", d)
+				t.Errorf("UpdateHPAMinMaxReplicasRecommendations() diff = %v", d)
 			}
 		})
 	}
@@ -2646,7 +2864,7 @@ func TestService_UpdateVPARecommendation(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "all vertical: scale down resources based on VPA recommendation plus bufferRatioOnVerticalResource",
+			name: "all vertical: scale resources based on VPA recommendation plus verticalBufferPercent in the behavior",
 			fields: fields{
 				preferredMaxReplicas:          6,
 				maxCPU:                        "1000m",
@@ -2669,38 +2887,26 @@ func TestService_UpdateVPARecommendation(t *testing.T) {
 				}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
 					ContainerName:         "test-container",
 					MinAllocatedResources: createResourceList("100m", "100Mi"),
+					Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+						corev1.ResourceCPU: {VerticalBufferPercent: ptr.To[int32](50)},
+					},
 				}).AddContainerRecommendationFromVPA(
 					v1beta3.ContainerRecommendationFromVPA{
 						ContainerName: "test-container",
 						MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
 							corev1.ResourceCPU: {
-								// ideal = 110m
-								Quantity: resource.MustParse("100m"),
+								Quantity: resource.MustParse("120m"),
 							},
 							corev1.ResourceMemory: {
-								// ideal = 121Mi
-								Quantity: resource.MustParse("110Mi"),
+								Quantity: resource.MustParse("120Mi"),
 							},
 						},
 					},
 				).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
 					ContainerName: "test-container",
-					// Previous ideal = 132m, 132Mi
-					// Previous ideal * 0.9 = 118m, 118.8Mi
-					//
-					// → CPU will be scaled down to 110m
-					// → Memory will not be scaled down (because 118.8Mi < 121Mi)
-					Resource: createResourceList("145m", "145.2Mi"),
-				}).SetRecommendations(v1beta3.Recommendations{
-					Vertical: v1beta3.VerticalRecommendations{
-						ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
-							{
-								ContainerName:       "test-container",
-								RecommendedResource: createResourceList("145m", "145.2Mi"),
-							},
-						},
-					},
+					Resource:      createResourceList("130m", "130Mi"),
 				}).Build(),
+				replicaNum: 3,
 			},
 			want: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
 				ContainerName: "test-container",
@@ -2711,28 +2917,32 @@ func TestService_UpdateVPARecommendation(t *testing.T) {
 			}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
 				ContainerName:         "test-container",
 				MinAllocatedResources: createResourceList("100m", "100Mi"),
+				Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+					corev1.ResourceCPU: {VerticalBufferPercent: ptr.To[int32](50)},
+				},
 			}).AddContainerRecommendationFromVPA(
 				v1beta3.ContainerRecommendationFromVPA{
 					ContainerName: "test-container",
 					MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
 						corev1.ResourceCPU: {
-							Quantity: resource.MustParse("100m"),
+							Quantity: resource.MustParse("120m"),
 						},
 						corev1.ResourceMemory: {
-							Quantity: resource.MustParse("110Mi"),
+							Quantity: resource.MustParse("120Mi"),
 						},
 					},
 				},
 			).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
 				ContainerName: "test-container",
-				Resource:      createResourceList("145m", "145.2Mi"),
+				Resource:      createResourceList("130m", "130Mi"),
 			}).SetRecommendations(v1beta3.Recommendations{
 				Vertical: v1beta3.VerticalRecommendations{
 					ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
 						{
 							ContainerName: "test-container",
-							// VPA recommendation * (1 + bufferRatioOnVerticalResource) * (1 + bufferRatioOnVerticalResource)
-							RecommendedResource: createResourceList("110m", "145.2Mi"),
+							// CPU: VPA recommendation * (1 + verticalBufferPercent/100) * (1 + verticalBufferPercent/100)
+							// Memory: VPA recommendation * (1 + bufferRatioOnVerticalResource) * (1 + bufferRatioOnVerticalResource)
+							RecommendedResource: createResourceList("270m", "145.2Mi"),
 						},
 					},
 				},
@@ -2740,11 +2950,12 @@ func TestService_UpdateVPARecommendation(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "all vertical: use MaxAllocatedResources when VPA recommendation is bigger than MaxAllocatedResources",
+			name: "all vertical: scale down resources based on VPA recommendation plus bufferRatioOnVerticalResource",
 			fields: fields{
-				preferredMaxReplicas: 6,
-				maxCPU:               "1000m",
-				maxMemory:            "1Gi",
+				preferredMaxReplicas:          6,
+				maxCPU:                        "1000m",
+				maxMemory:                     "1Gi",
+				bufferRatioOnVerticalResource: 0.1,
 			},
 			args: args{
 				hpa: &v2.HorizontalPodAutoscaler{
@@ -2761,36 +2972,129 @@ func TestService_UpdateVPARecommendation(t *testing.T) {
 					},
 				}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
 					ContainerName:         "test-container",
-					MaxAllocatedResources: createResourceList("300m", "300Mi"),
+					MinAllocatedResources: createResourceList("100m", "100Mi"),
 				}).AddContainerRecommendationFromVPA(
 					v1beta3.ContainerRecommendationFromVPA{
 						ContainerName: "test-container",
 						MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
 							corev1.ResourceCPU: {
-								Quantity: resource.MustParse("500m"), // too big
+								// ideal = 110m
+								Quantity: resource.MustParse("100m"),
 							},
 							corev1.ResourceMemory: {
-								Quantity: resource.MustParse("0.5Gi"), // too big
+								// ideal = 121Mi
+								Quantity: resource.MustParse("110Mi"),
 							},
 						},
 					},
 				).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
 					ContainerName: "test-container",
-					Resource:      createResourceList("130m", "130Mi"),
-				}).Build(),
-				replicaNum: 3,
-			},
-			want: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
-				ContainerName: "test-container",
-				Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
-					corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
-					corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
-				},
-			}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
-				ContainerName:         "test-container",
-				MaxAllocatedResources: createResourceList("300m", "300Mi"),
-			}).AddContainerRecommendationFromVPA(
-				v1beta3.ContainerRecommendationFromVPA{
+					// Previous ideal = 132m, 132Mi
+					// Previous ideal * 0.9 = 118m, 118.8Mi
+					//
+					// → CPU will be scaled down to 110m
+					// → Memory will not be scaled down (because 118.8Mi < 121Mi)
+					Resource: createResourceList("145m", "145.2Mi"),
+				}).SetRecommendations(v1beta3.Recommendations{
+					Vertical: v1beta3.VerticalRecommendations{
+						ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+							{
+								ContainerName:       "test-container",
+								RecommendedResource: createResourceList("145m", "145.2Mi"),
+							},
+						},
+					},
+				}).Build(),
+			},
+			want: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
+				ContainerName: "test-container",
+				Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+					corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+					corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+				},
+			}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
+				ContainerName:         "test-container",
+				MinAllocatedResources: createResourceList("100m", "100Mi"),
+			}).AddContainerRecommendationFromVPA(
+				v1beta3.ContainerRecommendationFromVPA{
+					ContainerName: "test-container",
+					MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+						corev1.ResourceCPU: {
+							Quantity: resource.MustParse("100m"),
+						},
+						corev1.ResourceMemory: {
+							Quantity: resource.MustParse("110Mi"),
+						},
+					},
+				},
+			).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
+				ContainerName: "test-container",
+				Resource:      createResourceList("145m", "145.2Mi"),
+			}).SetRecommendations(v1beta3.Recommendations{
+				Vertical: v1beta3.VerticalRecommendations{
+					ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+						{
+							ContainerName: "test-container",
+							// VPA recommendation * (1 + bufferRatioOnVerticalResource) * (1 + bufferRatioOnVerticalResource)
+							RecommendedResource: createResourceList("110m", "145.2Mi"),
+						},
+					},
+				},
+			}).Build(),
+			wantErr: false,
+		},
+		{
+			name: "all vertical: use MaxAllocatedResources when VPA recommendation is bigger than MaxAllocatedResources",
+			fields: fields{
+				preferredMaxReplicas: 6,
+				maxCPU:               "1000m",
+				maxMemory:            "1Gi",
+			},
+			args: args{
+				hpa: &v2.HorizontalPodAutoscaler{
+					Spec: v2.HorizontalPodAutoscalerSpec{
+						MinReplicas: ptr.To[int32](1),
+						Metrics:     []v2.MetricSpec{},
+					},
+				},
+				tortoise: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
+					ContainerName: "test-container",
+					Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+						corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+						corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+					},
+				}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
+					ContainerName:         "test-container",
+					MaxAllocatedResources: createResourceList("300m", "300Mi"),
+				}).AddContainerRecommendationFromVPA(
+					v1beta3.ContainerRecommendationFromVPA{
+						ContainerName: "test-container",
+						MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+							corev1.ResourceCPU: {
+								Quantity: resource.MustParse("500m"), // too big
+							},
+							corev1.ResourceMemory: {
+								Quantity: resource.MustParse("0.5Gi"), // too big
+							},
+						},
+					},
+				).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
+					ContainerName: "test-container",
+					Resource:      createResourceList("130m", "130Mi"),
+				}).Build(),
+				replicaNum: 3,
+			},
+			want: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
+				ContainerName: "test-container",
+				Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+					corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+					corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+				},
+			}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
+				ContainerName:         "test-container",
+				MaxAllocatedResources: createResourceList("300m", "300Mi"),
+			}).AddContainerRecommendationFromVPA(
+				v1beta3.ContainerRecommendationFromVPA{
 					ContainerName: "test-container",
 					MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
 						corev1.ResourceCPU: {
@@ -2970,6 +3274,191 @@ func TestService_UpdateVPARecommendation(t *testing.T) {
 			}).Build(),
 			wantErr: false,
 		},
+		{
+			name: "all vertical: use MinAllocatedResources in the constraints when it's bigger than the one in resourcePolicy",
+			fields: fields{
+				preferredMaxReplicas: 6,
+				maxCPU:               "1000m",
+				maxMemory:            "1Gi",
+			},
+			args: args{
+				hpa: &v2.HorizontalPodAutoscaler{
+					Spec: v2.HorizontalPodAutoscalerSpec{
+						MinReplicas: ptr.To[int32](1),
+						Metrics:     []v2.MetricSpec{},
+					},
+				},
+				tortoise: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
+					ContainerName: "test-container",
+					Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+						corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+						corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+					},
+				}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
+					ContainerName:         "test-container",
+					MinAllocatedResources: createResourceList("100m", "100Mi"),
+				}).AddContainerRecommendationFromVPA(
+					v1beta3.ContainerRecommendationFromVPA{
+						ContainerName: "test-container",
+						MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+							corev1.ResourceCPU: {
+								Quantity: resource.MustParse("10m"), // too small
+							},
+							corev1.ResourceMemory: {
+								Quantity: resource.MustParse("10Mi"), // too small
+							},
+						},
+					},
+				).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
+					ContainerName: "test-container",
+					Resource:      createResourceList("130m", "130Mi"),
+				}).SetRecommendations(v1beta3.Recommendations{
+					Constraints: &v1beta3.Constraints{
+						MinAllocatedResources: []v1beta3.ContainerResourcePolicy{
+							{
+								ContainerName: "test-container",
+								MinAllocatedResources: corev1.ResourceList{
+									corev1.ResourceCPU: resource.MustParse("500m"),
+								},
+							},
+						},
+					},
+				}).Build(),
+				replicaNum: 3,
+			},
+			want: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
+				ContainerName: "test-container",
+				Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+					corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+					corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+				},
+			}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
+				ContainerName:         "test-container",
+				MinAllocatedResources: createResourceList("100m", "100Mi"),
+			}).AddContainerRecommendationFromVPA(
+				v1beta3.ContainerRecommendationFromVPA{
+					ContainerName: "test-container",
+					MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+						corev1.ResourceCPU: {
+							Quantity: resource.MustParse("10m"),
+						},
+						corev1.ResourceMemory: {
+							Quantity: resource.MustParse("10Mi"),
+						},
+					},
+				},
+			).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
+				ContainerName: "test-container",
+				Resource:      createResourceList("130m", "130Mi"),
+			}).SetRecommendations(v1beta3.Recommendations{
+				Vertical: v1beta3.VerticalRecommendations{
+					ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+						{
+							ContainerName:       "test-container",
+							RecommendedResource: createResourceList("500m", "100Mi"), // CPU from the constraints, Memory from resourcePolicy
+						},
+					},
+				},
+				Constraints: &v1beta3.Constraints{
+					MinAllocatedResources: []v1beta3.ContainerResourcePolicy{
+						{
+							ContainerName: "test-container",
+							MinAllocatedResources: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("500m"),
+							},
+						},
+					},
+				},
+			}).Build(),
+			wantErr: false,
+		},
+		{
+			name: "all vertical: use the floor raised by the rollback when it's bigger than the one in resourcePolicy",
+			fields: fields{
+				preferredMaxReplicas: 6,
+				maxCPU:               "1000m",
+				maxMemory:            "1Gi",
+			},
+			args: args{
+				hpa: &v2.HorizontalPodAutoscaler{
+					Spec: v2.HorizontalPodAutoscalerSpec{
+						MinReplicas: ptr.To[int32](1),
+						Metrics:     []v2.MetricSpec{},
+					},
+				},
+				tortoise: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
+					ContainerName: "test-container",
+					Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+						corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+						corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+					},
+				}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
+					ContainerName:         "test-container",
+					MinAllocatedResources: createResourceList("100m", "100Mi"),
+				}).AddContainerRecommendationFromVPA(
+					v1beta3.ContainerRecommendationFromVPA{
+						ContainerName: "test-container",
+						MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+							corev1.ResourceCPU: {
+								Quantity: resource.MustParse("10m"), // too small
+							},
+							corev1.ResourceMemory: {
+								Quantity: resource.MustParse("10Mi"), // too small
+							},
+						},
+					},
+				).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
+					ContainerName: "test-container",
+					Resource:      createResourceList("130m", "130Mi"),
+				}).AddContainerResourceFloors(v1beta3.ContainerResourceFloor{
+					ContainerName: "test-container",
+					Resource: map[corev1.ResourceName]v1beta3.ResourceFloor{
+						corev1.ResourceMemory: {Quantity: resource.MustParse("120Mi")},
+					},
+				}).Build(),
+				replicaNum: 3,
+			},
+			want: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
+				ContainerName: "test-container",
+				Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+					corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+					corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+				},
+			}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
+				ContainerName:         "test-container",
+				MinAllocatedResources: createResourceList("100m", "100Mi"),
+			}).AddContainerRecommendationFromVPA(
+				v1beta3.ContainerRecommendationFromVPA{
+					ContainerName: "test-container",
+					MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+						corev1.ResourceCPU: {
+							Quantity: resource.MustParse("10m"),
+						},
+						corev1.ResourceMemory: {
+							Quantity: resource.MustParse("10Mi"),
+						},
+					},
+				},
+			).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
+				ContainerName: "test-container",
+				Resource:      createResourceList("130m", "130Mi"),
+			}).AddContainerResourceFloors(v1beta3.ContainerResourceFloor{
+				ContainerName: "test-container",
+				Resource: map[corev1.ResourceName]v1beta3.ResourceFloor{
+					corev1.ResourceMemory: {Quantity: resource.MustParse("120Mi")},
+				},
+			}).SetRecommendations(v1beta3.Recommendations{
+				Vertical: v1beta3.VerticalRecommendations{
+					ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+						{
+							ContainerName:       "test-container",
+							RecommendedResource: createResourceList("100m", "120Mi"), // CPU from resourcePolicy, Memory from the floor
+						},
+					},
+				},
+			}).Build(),
+			wantErr: false,
+		},
 		{
 			name: "all vertical: use minResourceSize when VPA recommendation is smaller than minResourceSize",
 			fields: fields{
@@ -3556,19 +4045,282 @@ func TestService_UpdateVPARecommendation(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			s := New(0, 0, 0, 0, int(tt.fields.minimumMinReplicas), int(tt.fields.preferredMaxReplicas), "5m", "5Mi", map[string]string{"istio-proxy": "7m"}, map[string]string{"istio-proxy": "7Mi"}, tt.fields.maxCPU, tt.fields.maxMemory, 10000, tt.fields.maxAllowedScalingDownRatio, tt.fields.bufferRatioOnVerticalResource, tt.fields.features, record.NewFakeRecorder(10))
+			s, err := New(0, 0, 0, 0, int(tt.fields.minimumMinReplicas), int(tt.fields.preferredMaxReplicas), "5m", "5Mi", map[string]string{"istio-proxy": "7m"}, map[string]string{"istio-proxy": "7Mi"}, tt.fields.maxCPU, tt.fields.maxMemory, 10000, tt.fields.maxAllowedScalingDownRatio, tt.fields.bufferRatioOnVerticalResource, 0, "", "", 0, tt.fields.features, record.NewFakeRecorder(10))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
 			got, err := s.updateVPARecommendation(context.Background(), tt.args.tortoise, tt.args.hpa, tt.args.replicaNum, now)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("updateVPARecommendation() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if d := cmp.Diff(got, tt.want, cmpopts.IgnoreTypes(metav1.Time{})); d != "" {
+			// The explanations are tested in TestService_RecommendationExplanations.
+			if d := cmp.Diff(got, tt.want, cmpopts.IgnoreTypes(metav1.Time{}), cmpopts.IgnoreFields(v1beta3.VerticalRecommendations{}, "Explanations")); d != "" {
 				t.Errorf("updateVPARecommendation() diff = %s", d)
 			}
 		})
 	}
 }
 
+func TestService_calculateBestNewSize_Vertical(t *testing.T) {
+	type fields struct {
+		verticalMinimumChangeRatio float64
+		verticalMinimumCPUChange   string
+		verticalMaxStepRatio       float64
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		behavior    *v1beta3.ResourceBehavior
+		recommended string
+		current     string
+		want        int64
+	}{
+		{
+			name:        "the buffer is used as the threshold of the decrease by default",
+			recommended: "850m", // ideal = 935m, previous ideal * 0.9 = 818m
+			current:     "1000m",
+			want:        1000,
+		},
+		{
+			name:        "VerticalMinimumChangeRatio replaces the buffer as the threshold of the decrease",
+			fields:      fields{verticalMinimumChangeRatio: 0.05},
+			recommended: "850m",
+			current:     "1000m",
+			want:        935,
+		},
+		{
+			name:        "the decrease smaller than VerticalMinimumChangeRatio is ignored",
+			fields:      fields{verticalMinimumChangeRatio: 0.1},
+			recommended: "850m",
+			current:     "1000m",
+			want:        1000,
+		},
+		{
+			name:        "the decrease smaller than VerticalMinimumCPUChange is ignored",
+			fields:      fields{verticalMinimumCPUChange: "500m"},
+			recommended: "500m", // ideal = 550m
+			current:     "1000m",
+			want:        1000,
+		},
+		{
+			name:        "verticalMinimumChange in the tortoise overrides VerticalMinimumCPUChange",
+			fields:      fields{verticalMinimumCPUChange: "500m"},
+			behavior:    &v1beta3.ResourceBehavior{VerticalMinimumChange: ptr.To(resource.MustParse("100m"))},
+			recommended: "500m",
+			current:     "1000m",
+			want:        550,
+		},
+		{
+			name:        "the increase smaller than VerticalMinimumChangeRatio is ignored when the current request covers the recommendation",
+			fields:      fields{verticalMinimumChangeRatio: 0.2},
+			recommended: "950m", // ideal = 1149m
+			current:     "1000m",
+			want:        1000,
+		},
+		{
+			name:        "the increase isn't ignored when the current request doesn't cover the recommendation",
+			behavior:    &v1beta3.ResourceBehavior{VerticalMinimumChangePercent: ptr.To[int32](50)},
+			recommended: "1050m", // ideal = 1270m
+			current:     "1000m",
+			want:        1270,
+		},
+		{
+			name:        "the increase is limited by VerticalMaxStepRatio",
+			fields:      fields{verticalMaxStepRatio: 0.5},
+			recommended: "3000m",
+			current:     "1000m",
+			want:        1500,
+		},
+		{
+			name:        "verticalMaxStepPercent in the tortoise limits the decrease",
+			fields:      fields{verticalMaxStepRatio: 0.5},
+			behavior:    &v1beta3.ResourceBehavior{VerticalMaxStepPercent: ptr.To[int32](20)},
+			recommended: "100m",
+			current:     "1000m",
+			want:        800,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(0, 0, 0, 0, 3, 30, "5m", "5Mi", map[string]string{}, map[string]string{}, "10", "10Gi", 10000, 0, 0.1, tt.fields.verticalMinimumChangeRatio, tt.fields.verticalMinimumCPUChange, "", tt.fields.verticalMaxStepRatio, nil, record.NewFakeRecorder(10))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			policy := v1beta3.ContainerResourcePolicy{ContainerName: "app"}
+			if tt.behavior != nil {
+				policy.Behavior = map[corev1.ResourceName]v1beta3.ResourceBehavior{corev1.ResourceCPU: *tt.behavior}
+			}
+			tortoise := utils.NewTortoiseBuilder().AddResourcePolicy(policy).Build()
+
+			got, _, err := s.calculateBestNewSize(context.Background(), tortoise, v1beta3.AutoscalingTypeVertical, "app", resource.MustParse(tt.recommended), corev1.ResourceCPU, nil, 1, resource.MustParse(tt.current), nil, nil, false, false)
+			if err != nil {
+				t.Fatalf("calculateBestNewSize() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("calculateBestNewSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_RecommendationExplanations(t *testing.T) {
+	now := time.Date(2023, 3, 19, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name                              string
+		vpaCPU                            string
+		wantVerticalExplanations          []v1beta3.RecommendationExplanation
+		wantTargetUtilizationExplanations []v1beta3.RecommendationExplanation
+	}{
+		{
+			name:   "the explanations have the inputs, the branch and the clamps",
+			vpaCPU: "900m",
+			wantVerticalExplanations: []v1beta3.RecommendationExplanation{
+				{
+					ContainerName:        "app",
+					ResourceName:         corev1.ResourceCPU,
+					ResourceRequest:      resource.MustParse("1"),
+					VPAMaxRecommendation: resource.MustParse("900m"),
+					Replicas:             5,
+					HPATargetUtilization: ptr.To[int32](60),
+					Branch:               v1beta3.RecommendationBranchNoChange,
+					GeneratedAt:          metav1.NewTime(now),
+				},
+				{
+					ContainerName:        "app",
+					ResourceName:         corev1.ResourceMemory,
+					ResourceRequest:      resource.MustParse("1Gi"),
+					VPAMaxRecommendation: resource.MustParse("2Gi"),
+					Replicas:             5,
+					Branch:               v1beta3.RecommendationBranchVerticalScaleUp,
+					Clamps:               []v1beta3.RecommendationClamp{v1beta3.RecommendationClampMaximumResource},
+					GeneratedAt:          metav1.NewTime(now),
+				},
+			},
+			wantTargetUtilizationExplanations: []v1beta3.RecommendationExplanation{
+				{
+					ContainerName:        "app",
+					ResourceName:         corev1.ResourceCPU,
+					ResourceRequest:      resource.MustParse("1"),
+					VPAMaxRecommendation: resource.MustParse("900m"),
+					Replicas:             5,
+					HPATargetUtilization: ptr.To[int32](60),
+					Branch:               v1beta3.RecommendationBranchTargetUtilizationUpdated,
+					GeneratedAt:          metav1.NewTime(now),
+				},
+			},
+		},
+		{
+			name:   "the target utilization is kept due to the unbalanced container size",
+			vpaCPU: "500m",
+			wantVerticalExplanations: []v1beta3.RecommendationExplanation{
+				{
+					ContainerName:        "app",
+					ResourceName:         corev1.ResourceCPU,
+					ResourceRequest:      resource.MustParse("1"),
+					VPAMaxRecommendation: resource.MustParse("500m"),
+					Replicas:             5,
+					HPATargetUtilization: ptr.To[int32](60),
+					Branch:               v1beta3.RecommendationBranchNoChange,
+					GeneratedAt:          metav1.NewTime(now),
+				},
+				{
+					ContainerName:        "app",
+					ResourceName:         corev1.ResourceMemory,
+					ResourceRequest:      resource.MustParse("1Gi"),
+					VPAMaxRecommendation: resource.MustParse("2Gi"),
+					Replicas:             5,
+					Branch:               v1beta3.RecommendationBranchVerticalScaleUp,
+					Clamps:               []v1beta3.RecommendationClamp{v1beta3.RecommendationClampMaximumResource},
+					GeneratedAt:          metav1.NewTime(now),
+				},
+			},
+			wantTargetUtilizationExplanations: []v1beta3.RecommendationExplanation{
+				{
+					ContainerName:        "app",
+					ResourceName:         corev1.ResourceCPU,
+					ResourceRequest:      resource.MustParse("1"),
+					VPAMaxRecommendation: resource.MustParse("500m"),
+					Replicas:             5,
+					HPATargetUtilization: ptr.To[int32](60),
+					Branch:               v1beta3.RecommendationBranchUnbalancedContainer,
+					GeneratedAt:          metav1.NewTime(now),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(2.0, 0.5, 90, 40, 3, 30, "5m", "5Mi", map[string]string{}, map[string]string{}, "10", "2Gi", 1000, 0.5, 0.1, 0, "", "", 0, nil, record.NewFakeRecorder(10))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			tortoise := utils.NewTortoiseBuilder().
+				AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
+					ContainerName: "app",
+					Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+						corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+						corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+					},
+				}).
+				AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
+					ContainerName: "app",
+					Resource:      createResourceList("1", "1Gi"),
+				}).
+				AddContainerRecommendationFromVPA(v1beta3.ContainerRecommendationFromVPA{
+					ContainerName: "app",
+					MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+						corev1.ResourceCPU:    {Quantity: resource.MustParse(tt.vpaCPU)},
+						corev1.ResourceMemory: {Quantity: resource.MustParse("2Gi")},
+					},
+				}).
+				Build()
+			hpa := &v2.HorizontalPodAutoscaler{
+				Spec: v2.HorizontalPodAutoscalerSpec{
+					MinReplicas: ptr.To[int32](1),
+					Metrics: []v2.MetricSpec{
+						{
+							Type: v2.ContainerResourceMetricSourceType,
+							ContainerResource: &v2.ContainerResourceMetricSource{
+								Name:      corev1.ResourceCPU,
+								Container: "app",
+								Target: v2.MetricTarget{
+									Type:               v2.UtilizationMetricType,
+									AverageUtilization: ptr.To[int32](60),
+								},
+							},
+						},
+					},
+				},
+			}
+
+			tortoise, err = s.updateHPATargetUtilizationRecommendations(context.Background(), tortoise, hpa, 5, now)
+			if err != nil {
+				t.Fatalf("updateHPATargetUtilizationRecommendations() error = %v", err)
+			}
+			tortoise, err = s.updateVPARecommendation(context.Background(), tortoise, hpa, 5, now)
+			if err != nil {
+				t.Fatalf("updateVPARecommendation() error = %v", err)
+			}
+
+			opts := []cmp.Option{
+				cmpopts.IgnoreFields(v1beta3.RecommendationExplanation{}, "Message"),
+			}
+			if d := cmp.Diff(tt.wantVerticalExplanations, tortoise.Status.Recommendations.Vertical.Explanations, opts...); d != "" {
+				t.Errorf("unexpected vertical explanations: diff = %s", d)
+			}
+			if d := cmp.Diff(tt.wantTargetUtilizationExplanations, tortoise.Status.Recommendations.Horizontal.TargetUtilizationExplanations, opts...); d != "" {
+				t.Errorf("unexpected target utilization explanations: diff = %s", d)
+			}
+			for _, e := range append(tortoise.Status.Recommendations.Vertical.Explanations, tortoise.Status.Recommendations.Horizontal.TargetUtilizationExplanations...) {
+				if e.Message == "" {
+					t.Errorf("the explanation of %s (%s) has no message", e.ResourceName, e.ContainerName)
+				}
+			}
+		})
+	}
+}
+
 // Helper functions to create test objects
 func createResourceList(cpu, memory string) corev1.ResourceList {
 	return corev1.ResourceList{
@@ -3576,3 +4328,49 @@ func createResourceList(cpu, memory string) corev1.ResourceList {
 		corev1.ResourceMemory: resource.MustParse(memory),
 	}
 }
+
+func TestService_WithOverrides(t *testing.T) {
+	base, err := New(2.0, 0.5, 90, 40, 3, 30, "50m", "50Mi", map[string]string{"istio-proxy": "100m"}, map[string]string{}, "10", "10Gi", 1000, 0.5, 0.1, 0, "", "", 0, nil, record.NewFakeRecorder(10))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	got := base.WithOverrides(v1alpha1.ConfigOverrides{
+		MinimumMinReplicas:               ptr.To[int32](1),
+		PreferredMaxReplicas:             ptr.To[int32](100),
+		MaximumMaxReplicas:               ptr.To[int32](5000),
+		MinimumTargetResourceUtilization: ptr.To[int32](50),
+		MaximumTargetResourceUtilization: ptr.To[int32](80),
+		MinimumCPURequest:                ptr.To(resource.MustParse("10m")),
+		MaximumMemoryRequest:             ptr.To(resource.MustParse("64Gi")),
+	})
+
+	if got.minimumMinReplicas != 1 || got.preferredMaxReplicas != 100 || got.maximumMaxReplica != 5000 {
+		t.Errorf("replicas aren't overridden: minimumMinReplicas=%v, preferredMaxReplicas=%v, maximumMaxReplica=%v", got.minimumMinReplicas, got.preferredMaxReplicas, got.maximumMaxReplica)
+	}
+	if got.minimumTargetResourceUtilization != 50 || got.maximumTargetResourceUtilization != 80 {
+		t.Errorf("target utilizations aren't overridden: minimum=%v, maximum=%v", got.minimumTargetResourceUtilization, got.maximumTargetResourceUtilization)
+	}
+	wantMax := createResourceList("10", "64Gi")
+	if d := cmp.Diff(wantMax, got.maxResourceSize); d != "" {
+		t.Errorf("maxResourceSize diff = %v", d)
+	}
+	wantMin := map[string]corev1.ResourceList{
+		"*":           createResourceList("10m", "50Mi"),
+		"istio-proxy": {corev1.ResourceCPU: resource.MustParse("100m")},
+	}
+	if d := cmp.Diff(wantMin, got.minResourceSizePerContainer); d != "" {
+		t.Errorf("minResourceSizePerContainer diff = %v", d)
+	}
+
+	// The original service isn't changed.
+	if base.minimumMinReplicas != 3 {
+		t.Errorf("the original minimumMinReplicas is changed: %v", base.minimumMinReplicas)
+	}
+	if d := cmp.Diff(createResourceList("10", "10Gi"), base.maxResourceSize); d != "" {
+		t.Errorf("the original maxResourceSize is changed: %v", d)
+	}
+	if d := cmp.Diff(createResourceList("50m", "50Mi"), base.minResourceSizePerContainer["*"]); d != "" {
+		t.Errorf("the original minResourceSizePerContainer is changed: %v", d)
+	}
+}
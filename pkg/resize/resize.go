@@ -0,0 +1,148 @@
+package resize
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/pod"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+// Service resizes the containers of the running Pods in place via the resize subresource (InPlacePodVerticalScaling)
+// so that the new resource requests are applied without recreating the Pods.
+type Service struct {
+	c        client.Client
+	reader   client.Reader
+	recorder record.EventRecorder
+	// podService calculates the new resources of the containers in the same way as the Pod mutating webhook.
+	podService *pod.Service
+	// defaultStrategy is used for the tortoises which don't specify .spec.verticalRollout.strategy.
+	defaultStrategy v1beta3.VerticalRolloutStrategy
+}
+
+func New(c client.Client, reader client.Reader, recorder record.EventRecorder, podService *pod.Service, defaultStrategy string) *Service {
+	return &Service{c: c, reader: reader, recorder: recorder, podService: podService, defaultStrategy: v1beta3.VerticalRolloutStrategy(defaultStrategy)}
+}
+
+// InPlace returns true when the new resource requests of the tortoise are applied by resizing the Pods in place.
+func (s *Service) InPlace(t *v1beta3.Tortoise) bool {
+	strategy := s.defaultStrategy
+	if t.Spec.VerticalRollout != nil && t.Spec.VerticalRollout.Strategy != "" {
+		strategy = t.Spec.VerticalRollout.Strategy
+	}
+	return strategy == v1beta3.VerticalRolloutStrategyInPlace
+}
+
+// Resize resizes the containers of the running Pods of the workload to .status.conditions.containerResourceRequests.
+//
+// It returns true without resizing any Pod when some Pod cannot get the new resources without restarting its containers, that is:
+// - resizePolicy of the container is RestartContainer for the resource to be changed.
+// - GOMAXPROCS or GOMEMLIMIT of the container has to be changed along with the resources, which cannot be done in place.
+// - the last resize of the Pod was Infeasible.
+// It also returns true when the resize is rejected, e.g., the cluster doesn't support the resize subresource.
+// In such cases, the caller is supposed to fall back to restarting the workload.
+func (s *Service) Resize(ctx context.Context, t *v1beta3.Tortoise, w *workload.Workload) (bool, error) {
+	pods := &corev1.PodList{}
+	if err := s.reader.List(ctx, pods, client.InNamespace(t.Namespace), client.MatchingLabels(w.PodTemplate.Labels)); err != nil {
+		return false, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	type podResize struct {
+		original *corev1.Pod
+		desired  *corev1.Pod
+	}
+	resizes := []podResize{}
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		if !p.DeletionTimestamp.IsZero() || p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		desired := p.DeepCopy()
+		s.podService.ModifyPodSpecResource(&desired.Spec, t)
+		if equality.Semantic.DeepEqual(p.Spec.Containers, desired.Spec.Containers) {
+			// The Pod already has the new resources.
+			continue
+		}
+		if reason := restartRequired(p, desired); reason != "" {
+			log.FromContext(ctx).Info("fall back to restarting the workload because the Pod cannot be resized in place", "tortoise", klog.KObj(t), "pod", klog.KObj(p), "reason", reason)
+			return true, nil
+		}
+		resizes = append(resizes, podResize{original: p, desired: desired})
+	}
+
+	for _, r := range resizes {
+		err := s.c.SubResource("resize").Patch(ctx, r.desired, client.StrategicMergeFrom(r.original))
+		if err == nil {
+			continue
+		}
+		if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) || apierrors.IsInvalid(err) || apierrors.IsForbidden(err) {
+			log.FromContext(ctx).Info("fall back to restarting the workload because the resize of the Pod is rejected", "tortoise", klog.KObj(t), "pod", klog.KObj(r.original), "error", err.Error())
+			return true, nil
+		}
+		return false, fmt.Errorf("resize the Pod %s/%s in place: %w", r.original.Namespace, r.original.Name, err)
+	}
+
+	if len(resizes) != 0 {
+		s.recorder.Event(t, corev1.EventTypeNormal, event.ResizedInPlace, fmt.Sprintf("Resized %d Pod(s) in place to apply the new resource requests", len(resizes)))
+	}
+	return false, nil
+}
+
+// restartRequired returns the reason why the Pod cannot be resized to the desired one without restarting its containers,
+// or the empty string if it can.
+func restartRequired(original, desired *corev1.Pod) string {
+	if original.Status.Resize == corev1.PodResizeStatusInfeasible {
+		return "the last resize of the Pod was infeasible"
+	}
+	for i, c := range original.Spec.Containers {
+		d := desired.Spec.Containers[i]
+		if !equality.Semantic.DeepEqual(c.Env, d.Env) {
+			return fmt.Sprintf("the environment variables of the container %s have to be changed", c.Name)
+		}
+		for _, rn := range changedResources(c.Resources, d.Resources) {
+			if resizeRestartPolicy(c, rn) == corev1.RestartContainer {
+				return fmt.Sprintf("resizePolicy of the container %s requires the restart to resize %s", c.Name, rn)
+			}
+		}
+	}
+	return ""
+}
+
+// changedResources returns the resources whose request or limit is different between old and new.
+func changedResources(old, new corev1.ResourceRequirements) []corev1.ResourceName {
+	changed := []corev1.ResourceName{}
+	seen := map[corev1.ResourceName]bool{}
+	for _, lists := range [][2]corev1.ResourceList{{old.Requests, new.Requests}, {old.Limits, new.Limits}} {
+		for rn, q := range lists[1] {
+			if seen[rn] {
+				continue
+			}
+			if o, ok := lists[0][rn]; !ok || o.Cmp(q) != 0 {
+				seen[rn] = true
+				changed = append(changed, rn)
+			}
+		}
+	}
+	return changed
+}
+
+// resizeRestartPolicy returns the restart policy of the container for resizing the resource.
+// It's NotRequired when resizePolicy doesn't mention the resource.
+func resizeRestartPolicy(c corev1.Container, rn corev1.ResourceName) corev1.ResourceResizeRestartPolicy {
+	for _, p := range c.ResizePolicy {
+		if p.ResourceName == rn {
+			return p.RestartPolicy
+		}
+	}
+	return corev1.NotRequired
+}
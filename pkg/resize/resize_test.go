@@ -0,0 +1,200 @@
+package resize
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/pod"
+	"github.com/mercari/tortoise/pkg/utils"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+// resources returns the resource requests of the app container.
+func resources(cpu, memory string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		},
+	}
+}
+
+// recordResizes returns the interceptor which records the names of the Pods resized via the resize subresource.
+// The resize fails with resizeErr if it's not nil.
+func recordResizes(resized *[]string, resizeErr error) interceptor.Funcs {
+	return interceptor.Funcs{
+		SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			if subResourceName != "resize" {
+				return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			}
+			if resizeErr != nil {
+				return resizeErr
+			}
+			*resized = append(*resized, obj.GetName())
+			return nil
+		},
+	}
+}
+
+func TestService_InPlace(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultStrategy string
+		strategy        v1beta3.VerticalRolloutStrategy
+		want            bool
+	}{
+		{
+			name:            "the default strategy is used",
+			defaultStrategy: "InPlace",
+			want:            true,
+		},
+		{
+			name:            "the tortoise overrides the default strategy",
+			defaultStrategy: "InPlace",
+			strategy:        v1beta3.VerticalRolloutStrategyRestart,
+			want:            false,
+		},
+		{
+			name:            "the tortoise opts in to the in-place resize",
+			defaultStrategy: "Restart",
+			strategy:        v1beta3.VerticalRolloutStrategyInPlace,
+			want:            true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, nil, nil, nil, tt.defaultStrategy)
+			b := utils.NewTortoiseBuilder().SetName("t").SetNamespace("default")
+			if tt.strategy != "" {
+				b = b.SetVerticalRollout(&v1beta3.VerticalRollout{Strategy: tt.strategy})
+			}
+			if got := s.InPlace(b.Build()); got != tt.want {
+				t.Errorf("InPlace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_Resize(t *testing.T) {
+	tests := []struct {
+		name        string
+		pods        []client.Object
+		resizeErr   error
+		wantRestart bool
+		wantResized []string
+		wantErr     bool
+	}{
+		{
+			name: "resize the Pods which don't have the new resources yet",
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").AddContainer(corev1.Container{Name: "app", Resources: resources("500m", "800Mi")}).Build(),
+				utils.NewPodBuilder().SetName("pod2").SetNamespace("default").AddLabel("app", "app").AddContainer(corev1.Container{
+					Name:      "app",
+					Resources: resources("500m", "800Mi"),
+					ResizePolicy: []corev1.ContainerResizePolicy{
+						{ResourceName: corev1.ResourceCPU, RestartPolicy: corev1.NotRequired},
+						{ResourceName: corev1.ResourceMemory, RestartPolicy: corev1.NotRequired},
+					},
+				}).Build(),
+				utils.NewPodBuilder().SetName("done").SetNamespace("default").AddLabel("app", "app").AddContainer(corev1.Container{Name: "app", Resources: resources("1", "1Gi")}).Build(),
+			},
+			wantResized: []string{"pod1", "pod2"},
+		},
+		{
+			name: "fall back to the restart when resizePolicy requires the restart",
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").AddContainer(corev1.Container{Name: "app", Resources: resources("500m", "800Mi")}).Build(),
+				utils.NewPodBuilder().SetName("pod2").SetNamespace("default").AddLabel("app", "app").AddContainer(corev1.Container{
+					Name:      "app",
+					Resources: resources("500m", "800Mi"),
+					ResizePolicy: []corev1.ContainerResizePolicy{
+						{ResourceName: corev1.ResourceMemory, RestartPolicy: corev1.RestartContainer},
+					},
+				}).Build(),
+			},
+			wantRestart: true,
+		},
+		{
+			name: "fall back to the restart when GOMAXPROCS has to be changed",
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").AddContainer(corev1.Container{
+					Name:      "app",
+					Resources: resources("500m", "800Mi"),
+					Env:       []corev1.EnvVar{{Name: "GOMAXPROCS", Value: "1"}},
+				}).Build(),
+			},
+			wantRestart: true,
+		},
+		{
+			name: "fall back to the restart when the last resize was infeasible",
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").AddContainer(corev1.Container{Name: "app", Resources: resources("500m", "800Mi")}).
+					SetResizeStatus(corev1.PodResizeStatusInfeasible).Build(),
+			},
+			wantRestart: true,
+		},
+		{
+			name: "fall back to the restart when the cluster doesn't support the resize subresource",
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").AddContainer(corev1.Container{Name: "app", Resources: resources("500m", "800Mi")}).Build(),
+			},
+			resizeErr:   apierrors.NewNotFound(schema.GroupResource{Resource: "pods/resize"}, "pod1"),
+			wantRestart: true,
+		},
+		{
+			name: "return the unexpected error",
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("pod1").SetNamespace("default").AddLabel("app", "app").AddContainer(corev1.Container{Name: "app", Resources: resources("500m", "800Mi")}).Build(),
+			},
+			resizeErr: apierrors.NewInternalError(errors.New("internal error")),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			podService, err := pod.New(map[string]int64{}, "0", nil, nil, nil)
+			if err != nil {
+				t.Fatalf("pod.New() error = %v", err)
+			}
+			resized := []string{}
+			c := fake.NewClientBuilder().WithObjects(tt.pods...).WithInterceptorFuncs(recordResizes(&resized, tt.resizeErr)).Build()
+			s := New(c, c, record.NewFakeRecorder(10), podService, "InPlace")
+
+			tortoise := utils.NewTortoiseBuilder().SetName("t").SetNamespace("default").SetUpdateMode(v1beta3.UpdateModeAuto).
+				SetTortoisePhase(v1beta3.TortoisePhaseWorking).
+				AddContainerResourceRequests(v1beta3.ContainerResourceRequests{ContainerName: "app", Resource: resources("1", "1Gi").Requests}).
+				Build()
+			w := &workload.Workload{
+				PodTemplate: &corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "app"}}},
+			}
+			restart, err := s.Resize(context.Background(), tortoise, w)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if restart != tt.wantRestart {
+				t.Errorf("Resize() restart = %v, want %v", restart, tt.wantRestart)
+			}
+			if tt.wantErr || tt.wantRestart {
+				return
+			}
+			sort.Strings(resized)
+			if d := cmp.Diff(tt.wantResized, resized); d != "" {
+				t.Errorf("Resize() resized Pods mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/canary"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/utils"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+const (
+	// The reasons of TortoiseConditionTypeVerticalRolledBack.
+	ReasonOOMKilled        = "OOMKilled"
+	ReasonCrashLoopBackOff = "CrashLoopBackOff"
+)
+
+// Service rolls back .status.conditions.containerResourceRequests to the previous one
+// when the Pods get OOMKilled or go into CrashLoopBackOff after tortoise decreased the resource requests.
+//
+// Instead of watching Pods, it lists the Pods of the target workload in each reconciliation during the window after the update.
+type Service struct {
+	reader   client.Reader
+	recorder record.EventRecorder
+	// window is the duration after the update during which the Pods are checked. 0 disables the rollback.
+	window time.Duration
+	// floorDecayWindow is how long the floor is kept after it's raised.
+	floorDecayWindow time.Duration
+}
+
+func New(reader client.Reader, recorder record.EventRecorder, window, floorDecayWindow time.Duration) *Service {
+	return &Service{reader: reader, recorder: recorder, window: window, floorDecayWindow: floorDecayWindow}
+}
+
+// RollbackIfUnhealthy checks the Pods created (or resized in place) after the last update of the resource requests,
+// and if any container of them is OOMKilled or in CrashLoopBackOff with the decreased resource requests,
+// it restores .status.conditions.previousContainerResourceRequests
+// It also raises the floors of the decreased resources to the restored values in .status.conditions.containerResourceFloors,
+// whatever the reason is, so that the same recommendation isn't applied again until the floors decay.
+// Otherwise, the next reconciliation would decrease the resource requests again, and they'd flap.
+//
+// It returns true when the resource requests are rolled back, and the caller is supposed to restart the workload.
+func (s *Service) RollbackIfUnhealthy(ctx context.Context, t *v1beta3.Tortoise, w *workload.Workload, now time.Time) (*v1beta3.Tortoise, bool, error) {
+	if s.window == 0 || t.Spec.UpdateMode == v1beta3.UpdateModeOff || t.Status.Conditions.PreviousContainerResourceRequests == nil {
+		return t, false, nil
+	}
+	updatedAt, ok := lastUpdateTime(t)
+	if !ok || !updatedAt.Add(s.window).After(now) {
+		return t, false, nil
+	}
+	decreased := decreasedResources(t)
+	if len(decreased) == 0 {
+		// Increasing the resource requests doesn't cause OOMKilled or CrashLoopBackOff.
+		return t, false, nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := s.reader.List(ctx, pods, client.InNamespace(t.Namespace), client.MatchingLabels(w.PodTemplate.Labels)); err != nil {
+		return t, false, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	// container name → reason
+	unhealthy := map[string]string{}
+	for _, p := range pods.Items {
+		if !p.DeletionTimestamp.IsZero() {
+			continue
+		}
+		// The terminations before the update aren't caused by the new resource requests.
+		var since time.Time
+		if p.CreationTimestamp.Time.Before(updatedAt) {
+			if !resizedInPlace(t, &p) {
+				// The Pods created before the update don't have the new resource requests.
+				continue
+			}
+			since = updatedAt
+		}
+		for _, cs := range p.Status.ContainerStatuses {
+			reason := unhealthyReason(cs, since)
+			if reason == "" || len(decreased[cs.Name]) == 0 {
+				continue
+			}
+			if reason == ReasonOOMKilled && !decreased[cs.Name][corev1.ResourceMemory] {
+				// The memory request wasn't decreased, so the rollback wouldn't help.
+				continue
+			}
+			if _, ok := unhealthy[cs.Name]; !ok {
+				unhealthy[cs.Name] = reason
+			}
+		}
+	}
+	if len(unhealthy) == 0 {
+		return t, false, nil
+	}
+
+	containers := make([]string, 0, len(unhealthy))
+	for name := range unhealthy {
+		containers = append(containers, name)
+	}
+	sort.Strings(containers)
+
+	reason := ""
+	details := make([]string, 0, len(containers))
+	for _, name := range containers {
+		if reason == "" || unhealthy[name] == ReasonOOMKilled {
+			// OOMKilled is the more specific reason.
+			reason = unhealthy[name]
+		}
+		details = append(details, fmt.Sprintf("%s (%s)", name, unhealthy[name]))
+	}
+
+	// All the containers are rolled back, so all the decreased resources are held.
+	for name, resources := range decreased {
+		for rn := range resources {
+			if previous, ok := previousRequest(t, name, rn); ok {
+				t = utils.RaiseTortoiseResourceFloor(t, name, rn, previous, now)
+			}
+		}
+	}
+
+	t.Status.Conditions.ContainerResourceRequests = t.Status.Conditions.PreviousContainerResourceRequests
+	t.Status.Conditions.PreviousContainerResourceRequests = nil
+
+	msg := fmt.Sprintf("The resource requests are rolled back because the Pods got unhealthy after they were decreased: %s", strings.Join(details, ", "))
+	t = utils.ChangeTortoiseCondition(t, v1beta3.TortoiseConditionTypeVerticalRolledBack, corev1.ConditionTrue, reason, msg, now)
+	s.recorder.Event(t, corev1.EventTypeWarning, event.VerticalRolledBack, msg)
+	log.FromContext(ctx).Info(msg, "tortoise", klog.KObj(t))
+
+	return t, true, nil
+}
+
+// lastUpdateTime returns the time when the resource requests were updated last time.
+// When the canary rollout was promoted after that, the time of the promotion is returned.
+func lastUpdateTime(t *v1beta3.Tortoise) (time.Time, bool) {
+	c := utils.GetTortoiseCondition(t, v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated)
+	if c == nil || c.Status != corev1.ConditionTrue {
+		return time.Time{}, false
+	}
+	updatedAt := c.LastTransitionTime.Time
+	if cc := utils.GetTortoiseCondition(t, v1beta3.TortoiseConditionTypeVerticalCanary); cc != nil && cc.Reason == canary.ReasonPromoted && cc.LastTransitionTime.After(updatedAt) {
+		updatedAt = cc.LastTransitionTime.Time
+	}
+	return updatedAt, true
+}
+
+// decreasedResources returns the resources whose request is smaller than the previous one for each container.
+func decreasedResources(t *v1beta3.Tortoise) map[string]map[corev1.ResourceName]bool {
+	decreased := map[string]map[corev1.ResourceName]bool{}
+	for _, previous := range t.Status.Conditions.PreviousContainerResourceRequests {
+		for _, current := range t.Status.Conditions.ContainerResourceRequests {
+			if current.ContainerName != previous.ContainerName {
+				continue
+			}
+			for rn, q := range current.Resource {
+				if p, ok := previous.Resource[rn]; ok && p.Cmp(q) > 0 {
+					if decreased[current.ContainerName] == nil {
+						decreased[current.ContainerName] = map[corev1.ResourceName]bool{}
+					}
+					decreased[current.ContainerName][rn] = true
+				}
+			}
+		}
+	}
+	return decreased
+}
+
+func previousRequest(t *v1beta3.Tortoise, containerName string, rn corev1.ResourceName) (resource.Quantity, bool) {
+	for _, r := range t.Status.Conditions.PreviousContainerResourceRequests {
+		if r.ContainerName == containerName {
+			q, ok := r.Resource[rn]
+			return q, ok
+		}
+	}
+	return resource.Quantity{}, false
+}
+
+// ExpireFloors removes the floors in .status.conditions.containerResourceFloors once the decay window has passed after they were raised.
+func (s *Service) ExpireFloors(t *v1beta3.Tortoise, now time.Time) *v1beta3.Tortoise {
+	var floors []v1beta3.ContainerResourceFloor
+	for _, f := range t.Status.Conditions.ContainerResourceFloors {
+		resources := map[corev1.ResourceName]v1beta3.ResourceFloor{}
+		for rn, r := range f.Resource {
+			if r.RaisedAt.Add(s.floorDecayWindow).After(now) {
+				resources[rn] = r
+			}
+		}
+		if len(resources) != 0 {
+			floors = append(floors, v1beta3.ContainerResourceFloor{ContainerName: f.ContainerName, Resource: resources})
+		}
+	}
+	t.Status.Conditions.ContainerResourceFloors = floors
+	return t
+}
+
+// resizedInPlace returns true when the containers of the Pod already have .status.conditions.containerResourceRequests,
+// that is, the Pod was resized in place after the update.
+func resizedInPlace(t *v1beta3.Tortoise, p *corev1.Pod) bool {
+	matched := false
+	for _, c := range p.Spec.Containers {
+		for rn, q := range c.Resources.Requests {
+			current, ok := utils.GetRequestFromTortoise(t, c.Name, rn)
+			if !ok {
+				continue
+			}
+			if current.Cmp(q) != 0 {
+				return false
+			}
+			matched = true
+		}
+	}
+	return matched
+}
+
+// unhealthyReason returns ReasonOOMKilled or ReasonCrashLoopBackOff if the container is unhealthy, or the empty string otherwise.
+// The OOMKills finished before since are ignored.
+func unhealthyReason(cs corev1.ContainerStatus, since time.Time) string {
+	if t := cs.State.Terminated; t != nil && t.Reason == ReasonOOMKilled && !t.FinishedAt.Time.Before(since) {
+		return ReasonOOMKilled
+	}
+	if t := cs.LastTerminationState.Terminated; t != nil && t.Reason == ReasonOOMKilled && !t.FinishedAt.Time.Before(since) {
+		return ReasonOOMKilled
+	}
+	if w := cs.State.Waiting; w != nil && w.Reason == ReasonCrashLoopBackOff {
+		return ReasonCrashLoopBackOff
+	}
+	return ""
+}
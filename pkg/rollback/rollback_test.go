@@ -0,0 +1,353 @@
+package rollback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/utils"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+var (
+	now       = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	updatedAt = now.Add(-10 * time.Minute)
+)
+
+func requests(cpu, memory string) []v1beta3.ContainerResourceRequests {
+	return []v1beta3.ContainerResourceRequests{
+		{
+			ContainerName: "app",
+			Resource: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+// floors returns the floors of the app container raised at raisedAt. The resource is omitted if it's empty.
+func floors(cpu, memory string, raisedAt time.Time) []v1beta3.ContainerResourceFloor {
+	f := map[corev1.ResourceName]v1beta3.ResourceFloor{}
+	if cpu != "" {
+		f[corev1.ResourceCPU] = v1beta3.ResourceFloor{Quantity: resource.MustParse(cpu), RaisedAt: metav1.NewTime(raisedAt)}
+	}
+	if memory != "" {
+		f[corev1.ResourceMemory] = v1beta3.ResourceFloor{Quantity: resource.MustParse(memory), RaisedAt: metav1.NewTime(raisedAt)}
+	}
+	return []v1beta3.ContainerResourceFloor{{ContainerName: "app", Resource: f}}
+}
+
+var (
+	running          = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	oomKilled        = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"}}
+	crashLoopBackOff = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+)
+
+func TestService_RollbackIfUnhealthy(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		// current and previous are the resource requests of the app container after and before the update.
+		current, previous []v1beta3.ContainerResourceRequests
+		updateMode        v1beta3.UpdateMode
+		floors            []v1beta3.ContainerResourceFloor
+		pods              []client.Object
+		// wantConditions is .status.conditions except tortoiseConditions.
+		wantConditions v1beta3.Conditions
+		wantRolledBack bool
+		wantReason     string
+	}{
+		{
+			name:     "roll back and hold the decreased resources when the container is OOMKilled after the memory request is decreased",
+			window:   30 * time.Minute,
+			current:  requests("500m", "800Mi"),
+			previous: requests("1", "1Gi"),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("new").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: running, LastTerminationState: oomKilled}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests: requests("1", "1Gi"),
+				ContainerResourceFloors:   floors("1", "1Gi", now),
+			},
+			wantRolledBack: true,
+			wantReason:     ReasonOOMKilled,
+		},
+		{
+			name:     "roll back and hold the decreased resources when the container is in CrashLoopBackOff",
+			window:   30 * time.Minute,
+			current:  requests("500m", "800Mi"),
+			previous: requests("1", "1Gi"),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("new").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: crashLoopBackOff}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests: requests("1", "1Gi"),
+				ContainerResourceFloors:   floors("1", "1Gi", now),
+			},
+			wantRolledBack: true,
+			wantReason:     ReasonCrashLoopBackOff,
+		},
+		{
+			name:     "hold only the decreased resource",
+			window:   30 * time.Minute,
+			current:  requests("500m", "1Gi"),
+			previous: requests("1", "800Mi"),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("new").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: crashLoopBackOff}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests: requests("1", "800Mi"),
+				ContainerResourceFloors:   floors("1", "", now),
+			},
+			wantRolledBack: true,
+			wantReason:     ReasonCrashLoopBackOff,
+		},
+		{
+			name:     "the container in CrashLoopBackOff which was OOMKilled is rolled back with the reason OOMKilled",
+			window:   30 * time.Minute,
+			current:  requests("500m", "800Mi"),
+			previous: requests("1", "1Gi"),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("new").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: crashLoopBackOff, LastTerminationState: oomKilled}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests: requests("1", "1Gi"),
+				ContainerResourceFloors:   floors("1", "1Gi", now),
+			},
+			wantRolledBack: true,
+			wantReason:     ReasonOOMKilled,
+		},
+		{
+			name:     "keep the higher floor",
+			window:   30 * time.Minute,
+			current:  requests("500m", "800Mi"),
+			previous: requests("1", "1Gi"),
+			floors:   floors("", "2Gi", now.Add(-time.Hour)),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("new").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: oomKilled}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests: requests("1", "1Gi"),
+				ContainerResourceFloors:   floors("1", "2Gi", now),
+			},
+			wantRolledBack: true,
+			wantReason:     ReasonOOMKilled,
+		},
+		{
+			name:     "don't roll back when the memory request wasn't decreased",
+			window:   30 * time.Minute,
+			current:  requests("500m", "1Gi"),
+			previous: requests("1", "800Mi"),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("new").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: running, LastTerminationState: oomKilled}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests:         requests("500m", "1Gi"),
+				PreviousContainerResourceRequests: requests("1", "800Mi"),
+			},
+		},
+		{
+			name:     "don't roll back because of the Pods created before the update",
+			window:   30 * time.Minute,
+			current:  requests("500m", "800Mi"),
+			previous: requests("1", "1Gi"),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("old").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(-time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: running, LastTerminationState: oomKilled}).Build(),
+				utils.NewPodBuilder().SetName("new").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: running}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests:         requests("500m", "800Mi"),
+				PreviousContainerResourceRequests: requests("1", "1Gi"),
+			},
+		},
+		{
+			name:     "roll back when the Pod resized in place is OOMKilled after the update",
+			window:   30 * time.Minute,
+			current:  requests("500m", "800Mi"),
+			previous: requests("1", "1Gi"),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("old").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(-time.Hour)).
+					// resized in place to the current resource requests
+					AddContainer(corev1.Container{Name: "app", Resources: corev1.ResourceRequirements{Requests: requests("500m", "800Mi")[0].Resource}}).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: running, LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(updatedAt.Add(time.Minute))},
+					}}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests: requests("1", "1Gi"),
+				ContainerResourceFloors:   floors("1", "1Gi", now),
+			},
+			wantRolledBack: true,
+			wantReason:     ReasonOOMKilled,
+		},
+		{
+			name:     "don't roll back because of the OOMKill before the Pod is resized in place",
+			window:   30 * time.Minute,
+			current:  requests("500m", "800Mi"),
+			previous: requests("1", "1Gi"),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("old").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(-time.Hour)).
+					// resized in place to the current resource requests
+					AddContainer(corev1.Container{Name: "app", Resources: corev1.ResourceRequirements{Requests: requests("500m", "800Mi")[0].Resource}}).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: running, LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(updatedAt.Add(-time.Minute))},
+					}}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests:         requests("500m", "800Mi"),
+				PreviousContainerResourceRequests: requests("1", "1Gi"),
+			},
+		},
+		{
+			name:     "don't roll back after the window",
+			window:   5 * time.Minute,
+			current:  requests("500m", "800Mi"),
+			previous: requests("1", "1Gi"),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("new").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: running, LastTerminationState: oomKilled}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests:         requests("500m", "800Mi"),
+				PreviousContainerResourceRequests: requests("1", "1Gi"),
+			},
+		},
+		{
+			name:     "disabled",
+			current:  requests("500m", "800Mi"),
+			previous: requests("1", "1Gi"),
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("new").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: running, LastTerminationState: oomKilled}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests:         requests("500m", "800Mi"),
+				PreviousContainerResourceRequests: requests("1", "1Gi"),
+			},
+		},
+		{
+			name:       "don't roll back in Off mode",
+			window:     30 * time.Minute,
+			current:    requests("500m", "800Mi"),
+			previous:   requests("1", "1Gi"),
+			updateMode: v1beta3.UpdateModeOff,
+			pods: []client.Object{
+				utils.NewPodBuilder().SetName("new").SetNamespace("default").AddLabel("app", "app").SetCreationTimestamp(updatedAt.Add(time.Minute)).
+					AddContainerStatus(corev1.ContainerStatus{Name: "app", State: running, LastTerminationState: oomKilled}).Build(),
+			},
+			wantConditions: v1beta3.Conditions{
+				ContainerResourceRequests:         requests("500m", "800Mi"),
+				PreviousContainerResourceRequests: requests("1", "1Gi"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateMode := v1beta3.UpdateModeAuto
+			if tt.updateMode != "" {
+				updateMode = tt.updateMode
+			}
+			b := utils.NewTortoiseBuilder().SetName("t").SetNamespace("default").SetUpdateMode(updateMode).
+				AddContainerResourceRequests(tt.current[0]).
+				AddPreviousContainerResourceRequests(tt.previous[0]).
+				AddTortoiseConditions(v1beta3.TortoiseCondition{
+					Type:               v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(updatedAt),
+					LastUpdateTime:     metav1.NewTime(updatedAt),
+				})
+			for _, f := range tt.floors {
+				b = b.AddContainerResourceFloors(f)
+			}
+			w := &workload.Workload{
+				PodTemplate: &corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "app"}}},
+			}
+
+			s := New(fake.NewClientBuilder().WithObjects(tt.pods...).Build(), record.NewFakeRecorder(10), tt.window, 24*time.Hour)
+			got, rolledBack, err := s.RollbackIfUnhealthy(context.Background(), b.Build(), w, now)
+			if err != nil {
+				t.Fatalf("RollbackIfUnhealthy() error = %v", err)
+			}
+			if rolledBack != tt.wantRolledBack {
+				t.Errorf("RollbackIfUnhealthy() rolledBack = %v, want %v", rolledBack, tt.wantRolledBack)
+			}
+
+			gotConditions := got.Status.Conditions
+			gotConditions.TortoiseConditions = nil
+			if d := cmp.Diff(tt.wantConditions, gotConditions); d != "" {
+				t.Errorf("RollbackIfUnhealthy() conditions mismatch (-want +got):\n%s", d)
+			}
+
+			c := utils.GetTortoiseCondition(got, v1beta3.TortoiseConditionTypeVerticalRolledBack)
+			if !tt.wantRolledBack {
+				if c != nil {
+					t.Errorf("RollbackIfUnhealthy() unexpected condition %v", c)
+				}
+				return
+			}
+			if c == nil || c.Status != corev1.ConditionTrue || c.Reason != tt.wantReason || !c.LastTransitionTime.Time.Equal(now) {
+				t.Errorf("RollbackIfUnhealthy() condition = %v, want True with the reason %s", c, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestService_ExpireFloors(t *testing.T) {
+	tests := []struct {
+		name   string
+		floors []v1beta3.ContainerResourceFloor
+		want   []v1beta3.ContainerResourceFloor
+	}{
+		{
+			name:   "keep the floor within the decay window",
+			floors: floors("", "1Gi", now.Add(-23*time.Hour)),
+			want:   floors("", "1Gi", now.Add(-23*time.Hour)),
+		},
+		{
+			name:   "remove the floor after the decay window",
+			floors: floors("", "1Gi", now.Add(-24*time.Hour)),
+		},
+		{
+			name: "remove only the expired floor",
+			floors: []v1beta3.ContainerResourceFloor{
+				{
+					ContainerName: "app",
+					Resource: map[corev1.ResourceName]v1beta3.ResourceFloor{
+						corev1.ResourceCPU:    {Quantity: resource.MustParse("1"), RaisedAt: metav1.NewTime(now.Add(-25 * time.Hour))},
+						corev1.ResourceMemory: {Quantity: resource.MustParse("1Gi"), RaisedAt: metav1.NewTime(now.Add(-time.Hour))},
+					},
+				},
+			},
+			want: floors("", "1Gi", now.Add(-time.Hour)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, record.NewFakeRecorder(10), 30*time.Minute, 24*time.Hour)
+			tortoise := utils.NewTortoiseBuilder().SetName("t").SetNamespace("default").AddContainerResourceRequests(requests("1", "1Gi")[0]).Build()
+			tortoise.Status.Conditions.ContainerResourceFloors = tt.floors
+			got := s.ExpireFloors(tortoise, now)
+			if d := cmp.Diff(tt.want, got.Status.Conditions.ContainerResourceFloors); d != "" {
+				t.Errorf("ExpireFloors() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package scheduledscaling
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+)
+
+const (
+	// RecommenderName is the name of the recommender which ScheduledScaling puts in .spec.recommenders of Tortoise.
+	RecommenderName = "ScheduledScaling"
+
+	scheduledScalingFinalizer = "scheduledscaling.autoscaling.mercari.com/finalizer"
+)
+
+type Service struct {
+	c        client.Client
+	recorder record.EventRecorder
+}
+
+func New(c client.Client, recorder record.EventRecorder) *Service {
+	return &Service{c: c, recorder: recorder}
+}
+
+// Phase returns the phase which the ScheduledScaling should be in at the given time,
+// and the duration until the next phase transition. The duration is zero when no transition is left.
+func Phase(ss *v1alpha1.ScheduledScaling, now time.Time) (v1alpha1.ScheduledScalingPhase, time.Duration) {
+	if !ss.Spec.Schedule.FinishAt.After(now) {
+		return v1alpha1.ScheduledScalingPhaseFinished, 0
+	}
+	if ss.Spec.Schedule.StartAt != nil && ss.Spec.Schedule.StartAt.After(now) {
+		return v1alpha1.ScheduledScalingPhasePending, ss.Spec.Schedule.StartAt.Sub(now)
+	}
+	return v1alpha1.ScheduledScalingPhaseActive, ss.Spec.Schedule.FinishAt.Sub(now)
+}
+
+func (s *Service) GetScheduledScaling(ctx context.Context, namespacedName types.NamespacedName) (*v1alpha1.ScheduledScaling, error) {
+	ss := &v1alpha1.ScheduledScaling{}
+	if err := s.c.Get(ctx, namespacedName, ss); err != nil {
+		return nil, fmt.Errorf("failed to get scheduled scaling: %w", err)
+	}
+	return ss, nil
+}
+
+// SyncTortoise gives the constraints from all the active ScheduledScalings targeting the tortoise,
+// or removes the constraints from the tortoise if no ScheduledScaling is active.
+func (s *Service) SyncTortoise(ctx context.Context, namespace, tortoiseName string, now time.Time) error {
+	ssl := &v1alpha1.ScheduledScalingList{}
+	if err := s.c.List(ctx, ssl, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list scheduled scalings in %s: %w", namespace, err)
+	}
+
+	active := []v1alpha1.ScheduledScaling{}
+	for _, ss := range ssl.Items {
+		if ss.Spec.TargetRefs.TortoiseName != tortoiseName || !ss.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if phase, _ := Phase(&ss, now); phase == v1alpha1.ScheduledScalingPhaseActive {
+			active = append(active, ss)
+		}
+	}
+	constraints := mergeConstraints(active)
+
+	key := types.NamespacedName{Namespace: namespace, Name: tortoiseName}
+	if constraints != nil {
+		// The recommender has to be registered first so that the tortoise controller doesn't remove the constraints.
+		if err := s.updateRecommender(ctx, key, true); err != nil {
+			return err
+		}
+		return s.updateConstraints(ctx, key, constraints)
+	}
+
+	if err := s.updateConstraints(ctx, key, nil); err != nil {
+		return err
+	}
+	return s.updateRecommender(ctx, key, false)
+}
+
+func (s *Service) updateRecommender(ctx context.Context, key types.NamespacedName, register bool) error {
+	updateFn := func() error {
+		t := &v1beta3.Tortoise{}
+		if err := s.c.Get(ctx, key, t); err != nil {
+			return err
+		}
+
+		index := -1
+		for i, r := range t.Spec.Recommenders {
+			if r.Name == RecommenderName {
+				index = i
+				break
+			}
+		}
+		switch {
+		case register && index == -1:
+			t.Spec.Recommenders = append(t.Spec.Recommenders, v1beta3.Recommender{
+				Name:           RecommenderName,
+				ResponsibleFor: []v1beta3.ResponsibleFor{v1beta3.ResponsibleForConstraints},
+			})
+		case !register && index != -1:
+			t.Spec.Recommenders = append(t.Spec.Recommenders[:index], t.Spec.Recommenders[index+1:]...)
+		default:
+			return nil
+		}
+		return s.c.Update(ctx, t)
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
+		return fmt.Errorf("failed to update the recommenders of tortoise %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *Service) updateConstraints(ctx context.Context, key types.NamespacedName, constraints *v1beta3.Constraints) error {
+	logger := log.FromContext(ctx)
+	updateFn := func() error {
+		t := &v1beta3.Tortoise{}
+		if err := s.c.Get(ctx, key, t); err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(t.Status.Recommendations.Constraints, constraints) {
+			return nil
+		}
+
+		t.Status.Recommendations.Constraints = constraints
+		if err := s.c.Status().Update(ctx, t); err != nil {
+			return err
+		}
+
+		if constraints == nil {
+			logger.Info("the constraints from ScheduledScaling are removed", "tortoise", key)
+			s.recorder.Event(t, corev1.EventTypeNormal, event.ScheduledScalingFinished, "The constraints from ScheduledScaling are removed, and Tortoise goes back to the usual scaling")
+		} else {
+			logger.Info("the constraints from ScheduledScaling are given", "tortoise", key, "constraints", constraints)
+			s.recorder.Event(t, corev1.EventTypeNormal, event.ScheduledScalingUp, "The constraints from ScheduledScaling are given to Tortoise")
+		}
+		return nil
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
+		return fmt.Errorf("failed to update the constraints of tortoise %s: %w", key, err)
+	}
+	return nil
+}
+
+// mergeConstraints merges the constraints from the given ScheduledScalings.
+// When multiple ScheduledScalings give the constraint on the same thing, the bigger one is used.
+// It returns nil if no ScheduledScaling gives any constraint.
+func mergeConstraints(sss []v1alpha1.ScheduledScaling) *v1beta3.Constraints {
+	if len(sss) == 0 {
+		return nil
+	}
+
+	var minimumMinReplicas *int32
+	// containerName → MinAllocatedResources
+	minAllocatedResources := map[string]corev1.ResourceList{}
+	for _, ss := range sss {
+		static := ss.Spec.Strategy.Static
+		if static == nil {
+			continue
+		}
+		if static.MinimumMinReplicas != nil && (minimumMinReplicas == nil || *minimumMinReplicas < *static.MinimumMinReplicas) {
+			minimumMinReplicas = static.MinimumMinReplicas
+		}
+		for _, r := range static.MinAllocatedResources {
+			if _, ok := minAllocatedResources[r.ContainerName]; !ok {
+				minAllocatedResources[r.ContainerName] = corev1.ResourceList{}
+			}
+			for k, q := range r.Resources {
+				if current, ok := minAllocatedResources[r.ContainerName][k]; !ok || current.Cmp(q) < 0 {
+					minAllocatedResources[r.ContainerName][k] = q
+				}
+			}
+		}
+	}
+
+	constraints := &v1beta3.Constraints{}
+	if minimumMinReplicas != nil {
+		constraints.MinimumMinReplicas = ptr.To(*minimumMinReplicas)
+	}
+	for containerName, resources := range minAllocatedResources {
+		constraints.MinAllocatedResources = append(constraints.MinAllocatedResources, v1beta3.ContainerResourcePolicy{
+			ContainerName:         containerName,
+			MinAllocatedResources: resources,
+		})
+	}
+	sort.Slice(constraints.MinAllocatedResources, func(i, j int) bool {
+		return constraints.MinAllocatedResources[i].ContainerName < constraints.MinAllocatedResources[j].ContainerName
+	})
+
+	return constraints
+}
+
+// UpdateScheduledScalingStatus updates the phase of the ScheduledScaling.
+func (s *Service) UpdateScheduledScalingStatus(ctx context.Context, ss *v1alpha1.ScheduledScaling, phase v1alpha1.ScheduledScalingPhase, reason string, now time.Time) error {
+	if ss.Status.Phase == phase && ss.Status.Reason == reason {
+		return nil
+	}
+
+	updateFn := func() error {
+		retSS := &v1alpha1.ScheduledScaling{}
+		if err := s.c.Get(ctx, client.ObjectKeyFromObject(ss), retSS); err != nil {
+			return err
+		}
+		if retSS.Status.Phase != phase {
+			retSS.Status.LastTransitionTime = metav1.NewTime(now)
+		}
+		retSS.Status.Phase = phase
+		retSS.Status.Reason = reason
+		return s.c.Status().Update(ctx, retSS)
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
+		return fmt.Errorf("failed to update scheduled scaling status: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) AddFinalizer(ctx context.Context, ss *v1alpha1.ScheduledScaling) error {
+	if controllerutil.ContainsFinalizer(ss, scheduledScalingFinalizer) {
+		return nil
+	}
+
+	updateFn := func() error {
+		retSS := &v1alpha1.ScheduledScaling{}
+		if err := s.c.Get(ctx, client.ObjectKeyFromObject(ss), retSS); err != nil {
+			return err
+		}
+		controllerutil.AddFinalizer(retSS, scheduledScalingFinalizer)
+		return s.c.Update(ctx, retSS)
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
+		return fmt.Errorf("failed to add finalizer: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) RemoveFinalizer(ctx context.Context, ss *v1alpha1.ScheduledScaling) error {
+	if !controllerutil.ContainsFinalizer(ss, scheduledScalingFinalizer) {
+		return nil
+	}
+
+	updateFn := func() error {
+		retSS := &v1alpha1.ScheduledScaling{}
+		if err := s.c.Get(ctx, client.ObjectKeyFromObject(ss), retSS); err != nil {
+			return err
+		}
+		controllerutil.RemoveFinalizer(retSS, scheduledScalingFinalizer)
+		return s.c.Update(ctx, retSS)
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
+		return fmt.Errorf("failed to remove finalizer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package scheduledscaling
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+var now = time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func scheduledScaling(name string, startAt *time.Time, finishAt time.Time, static *v1alpha1.StaticStrategy) *v1alpha1.ScheduledScaling {
+	ss := &v1alpha1.ScheduledScaling{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1alpha1.ScheduledScalingSpec{
+			TargetRefs: v1alpha1.TargetRefs{TortoiseName: "tortoise"},
+			Strategy:   v1alpha1.Strategy{Static: static},
+			Schedule:   v1alpha1.Schedule{FinishAt: metav1.NewTime(finishAt)},
+		},
+	}
+	if startAt != nil {
+		ss.Spec.Schedule.StartAt = ptr.To(metav1.NewTime(*startAt))
+	}
+	return ss
+}
+
+func TestPhase(t *testing.T) {
+	tests := []struct {
+		name             string
+		ss               *v1alpha1.ScheduledScaling
+		want             v1alpha1.ScheduledScalingPhase
+		wantRequeueAfter time.Duration
+	}{
+		{
+			name: "pending before startAt",
+			ss:   scheduledScaling("ss", ptr.To(now.Add(time.Hour)), now.Add(3*time.Hour), nil),
+			want: v1alpha1.ScheduledScalingPhasePending, wantRequeueAfter: time.Hour,
+		},
+		{
+			name: "active between startAt and finishAt",
+			ss:   scheduledScaling("ss", ptr.To(now.Add(-time.Hour)), now.Add(2*time.Hour), nil),
+			want: v1alpha1.ScheduledScalingPhaseActive, wantRequeueAfter: 2 * time.Hour,
+		},
+		{
+			name: "active without startAt",
+			ss:   scheduledScaling("ss", nil, now.Add(time.Hour), nil),
+			want: v1alpha1.ScheduledScalingPhaseActive, wantRequeueAfter: time.Hour,
+		},
+		{
+			name: "finished after finishAt",
+			ss:   scheduledScaling("ss", nil, now, nil),
+			want: v1alpha1.ScheduledScalingPhaseFinished,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotRequeueAfter := Phase(tt.ss, now)
+			if got != tt.want {
+				t.Errorf("Phase() phase = %v, want %v", got, tt.want)
+			}
+			if gotRequeueAfter != tt.wantRequeueAfter {
+				t.Errorf("Phase() requeueAfter = %v, want %v", gotRequeueAfter, tt.wantRequeueAfter)
+			}
+		})
+	}
+}
+
+func TestService_SyncTortoise(t *testing.T) {
+	recommender := v1beta3.Recommender{Name: RecommenderName, ResponsibleFor: []v1beta3.ResponsibleFor{v1beta3.ResponsibleForConstraints}}
+	tests := []struct {
+		name            string
+		tortoise        *v1beta3.Tortoise
+		sss             []client.Object
+		wantRecommender []v1beta3.Recommender
+		wantConstraints *v1beta3.Constraints
+		wantErr         bool
+	}{
+		{
+			name:     "an active ScheduledScaling gives the constraints",
+			tortoise: &v1beta3.Tortoise{ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"}},
+			sss: []client.Object{
+				scheduledScaling("ss", nil, now.Add(time.Hour), &v1alpha1.StaticStrategy{
+					MinimumMinReplicas: ptr.To[int32](10),
+					MinAllocatedResources: []v1alpha1.ContainerResourceRequirements{
+						{ContainerName: "app", Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+					},
+				}),
+			},
+			wantRecommender: []v1beta3.Recommender{recommender},
+			wantConstraints: &v1beta3.Constraints{
+				MinimumMinReplicas: ptr.To[int32](10),
+				MinAllocatedResources: []v1beta3.ContainerResourcePolicy{
+					{ContainerName: "app", MinAllocatedResources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+				},
+			},
+		},
+		{
+			name:     "the bigger one is used when multiple ScheduledScalings are active",
+			tortoise: &v1beta3.Tortoise{ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"}},
+			sss: []client.Object{
+				scheduledScaling("ss1", nil, now.Add(time.Hour), &v1alpha1.StaticStrategy{
+					MinimumMinReplicas: ptr.To[int32](10),
+					MinAllocatedResources: []v1alpha1.ContainerResourceRequirements{
+						{ContainerName: "app", Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("2Gi")}},
+					},
+				}),
+				scheduledScaling("ss2", nil, now.Add(time.Hour), &v1alpha1.StaticStrategy{
+					MinimumMinReplicas: ptr.To[int32](20),
+					MinAllocatedResources: []v1alpha1.ContainerResourceRequirements{
+						{ContainerName: "app", Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
+						{ContainerName: "istio-proxy", Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+					},
+				}),
+			},
+			wantRecommender: []v1beta3.Recommender{recommender},
+			wantConstraints: &v1beta3.Constraints{
+				MinimumMinReplicas: ptr.To[int32](20),
+				MinAllocatedResources: []v1beta3.ContainerResourcePolicy{
+					{ContainerName: "app", MinAllocatedResources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")}},
+					{ContainerName: "istio-proxy", MinAllocatedResources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+				},
+			},
+		},
+		{
+			name:     "pending and other tortoise's ScheduledScalings are ignored",
+			tortoise: &v1beta3.Tortoise{ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"}},
+			sss: []client.Object{
+				scheduledScaling("pending", ptr.To(now.Add(time.Hour)), now.Add(2*time.Hour), &v1alpha1.StaticStrategy{MinimumMinReplicas: ptr.To[int32](10)}),
+				func() client.Object {
+					ss := scheduledScaling("other", nil, now.Add(time.Hour), &v1alpha1.StaticStrategy{MinimumMinReplicas: ptr.To[int32](10)})
+					ss.Spec.TargetRefs.TortoiseName = "other"
+					return ss
+				}(),
+			},
+		},
+		{
+			name: "the constraints and the recommender are removed when no ScheduledScaling is active",
+			tortoise: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+				Spec: v1beta3.TortoiseSpec{Recommenders: []v1beta3.Recommender{
+					{Name: "other"},
+					recommender,
+				}},
+				Status: v1beta3.TortoiseStatus{Recommendations: v1beta3.Recommendations{
+					Constraints: &v1beta3.Constraints{MinimumMinReplicas: ptr.To[int32](10)},
+				}},
+			},
+			sss: []client.Object{
+				scheduledScaling("finished", nil, now.Add(-time.Hour), &v1alpha1.StaticStrategy{MinimumMinReplicas: ptr.To[int32](10)}),
+			},
+			wantRecommender: []v1beta3.Recommender{{Name: "other"}},
+		},
+		{
+			name:    "the tortoise is not found",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := v1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			if err := v1beta3.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			objs := tt.sss
+			if tt.tortoise != nil {
+				objs = append(objs, tt.tortoise)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(&v1beta3.Tortoise{}, &v1alpha1.ScheduledScaling{}).Build()
+			s := New(c, record.NewFakeRecorder(10))
+
+			err := s.SyncTortoise(context.Background(), "default", "tortoise", now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SyncTortoise() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got := &v1beta3.Tortoise{}
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(tt.tortoise), got); err != nil {
+				t.Fatalf("failed to get tortoise: %v", err)
+			}
+			if d := cmp.Diff(tt.wantRecommender, got.Spec.Recommenders); d != "" {
+				t.Errorf("SyncTortoise() recommenders diff = %v", d)
+			}
+			if d := cmp.Diff(tt.wantConstraints, got.Status.Recommendations.Constraints); d != "" {
+				t.Errorf("SyncTortoise() constraints diff = %v", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kyokomi/emoji/v2"
+	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/hpa"
+	"github.com/mercari/tortoise/pkg/pod"
+	"github.com/mercari/tortoise/pkg/policy"
+	"github.com/mercari/tortoise/pkg/recommender"
+	"github.com/mercari/tortoise/pkg/tortoise"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+// Simulator runs one recommendation cycle of the tortoise controller against the copies of the live objects,
+// and shows what tortoise would do, without writing anything to the cluster.
+type Simulator struct {
+	c client.Client
+
+	workloadService    *workload.Service
+	hpaService         *hpa.Service
+	recommenderService *recommender.Service
+	tortoiseService    *tortoise.Service
+	podService         *pod.Service
+	policyService      *policy.Service
+}
+
+// New returns Simulator with the services built from the controller's config.
+// The services get the dry-run client and the recorder discarding the events
+// so that nothing is written to the cluster even if they try to.
+func New(c client.Client, cfg *config.Config) (*Simulator, error) {
+	dryRunClient := client.NewDryRunClient(c)
+	recorder := &record.FakeRecorder{} // nil Events channel discards all events.
+
+	tortoiseService, err := tortoise.New(dryRunClient, recorder, cfg.RangeOfMinMaxReplicasRecommendationHours, cfg.TimeZone, cfg.TortoiseUpdateInterval, cfg.GatheringDataPeriodType, cfg.GlobalDisableMode, cfg.MaximumEmergencyModeDuration, nil, cfg.VerticalMaintenanceWindows, cfg.VerticalScaleUpCooldown, cfg.VerticalScaleDownCooldown)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tortoise service: %w", err)
+	}
+	hpaService, err := hpa.New(dryRunClient, recorder, cfg.ReplicaReductionFactor, cfg.MaximumTargetResourceUtilization, cfg.HPATargetUtilizationMaxIncrease, cfg.HPATargetUtilizationUpdateInterval, cfg.DefaultHPABehavior, cfg.MaximumMinReplicas, cfg.MaximumMaxReplicas, int32(cfg.MinimumMinReplicas), cfg.HPAExternalMetricExclusionRegex, cfg.EmergencyModeGracePeriod, cfg.GlobalDisableMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hpa service: %w", err)
+	}
+	podService, err := pod.New(cfg.ResourceLimitMultiplier, cfg.MinimumCPULimit, nil, cfg.FeatureFlags, cfg.ScaleSubresourceWorkloads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pod service: %w", err)
+	}
+
+	recommenderService, err := recommender.New(
+		cfg.MaxReplicasRecommendationMultiplier,
+		cfg.MinReplicasRecommendationMultiplier,
+		cfg.MaximumTargetResourceUtilization,
+		cfg.MinimumTargetResourceUtilization,
+		cfg.MinimumMinReplicas,
+		cfg.PreferredMaxReplicas,
+		cfg.MinimumCPURequest,
+		cfg.MinimumMemoryRequest,
+		cfg.MinimumCPURequestPerContainer,
+		cfg.MinimumMemoryRequestPerContainer,
+		cfg.MaximumCPURequest,
+		cfg.MaximumMemoryRequest,
+		cfg.MaximumMaxReplicas,
+		cfg.MaxAllowedScalingDownRatio,
+		cfg.BufferRatioOnVerticalResource,
+		cfg.VerticalMinimumChangeRatio,
+		cfg.VerticalMinimumCPUChange,
+		cfg.VerticalMinimumMemoryChange,
+		cfg.VerticalMaxStepRatio,
+		cfg.FeatureFlags,
+		recorder,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recommender service: %w", err)
+	}
+
+	return &Simulator{
+		c:                  c,
+		workloadService:    workload.New(dryRunClient, recorder, cfg.IstioSidecarProxyDefaultCPU, cfg.IstioSidecarProxyDefaultMemory, cfg.ScaleSubresourceWorkloads),
+		hpaService:         hpaService,
+		recommenderService: recommenderService,
+		tortoiseService:    tortoiseService,
+		podService:         podService,
+		policyService:      policy.New(c),
+	}, nil
+}
+
+// Simulate runs one recommendation cycle on the tortoise as if its updateMode were updateMode,
+// and writes the resulting HPA spec and the diff of the Pod resources.
+// The same as the controller, it uses the configurations overridden by the TortoisePolicies selecting the namespace,
+// and moves the tortoise phase before the recommendation, e.g., to Emergency with updateMode Emergency.
+//
+// It uses the recommendation from VPA that the tortoise controller observed in the last reconciliation (.status.conditions.containerRecommendationFromVPA).
+func (s *Simulator) Simulate(ctx context.Context, tortoiseName, namespace string, updateMode v1beta3.UpdateMode, now time.Time, writer io.Writer) error {
+	t := &v1beta3.Tortoise{}
+	if err := s.c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: tortoiseName}, t); err != nil {
+		return fmt.Errorf("failed to get tortoise: %w", err)
+	}
+
+	overrides, err := s.policyService.Resolve(ctx, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the tortoise policies: %w", err)
+	}
+	hpaService := s.hpaService.WithOverrides(overrides)
+	recommenderService := s.recommenderService.WithOverrides(overrides)
+
+	w, err := s.workloadService.GetWorkloadOnTortoise(ctx, t)
+	if err != nil {
+		return fmt.Errorf("failed to get the workload: %w", err)
+	}
+	if w.Replicas == nil {
+		return fmt.Errorf("the workload doesn't have the number of replicas")
+	}
+
+	if t.Spec.UpdateMode == v1beta3.UpdateModeOff || t.Status.Conditions.ContainerResourceRequests == nil {
+		// The same as the controller, ContainerResourceRequests has the requests in the workload while the tortoise is Off.
+		acr, err := s.workloadService.GetResourceRequests(w)
+		if err != nil {
+			return fmt.Errorf("failed to get resource requests in the workload: %w", err)
+		}
+		t.Status.Conditions.ContainerResourceRequests = acr
+	}
+	t.Spec.UpdateMode = updateMode
+
+	hpaSpec, err := hpaService.GetHPAOnTortoiseSpec(ctx, t)
+	if err != nil {
+		return fmt.Errorf("failed to get HPA: %w", err)
+	}
+	t = tortoise.UpdateTortoiseAutoscalingPolicyInStatus(t, hpaSpec, now)
+	t = s.tortoiseService.UpdateTortoisePhase(t, now)
+
+	currentHPA, ready, err := hpaService.GetHPAOnTortoise(ctx, t)
+	if err != nil {
+		return fmt.Errorf("failed to get HPA: %w", err)
+	}
+	if !ready {
+		return fmt.Errorf("HPA on tortoise is not ready yet")
+	}
+	t, err = s.tortoiseService.UpdateTortoisePhaseIfHPAIsUnhealthy(ctx, hpaService.IsHpaMetricAvailable(ctx, t, currentHPA), t)
+	if err != nil {
+		return fmt.Errorf("failed to update the tortoise phase: %w", err)
+	}
+
+	write(writer, fmt.Sprintf("%s simulating the recommendation cycle of your tortoise %s/%s as updateMode %s (nothing is written to the cluster)\n", emoji.Sprint(":turtle:"), namespace, tortoiseName, updateMode))
+	// The same as the controller, the recommendation isn't applied to HPA or Pods until the tortoise finishes gathering data.
+	gatheringData := false
+	switch t.Status.TortoisePhase {
+	case v1beta3.TortoisePhaseInitializing, v1beta3.TortoisePhaseGatheringData:
+		gatheringData = true
+		write(writer, fmt.Sprintf("\nthe tortoise is still gathering data (phase: %s), the recommendation wouldn't be applied yet.\n", t.Status.TortoisePhase))
+	default:
+		write(writer, fmt.Sprintf("\nthe tortoise phase would be %s.\n", t.Status.TortoisePhase))
+	}
+
+	t, err = recommenderService.UpdateRecommendations(ctx, t, currentHPA, *w.Replicas, now)
+	if err != nil {
+		return fmt.Errorf("failed to update recommendations: %w", err)
+	}
+	t, err = s.tortoiseService.UpdateResourceRequest(ctx, t, *w.Replicas, now, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update resource requests: %w", err)
+	}
+
+	if currentHPA != nil {
+		newHPA := currentHPA.DeepCopy()
+		if !gatheringData {
+			newHPA, _, err = hpaService.ChangeHPAFromTortoiseRecommendation(t, newHPA, now, false)
+			if err != nil {
+				return fmt.Errorf("failed to change HPA from the recommendation: %w", err)
+			}
+		}
+		if err := writeHPA(writer, currentHPA, newHPA); err != nil {
+			return err
+		}
+	}
+
+	newTemplate := w.PodTemplate.DeepCopy()
+	if !gatheringData {
+		s.podService.ModifyPodTemplateResource(newTemplate, t)
+	}
+	writePodResources(writer, &w.PodTemplate.Spec, &newTemplate.Spec)
+
+	return nil
+}
+
+func writeHPA(writer io.Writer, current, simulated *v2.HorizontalPodAutoscaler) error {
+	write(writer, fmt.Sprintf("\nHorizontalPodAutoscaler %s:\n", simulated.Name))
+
+	changes := []string{}
+	if !reflect.DeepEqual(current.Spec.MinReplicas, simulated.Spec.MinReplicas) {
+		changes = append(changes, fmt.Sprintf("minReplicas: %s -> %s", int32PtrString(current.Spec.MinReplicas), int32PtrString(simulated.Spec.MinReplicas)))
+	}
+	if current.Spec.MaxReplicas != simulated.Spec.MaxReplicas {
+		changes = append(changes, fmt.Sprintf("maxReplicas: %d -> %d", current.Spec.MaxReplicas, simulated.Spec.MaxReplicas))
+	}
+	currentTargets := containerResourceTargets(current)
+	simulatedTargets := containerResourceTargets(simulated)
+	keys := make([]string, 0, len(simulatedTargets))
+	for k := range simulatedTargets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if currentTargets[k] != simulatedTargets[k] {
+			changes = append(changes, fmt.Sprintf("target utilization of %s: %s -> %s", k, currentTargets[k], simulatedTargets[k]))
+		}
+	}
+
+	if len(changes) == 0 {
+		write(writer, "  no change\n")
+	}
+	for _, c := range changes {
+		write(writer, fmt.Sprintf("  %s\n", c))
+	}
+
+	y, err := yaml.Marshal(simulated.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal HPA spec: %w", err)
+	}
+	write(writer, "\n  the simulated spec:\n")
+	for _, l := range strings.Split(strings.TrimSuffix(string(y), "\n"), "\n") {
+		write(writer, fmt.Sprintf("    %s\n", l))
+	}
+	return nil
+}
+
+// containerResourceTargets returns the target utilization of the container resource metrics keyed by "container <name>, <resource>".
+func containerResourceTargets(h *v2.HorizontalPodAutoscaler) map[string]string {
+	targets := map[string]string{}
+	for _, m := range h.Spec.Metrics {
+		if m.Type != v2.ContainerResourceMetricSourceType || m.ContainerResource == nil || m.ContainerResource.Target.AverageUtilization == nil {
+			continue
+		}
+		targets[fmt.Sprintf("container %s, %s", m.ContainerResource.Container, m.ContainerResource.Name)] = fmt.Sprintf("%d%%", *m.ContainerResource.Target.AverageUtilization)
+	}
+	return targets
+}
+
+func writePodResources(writer io.Writer, current, simulated *corev1.PodSpec) {
+	write(writer, "\nPod resources:\n")
+
+	changes := []string{}
+	for i, c := range simulated.Containers {
+		old := current.Containers[i]
+		changes = append(changes, resourceListChanges(c.Name, "requests", old.Resources.Requests, c.Resources.Requests)...)
+		changes = append(changes, resourceListChanges(c.Name, "limits", old.Resources.Limits, c.Resources.Limits)...)
+	}
+
+	if len(changes) == 0 {
+		write(writer, "  no change\n")
+	}
+	for _, c := range changes {
+		write(writer, fmt.Sprintf("  %s\n", c))
+	}
+}
+
+func resourceListChanges(containerName, kind string, current, simulated corev1.ResourceList) []string {
+	rns := make([]string, 0, len(simulated))
+	for rn := range simulated {
+		rns = append(rns, string(rn))
+	}
+	sort.Strings(rns)
+
+	changes := []string{}
+	for _, rn := range rns {
+		oldQ, newQ := current[corev1.ResourceName(rn)], simulated[corev1.ResourceName(rn)]
+		if oldQ.Cmp(newQ) == 0 {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("container %s, %s %s: %s -> %s", containerName, kind, rn, oldQ.String(), newQ.String()))
+	}
+	return changes
+}
+
+func int32PtrString(p *int32) string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%d", *p)
+}
+
+func write(writer io.Writer, msg string) {
+	//nolint:errcheck // intentionally ignore the error because it's not critical
+	writer.Write([]byte(msg))
+}
@@ -0,0 +1,284 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+)
+
+func testObjects(phase v1beta3.TortoisePhase) []client.Object {
+	t := &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			UpdateMode: v1beta3.UpdateModeOff,
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef: v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app", APIVersion: "apps/v1"},
+			},
+		},
+		Status: v1beta3.TortoiseStatus{
+			TortoisePhase: phase,
+			Targets:       v1beta3.TargetsStatus{HorizontalPodAutoscaler: "tortoise-hpa-tortoise"},
+			Recommendations: v1beta3.Recommendations{
+				Horizontal: v1beta3.HorizontalRecommendations{
+					MinReplicas: []v1beta3.ReplicasRecommendation{{From: 0, To: 24, TimeZone: "UTC", Value: 3}},
+					MaxReplicas: []v1beta3.ReplicasRecommendation{{From: 0, To: 24, TimeZone: "UTC", Value: 10}},
+				},
+			},
+			AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+				{
+					ContainerName: "app",
+					Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+						corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+						corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+					},
+				},
+			},
+			ContainerResourcePhases: []v1beta3.ContainerResourcePhases{
+				{
+					ContainerName: "app",
+					ResourcePhases: map[corev1.ResourceName]v1beta3.ResourcePhase{
+						corev1.ResourceCPU:    {Phase: v1beta3.ContainerResourcePhaseWorking},
+						corev1.ResourceMemory: {Phase: v1beta3.ContainerResourcePhaseWorking},
+					},
+				},
+			},
+			Conditions: v1beta3.Conditions{
+				ContainerRecommendationFromVPA: []v1beta3.ContainerRecommendationFromVPA{
+					{
+						ContainerName: "app",
+						MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+							corev1.ResourceCPU:    {Quantity: resource.MustParse("500m")},
+							corev1.ResourceMemory: {Quantity: resource.MustParse("2Gi")},
+						},
+						Recommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+							corev1.ResourceCPU:    {Quantity: resource.MustParse("500m")},
+							corev1.ResourceMemory: {Quantity: resource.MustParse("2Gi")},
+						},
+					},
+				},
+			},
+		},
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](5),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "app"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "app"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("1"),
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	hpa := &v2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise-hpa-tortoise", Namespace: "default"},
+		Spec: v2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: v2.CrossVersionObjectReference{Kind: "Deployment", Name: "app", APIVersion: "apps/v1"},
+			MinReplicas:    ptr.To[int32](3),
+			MaxReplicas:    10,
+			Metrics: []v2.MetricSpec{
+				{
+					Type: v2.ContainerResourceMetricSourceType,
+					ContainerResource: &v2.ContainerResourceMetricSource{
+						Name:      corev1.ResourceCPU,
+						Container: "app",
+						Target:    v2.MetricTarget{Type: v2.UtilizationMetricType, AverageUtilization: ptr.To[int32](50)},
+					},
+				},
+			},
+		},
+		Status: v2.HorizontalPodAutoscalerStatus{
+			Conditions: []v2.HorizontalPodAutoscalerCondition{{Type: v2.ScalingActive, Status: corev1.ConditionTrue}},
+			CurrentMetrics: []v2.MetricStatus{
+				{
+					Type: v2.ContainerResourceMetricSourceType,
+					ContainerResource: &v2.ContainerResourceMetricStatus{
+						Name:      corev1.ResourceCPU,
+						Container: "app",
+						Current:   v2.MetricValueStatus{Value: ptr.To(resource.MustParse("400m")), AverageUtilization: ptr.To[int32](40)},
+					},
+				},
+			},
+		},
+	}
+	return []client.Object{t, dp, hpa}
+}
+
+func TestSimulator_Simulate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		phase      v1beta3.TortoisePhase
+		updateMode v1beta3.UpdateMode
+		// modify modifies the tortoise before the simulation.
+		modify func(t *v1beta3.Tortoise)
+		// policies are the TortoisePolicies in the cluster.
+		policies   []client.Object
+		wantOutput []string
+		dontWant   []string
+	}{
+		{
+			name:  "working tortoise",
+			phase: v1beta3.TortoisePhaseWorking,
+			wantOutput: []string{
+				"as updateMode Auto (nothing is written to the cluster)",
+				"HorizontalPodAutoscaler tortoise-hpa-tortoise:",
+				"target utilization of container app, cpu: 50% -> ",
+				"the simulated spec:",
+				"container app, requests memory: 1Gi -> ",
+			},
+			dontWant: []string{"gathering data"},
+		},
+		{
+			name:  "tortoise gathering data",
+			phase: v1beta3.TortoisePhaseGatheringData,
+			modify: func(t *v1beta3.Tortoise) {
+				for rn := range t.Status.ContainerResourcePhases[0].ResourcePhases {
+					t.Status.ContainerResourcePhases[0].ResourcePhases[rn] = v1beta3.ResourcePhase{
+						Phase:              v1beta3.ContainerResourcePhaseGatheringData,
+						LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+					}
+				}
+			},
+			wantOutput: []string{
+				"the tortoise is still gathering data (phase: GatheringData)",
+				"HorizontalPodAutoscaler tortoise-hpa-tortoise:\n  no change\n",
+				"Pod resources:\n  no change\n",
+			},
+		},
+		{
+			name:  "tortoise which has finished gathering data starts to work",
+			phase: v1beta3.TortoisePhaseGatheringData,
+			modify: func(t *v1beta3.Tortoise) {
+				for rn := range t.Status.ContainerResourcePhases[0].ResourcePhases {
+					t.Status.ContainerResourcePhases[0].ResourcePhases[rn] = v1beta3.ResourcePhase{
+						Phase:              v1beta3.ContainerResourcePhaseGatheringData,
+						LastTransitionTime: metav1.NewTime(now.Add(-8 * 24 * time.Hour)),
+					}
+				}
+			},
+			wantOutput: []string{
+				"the tortoise phase would be Working.",
+				"container app, requests memory: 1Gi -> ",
+			},
+			dontWant: []string{"gathering data"},
+		},
+		{
+			name:       "tortoise moves to Emergency with updateMode Emergency",
+			phase:      v1beta3.TortoisePhaseWorking,
+			updateMode: v1beta3.UpdateModeEmergency,
+			wantOutput: []string{
+				"as updateMode Emergency",
+				"the tortoise phase would be Emergency.",
+				"minReplicas: 3 -> 10",
+			},
+		},
+		{
+			name:  "the configurations are overridden by the TortoisePolicy",
+			phase: v1beta3.TortoisePhaseWorking,
+			policies: []client.Object{
+				&v1alpha1.TortoisePolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+					Spec: v1alpha1.TortoisePolicySpec{
+						Overrides: v1alpha1.ConfigOverrides{
+							MinimumTargetResourceUtilization: ptr.To[int32](40),
+							MaximumTargetResourceUtilization: ptr.To[int32](52),
+						},
+					},
+				},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			},
+			wantOutput: []string{
+				"target utilization of container app, cpu: 50% -> 52%",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			if err := v1beta3.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			if err := v1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			objs := testObjects(tt.phase)
+			if tt.modify != nil {
+				tt.modify(objs[0].(*v1beta3.Tortoise))
+			}
+			objs = append(objs, tt.policies...)
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(&v1beta3.Tortoise{}).Build()
+
+			cfg, err := config.ParseConfig("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := New(c, cfg)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			updateMode := v1beta3.UpdateModeAuto
+			if tt.updateMode != "" {
+				updateMode = tt.updateMode
+			}
+			out := &bytes.Buffer{}
+			if err := s.Simulate(context.Background(), "tortoise", "default", updateMode, now, out); err != nil {
+				t.Fatalf("Simulate() error = %v", err)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Simulate() output doesn't contain %q:\n%s", want, out.String())
+				}
+			}
+			for _, dontWant := range tt.dontWant {
+				if strings.Contains(out.String(), dontWant) {
+					t.Errorf("Simulate() output shouldn't contain %q:\n%s", dontWant, out.String())
+				}
+			}
+
+			// Nothing should be written to the cluster.
+			for _, o := range objs {
+				got := o.DeepCopyObject().(client.Object)
+				if err := c.Get(context.Background(), client.ObjectKeyFromObject(o), got); err != nil {
+					t.Fatalf("failed to get %T: %v", o, err)
+				}
+				if got.GetResourceVersion() != "999" {
+					t.Errorf("%T %s is updated during the simulation, resourceVersion = %s", o, o.GetName(), got.GetResourceVersion())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/kyokomi/emoji/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/pod"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+// Startr is the struct for starting tortoise, stopped by `tortoisectl stop` or manually, safely.
+type Startr struct {
+	c client.Client
+
+	workloadService *workload.Service
+	podService      *pod.Service
+
+	// sleep waits for d between the restarts of the tortoises. It's replaced in the test.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func New(c client.Client, ws *workload.Service, ps *pod.Service) *Startr {
+	return &Startr{
+		c:               c,
+		workloadService: ws,
+		podService:      ps,
+		sleep:           sleep,
+	}
+}
+
+type StartrOption string
+
+var (
+	// DryRun only shows the preview of the resource request changes, and doesn't change anything.
+	DryRun StartrOption = "DryRun"
+	// OnlyIncrease patches the workload to apply only the increases of the resource requests, and keeps the tortoise Off.
+	// After the Pods get stable with the increased resources, you can run start again without this option to apply the decreases as well.
+	OnlyIncrease StartrOption = "OnlyIncrease"
+)
+
+// Start changes the tortoise(s) updateMode to Auto.
+//
+// When multiple tortoises are started, it waits for restartInterval between the tortoises which will restart the Pods,
+// so that many Pods in the cluster aren't restarted at once.
+func (s *Startr) Start(ctx context.Context, tortoiseNames []string, namespace string, all bool, restartInterval time.Duration, writer io.Writer, opts ...StartrOption) error {
+	// It assumes the validation is already done in the CLI layer.
+
+	targets, err := s.listTargets(ctx, tortoiseNames, namespace, all)
+	if err != nil {
+		return err
+	}
+
+	var finalerr error
+	restarted := false
+	for _, target := range targets {
+		write(writer, fmt.Sprintf("\n%s starting your tortoise %s ... \n", emoji.Sprint(":turtle:"), &target))
+
+		tortoise := &v1beta3.Tortoise{}
+		if err := s.c.Get(ctx, target, tortoise); err != nil {
+			finalerr = errors.Join(finalerr, err)
+			write(writer, fmt.Sprintf("failed to get your tortoise %s.\nError: %v\n", emoji.Sprint(":face_with_spiral_eyes:"), err))
+			continue
+		}
+		if tortoise.Spec.UpdateMode != v1beta3.UpdateModeOff {
+			write(writer, fmt.Sprintf("this tortoise is already started (updateMode: %s) %s\n", tortoise.Spec.UpdateMode, emoji.Sprint(":running:")))
+			continue
+		}
+
+		// 1. Preview the changes of the resource requests.
+		changes := RequestChanges(tortoise)
+		writePreview(writer, tortoise, changes)
+
+		if containsOption(opts, DryRun) {
+			continue
+		}
+
+		willRestart := len(changes) != 0
+		if containsOption(opts, OnlyIncrease) {
+			willRestart = containsIncrease(changes)
+		}
+		if willRestart && restarted && restartInterval > 0 {
+			write(writer, fmt.Sprintf("%s waiting for %s before restarting the next workload ... \n", emoji.Sprint(":hourglass:"), restartInterval))
+			if err := s.sleep(ctx, restartInterval); err != nil {
+				return errors.Join(finalerr, err)
+			}
+		}
+
+		// 2. [when OnlyIncrease is true] Patch the workload to apply only the increases, and keep the tortoise Off.
+		if containsOption(opts, OnlyIncrease) {
+			if !willRestart {
+				write(writer, fmt.Sprintf("no resource request would be increased, skip patching your workload %s\n", emoji.Sprint(":ok_hand:")))
+				continue
+			}
+
+			write(writer, fmt.Sprintf("%s patching your workload to apply only the increases of the resource requests ... ", emoji.Sprint(":hammer_and_wrench:")))
+			if err := s.patchWorkloadToIncreaseResources(ctx, tortoise); err != nil {
+				finalerr = errors.Join(finalerr, err)
+				write(writer, fmt.Sprintf("%s failed to patch your workload %s.\nError: %v\n", emoji.Sprint(":face_with_spiral_eyes:"), &target, err))
+				continue
+			}
+			restarted = true
+			write(writer, fmt.Sprintf("Done, your tortoise is still Off. Run start again without --only-increase once your Pods get stable %s\n", emoji.Sprint(":muscle:")))
+			continue
+		}
+
+		// 3. Start Tortoise.
+		// The tortoise controller restarts the workload to apply the recommendation in the next reconciliation.
+		tortoise.Spec.UpdateMode = v1beta3.UpdateModeAuto
+		if err := s.c.Update(ctx, tortoise); err != nil {
+			finalerr = errors.Join(finalerr, err)
+			write(writer, fmt.Sprintf("%s failed to start your tortoise %s.\nError: %v\n", emoji.Sprint(":face_with_spiral_eyes:"), &target, err))
+			continue
+		}
+		restarted = restarted || willRestart
+		write(writer, fmt.Sprintf("Done, your tortoise is Auto now %s\n", emoji.Sprint(":running:")))
+	}
+
+	return finalerr
+}
+
+func (s *Startr) listTargets(ctx context.Context, tortoiseNames []string, namespace string, all bool) ([]types.NamespacedName, error) {
+	targets := []types.NamespacedName{}
+	if !all {
+		for _, name := range tortoiseNames {
+			targets = append(targets, types.NamespacedName{Name: name, Namespace: namespace})
+		}
+		return targets, nil
+	}
+
+	tortoises := &v1beta3.TortoiseList{}
+	opt := &client.ListOptions{}
+	if namespace != "" {
+		// start all tortoises in the namespace
+		opt.Namespace = namespace
+	}
+	if err := s.c.List(ctx, tortoises, opt); err != nil {
+		return nil, fmt.Errorf("failed to list tortoises: %w", err)
+	}
+
+	for _, t := range tortoises.Items {
+		targets = append(targets, types.NamespacedName{Name: t.Name, Namespace: t.Namespace})
+	}
+	return targets, nil
+}
+
+// RequestChange is the change of the resource request in one container when the tortoise is started.
+type RequestChange struct {
+	ContainerName string
+	ResourceName  corev1.ResourceName
+	Current       resource.Quantity
+	Recommended   resource.Quantity
+}
+
+// IsIncrease returns true if the resource request is increased.
+func (c RequestChange) IsIncrease() bool {
+	return c.Recommended.Cmp(c.Current) > 0
+}
+
+// RequestChanges returns the changes of the resource requests that would happen when the tortoise gets Auto.
+// While the tortoise is Off, .status.conditions.containerResourceRequests has the resource requests in the workload,
+// and they'll be replaced with the recommendation once the tortoise gets Auto.
+func RequestChanges(t *v1beta3.Tortoise) []RequestChange {
+	if t.Status.TortoisePhase == "" ||
+		t.Status.TortoisePhase == v1beta3.TortoisePhaseInitializing ||
+		t.Status.TortoisePhase == v1beta3.TortoisePhaseGatheringData {
+		// The recommendation isn't applied until the tortoise finishes gathering data.
+		return nil
+	}
+
+	changes := []RequestChange{}
+	for _, current := range t.Status.Conditions.ContainerResourceRequests {
+		for _, rec := range t.Status.Recommendations.Vertical.ContainerResourceRecommendation {
+			if rec.ContainerName != current.ContainerName {
+				continue
+			}
+			for rn, currentReq := range current.Resource {
+				recommendedReq, ok := rec.RecommendedResource[rn]
+				if !ok || recommendedReq.Cmp(currentReq) == 0 {
+					continue
+				}
+				changes = append(changes, RequestChange{
+					ContainerName: current.ContainerName,
+					ResourceName:  rn,
+					Current:       currentReq,
+					Recommended:   recommendedReq,
+				})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].ContainerName != changes[j].ContainerName {
+			return changes[i].ContainerName < changes[j].ContainerName
+		}
+		return changes[i].ResourceName < changes[j].ResourceName
+	})
+	return changes
+}
+
+func containsIncrease(changes []RequestChange) bool {
+	for _, c := range changes {
+		if c.IsIncrease() {
+			return true
+		}
+	}
+	return false
+}
+
+func writePreview(writer io.Writer, t *v1beta3.Tortoise, changes []RequestChange) {
+	if len(changes) == 0 {
+		if t.Status.TortoisePhase == v1beta3.TortoisePhaseInitializing || t.Status.TortoisePhase == v1beta3.TortoisePhaseGatheringData {
+			write(writer, fmt.Sprintf("  the tortoise is still gathering data (phase: %s), no resource request would be changed\n", t.Status.TortoisePhase))
+			return
+		}
+		write(writer, "  no resource request would be changed\n")
+		return
+	}
+
+	write(writer, "  the resource requests would be changed:\n")
+	for _, c := range changes {
+		direction := "decrease"
+		if c.IsIncrease() {
+			direction = "increase"
+		}
+		write(writer, fmt.Sprintf("    container %s, %s: %s -> %s (%s)\n", c.ContainerName, c.ResourceName, c.Current.String(), c.Recommended.String(), direction))
+	}
+}
+
+// patchWorkloadToIncreaseResources patches the workload to apply only the increases of the resource requests in the recommendation.
+func (s *Startr) patchWorkloadToIncreaseResources(ctx context.Context, tortoise *v1beta3.Tortoise) error {
+	w, err := s.workloadService.GetWorkloadOnTortoise(ctx, tortoise)
+	if err != nil {
+		return err
+	}
+	originalTemplate := w.PodTemplate.DeepCopy()
+
+	// ModifyPodTemplateResource takes the resource requests from .status.conditions.containerResourceRequests,
+	// so we give the recommendation there.
+	// Also, set to Auto because ModifyPodTemplateResource doesn't change anything if it's set to Off.
+	recommended := tortoise.DeepCopy()
+	recommended.Spec.UpdateMode = v1beta3.UpdateModeAuto
+	recommended.Status.Conditions.ContainerResourceRequests = nil
+	for _, rec := range tortoise.Status.Recommendations.Vertical.ContainerResourceRecommendation {
+		recommended.Status.Conditions.ContainerResourceRequests = append(recommended.Status.Conditions.ContainerResourceRequests, v1beta3.ContainerResourceRequests{
+			ContainerName: rec.ContainerName,
+			Resource:      rec.RecommendedResource,
+		})
+	}
+	s.podService.ModifyPodTemplateResource(w.PodTemplate, recommended, pod.NoScaleDown)
+
+	if reflect.DeepEqual(originalTemplate, w.PodTemplate) {
+		return nil
+	}
+
+	return s.workloadService.UpdatePodTemplate(ctx, w, tortoise)
+}
+
+func write(writer io.Writer, msg string) {
+	//nolint:errcheck // intentionally ignore the error because it's not critical
+	writer.Write([]byte(msg))
+}
+
+func containsOption(opts []StartrOption, opt StartrOption) bool {
+	for _, o := range opts {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
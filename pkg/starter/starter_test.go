@@ -0,0 +1,331 @@
+package starter
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/pod"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+func offTortoise(name string, phase v1beta3.TortoisePhase, current, recommended corev1.ResourceList) *v1beta3.Tortoise {
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			UpdateMode: v1beta3.UpdateModeOff,
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef: v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: name, APIVersion: "apps/v1"},
+			},
+		},
+		Status: v1beta3.TortoiseStatus{
+			TortoisePhase: phase,
+			Conditions: v1beta3.Conditions{
+				ContainerResourceRequests: []v1beta3.ContainerResourceRequests{{ContainerName: "app", Resource: current}},
+			},
+			Recommendations: v1beta3.Recommendations{
+				Vertical: v1beta3.VerticalRecommendations{
+					ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{{ContainerName: "app", RecommendedResource: recommended}},
+				},
+			},
+		},
+	}
+}
+
+func testDeployment(name string, requests corev1.ResourceList) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Resources: corev1.ResourceRequirements{Requests: requests}}},
+				},
+			},
+		},
+	}
+}
+
+func TestStartr_Start(t *testing.T) {
+	current := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	recommended := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("500Mi"),
+	}
+
+	tests := []struct {
+		name         string
+		tortoises    []*v1beta3.Tortoise
+		tortoiseName []string
+		all          bool
+		opts         []StartrOption
+		wantMode     map[string]v1beta3.UpdateMode
+		wantRequests corev1.ResourceList // the requests in the deployment "a" after start.
+		wantSleeps   int
+		wantOutput   []string
+	}{
+		{
+			name:         "start a tortoise",
+			tortoises:    []*v1beta3.Tortoise{offTortoise("a", v1beta3.TortoisePhaseWorking, current, recommended)},
+			tortoiseName: []string{"a"},
+			wantMode:     map[string]v1beta3.UpdateMode{"a": v1beta3.UpdateModeAuto},
+			wantRequests: current,
+			wantOutput: []string{
+				"container app, cpu: 1 -> 2 (increase)",
+				"container app, memory: 1Gi -> 500Mi (decrease)",
+				"your tortoise is Auto now",
+			},
+		},
+		{
+			name: "already started tortoise isn't changed",
+			tortoises: func() []*v1beta3.Tortoise {
+				t := offTortoise("a", v1beta3.TortoisePhaseWorking, current, recommended)
+				t.Spec.UpdateMode = v1beta3.UpdateModeEmergency
+				return []*v1beta3.Tortoise{t}
+			}(),
+			tortoiseName: []string{"a"},
+			wantMode:     map[string]v1beta3.UpdateMode{"a": v1beta3.UpdateModeEmergency},
+			wantRequests: current,
+			wantOutput:   []string{"this tortoise is already started (updateMode: Emergency)"},
+		},
+		{
+			name:         "dry run only shows the preview",
+			tortoises:    []*v1beta3.Tortoise{offTortoise("a", v1beta3.TortoisePhaseWorking, current, recommended)},
+			tortoiseName: []string{"a"},
+			opts:         []StartrOption{DryRun},
+			wantMode:     map[string]v1beta3.UpdateMode{"a": v1beta3.UpdateModeOff},
+			wantRequests: current,
+			wantOutput:   []string{"container app, cpu: 1 -> 2 (increase)"},
+		},
+		{
+			name:         "only increase patches the deployment and keeps the tortoise Off",
+			tortoises:    []*v1beta3.Tortoise{offTortoise("a", v1beta3.TortoisePhaseWorking, current, recommended)},
+			tortoiseName: []string{"a"},
+			opts:         []StartrOption{OnlyIncrease},
+			wantMode:     map[string]v1beta3.UpdateMode{"a": v1beta3.UpdateModeOff},
+			wantRequests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+			wantOutput: []string{"your tortoise is still Off"},
+		},
+		{
+			name: "the tortoise gathering data doesn't change resource requests",
+			tortoises: []*v1beta3.Tortoise{
+				offTortoise("a", v1beta3.TortoisePhaseGatheringData, current, recommended),
+				offTortoise("b", v1beta3.TortoisePhaseGatheringData, current, recommended),
+			},
+			all:          true,
+			wantMode:     map[string]v1beta3.UpdateMode{"a": v1beta3.UpdateModeAuto, "b": v1beta3.UpdateModeAuto},
+			wantRequests: current,
+			wantSleeps:   0,
+			wantOutput:   []string{"the tortoise is still gathering data (phase: GatheringData)"},
+		},
+		{
+			name: "restarts are staggered with --all",
+			tortoises: []*v1beta3.Tortoise{
+				offTortoise("a", v1beta3.TortoisePhaseWorking, current, recommended),
+				offTortoise("b", v1beta3.TortoisePhaseWorking, current, recommended),
+				offTortoise("c", v1beta3.TortoisePhaseWorking, current, recommended),
+			},
+			all:          true,
+			wantMode:     map[string]v1beta3.UpdateMode{"a": v1beta3.UpdateModeAuto, "b": v1beta3.UpdateModeAuto, "c": v1beta3.UpdateModeAuto},
+			wantRequests: current,
+			wantSleeps:   2,
+			wantOutput:   []string{"waiting for 1m0s before restarting the next workload"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			if err := v1beta3.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			objs := []client.Object{}
+			for _, tortoise := range tt.tortoises {
+				objs = append(objs, tortoise, testDeployment(tortoise.Name, current))
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+			podService, err := pod.New(map[string]int64{}, "", nil, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to create pod service: %v", err)
+			}
+			s := New(c, workload.New(c, record.NewFakeRecorder(10), "", "", nil), podService)
+			sleeps := 0
+			s.sleep = func(ctx context.Context, d time.Duration) error {
+				sleeps++
+				return nil
+			}
+
+			out := &bytes.Buffer{}
+			namespace := "default"
+			if tt.all {
+				namespace = ""
+			}
+			if err := s.Start(context.Background(), tt.tortoiseName, namespace, tt.all, time.Minute, out, tt.opts...); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			for name, want := range tt.wantMode {
+				got := &v1beta3.Tortoise{}
+				if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, got); err != nil {
+					t.Fatalf("failed to get tortoise: %v", err)
+				}
+				if got.Spec.UpdateMode != want {
+					t.Errorf("tortoise %s updateMode = %v, want %v", name, got.Spec.UpdateMode, want)
+				}
+			}
+
+			dp := &appsv1.Deployment{}
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "a"}, dp); err != nil {
+				t.Fatalf("failed to get deployment: %v", err)
+			}
+			for rn, want := range tt.wantRequests {
+				got := dp.Spec.Template.Spec.Containers[0].Resources.Requests[rn]
+				if got.Cmp(want) != 0 {
+					t.Errorf("deployment request %s = %v, want %v", rn, got.String(), want.String())
+				}
+			}
+
+			if sleeps != tt.wantSleeps {
+				t.Errorf("Start() waited %d times, want %d", sleeps, tt.wantSleeps)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Start() output doesn't contain %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestStartr_Start_OnlyIncreaseOnWorkloads(t *testing.T) {
+	current := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	recommended := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("500Mi"),
+	}
+	wantRequests := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "a"}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Resources: corev1.ResourceRequirements{Requests: current}}},
+		},
+	}
+	rollout := func() client.Object {
+		m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"replicas": int64(1), "template": m},
+		}}
+		u.SetAPIVersion("argoproj.io/v1alpha1")
+		u.SetKind("Rollout")
+		u.SetName("a")
+		u.SetNamespace("default")
+		return u
+	}
+
+	tests := []struct {
+		name       string
+		apiVersion string
+		kind       string
+		workload   client.Object
+	}{
+		{
+			name:       "StatefulSet",
+			apiVersion: "apps/v1",
+			kind:       "StatefulSet",
+			workload: &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"},
+				Spec: appsv1.StatefulSetSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "a"}},
+					Template: template,
+				},
+			},
+		},
+		{
+			name:       "custom workload with the scale subresource",
+			apiVersion: "argoproj.io/v1alpha1",
+			kind:       "Rollout",
+			workload:   rollout(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			if err := v1beta3.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			tortoise := offTortoise("a", v1beta3.TortoisePhaseWorking, current, recommended)
+			tortoise.Spec.TargetRefs.ScaleTargetRef = v1beta3.CrossVersionObjectReference{Kind: tt.kind, Name: "a", APIVersion: tt.apiVersion}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tortoise, tt.workload).WithInterceptorFuncs(interceptor.Funcs{
+				SubResourceGet: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+					if _, ok := obj.(*unstructured.Unstructured); !ok || subResourceName != "scale" {
+						return c.SubResource(subResourceName).Get(ctx, obj, subResource, opts...)
+					}
+					subResource.(*autoscalingv1.Scale).Spec.Replicas = 1
+					return nil
+				},
+			}).Build()
+
+			podService, err := pod.New(map[string]int64{}, "", nil, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to create pod service: %v", err)
+			}
+			workloadService := workload.New(c, record.NewFakeRecorder(10), "", "", []config.ScaleSubresourceWorkload{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout"}})
+			s := New(c, workloadService, podService)
+
+			if err := s.Start(context.Background(), []string{"a"}, "default", false, time.Minute, &bytes.Buffer{}, OnlyIncrease); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			w, err := workloadService.GetWorkloadOnTortoise(context.Background(), tortoise)
+			if err != nil {
+				t.Fatalf("failed to get the workload: %v", err)
+			}
+			for rn, want := range wantRequests {
+				got := w.PodTemplate.Spec.Containers[0].Resources.Requests[rn]
+				if got.Cmp(want) != 0 {
+					t.Errorf("%s request %s = %v, want %v", tt.kind, rn, got.String(), want.String())
+				}
+			}
+		})
+	}
+}
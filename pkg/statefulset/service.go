@@ -0,0 +1,74 @@
+package statefulset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/event"
+)
+
+type Service struct {
+	c        client.Client
+	recorder record.EventRecorder
+}
+
+func New(c client.Client, recorder record.EventRecorder) *Service {
+	return &Service{c: c, recorder: recorder}
+}
+
+func (c *Service) GetStatefulSetOnTortoise(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*v1.StatefulSet, error) {
+	sts := &v1.StatefulSet{}
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Spec.TargetRefs.ScaleTargetRef.Name}, sts); err != nil {
+		return nil, fmt.Errorf("failed to get statefulset on tortoise: %w", err)
+	}
+	return sts, nil
+}
+
+// RolloutRestart restarts the Pods in the StatefulSet by updating the annotation in the pod template.
+// It respects the update strategy that users define in the StatefulSet:
+// - When rollingUpdate.partition is set, only the Pods with an ordinal greater than or equal to the partition are restarted by the StatefulSet controller.
+// - When the update strategy is OnDelete, the Pods aren't restarted until they're deleted by someone else.
+// Tortoise never modifies the update strategy itself.
+func (c *Service) RolloutRestart(ctx context.Context, sts *v1.StatefulSet, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	if sts.Spec.Template.ObjectMeta.Annotations == nil {
+		sts.Spec.Template.ObjectMeta.Annotations = make(map[string]string)
+	}
+	sts.Spec.Template.ObjectMeta.Annotations[annotation.UpdatedAtAnnotation] = now.Format(time.RFC3339)
+
+	if err := c.c.Update(ctx, sts); err != nil {
+		return fmt.Errorf("failed to update statefulset: %w", err)
+	}
+
+	msg := "StatefulSet is restarted to apply the recommendation from Tortoise"
+	eventType := corev1.EventTypeNormal
+	if sts.Spec.UpdateStrategy.Type == v1.OnDeleteStatefulSetStrategyType {
+		eventType = corev1.EventTypeWarning
+		msg = "StatefulSet uses the OnDelete update strategy; the recommendation from Tortoise is applied only when Pods are deleted"
+	} else if partition := Partition(sts); partition > 0 {
+		msg = fmt.Sprintf("StatefulSet is restarted to apply the recommendation from Tortoise; only Pods with an ordinal >= %d are restarted because of rollingUpdate.partition", partition)
+	}
+
+	c.recorder.Event(tortoise, eventType, event.RestartStatefulSet, msg)
+	log.FromContext(ctx).Info(msg, "tortoise", tortoise)
+
+	return nil
+}
+
+// Partition returns the rollingUpdate.partition of the StatefulSet.
+// It returns 0 when the partition isn't specified, which means all Pods are updated.
+func Partition(sts *v1.StatefulSet) int32 {
+	if sts.Spec.UpdateStrategy.RollingUpdate == nil || sts.Spec.UpdateStrategy.RollingUpdate.Partition == nil {
+		return 0
+	}
+	return *sts.Spec.UpdateStrategy.RollingUpdate.Partition
+}
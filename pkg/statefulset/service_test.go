@@ -0,0 +1,101 @@
+package statefulset
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+)
+
+func TestService_RolloutRestart(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	tortoise := &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef: v1beta3.CrossVersionObjectReference{
+					Kind: "StatefulSet",
+					Name: "sts",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		updateStrategy v1.StatefulSetUpdateStrategy
+		wantEvent      string
+	}{
+		{
+			name:           "rolling update without partition",
+			updateStrategy: v1.StatefulSetUpdateStrategy{Type: v1.RollingUpdateStatefulSetStrategyType},
+			wantEvent:      "Normal RestartStatefulSet StatefulSet is restarted to apply the recommendation from Tortoise",
+		},
+		{
+			name: "rolling update with partition",
+			updateStrategy: v1.StatefulSetUpdateStrategy{
+				Type: v1.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &v1.RollingUpdateStatefulSetStrategy{
+					Partition: ptr.To[int32](2),
+				},
+			},
+			wantEvent: "Normal RestartStatefulSet StatefulSet is restarted to apply the recommendation from Tortoise; only Pods with an ordinal >= 2 are restarted because of rollingUpdate.partition",
+		},
+		{
+			name:           "on delete",
+			updateStrategy: v1.StatefulSetUpdateStrategy{Type: v1.OnDeleteStatefulSetStrategyType},
+			wantEvent:      "Warning RestartStatefulSet StatefulSet uses the OnDelete update strategy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sts := &v1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "sts", Namespace: "default"},
+				Spec: v1.StatefulSetSpec{
+					UpdateStrategy: tt.updateStrategy,
+				},
+			}
+			c := fake.NewClientBuilder().WithRuntimeObjects(sts).Build()
+			recorder := record.NewFakeRecorder(10)
+			s := New(c, recorder)
+
+			got, err := s.GetStatefulSetOnTortoise(context.Background(), tortoise)
+			if err != nil {
+				t.Fatalf("GetStatefulSetOnTortoise() error = %v", err)
+			}
+			if err := s.RolloutRestart(context.Background(), got, tortoise, now); err != nil {
+				t.Fatalf("RolloutRestart() error = %v", err)
+			}
+
+			updated := &v1.StatefulSet{}
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "sts"}, updated); err != nil {
+				t.Fatalf("failed to get statefulset: %v", err)
+			}
+			if updated.Spec.Template.Annotations[annotation.UpdatedAtAnnotation] != now.Format(time.RFC3339) {
+				t.Errorf("RolloutRestart() didn't update the annotation: %v", updated.Spec.Template.Annotations)
+			}
+			if Partition(updated) != Partition(sts) {
+				t.Errorf("RolloutRestart() shouldn't change the partition: got %v, want %v", Partition(updated), Partition(sts))
+			}
+
+			select {
+			case e := <-recorder.Events:
+				if !strings.HasPrefix(e, tt.wantEvent) {
+					t.Errorf("RolloutRestart() event = %q, want prefix %q", e, tt.wantEvent)
+				}
+			default:
+				t.Errorf("RolloutRestart() didn't record any event")
+			}
+		})
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"golang.org/x/time/rate"
 	v2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -24,6 +26,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/cron"
 	"github.com/mercari/tortoise/pkg/event"
 	"github.com/mercari/tortoise/pkg/metrics"
 	"github.com/mercari/tortoise/pkg/utils"
@@ -46,13 +51,29 @@ type Service struct {
 	// When enabled, Tortoise will continue to calculate recommendations and update status,
 	// but will not apply any changes to HPA, VPA, or Pod resources.
 	globalDisableMode bool
+	// maximumEmergencyModeDuration is the maximum duration of Emergency mode. 0 means no limit.
+	maximumEmergencyModeDuration time.Duration
+	// verticalApplyLimiter limits the vertical applies in the whole cluster. nil means no limit.
+	// It's shared with the services rebuilt on the config reload so that the reload doesn't refill the burst.
+	verticalApplyLimiter *rate.Limiter
+	// maintenanceWindows are the windows when the resource requests can be decreased. Empty means anytime.
+	maintenanceWindows []maintenanceWindow
+	// verticalScaleUpCooldown and verticalScaleDownCooldown are the minimum intervals between increasing and decreasing the resource requests
+	// for the resources without .spec.resourcePolicy[*].behavior[*].verticalScaleUpCooldown and verticalScaleDownCooldown.
+	verticalScaleUpCooldown   time.Duration
+	verticalScaleDownCooldown time.Duration
 
 	mu sync.RWMutex
 	// TODO: Instead of here, we should store the last time of each tortoise in the status of the tortoise.
 	lastTimeUpdateTortoise map[client.ObjectKey]time.Time
 }
 
-func New(c client.Client, recorder record.EventRecorder, rangeOfMinMaxReplicasRecommendationHour int, timeZone string, tortoiseUpdateInterval time.Duration, gatheringDataDuration string, globalDisableMode bool) (*Service, error) {
+type maintenanceWindow struct {
+	schedule *cron.Schedule
+	duration time.Duration
+}
+
+func New(c client.Client, recorder record.EventRecorder, rangeOfMinMaxReplicasRecommendationHour int, timeZone string, tortoiseUpdateInterval time.Duration, gatheringDataDuration string, globalDisableMode bool, maximumEmergencyModeDuration time.Duration, verticalApplyLimiter *rate.Limiter, maintenanceWindows []config.MaintenanceWindow, verticalScaleUpCooldown, verticalScaleDownCooldown time.Duration) (*Service, error) {
 	jst, err := time.LoadLocation(timeZone)
 	if err != nil {
 		return nil, fmt.Errorf("load location: %w", err)
@@ -61,6 +82,15 @@ func New(c client.Client, recorder record.EventRecorder, rangeOfMinMaxReplicasRe
 		gatheringDataDuration = "weekly"
 	}
 
+	windows := make([]maintenanceWindow, 0, len(maintenanceWindows))
+	for _, w := range maintenanceWindows {
+		schedule, err := cron.Parse(w.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("parse the maintenance window schedule: %w", err)
+		}
+		windows = append(windows, maintenanceWindow{schedule: schedule, duration: w.Duration})
+	}
+
 	return &Service{
 		c: c,
 
@@ -70,10 +100,34 @@ func New(c client.Client, recorder record.EventRecorder, rangeOfMinMaxReplicasRe
 		timeZone:                                jst,
 		tortoiseUpdateInterval:                  tortoiseUpdateInterval,
 		globalDisableMode:                       globalDisableMode,
+		maximumEmergencyModeDuration:            maximumEmergencyModeDuration,
+		verticalApplyLimiter:                    verticalApplyLimiter,
+		maintenanceWindows:                      windows,
+		verticalScaleUpCooldown:                 verticalScaleUpCooldown,
+		verticalScaleDownCooldown:               verticalScaleDownCooldown,
 		lastTimeUpdateTortoise:                  map[client.ObjectKey]time.Time{},
 	}, nil
 }
 
+// NewVerticalApplyLimiter returns the limiter for the vertical applies in the whole cluster.
+// ratePerMinute is the number of the vertical applies per minute, and 0 means no limit.
+func NewVerticalApplyLimiter(ratePerMinute float64, burst int) *rate.Limiter {
+	if ratePerMinute <= 0 {
+		return rate.NewLimiter(rate.Inf, burst)
+	}
+	return rate.NewLimiter(rate.Limit(ratePerMinute/60), burst)
+}
+
+// UpdateVerticalApplyLimiter updates the limit and the burst of the limiter in place, keeping the tokens already consumed.
+func UpdateVerticalApplyLimiter(l *rate.Limiter, ratePerMinute float64, burst int) {
+	if ratePerMinute <= 0 {
+		l.SetLimit(rate.Inf)
+		return
+	}
+	l.SetBurst(burst)
+	l.SetLimit(rate.Limit(ratePerMinute / 60))
+}
+
 func (s *Service) ShouldReconcileTortoiseNow(tortoise *v1beta3.Tortoise, now time.Time) (bool, time.Duration) {
 	if tortoise.Spec.UpdateMode == v1beta3.UpdateModeEmergency && tortoise.Status.TortoisePhase != v1beta3.TortoisePhaseEmergency {
 		// Tortoise which is emergency mode, but hasn't been handled by the controller yet. It should be updated ASAP.
@@ -162,6 +216,19 @@ func (s *Service) UpdateTortoisePhase(tortoise *v1beta3.Tortoise, now time.Time)
 		}
 	}
 
+	return syncEmergencyModeCondition(tortoise, now)
+}
+
+// syncEmergencyModeCondition records when the tortoise moved to Emergency mode in the EmergencyMode condition.
+func syncEmergencyModeCondition(tortoise *v1beta3.Tortoise, now time.Time) *v1beta3.Tortoise {
+	c := utils.GetTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeEmergencyMode)
+	inEmergency := tortoise.Status.TortoisePhase == v1beta3.TortoisePhaseEmergency
+	if inEmergency && (c == nil || c.Status != corev1.ConditionTrue) {
+		return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeEmergencyMode, corev1.ConditionTrue, "EmergencyMode", "Tortoise is in Emergency mode", now)
+	}
+	if !inEmergency && c != nil && c.Status == corev1.ConditionTrue {
+		return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeEmergencyMode, corev1.ConditionFalse, "", "", now)
+	}
 	return tortoise
 }
 
@@ -364,25 +431,28 @@ func (s *Service) syncContainerRecommendationFromVPA(tortoise *v1beta3.Tortoise)
 	return tortoise
 }
 
-func (s *Service) UpdateContainerRecommendationFromVPA(tortoise *v1beta3.Tortoise, vpa *v1.VerticalPodAutoscaler, now time.Time) *v1beta3.Tortoise {
+// UpdateContainerRecommendationFromVPA updates ContainerRecommendationFromVPA in the tortoise status
+// with the recommendation from the monitor VPA or the in-process recommender.
+func (s *Service) UpdateContainerRecommendationFromVPA(tortoise *v1beta3.Tortoise, recommendation *v1.RecommendedPodResources, now time.Time) *v1beta3.Tortoise {
 	tortoise = s.syncContainerRecommendationFromVPA(tortoise)
 
-	upperMap := make(map[string]map[corev1.ResourceName]resource.Quantity, len(vpa.Status.Recommendation.ContainerRecommendations))
-	for _, c := range vpa.Status.Recommendation.ContainerRecommendations {
+	upperMap := make(map[string]map[corev1.ResourceName]resource.Quantity, len(recommendation.ContainerRecommendations))
+	for _, c := range recommendation.ContainerRecommendations {
 		upperMap[c.ContainerName] = make(map[corev1.ResourceName]resource.Quantity, len(c.UpperBound))
 		for rn, r := range c.UpperBound {
 			upperMap[c.ContainerName][rn] = r
 		}
 	}
 
-	targetMap := make(map[string]map[corev1.ResourceName]resource.Quantity, len(vpa.Status.Recommendation.ContainerRecommendations))
-	for _, c := range vpa.Status.Recommendation.ContainerRecommendations {
+	targetMap := make(map[string]map[corev1.ResourceName]resource.Quantity, len(recommendation.ContainerRecommendations))
+	for _, c := range recommendation.ContainerRecommendations {
 		targetMap[c.ContainerName] = make(map[corev1.ResourceName]resource.Quantity, len(c.UpperBound))
 		for rn, r := range c.Target {
 			targetMap[c.ContainerName][rn] = r
 		}
 	}
 
+	backfilled := s.isBackfilledRecommendationEffective(tortoise, now)
 	for k, r := range tortoise.Status.Conditions.ContainerRecommendationFromVPA {
 		for rn, max := range r.MaxRecommendation {
 			currentUpperFromVPA := upperMap[r.ContainerName][rn]
@@ -394,6 +464,18 @@ func (s *Service) UpdateContainerRecommendationFromVPA(tortoise *v1beta3.Tortois
 				UpdatedAt: metav1.NewTime(now),
 			}
 
+			if backfilled {
+				// The recommendation backfilled from the usage history covers more history than VPA until the gathering data period passes after the backfill.
+				// So, we keep the larger one instead of replacing it with the current recommendation.
+				if backfilledRecommendation := r.Recommendation[rn].Quantity; backfilledRecommendation.Cmp(currentTargetFromVPA) < 0 {
+					tortoise.Status.Conditions.ContainerRecommendationFromVPA[k].Recommendation[rn] = rq
+				}
+				if currentMaxRecommendation.Cmp(currentTargetFromVPA) < 0 {
+					tortoise.Status.Conditions.ContainerRecommendationFromVPA[k].MaxRecommendation[rn] = rq
+				}
+				continue
+			}
+
 			// Always replace Recommendation with the current recommendation.
 			tortoise.Status.Conditions.ContainerRecommendationFromVPA[k].Recommendation[rn] = rq
 
@@ -417,6 +499,20 @@ func (s *Service) UpdateContainerRecommendationFromVPA(tortoise *v1beta3.Tortois
 	return tortoise
 }
 
+// isBackfilledRecommendationEffective checks if ContainerRecommendationFromVPA was backfilled from the usage history,
+// and the gathering data period hasn't passed since then.
+func (s *Service) isBackfilledRecommendationEffective(tortoise *v1beta3.Tortoise, now time.Time) bool {
+	cond := utils.GetTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeHistoryBackfilled)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		return false
+	}
+	period := 7 * 24 * time.Hour
+	if s.gatheringDataDuration == "daily" {
+		period = 24 * time.Hour
+	}
+	return cond.LastTransitionTime.Add(period).After(now)
+}
+
 func (s *Service) GetTortoise(ctx context.Context, namespacedName types.NamespacedName) (*v1beta3.Tortoise, error) {
 	t := &v1beta3.Tortoise{}
 	if err := s.c.Get(ctx, namespacedName, t); err != nil {
@@ -458,6 +554,76 @@ func (s *Service) AddFinalizer(ctx context.Context, tortoise *v1beta3.Tortoise)
 	return tortoise, nil
 }
 
+// ExpireEmergencyMode turns off Emergency mode if it's expired;
+// the time in the emergency-until annotation has passed, or the tortoise has been in Emergency mode longer than MaximumEmergencyModeDuration.
+// It changes .spec.updateMode to Auto and removes the annotation.
+// After that, UpdateTortoisePhase moves the tortoise to TortoisePhaseBackToNormal as usual.
+func (s *Service) ExpireEmergencyMode(ctx context.Context, tortoise *v1beta3.Tortoise, now time.Time) (*v1beta3.Tortoise, error) {
+	if tortoise.Spec.UpdateMode != v1beta3.UpdateModeEmergency {
+		return tortoise, nil
+	}
+	expiry, ok := s.emergencyModeExpiry(ctx, tortoise)
+	if !ok || now.Before(expiry) {
+		return tortoise, nil
+	}
+
+	until := tortoise.Annotations[annotation.EmergencyUntilAnnotation]
+	retTortoise := &v1beta3.Tortoise{}
+	updateFn := func() error {
+		retTortoise = &v1beta3.Tortoise{}
+		err := s.c.Get(ctx, client.ObjectKeyFromObject(tortoise), retTortoise)
+		if err != nil {
+			return err
+		}
+		if retTortoise.Spec.UpdateMode != v1beta3.UpdateModeEmergency || retTortoise.Annotations[annotation.EmergencyUntilAnnotation] != until {
+			// Someone has changed it in the meantime.
+			return nil
+		}
+		retTortoise.Spec.UpdateMode = v1beta3.UpdateModeAuto
+		delete(retTortoise.Annotations, annotation.EmergencyUntilAnnotation)
+		return s.c.Update(ctx, retTortoise)
+	}
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
+		return tortoise, fmt.Errorf("failed to turn off emergency mode: %w", err)
+	}
+
+	if retTortoise.Spec.UpdateMode == v1beta3.UpdateModeAuto {
+		s.recorder.Event(tortoise, corev1.EventTypeNormal, event.EmergencyModeExpired, fmt.Sprintf("Emergency mode is expired at %s. Tortoise changes updateMode to Auto", expiry.Format(time.RFC3339)))
+	}
+
+	// Keep the status because it's not updated yet in this reconciliation.
+	retTortoise.Status = tortoise.Status
+	return retTortoise, nil
+}
+
+// emergencyModeExpiry returns the earlier one of the time in the emergency-until annotation
+// and the time when the tortoise moved to Emergency mode + MaximumEmergencyModeDuration.
+// It returns false if Emergency mode of the tortoise doesn't expire.
+func (s *Service) emergencyModeExpiry(ctx context.Context, tortoise *v1beta3.Tortoise) (time.Time, bool) {
+	var expiry time.Time
+	if v, ok := tortoise.Annotations[annotation.EmergencyUntilAnnotation]; ok {
+		until, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			// shouldn't happen because the webhook validates it.
+			log.FromContext(ctx).Error(err, "invalid emergency-until annotation, ignore it", "tortoise", klog.KObj(tortoise))
+		} else {
+			expiry = until
+		}
+	}
+
+	if s.maximumEmergencyModeDuration != 0 {
+		c := utils.GetTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeEmergencyMode)
+		if c != nil && c.Status == corev1.ConditionTrue {
+			maxExpiry := c.LastTransitionTime.Add(s.maximumEmergencyModeDuration)
+			if expiry.IsZero() || maxExpiry.Before(expiry) {
+				expiry = maxExpiry
+			}
+		}
+	}
+
+	return expiry, !expiry.IsZero()
+}
+
 func (s *Service) RemoveFinalizer(ctx context.Context, tortoise *v1beta3.Tortoise) error {
 	if !controllerutil.ContainsFinalizer(tortoise, tortoiseFinalizer) {
 		return nil
@@ -492,7 +658,13 @@ func (s *Service) UpdateTortoiseStatus(ctx context.Context, originalTortoise *v1
 			return fmt.Errorf("get tortoise to update status: %w", err)
 		}
 		// It should be OK to overwrite the status, because the controller is the only person to update it.
+		// The exception is the constraints, which are managed by the recommenders in .spec.recommenders.
+		constraints := retTortoise.Status.Recommendations.Constraints
 		retTortoise.Status = originalTortoise.Status
+		retTortoise.Status.Recommendations.Constraints = nil
+		if HasRecommenderResponsibleFor(retTortoise, v1beta3.ResponsibleForConstraints) {
+			retTortoise.Status.Recommendations.Constraints = constraints
+		}
 
 		err = s.c.Status().Update(ctx, retTortoise)
 		if err != nil {
@@ -513,6 +685,33 @@ func (s *Service) UpdateTortoiseStatus(ctx context.Context, originalTortoise *v1
 	return retTortoise, nil
 }
 
+// HasRecommenderResponsibleFor returns true if any recommender in .spec.recommenders is responsible for the given kind of recommendation.
+func HasRecommenderResponsibleFor(tortoise *v1beta3.Tortoise, r v1beta3.ResponsibleFor) bool {
+	for _, recommender := range tortoise.Spec.Recommenders {
+		for _, rf := range recommender.ResponsibleFor {
+			if rf == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// InheritState copies the last update time of each Tortoise from old,
+// so that the Tortoises aren't reconciled all at once after the service is rebuilt on the config reload.
+func (s *Service) InheritState(old *Service) {
+	old.mu.RLock()
+	defer old.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, t := range old.lastTimeUpdateTortoise {
+		if _, ok := s.lastTimeUpdateTortoise[k]; !ok {
+			s.lastTimeUpdateTortoise[k] = t
+		}
+	}
+}
+
 func (s *Service) updateLastTimeUpdateTortoise(tortoise *v1beta3.Tortoise, now time.Time) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -581,12 +780,18 @@ func UpdateTortoiseAutoscalingPolicyInStatus(tortoise *v1beta3.Tortoise, hpa *v2
 		}
 	}
 
+	defaultCPUPolicy := v1beta3.AutoscalingTypeHorizontal
+	if tortoise.Spec.TargetRefs.ScaleTargetRef.Kind == "DaemonSet" {
+		// DaemonSet cannot be scaled horizontally.
+		defaultCPUPolicy = v1beta3.AutoscalingTypeVertical
+	}
+
 	lackingPolicies := containerNames.Difference(containersWithPolicy)
 	for _, p := range lackingPolicies.UnsortedList() {
 		tortoise.Status.AutoscalingPolicy = append(tortoise.Status.AutoscalingPolicy, v1beta3.ContainerAutoscalingPolicy{
 			ContainerName: p,
 			Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
-				corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+				corev1.ResourceCPU:    defaultCPUPolicy,
 				corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
 			},
 		})
@@ -667,9 +872,9 @@ type containerNameAndResource struct {
 // Updated ContainerResourceRequests will be used in the next mutating webhook of Pods.
 // It updates ContainerResourceRequests in the status of the Tortoise, when ALL the following conditions are met:
 //   - UpdateMode is Auto
-//   - Any of the recommended resource request is increased,
-//     OR, all the recommended resource request is decreased, but it's been a while (1h) after the last update.
-func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.Tortoise, replica int32, now time.Time) (
+//   - Any of the recommended resource request is increased, and it's been the scale up cooldown after the last update,
+//     OR, all the recommended resource request is decreased, and it's been the scale down cooldown (1h by default) after the last update.
+func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.Tortoise, replica int32, now time.Time, deferral *ApplyDeferral) (
 	*v1beta3.Tortoise,
 	error,
 ) {
@@ -764,7 +969,7 @@ func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.T
 
 	if tortoise.Status.Conditions.ContainerResourceRequests != nil && reflect.DeepEqual(newRequests, tortoise.Status.Conditions.ContainerResourceRequests) {
 		// If the recommendation is not changed at all, we don't need to update VPA and Pods.
-		return tortoise, nil
+		return resolveApplyDeferral(tortoise, "There're no new resource requests to apply", now), nil
 	}
 
 	// The recommendation will be applied to VPA and the deployment will be restarted with the new resources.
@@ -772,20 +977,49 @@ func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.T
 	tortoise.Status.Conditions.ContainerResourceRequests = newRequests
 
 	increased := recommendationIncreaseAnyResource(oldTortoise, tortoise)
+	cooldown := c.verticalCooldown(oldTortoise, tortoise, increased)
 	for _, v := range tortoise.Status.Conditions.TortoiseConditions {
 		if v.Type == v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated {
 			if v.Status == corev1.ConditionTrue {
-				// TODO: move the 1h to a config.
-				if v.LastTransitionTime.Add(time.Hour).After(now) && !increased {
-					// if all the recommended resources is decreased and it's NOT yet been 1h after the last update,
+				if v.LastTransitionTime.Add(cooldown).After(now) {
+					// if it's NOT yet been the scale up cooldown (when any of the recommended resources is increased)
+					// or the scale down cooldown (when all the recommended resources is decreased) after the last update,
 					// we don't want to update the Pod too frequently.
-					log.FromContext(ctx).Info("Skip applying vertical recommendation because it's been less than 1h since the last update", "tortoise", tortoise.Name, "namespace", tortoise.Namespace)
+					log.FromContext(ctx).Info("Skip applying vertical recommendation because it's been less than the cooldown since the last update", "tortoise", tortoise.Name, "namespace", tortoise.Namespace, "increased", increased, "cooldown", cooldown)
 					return oldTortoise, nil
 				}
 			}
 		}
 	}
 
+	if deferral != nil {
+		log.FromContext(ctx).Info("Defer applying vertical recommendation", "tortoise", klog.KObj(tortoise), "reason", deferral.Reason, "message", deferral.Message)
+		return deferApply(oldTortoise, deferral, now), nil
+	}
+
+	var heldDecreases []string
+	if !c.inMaintenanceWindow(now) {
+		// Outside the maintenance windows, only the increases are applied.
+		tortoise.Status.Conditions.ContainerResourceRequests, heldDecreases = keepIncreasesOnly(oldTortoise.Status.Conditions.ContainerResourceRequests, newRequests)
+		if len(heldDecreases) != 0 && reflect.DeepEqual(tortoise.Status.Conditions.ContainerResourceRequests, oldTortoise.Status.Conditions.ContainerResourceRequests) {
+			d := &ApplyDeferral{Reason: ReasonOutsideMaintenanceWindow, Message: fmt.Sprintf("The decreases of the resource requests (%s) wait for the next maintenance window", strings.Join(heldDecreases, ", "))}
+			log.FromContext(ctx).Info("Defer applying vertical recommendation", "tortoise", klog.KObj(tortoise), "reason", d.Reason, "message", d.Message)
+			return deferApply(oldTortoise, d, now), nil
+		}
+	}
+
+	if c.verticalApplyLimiter != nil && !c.verticalApplyLimiter.Allow() {
+		d := &ApplyDeferral{Reason: ReasonRateLimited, Message: "The new resource requests wait for the cluster-wide rate limit of the vertical applies"}
+		log.FromContext(ctx).Info("Defer applying vertical recommendation", "tortoise", klog.KObj(tortoise), "reason", d.Reason, "message", d.Message)
+		return deferApply(oldTortoise, d, now), nil
+	}
+
+	if len(heldDecreases) != 0 {
+		tortoise = deferApply(tortoise, &ApplyDeferral{Reason: ReasonOutsideMaintenanceWindow, Message: fmt.Sprintf("The increases of the resource requests are applied, but the decreases (%s) wait for the next maintenance window", strings.Join(heldDecreases, ", "))}, now)
+	} else {
+		tortoise = resolveApplyDeferral(tortoise, "The new resource requests are applied", now)
+	}
+
 	tortoise = utils.ChangeTortoiseCondition(tortoise,
 		v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
 		corev1.ConditionTrue,
@@ -822,6 +1056,145 @@ func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.T
 	return tortoise, nil
 }
 
+// AllowCanaryStep returns whether the canary rollout in progress can restart the Pods now,
+// that is, evict the Pods to create the canary Pods or promote the new resource requests to all the Pods.
+// They wait for the same things as the vertical applies: the deferral, the maintenance windows for the decreases and the cluster-wide rate limit.
+func (c *Service) AllowCanaryStep(ctx context.Context, tortoise *v1beta3.Tortoise, deferral *ApplyDeferral, now time.Time) (*v1beta3.Tortoise, bool) {
+	if deferral == nil && !c.inMaintenanceWindow(now) {
+		if _, held := keepIncreasesOnly(tortoise.Status.Conditions.ContainerResourceRequests, tortoise.Status.VerticalRollout.ContainerResourceRequests); len(held) != 0 {
+			deferral = &ApplyDeferral{Reason: ReasonOutsideMaintenanceWindow, Message: fmt.Sprintf("The canary rollout of the decreases of the resource requests (%s) waits for the next maintenance window", strings.Join(held, ", "))}
+		}
+	}
+	if deferral == nil && c.verticalApplyLimiter != nil && !c.verticalApplyLimiter.Allow() {
+		deferral = &ApplyDeferral{Reason: ReasonRateLimited, Message: "The canary rollout waits for the cluster-wide rate limit of the vertical applies"}
+	}
+	if deferral != nil {
+		log.FromContext(ctx).Info("Defer the canary rollout", "tortoise", klog.KObj(tortoise), "reason", deferral.Reason, "message", deferral.Message)
+		return deferApply(tortoise, deferral, now), false
+	}
+	return resolveApplyDeferral(tortoise, "The canary rollout proceeds", now), true
+}
+
+// ApplyDeferral holds back the new resource requests, e.g., while the target workload is in the middle of a rollout.
+type ApplyDeferral struct {
+	// Reason is the reason of the VerticalApplyDeferred condition.
+	Reason  string
+	Message string
+}
+
+const (
+	// ReasonOutsideMaintenanceWindow is the reason of the VerticalApplyDeferred condition
+	// when the decreases of the resource requests wait for the next maintenance window.
+	ReasonOutsideMaintenanceWindow = "OutsideMaintenanceWindow"
+	// ReasonRateLimited is the reason of the VerticalApplyDeferred condition
+	// when the new resource requests wait for the cluster-wide rate limit of the vertical applies.
+	ReasonRateLimited = "RateLimited"
+)
+
+// inMaintenanceWindow returns true when now is in any of the maintenance windows, or no maintenance window is configured.
+func (c *Service) inMaintenanceWindow(now time.Time) bool {
+	if len(c.maintenanceWindows) == 0 {
+		return true
+	}
+	now = now.In(c.timeZone)
+	for _, w := range c.maintenanceWindows {
+		if w.schedule.ActiveWithin(now, w.duration) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepIncreasesOnly returns the new requests in which the decreased resources are restored to the old requests,
+// and the descriptions of the restored resources.
+func keepIncreasesOnly(oldRequests, newRequests []v1beta3.ContainerResourceRequests) ([]v1beta3.ContainerResourceRequests, []string) {
+	oldRequestMap := map[string]corev1.ResourceList{}
+	for _, r := range oldRequests {
+		oldRequestMap[r.ContainerName] = r.Resource
+	}
+
+	var held []string
+	requests := make([]v1beta3.ContainerResourceRequests, 0, len(newRequests))
+	for _, r := range newRequests {
+		resources := corev1.ResourceList{}
+		for rn, newRequest := range r.Resource {
+			resources[rn] = newRequest
+			oldRequest, ok := oldRequestMap[r.ContainerName][rn]
+			if ok && oldRequest.Cmp(newRequest) > 0 {
+				resources[rn] = oldRequest
+				held = append(held, fmt.Sprintf("%s/%s", r.ContainerName, rn))
+			}
+		}
+		requests = append(requests, v1beta3.ContainerResourceRequests{ContainerName: r.ContainerName, Resource: resources})
+	}
+	sort.Strings(held)
+	return requests, held
+}
+
+// deferApply makes the VerticalApplyDeferred condition True.
+// Its lastTransitionTime is kept while the new resource requests are deferred for the same reason.
+func deferApply(tortoise *v1beta3.Tortoise, deferral *ApplyDeferral, now time.Time) *v1beta3.Tortoise {
+	if c := utils.GetTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeVerticalApplyDeferred); c != nil && c.Status == corev1.ConditionTrue && c.Reason == deferral.Reason {
+		c.Message = deferral.Message
+		c.LastUpdateTime = metav1.NewTime(now)
+		return tortoise
+	}
+	return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeVerticalApplyDeferred, corev1.ConditionTrue, deferral.Reason, deferral.Message, now)
+}
+
+// resolveApplyDeferral makes the VerticalApplyDeferred condition False if the new resource requests were deferred.
+func resolveApplyDeferral(tortoise *v1beta3.Tortoise, message string, now time.Time) *v1beta3.Tortoise {
+	if c := utils.GetTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeVerticalApplyDeferred); c == nil || c.Status != corev1.ConditionTrue {
+		return tortoise
+	}
+	return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeVerticalApplyDeferred, corev1.ConditionFalse, "", message, now)
+}
+
+// verticalCooldown returns the longest cooldown among the resources scaled up by the new requests if increased,
+// otherwise among the resources scaled down.
+func (c *Service) verticalCooldown(oldTortoise, newTortoise *v1beta3.Tortoise, increased bool) time.Duration {
+	defaultCooldown := c.verticalScaleDownCooldown
+	if increased {
+		defaultCooldown = c.verticalScaleUpCooldown
+	}
+
+	var cooldown time.Duration
+	changed := false
+	for _, new := range newTortoise.Status.Conditions.ContainerResourceRequests {
+		for _, old := range oldTortoise.Status.Conditions.ContainerResourceRequests {
+			if old.ContainerName != new.ContainerName {
+				continue
+			}
+			for rn, newRequest := range new.Resource {
+				oldRequest, ok := old.Resource[rn]
+				if !ok {
+					continue
+				}
+				if diff := oldRequest.Cmp(newRequest); (increased && diff >= 0) || (!increased && diff <= 0) {
+					continue
+				}
+				changed = true
+				c := defaultCooldown
+				behavior := utils.GetResourceBehavior(newTortoise, new.ContainerName, rn)
+				if d := behavior.VerticalScaleUpCooldown; increased && d != nil {
+					c = d.Duration
+				}
+				if d := behavior.VerticalScaleDownCooldown; !increased && d != nil {
+					c = d.Duration
+				}
+				if c > cooldown {
+					cooldown = c
+				}
+			}
+		}
+	}
+
+	if !changed {
+		return defaultCooldown
+	}
+	return cooldown
+}
+
 func recommendationIncreaseAnyResource(oldTortoise, newTortoise *v1beta3.Tortoise) bool {
 	if newTortoise.Status.Conditions.ContainerResourceRequests == nil {
 		// if newVPA doesn't have recommendation, it means we're going to remove the recommendation.
@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/time/rate"
 	appv1 "k8s.io/api/apps/v1"
 	v2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
@@ -22,6 +23,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/cron"
+	"github.com/mercari/tortoise/pkg/utils"
 )
 
 func TestService_updateUpperRecommendation(t *testing.T) {
@@ -514,7 +518,7 @@ func TestService_updateUpperRecommendation(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &Service{}
-			got := s.UpdateContainerRecommendationFromVPA(tt.tortoise, tt.vpa, time.Now())
+			got := s.UpdateContainerRecommendationFromVPA(tt.tortoise, tt.vpa.Status.Recommendation, time.Now())
 			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreTypes(metav1.Time{})); diff != "" {
 				t.Fatalf("diff: %s", diff)
 			}
@@ -522,6 +526,95 @@ func TestService_updateUpperRecommendation(t *testing.T) {
 	}
 }
 
+func TestService_UpdateContainerRecommendationFromVPA_Backfilled(t *testing.T) {
+	now := time.Date(2023, 3, 19, 0, 0, 0, 0, time.UTC)
+	recommendation := func(cpu, memory, maxCPU, maxMemory string) []v1beta3.ContainerRecommendationFromVPA {
+		return []v1beta3.ContainerRecommendationFromVPA{
+			{
+				ContainerName: "app",
+				Recommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+					corev1.ResourceCPU:    {Quantity: resource.MustParse(cpu)},
+					corev1.ResourceMemory: {Quantity: resource.MustParse(memory)},
+				},
+				MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+					corev1.ResourceCPU:    {Quantity: resource.MustParse(maxCPU)},
+					corev1.ResourceMemory: {Quantity: resource.MustParse(maxMemory)},
+				},
+			},
+		}
+	}
+	vpaRecommendation := &v1.RecommendedPodResources{
+		ContainerRecommendations: []v1.RecommendedContainerResources{
+			{
+				ContainerName: "app",
+				Target: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:    resource.MustParse("2"),
+					corev1.ResourceMemory: resource.MustParse("2Gi"),
+				},
+				UpperBound: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:    resource.MustParse("3"),
+					corev1.ResourceMemory: resource.MustParse("3Gi"),
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		backfilled *v1beta3.TortoiseCondition
+		current    []v1beta3.ContainerRecommendationFromVPA
+		want       []v1beta3.ContainerRecommendationFromVPA
+	}{
+		{
+			name: "keep the larger backfilled recommendation within the gathering data period",
+			backfilled: &v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeHistoryBackfilled,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+			},
+			current: recommendation("1", "4Gi", "1", "6Gi"),
+			want:    recommendation("2", "4Gi", "2", "6Gi"),
+		},
+		{
+			name: "replace the backfilled recommendation after the gathering data period",
+			backfilled: &v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeHistoryBackfilled,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now.Add(-24 * time.Hour)),
+			},
+			current: recommendation("1", "4Gi", "1", "6Gi"),
+			want:    recommendation("2", "2Gi", "2", "2Gi"),
+		},
+		{
+			name: "replace the recommendation when the backfill failed",
+			backfilled: &v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeHistoryBackfilled,
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+			},
+			current: recommendation("1", "4Gi", "1", "6Gi"),
+			want:    recommendation("2", "2Gi", "2", "2Gi"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{gatheringDataDuration: "daily"}
+			tortoise := &v1beta3.Tortoise{
+				Status: v1beta3.TortoiseStatus{
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{{ContainerName: "app"}},
+					Conditions: v1beta3.Conditions{
+						TortoiseConditions:             []v1beta3.TortoiseCondition{*tt.backfilled},
+						ContainerRecommendationFromVPA: tt.current,
+					},
+				},
+			}
+			got := s.UpdateContainerRecommendationFromVPA(tortoise, vpaRecommendation, now)
+			if diff := cmp.Diff(tt.want, got.Status.Conditions.ContainerRecommendationFromVPA, cmpopts.IgnoreTypes(metav1.Time{})); diff != "" {
+				t.Fatalf("diff: %s", diff)
+			}
+		})
+	}
+}
+
 func TestService_InitializeTortoise(t *testing.T) {
 	timeZone := "Asia/Tokyo"
 	jst, err := time.LoadLocation(timeZone)
@@ -1202,6 +1295,73 @@ func TestService_UpdateTortoiseStatus(t *testing.T) {
 	}
 }
 
+func TestService_UpdateTortoiseStatus_Constraints(t *testing.T) {
+	constraints := &v1beta3.Constraints{MinimumMinReplicas: ptr.To[int32](10)}
+	tests := []struct {
+		name             string
+		originalTortoise *v1beta3.Tortoise
+		t                *v1beta3.Tortoise
+		want             *v1beta3.Constraints
+	}{
+		{
+			name: "the constraints given by the recommender are kept",
+			originalTortoise: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{Name: "t", Namespace: "test"},
+				Spec: v1beta3.TortoiseSpec{Recommenders: []v1beta3.Recommender{
+					{Name: "ScheduledScaling", ResponsibleFor: []v1beta3.ResponsibleFor{v1beta3.ResponsibleForConstraints}},
+				}},
+				Status: v1beta3.TortoiseStatus{Recommendations: v1beta3.Recommendations{Constraints: constraints}},
+			},
+			// the tortoise in the controller doesn't know the constraints given in the meantime.
+			t: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{Name: "t", Namespace: "test"},
+				Status:     v1beta3.TortoiseStatus{TortoisePhase: v1beta3.TortoisePhaseWorking},
+			},
+			want: constraints,
+		},
+		{
+			name: "the constraints are removed when no recommender is responsible for them",
+			originalTortoise: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{Name: "t", Namespace: "test"},
+				Status:     v1beta3.TortoiseStatus{Recommendations: v1beta3.Recommendations{Constraints: constraints}},
+			},
+			t: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{Name: "t", Namespace: "test"},
+				Status: v1beta3.TortoiseStatus{
+					TortoisePhase:   v1beta3.TortoisePhaseWorking,
+					Recommendations: v1beta3.Recommendations{Constraints: constraints},
+				},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			err := v1beta3.AddToScheme(scheme)
+			if err != nil {
+				t.Fatalf("failed to add to scheme: %v", err)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.originalTortoise).WithStatusSubresource(&v1beta3.Tortoise{}).Build()
+			s := &Service{
+				c:                      c,
+				lastTimeUpdateTortoise: make(map[client.ObjectKey]time.Time),
+			}
+
+			got, err := s.UpdateTortoiseStatus(context.Background(), tt.t, time.Now(), false)
+			if err != nil {
+				t.Fatalf("UpdateTortoiseStatus() error = %v", err)
+			}
+			if d := cmp.Diff(tt.want, got.Status.Recommendations.Constraints); d != "" {
+				t.Errorf("UpdateTortoiseStatus() constraints diff = %v", d)
+			}
+			if got.Status.TortoisePhase != v1beta3.TortoisePhaseWorking {
+				t.Errorf("UpdateTortoiseStatus() phase = %v, want %v", got.Status.TortoisePhase, v1beta3.TortoisePhaseWorking)
+			}
+		})
+	}
+}
+
 func TestService_RecordReconciliationFailure(t *testing.T) {
 	now := time.Now()
 	type args struct {
@@ -2632,6 +2792,79 @@ func TestUpdateTortoiseAutoscalingPolicyInStatus(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "autoscaling policy is empty, and the DaemonSet container doesn't have policy",
+			args: args{
+				tortoise: &v1beta3.Tortoise{
+					Spec: v1beta3.TortoiseSpec{
+						TargetRefs: v1beta3.TargetRefs{
+							ScaleTargetRef: v1beta3.CrossVersionObjectReference{
+								Kind: "DaemonSet",
+								Name: "daemonset",
+							},
+						},
+					},
+					Status: v1beta3.TortoiseStatus{
+						Conditions: v1beta3.Conditions{
+							ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+								{
+									ContainerName: "app",
+									Resource: corev1.ResourceList{
+										corev1.ResourceCPU:    resource.MustParse("1"),
+										corev1.ResourceMemory: resource.MustParse("1Gi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: &v1beta3.Tortoise{
+				Spec: v1beta3.TortoiseSpec{
+					TargetRefs: v1beta3.TargetRefs{
+						ScaleTargetRef: v1beta3.CrossVersionObjectReference{
+							Kind: "DaemonSet",
+							Name: "daemonset",
+						},
+					},
+				},
+				Status: v1beta3.TortoiseStatus{
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{
+							ContainerName: "app",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+							},
+						},
+					},
+					Conditions: v1beta3.Conditions{
+						ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+							{
+								ContainerName: "app",
+								Resource: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("1"),
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+								},
+							},
+						},
+					},
+					ContainerResourcePhases: []v1beta3.ContainerResourcePhases{
+						{
+							ContainerName: "app",
+							ResourcePhases: map[corev1.ResourceName]v1beta3.ResourcePhase{
+								corev1.ResourceCPU: {
+									Phase: v1beta3.ContainerResourcePhaseGatheringData,
+								},
+								corev1.ResourceMemory: {
+									Phase: v1beta3.ContainerResourcePhaseGatheringData,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "autoscaling policy is empty, and some containers don't have policy, but some resources doesn't have resource request",
 			args: args{
@@ -4051,7 +4284,7 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 			},
 		},
 		{
-			name: "ContainerResourceRequests is not modified and podShouldBeUpdatedWithNewResource:false is always returned",
+			name: "The recommendation is smaller than before, and we don't recently update the value, but the scale down cooldown of a decreased resource is longer",
 			tortoise: &v1beta3.Tortoise{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "tortoise",
@@ -4059,6 +4292,14 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 				},
 				Spec: v1beta3.TortoiseSpec{
 					UpdateMode: v1beta3.UpdateModeAuto,
+					ResourcePolicy: []v1beta3.ContainerResourcePolicy{
+						{
+							ContainerName: "app",
+							Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+								corev1.ResourceMemory: {VerticalScaleDownCooldown: &metav1.Duration{Duration: 6 * time.Hour}},
+							},
+						},
+					},
 				},
 				Status: v1beta3.TortoiseStatus{
 					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
@@ -4078,20 +4319,29 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 						},
 					},
 					Conditions: v1beta3.Conditions{
+						TortoiseConditions: []v1beta3.TortoiseCondition{
+							{
+								Type:   v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
+								Status: corev1.ConditionTrue,
+								// Not recently
+								LastTransitionTime: metav1.NewTime(now.Add(-3 * time.Hour)),
+								LastUpdateTime:     metav1.NewTime(now.Add(-3 * time.Hour)),
+								Message:            "The recommendation is provided",
+							},
+						},
 						ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
-							// updated
 							{
 								ContainerName: "app",
 								Resource: corev1.ResourceList{
-									corev1.ResourceMemory: resource.MustParse("1Gi"),
-									corev1.ResourceCPU:    resource.MustParse("1"),
+									corev1.ResourceMemory: resource.MustParse("2Gi"),
+									corev1.ResourceCPU:    resource.MustParse("2"),
 								},
 							},
 							{
 								ContainerName: "sidecar",
 								Resource: corev1.ResourceList{
-									corev1.ResourceMemory: resource.MustParse("1Gi"),
-									corev1.ResourceCPU:    resource.MustParse("1"),
+									corev1.ResourceMemory: resource.MustParse("2Gi"),
+									corev1.ResourceCPU:    resource.MustParse("2"),
 								},
 							},
 						},
@@ -4125,6 +4375,14 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 				},
 				Spec: v1beta3.TortoiseSpec{
 					UpdateMode: v1beta3.UpdateModeAuto,
+					ResourcePolicy: []v1beta3.ContainerResourcePolicy{
+						{
+							ContainerName: "app",
+							Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+								corev1.ResourceMemory: {VerticalScaleDownCooldown: &metav1.Duration{Duration: 6 * time.Hour}},
+							},
+						},
+					},
 				},
 				Status: v1beta3.TortoiseStatus{
 					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
@@ -4144,19 +4402,28 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 						},
 					},
 					Conditions: v1beta3.Conditions{
+						TortoiseConditions: []v1beta3.TortoiseCondition{
+							{
+								Type:               v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
+								Status:             corev1.ConditionTrue,
+								LastTransitionTime: metav1.NewTime(now.Add(-3 * time.Hour)),
+								LastUpdateTime:     metav1.NewTime(now.Add(-3 * time.Hour)),
+								Message:            "The recommendation is provided",
+							},
+						},
 						ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
 							{
 								ContainerName: "app",
 								Resource: corev1.ResourceList{
-									corev1.ResourceMemory: resource.MustParse("1Gi"),
-									corev1.ResourceCPU:    resource.MustParse("1"),
+									corev1.ResourceMemory: resource.MustParse("2Gi"),
+									corev1.ResourceCPU:    resource.MustParse("2"),
 								},
 							},
 							{
 								ContainerName: "sidecar",
 								Resource: corev1.ResourceList{
-									corev1.ResourceMemory: resource.MustParse("1Gi"),
-									corev1.ResourceCPU:    resource.MustParse("1"),
+									corev1.ResourceMemory: resource.MustParse("2Gi"),
+									corev1.ResourceCPU:    resource.MustParse("2"),
 								},
 							},
 						},
@@ -4185,14 +4452,22 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 			},
 		},
 		{
-			name: "ContainerResourceRequests is NOT modified when tortoise is Off mode",
+			name: "The recommendation is bigger than before, and we don't recently update the value, but the scale up cooldown of an increased resource is longer",
 			tortoise: &v1beta3.Tortoise{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "tortoise",
 					Namespace: "default",
 				},
 				Spec: v1beta3.TortoiseSpec{
-					UpdateMode: v1beta3.UpdateModeOff,
+					UpdateMode: v1beta3.UpdateModeAuto,
+					ResourcePolicy: []v1beta3.ContainerResourcePolicy{
+						{
+							ContainerName: "app",
+							Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+								corev1.ResourceMemory: {VerticalScaleUpCooldown: &metav1.Duration{Duration: 6 * time.Hour}},
+							},
+						},
+					},
 				},
 				Status: v1beta3.TortoiseStatus{
 					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
@@ -4212,8 +4487,32 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 						},
 					},
 					Conditions: v1beta3.Conditions{
-						// empty
-						ContainerResourceRequests: nil,
+						TortoiseConditions: []v1beta3.TortoiseCondition{
+							{
+								Type:   v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
+								Status: corev1.ConditionTrue,
+								// Not recently
+								LastTransitionTime: metav1.NewTime(now.Add(-3 * time.Hour)),
+								LastUpdateTime:     metav1.NewTime(now.Add(-3 * time.Hour)),
+								Message:            "The recommendation is provided",
+							},
+						},
+						ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+							{
+								ContainerName: "app",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+									corev1.ResourceCPU:    resource.MustParse("1"),
+								},
+							},
+							{
+								ContainerName: "sidecar",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+									corev1.ResourceCPU:    resource.MustParse("1"),
+								},
+							},
+						},
 					},
 					Recommendations: v1beta3.Recommendations{
 						Vertical: v1beta3.VerticalRecommendations{
@@ -4243,7 +4542,15 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 					Namespace: "default",
 				},
 				Spec: v1beta3.TortoiseSpec{
-					UpdateMode: v1beta3.UpdateModeOff,
+					UpdateMode: v1beta3.UpdateModeAuto,
+					ResourcePolicy: []v1beta3.ContainerResourcePolicy{
+						{
+							ContainerName: "app",
+							Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+								corev1.ResourceMemory: {VerticalScaleUpCooldown: &metav1.Duration{Duration: 6 * time.Hour}},
+							},
+						},
+					},
 				},
 				Status: v1beta3.TortoiseStatus{
 					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
@@ -4266,14 +4573,28 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 						TortoiseConditions: []v1beta3.TortoiseCondition{
 							{
 								Type:               v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
-								Status:             corev1.ConditionFalse,
-								LastTransitionTime: metav1.NewTime(now),
-								LastUpdateTime:     metav1.NewTime(now),
-								Message:            "The recommendation is not provided because it's Off mode",
+								Status:             corev1.ConditionTrue,
+								LastTransitionTime: metav1.NewTime(now.Add(-3 * time.Hour)),
+								LastUpdateTime:     metav1.NewTime(now.Add(-3 * time.Hour)),
+								Message:            "The recommendation is provided",
+							},
+						},
+						ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+							{
+								ContainerName: "app",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+									corev1.ResourceCPU:    resource.MustParse("1"),
+								},
+							},
+							{
+								ContainerName: "sidecar",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+									corev1.ResourceCPU:    resource.MustParse("1"),
+								},
 							},
 						},
-						// still empty because it's Off.
-						ContainerResourceRequests: nil,
 					},
 					Recommendations: v1beta3.Recommendations{
 						Vertical: v1beta3.VerticalRecommendations{
@@ -4299,14 +4620,447 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 			},
 		},
 		{
-			name: "ContainerResourceRequests is removed when tortoise is Off mode (= probably this tortoise is changed back from auto to off)",
+			name: "The recommendation is smaller than before, and we recently update the value, but the scale down cooldown of all the decreased resources has passed",
 			tortoise: &v1beta3.Tortoise{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "tortoise",
 					Namespace: "default",
 				},
 				Spec: v1beta3.TortoiseSpec{
-					UpdateMode: v1beta3.UpdateModeOff,
+					UpdateMode: v1beta3.UpdateModeAuto,
+					ResourcePolicy: []v1beta3.ContainerResourcePolicy{
+						{
+							ContainerName: "app",
+							Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+								corev1.ResourceCPU:    {VerticalScaleDownCooldown: &metav1.Duration{Duration: 30 * time.Second}},
+								corev1.ResourceMemory: {VerticalScaleDownCooldown: &metav1.Duration{Duration: 30 * time.Second}},
+							},
+						},
+						{
+							ContainerName: "sidecar",
+							Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+								corev1.ResourceCPU:    {VerticalScaleDownCooldown: &metav1.Duration{Duration: 30 * time.Second}},
+								corev1.ResourceMemory: {VerticalScaleDownCooldown: &metav1.Duration{Duration: 30 * time.Second}},
+							},
+						},
+					},
+				},
+				Status: v1beta3.TortoiseStatus{
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{
+							ContainerName: "app",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+						{
+							ContainerName: "sidecar",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+					},
+					Conditions: v1beta3.Conditions{
+						TortoiseConditions: []v1beta3.TortoiseCondition{
+							{
+								Type:   v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
+								Status: corev1.ConditionTrue,
+								// Recently, but longer than the cooldown
+								LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)),
+								LastUpdateTime:     metav1.NewTime(now.Add(-time.Minute)),
+								Message:            "The recommendation is provided",
+							},
+						},
+						ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+							{
+								ContainerName: "app",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("2Gi"),
+									corev1.ResourceCPU:    resource.MustParse("2"),
+								},
+							},
+							{
+								ContainerName: "sidecar",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("2Gi"),
+									corev1.ResourceCPU:    resource.MustParse("2"),
+								},
+							},
+						},
+					},
+					Recommendations: v1beta3.Recommendations{
+						Vertical: v1beta3.VerticalRecommendations{
+							ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+								{
+									ContainerName: "app",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("1Gi"),
+										corev1.ResourceCPU:    resource.MustParse("1"),
+									},
+								},
+								{
+									ContainerName: "sidecar",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("1Gi"),
+										corev1.ResourceCPU:    resource.MustParse("1"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantTortoise: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tortoise",
+					Namespace: "default",
+				},
+				Spec: v1beta3.TortoiseSpec{
+					UpdateMode: v1beta3.UpdateModeAuto,
+					ResourcePolicy: []v1beta3.ContainerResourcePolicy{
+						{
+							ContainerName: "app",
+							Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+								corev1.ResourceCPU:    {VerticalScaleDownCooldown: &metav1.Duration{Duration: 30 * time.Second}},
+								corev1.ResourceMemory: {VerticalScaleDownCooldown: &metav1.Duration{Duration: 30 * time.Second}},
+							},
+						},
+						{
+							ContainerName: "sidecar",
+							Behavior: map[corev1.ResourceName]v1beta3.ResourceBehavior{
+								corev1.ResourceCPU:    {VerticalScaleDownCooldown: &metav1.Duration{Duration: 30 * time.Second}},
+								corev1.ResourceMemory: {VerticalScaleDownCooldown: &metav1.Duration{Duration: 30 * time.Second}},
+							},
+						},
+					},
+				},
+				Status: v1beta3.TortoiseStatus{
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{
+							ContainerName: "app",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+						{
+							ContainerName: "sidecar",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+					},
+					Conditions: v1beta3.Conditions{
+						TortoiseConditions: []v1beta3.TortoiseCondition{
+							{
+								Type:               v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
+								Status:             corev1.ConditionTrue,
+								LastTransitionTime: metav1.NewTime(now),
+								LastUpdateTime:     metav1.NewTime(now),
+								Message:            "The recommendation is provided",
+							},
+						},
+						ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+							// updated
+							{
+								ContainerName: "app",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+									corev1.ResourceCPU:    resource.MustParse("1"),
+								},
+							},
+							{
+								ContainerName: "sidecar",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+									corev1.ResourceCPU:    resource.MustParse("1"),
+								},
+							},
+						},
+					},
+					Recommendations: v1beta3.Recommendations{
+						Vertical: v1beta3.VerticalRecommendations{
+							ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+								{
+									ContainerName: "app",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("1Gi"),
+										corev1.ResourceCPU:    resource.MustParse("1"),
+									},
+								},
+								{
+									ContainerName: "sidecar",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("1Gi"),
+										corev1.ResourceCPU:    resource.MustParse("1"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ContainerResourceRequests is not modified and podShouldBeUpdatedWithNewResource:false is always returned",
+			tortoise: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tortoise",
+					Namespace: "default",
+				},
+				Spec: v1beta3.TortoiseSpec{
+					UpdateMode: v1beta3.UpdateModeAuto,
+				},
+				Status: v1beta3.TortoiseStatus{
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{
+							ContainerName: "app",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+						{
+							ContainerName: "sidecar",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+					},
+					Conditions: v1beta3.Conditions{
+						ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+							// updated
+							{
+								ContainerName: "app",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+									corev1.ResourceCPU:    resource.MustParse("1"),
+								},
+							},
+							{
+								ContainerName: "sidecar",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+									corev1.ResourceCPU:    resource.MustParse("1"),
+								},
+							},
+						},
+					},
+					Recommendations: v1beta3.Recommendations{
+						Vertical: v1beta3.VerticalRecommendations{
+							ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+								{
+									ContainerName: "app",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("1Gi"),
+										corev1.ResourceCPU:    resource.MustParse("1"),
+									},
+								},
+								{
+									ContainerName: "sidecar",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("1Gi"),
+										corev1.ResourceCPU:    resource.MustParse("1"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantTortoise: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tortoise",
+					Namespace: "default",
+				},
+				Spec: v1beta3.TortoiseSpec{
+					UpdateMode: v1beta3.UpdateModeAuto,
+				},
+				Status: v1beta3.TortoiseStatus{
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{
+							ContainerName: "app",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+						{
+							ContainerName: "sidecar",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+					},
+					Conditions: v1beta3.Conditions{
+						ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+							{
+								ContainerName: "app",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+									corev1.ResourceCPU:    resource.MustParse("1"),
+								},
+							},
+							{
+								ContainerName: "sidecar",
+								Resource: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("1Gi"),
+									corev1.ResourceCPU:    resource.MustParse("1"),
+								},
+							},
+						},
+					},
+					Recommendations: v1beta3.Recommendations{
+						Vertical: v1beta3.VerticalRecommendations{
+							ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+								{
+									ContainerName: "app",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("1Gi"),
+										corev1.ResourceCPU:    resource.MustParse("1"),
+									},
+								},
+								{
+									ContainerName: "sidecar",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("1Gi"),
+										corev1.ResourceCPU:    resource.MustParse("1"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ContainerResourceRequests is NOT modified when tortoise is Off mode",
+			tortoise: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tortoise",
+					Namespace: "default",
+				},
+				Spec: v1beta3.TortoiseSpec{
+					UpdateMode: v1beta3.UpdateModeOff,
+				},
+				Status: v1beta3.TortoiseStatus{
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{
+							ContainerName: "app",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+						{
+							ContainerName: "sidecar",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+					},
+					Conditions: v1beta3.Conditions{
+						// empty
+						ContainerResourceRequests: nil,
+					},
+					Recommendations: v1beta3.Recommendations{
+						Vertical: v1beta3.VerticalRecommendations{
+							ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+								{
+									ContainerName: "app",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("2Gi"),
+										corev1.ResourceCPU:    resource.MustParse("2"),
+									},
+								},
+								{
+									ContainerName: "sidecar",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("2Gi"),
+										corev1.ResourceCPU:    resource.MustParse("2"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantTortoise: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tortoise",
+					Namespace: "default",
+				},
+				Spec: v1beta3.TortoiseSpec{
+					UpdateMode: v1beta3.UpdateModeOff,
+				},
+				Status: v1beta3.TortoiseStatus{
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{
+							ContainerName: "app",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+						{
+							ContainerName: "sidecar",
+							Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+								corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+								corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+					},
+					Conditions: v1beta3.Conditions{
+						TortoiseConditions: []v1beta3.TortoiseCondition{
+							{
+								Type:               v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
+								Status:             corev1.ConditionFalse,
+								LastTransitionTime: metav1.NewTime(now),
+								LastUpdateTime:     metav1.NewTime(now),
+								Message:            "The recommendation is not provided because it's Off mode",
+							},
+						},
+						// still empty because it's Off.
+						ContainerResourceRequests: nil,
+					},
+					Recommendations: v1beta3.Recommendations{
+						Vertical: v1beta3.VerticalRecommendations{
+							ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+								{
+									ContainerName: "app",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("2Gi"),
+										corev1.ResourceCPU:    resource.MustParse("2"),
+									},
+								},
+								{
+									ContainerName: "sidecar",
+									RecommendedResource: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("2Gi"),
+										corev1.ResourceCPU:    resource.MustParse("2"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ContainerResourceRequests is removed when tortoise is Off mode (= probably this tortoise is changed back from auto to off)",
+			tortoise: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tortoise",
+					Namespace: "default",
+				},
+				Spec: v1beta3.TortoiseSpec{
+					UpdateMode: v1beta3.UpdateModeOff,
 				},
 				Status: v1beta3.TortoiseStatus{
 					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
@@ -4590,10 +5344,11 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := &Service{
-				recorder: record.NewFakeRecorder(10),
+				recorder:                  record.NewFakeRecorder(10),
+				verticalScaleDownCooldown: time.Hour,
 			}
 
-			gotTortoise, err := c.UpdateResourceRequest(context.Background(), tt.tortoise.DeepCopy(), 10, now)
+			gotTortoise, err := c.UpdateResourceRequest(context.Background(), tt.tortoise.DeepCopy(), 10, now, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Service.UpdateResourceRequest() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -4606,6 +5361,253 @@ func TestService_UpdateResourceRequest(t *testing.T) {
 	}
 }
 
+func TestService_UpdateResourceRequest_Deferral(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	requests := func(memory string) []v1beta3.ContainerResourceRequests {
+		return []v1beta3.ContainerResourceRequests{
+			{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)}},
+		}
+	}
+	// testTortoise returns the tortoise which requests 1Gi memory and gets the given recommendation.
+	testTortoise := func(recommendation string, conditions ...v1beta3.TortoiseCondition) *v1beta3.Tortoise {
+		return &v1beta3.Tortoise{
+			ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+			Spec:       v1beta3.TortoiseSpec{UpdateMode: v1beta3.UpdateModeAuto},
+			Status: v1beta3.TortoiseStatus{
+				Conditions: v1beta3.Conditions{
+					TortoiseConditions:        conditions,
+					ContainerResourceRequests: requests("1Gi"),
+				},
+				Recommendations: v1beta3.Recommendations{
+					Vertical: v1beta3.VerticalRecommendations{
+						ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+							{ContainerName: "app", RecommendedResource: requests(recommendation)[0].Resource},
+						},
+					},
+				},
+			},
+		}
+	}
+	deferred := v1beta3.TortoiseCondition{
+		Type:               v1beta3.TortoiseConditionTypeVerticalApplyDeferred,
+		Status:             corev1.ConditionTrue,
+		Reason:             "RolloutInProgress",
+		Message:            "rollout",
+		LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+		LastUpdateTime:     metav1.NewTime(now.Add(-time.Hour)),
+	}
+
+	tests := []struct {
+		name          string
+		tortoise      *v1beta3.Tortoise
+		deferral      *ApplyDeferral
+		wantRequests  []v1beta3.ContainerResourceRequests
+		wantCondition *v1beta3.TortoiseCondition
+	}{
+		{
+			name:         "defer the new resource requests",
+			tortoise:     testTortoise("2Gi"),
+			deferral:     &ApplyDeferral{Reason: "RolloutInProgress", Message: "rollout"},
+			wantRequests: requests("1Gi"),
+			wantCondition: &v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeVerticalApplyDeferred,
+				Status:             corev1.ConditionTrue,
+				Reason:             "RolloutInProgress",
+				Message:            "rollout",
+				LastTransitionTime: metav1.NewTime(now),
+				LastUpdateTime:     metav1.NewTime(now),
+			},
+		},
+		{
+			name:         "keep lastTransitionTime while it's deferred for the same reason",
+			tortoise:     testTortoise("2Gi", deferred),
+			deferral:     &ApplyDeferral{Reason: "RolloutInProgress", Message: "still rollout"},
+			wantRequests: requests("1Gi"),
+			wantCondition: &v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeVerticalApplyDeferred,
+				Status:             corev1.ConditionTrue,
+				Reason:             "RolloutInProgress",
+				Message:            "still rollout",
+				LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+				LastUpdateTime:     metav1.NewTime(now),
+			},
+		},
+		{
+			name:         "apply the deferred resource requests",
+			tortoise:     testTortoise("2Gi", deferred),
+			wantRequests: requests("2Gi"),
+			wantCondition: &v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeVerticalApplyDeferred,
+				Status:             corev1.ConditionFalse,
+				Message:            "The new resource requests are applied",
+				LastTransitionTime: metav1.NewTime(now),
+				LastUpdateTime:     metav1.NewTime(now),
+			},
+		},
+		{
+			name:         "resolve the deferral when there's nothing to apply",
+			tortoise:     testTortoise("1Gi", deferred),
+			deferral:     &ApplyDeferral{Reason: "RolloutInProgress", Message: "rollout"},
+			wantRequests: requests("1Gi"),
+			wantCondition: &v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeVerticalApplyDeferred,
+				Status:             corev1.ConditionFalse,
+				Message:            "There're no new resource requests to apply",
+				LastTransitionTime: metav1.NewTime(now),
+				LastUpdateTime:     metav1.NewTime(now),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Service{
+				recorder: record.NewFakeRecorder(10),
+			}
+
+			got, err := c.UpdateResourceRequest(context.Background(), tt.tortoise, 10, now, tt.deferral)
+			if err != nil {
+				t.Fatalf("Service.UpdateResourceRequest() error = %v", err)
+			}
+			if d := cmp.Diff(tt.wantRequests, got.Status.Conditions.ContainerResourceRequests); d != "" {
+				t.Errorf("Service.UpdateResourceRequest() requests mismatch (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(tt.wantCondition, utils.GetTortoiseCondition(got, v1beta3.TortoiseConditionTypeVerticalApplyDeferred)); d != "" {
+				t.Errorf("Service.UpdateResourceRequest() condition mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestService_UpdateResourceRequest_MaintenanceWindowAndRateLimit(t *testing.T) {
+	// 2023-01-01 00:00 is Sunday.
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	requests := func(cpu, memory string) []v1beta3.ContainerResourceRequests {
+		return []v1beta3.ContainerResourceRequests{
+			{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)}},
+		}
+	}
+	// testTortoise returns the tortoise which requests 1 CPU and 1Gi memory and gets the given recommendation.
+	testTortoise := func(cpu, memory string) *v1beta3.Tortoise {
+		return &v1beta3.Tortoise{
+			ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+			Spec:       v1beta3.TortoiseSpec{UpdateMode: v1beta3.UpdateModeAuto},
+			Status: v1beta3.TortoiseStatus{
+				Conditions: v1beta3.Conditions{
+					ContainerResourceRequests: requests("1", "1Gi"),
+				},
+				Recommendations: v1beta3.Recommendations{
+					Vertical: v1beta3.VerticalRecommendations{
+						ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+							{ContainerName: "app", RecommendedResource: requests(cpu, memory)[0].Resource},
+						},
+					},
+				},
+			},
+		}
+	}
+	mustParse := func(spec string) *cron.Schedule {
+		s, err := cron.Parse(spec)
+		if err != nil {
+			t.Fatalf("cron.Parse() error = %v", err)
+		}
+		return s
+	}
+	// From 2:00 to 5:00 every day, which doesn't contain now.
+	outside := []maintenanceWindow{{schedule: mustParse("0 2 * * *"), duration: 3 * time.Hour}}
+	// From 23:00 to 1:00 every day, which contains now.
+	inside := []maintenanceWindow{{schedule: mustParse("0 23 * * *"), duration: 2 * time.Hour}}
+	exhausted := func() *rate.Limiter {
+		l := rate.NewLimiter(rate.Limit(1.0/60), 1)
+		l.Allow()
+		return l
+	}
+
+	tests := []struct {
+		name          string
+		tortoise      *v1beta3.Tortoise
+		windows       []maintenanceWindow
+		limiter       *rate.Limiter
+		wantRequests  []v1beta3.ContainerResourceRequests
+		wantCondition *v1beta3.TortoiseCondition
+	}{
+		{
+			name:         "hold the decreases outside the maintenance windows",
+			tortoise:     testTortoise("500m", "512Mi"),
+			windows:      outside,
+			wantRequests: requests("1", "1Gi"),
+			wantCondition: &v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeVerticalApplyDeferred,
+				Status:             corev1.ConditionTrue,
+				Reason:             ReasonOutsideMaintenanceWindow,
+				Message:            "The decreases of the resource requests (app/cpu, app/memory) wait for the next maintenance window",
+				LastTransitionTime: metav1.NewTime(now),
+				LastUpdateTime:     metav1.NewTime(now),
+			},
+		},
+		{
+			name:         "apply only the increases outside the maintenance windows",
+			tortoise:     testTortoise("2", "512Mi"),
+			windows:      outside,
+			wantRequests: requests("2", "1Gi"),
+			wantCondition: &v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeVerticalApplyDeferred,
+				Status:             corev1.ConditionTrue,
+				Reason:             ReasonOutsideMaintenanceWindow,
+				Message:            "The increases of the resource requests are applied, but the decreases (app/memory) wait for the next maintenance window",
+				LastTransitionTime: metav1.NewTime(now),
+				LastUpdateTime:     metav1.NewTime(now),
+			},
+		},
+		{
+			name:         "apply the decreases in the maintenance window",
+			tortoise:     testTortoise("500m", "512Mi"),
+			windows:      inside,
+			wantRequests: requests("500m", "512Mi"),
+		},
+		{
+			name:         "defer the new resource requests when the rate limit is exceeded",
+			tortoise:     testTortoise("2", "2Gi"),
+			limiter:      exhausted(),
+			wantRequests: requests("1", "1Gi"),
+			wantCondition: &v1beta3.TortoiseCondition{
+				Type:               v1beta3.TortoiseConditionTypeVerticalApplyDeferred,
+				Status:             corev1.ConditionTrue,
+				Reason:             ReasonRateLimited,
+				Message:            "The new resource requests wait for the cluster-wide rate limit of the vertical applies",
+				LastTransitionTime: metav1.NewTime(now),
+				LastUpdateTime:     metav1.NewTime(now),
+			},
+		},
+		{
+			name:         "apply the new resource requests within the rate limit",
+			tortoise:     testTortoise("2", "2Gi"),
+			limiter:      rate.NewLimiter(rate.Limit(1.0/60), 1),
+			wantRequests: requests("2", "2Gi"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Service{
+				recorder:             record.NewFakeRecorder(10),
+				timeZone:             time.UTC,
+				maintenanceWindows:   tt.windows,
+				verticalApplyLimiter: tt.limiter,
+			}
+
+			got, err := c.UpdateResourceRequest(context.Background(), tt.tortoise, 10, now, nil)
+			if err != nil {
+				t.Fatalf("Service.UpdateResourceRequest() error = %v", err)
+			}
+			if d := cmp.Diff(tt.wantRequests, got.Status.Conditions.ContainerResourceRequests); d != "" {
+				t.Errorf("Service.UpdateResourceRequest() requests mismatch (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(tt.wantCondition, utils.GetTortoiseCondition(got, v1beta3.TortoiseConditionTypeVerticalApplyDeferred)); d != "" {
+				t.Errorf("Service.UpdateResourceRequest() condition mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
 func TestService_IsGlobalDisableModeEnabled(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -5133,3 +6135,321 @@ func TestService_UpdateTortoisePhase(t *testing.T) {
 		})
 	}
 }
+
+func TestService_ExpireEmergencyMode(t *testing.T) {
+	now := time.Date(2023, 3, 19, 0, 0, 0, 0, time.UTC)
+	emergencyTortoise := func(until string, since time.Time) *v1beta3.Tortoise {
+		t := &v1beta3.Tortoise{
+			ObjectMeta: metav1.ObjectMeta{Name: "t", Namespace: "test"},
+			Spec:       v1beta3.TortoiseSpec{UpdateMode: v1beta3.UpdateModeEmergency},
+			Status: v1beta3.TortoiseStatus{
+				TortoisePhase: v1beta3.TortoisePhaseEmergency,
+				Conditions: v1beta3.Conditions{
+					TortoiseConditions: []v1beta3.TortoiseCondition{
+						{
+							Type:               v1beta3.TortoiseConditionTypeEmergencyMode,
+							Status:             corev1.ConditionTrue,
+							LastTransitionTime: metav1.NewTime(since),
+						},
+					},
+				},
+			},
+		}
+		if until != "" {
+			t.Annotations = map[string]string{annotation.EmergencyUntilAnnotation: until}
+		}
+		return t
+	}
+	tests := []struct {
+		name                         string
+		tortoise                     *v1beta3.Tortoise
+		maximumEmergencyModeDuration time.Duration
+		wantUpdateMode               v1beta3.UpdateMode
+		wantAnnotation               bool
+		wantEvent                    bool
+	}{
+		{
+			name:           "emergency mode is expired",
+			tortoise:       emergencyTortoise(now.Add(-time.Minute).Format(time.RFC3339), now.Add(-time.Hour)),
+			wantUpdateMode: v1beta3.UpdateModeAuto,
+			wantAnnotation: false,
+			wantEvent:      true,
+		},
+		{
+			name:           "emergency mode is not expired yet",
+			tortoise:       emergencyTortoise(now.Add(time.Minute).Format(time.RFC3339), now.Add(-time.Hour)),
+			wantUpdateMode: v1beta3.UpdateModeEmergency,
+			wantAnnotation: true,
+		},
+		{
+			name:           "emergency mode without the annotation",
+			tortoise:       emergencyTortoise("", now.Add(-time.Hour)),
+			wantUpdateMode: v1beta3.UpdateModeEmergency,
+		},
+		{
+			name: "the annotation is ignored when the tortoise is not in emergency mode",
+			tortoise: func() *v1beta3.Tortoise {
+				t := emergencyTortoise(now.Add(-time.Minute).Format(time.RFC3339), now.Add(-time.Hour))
+				t.Spec.UpdateMode = v1beta3.UpdateModeOff
+				return t
+			}(),
+			wantUpdateMode: v1beta3.UpdateModeOff,
+			wantAnnotation: true,
+		},
+		{
+			name:                         "emergency mode exceeds MaximumEmergencyModeDuration",
+			tortoise:                     emergencyTortoise("", now.Add(-2*time.Hour)),
+			maximumEmergencyModeDuration: time.Hour,
+			wantUpdateMode:               v1beta3.UpdateModeAuto,
+			wantEvent:                    true,
+		},
+		{
+			name:                         "emergency mode within MaximumEmergencyModeDuration",
+			tortoise:                     emergencyTortoise("", now.Add(-30*time.Minute)),
+			maximumEmergencyModeDuration: time.Hour,
+			wantUpdateMode:               v1beta3.UpdateModeEmergency,
+		},
+		{
+			name:                         "MaximumEmergencyModeDuration is earlier than the annotation",
+			tortoise:                     emergencyTortoise(now.Add(time.Hour).Format(time.RFC3339), now.Add(-2*time.Hour)),
+			maximumEmergencyModeDuration: time.Hour,
+			wantUpdateMode:               v1beta3.UpdateModeAuto,
+			wantAnnotation:               false,
+			wantEvent:                    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := v1beta3.AddToScheme(scheme); err != nil {
+				t.Fatalf("failed to add to scheme: %v", err)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.tortoise.DeepCopy()).Build()
+			recorder := record.NewFakeRecorder(10)
+			s := &Service{c: c, recorder: recorder, maximumEmergencyModeDuration: tt.maximumEmergencyModeDuration}
+
+			got, err := s.ExpireEmergencyMode(context.Background(), tt.tortoise, now)
+			if err != nil {
+				t.Fatalf("ExpireEmergencyMode() error = %v", err)
+			}
+			if got.Spec.UpdateMode != tt.wantUpdateMode {
+				t.Errorf("ExpireEmergencyMode() updateMode = %v, want %v", got.Spec.UpdateMode, tt.wantUpdateMode)
+			}
+			if got.Status.TortoisePhase != tt.tortoise.Status.TortoisePhase {
+				t.Errorf("ExpireEmergencyMode() shouldn't change the status, phase = %v", got.Status.TortoisePhase)
+			}
+
+			stored := &v1beta3.Tortoise{}
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(tt.tortoise), stored); err != nil {
+				t.Fatalf("failed to get tortoise: %v", err)
+			}
+			if stored.Spec.UpdateMode != tt.wantUpdateMode {
+				t.Errorf("stored updateMode = %v, want %v", stored.Spec.UpdateMode, tt.wantUpdateMode)
+			}
+			if _, ok := stored.Annotations[annotation.EmergencyUntilAnnotation]; ok != tt.wantAnnotation {
+				t.Errorf("stored tortoise has the annotation = %v, want %v", ok, tt.wantAnnotation)
+			}
+			if gotEvent := len(recorder.Events) > 0; gotEvent != tt.wantEvent {
+				t.Errorf("ExpireEmergencyMode() recorded event = %v, want %v", gotEvent, tt.wantEvent)
+			}
+		})
+	}
+}
+
+func Test_syncEmergencyModeCondition(t *testing.T) {
+	now := time.Date(2023, 3, 19, 0, 0, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	condition := func(status corev1.ConditionStatus, at time.Time) []v1beta3.TortoiseCondition {
+		c := v1beta3.TortoiseCondition{
+			Type:               v1beta3.TortoiseConditionTypeEmergencyMode,
+			Status:             status,
+			LastTransitionTime: metav1.NewTime(at),
+			LastUpdateTime:     metav1.NewTime(at),
+		}
+		if status == corev1.ConditionTrue {
+			c.Reason = "EmergencyMode"
+			c.Message = "Tortoise is in Emergency mode"
+		}
+		return []v1beta3.TortoiseCondition{c}
+	}
+	tests := []struct {
+		name           string
+		phase          v1beta3.TortoisePhase
+		conditions     []v1beta3.TortoiseCondition
+		wantConditions []v1beta3.TortoiseCondition
+	}{
+		{
+			name:           "moved to emergency mode",
+			phase:          v1beta3.TortoisePhaseEmergency,
+			wantConditions: condition(corev1.ConditionTrue, now),
+		},
+		{
+			name:           "still in emergency mode",
+			phase:          v1beta3.TortoisePhaseEmergency,
+			conditions:     condition(corev1.ConditionTrue, before),
+			wantConditions: condition(corev1.ConditionTrue, before),
+		},
+		{
+			name:           "emergency mode is turned off",
+			phase:          v1beta3.TortoisePhaseBackToNormal,
+			conditions:     condition(corev1.ConditionTrue, before),
+			wantConditions: condition(corev1.ConditionFalse, now),
+		},
+		{
+			name:  "never in emergency mode",
+			phase: v1beta3.TortoisePhaseWorking,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tortoise := &v1beta3.Tortoise{
+				Status: v1beta3.TortoiseStatus{
+					TortoisePhase: tt.phase,
+					Conditions:    v1beta3.Conditions{TortoiseConditions: tt.conditions},
+				},
+			}
+			got := syncEmergencyModeCondition(tortoise, now)
+			if d := cmp.Diff(tt.wantConditions, got.Status.Conditions.TortoiseConditions); d != "" {
+				t.Errorf("syncEmergencyModeCondition() diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestService_AllowCanaryStep(t *testing.T) {
+	// 2023-01-01 00:00 is Sunday.
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	requests := func(memory string) []v1beta3.ContainerResourceRequests {
+		return []v1beta3.ContainerResourceRequests{
+			{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)}},
+		}
+	}
+	// testTortoise returns the tortoise whose canary rollout changes the memory request from 1Gi to the given one.
+	testTortoise := func(memory string) *v1beta3.Tortoise {
+		return &v1beta3.Tortoise{
+			ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+			Spec:       v1beta3.TortoiseSpec{UpdateMode: v1beta3.UpdateModeAuto},
+			Status: v1beta3.TortoiseStatus{
+				Conditions:      v1beta3.Conditions{ContainerResourceRequests: requests("1Gi")},
+				VerticalRollout: &v1beta3.VerticalRolloutStatus{ContainerResourceRequests: requests(memory)},
+			},
+		}
+	}
+	schedule, err := cron.Parse("0 2 * * *")
+	if err != nil {
+		t.Fatalf("cron.Parse() error = %v", err)
+	}
+	// From 2:00 to 5:00 every day, which doesn't contain now.
+	outside := []maintenanceWindow{{schedule: schedule, duration: 3 * time.Hour}}
+	exhausted := func() *rate.Limiter {
+		l := rate.NewLimiter(rate.Limit(1.0/60), 1)
+		l.Allow()
+		return l
+	}
+
+	tests := []struct {
+		name       string
+		tortoise   *v1beta3.Tortoise
+		deferral   *ApplyDeferral
+		windows    []maintenanceWindow
+		limiter    *rate.Limiter
+		want       bool
+		wantReason string
+	}{
+		{
+			name:     "allowed",
+			tortoise: testTortoise("800Mi"),
+			limiter:  rate.NewLimiter(rate.Limit(1.0/60), 1),
+			want:     true,
+		},
+		{
+			name:       "deferred by the deferral",
+			tortoise:   testTortoise("800Mi"),
+			deferral:   &ApplyDeferral{Reason: "RolloutInProgress", Message: "rollout in progress"},
+			wantReason: "RolloutInProgress",
+		},
+		{
+			name:       "the decreases wait for the maintenance window",
+			tortoise:   testTortoise("800Mi"),
+			windows:    outside,
+			wantReason: ReasonOutsideMaintenanceWindow,
+		},
+		{
+			name:     "the increases don't wait for the maintenance window",
+			tortoise: testTortoise("2Gi"),
+			windows:  outside,
+			want:     true,
+		},
+		{
+			name:       "deferred by the rate limit",
+			tortoise:   testTortoise("800Mi"),
+			limiter:    exhausted(),
+			wantReason: ReasonRateLimited,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{timeZone: time.UTC, maintenanceWindows: tt.windows, verticalApplyLimiter: tt.limiter}
+			got, allowed := s.AllowCanaryStep(context.Background(), tt.tortoise, tt.deferral, now)
+			if allowed != tt.want {
+				t.Errorf("AllowCanaryStep() = %v, want %v", allowed, tt.want)
+			}
+			c := utils.GetTortoiseCondition(got, v1beta3.TortoiseConditionTypeVerticalApplyDeferred)
+			if tt.want {
+				if c != nil && c.Status == corev1.ConditionTrue {
+					t.Errorf("AllowCanaryStep() shouldn't defer, got %v", c)
+				}
+				return
+			}
+			if c == nil || c.Status != corev1.ConditionTrue || c.Reason != tt.wantReason {
+				t.Errorf("AllowCanaryStep() condition = %v, want the reason %s", c, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestService_InheritState(t *testing.T) {
+	now := time.Date(2023, 3, 19, 0, 0, 0, 0, time.UTC)
+	old := &Service{
+		lastTimeUpdateTortoise: map[client.ObjectKey]time.Time{
+			{Name: "t1", Namespace: "default"}: now.Add(-time.Minute),
+			{Name: "t2", Namespace: "default"}: now.Add(-time.Minute),
+		},
+	}
+	s := &Service{
+		lastTimeUpdateTortoise: map[client.ObjectKey]time.Time{
+			{Name: "t2", Namespace: "default"}: now,
+		},
+	}
+
+	s.InheritState(old)
+
+	want := map[client.ObjectKey]time.Time{
+		{Name: "t1", Namespace: "default"}: now.Add(-time.Minute),
+		{Name: "t2", Namespace: "default"}: now,
+	}
+	if d := cmp.Diff(want, s.lastTimeUpdateTortoise); d != "" {
+		t.Errorf("InheritState() diff = %v", d)
+	}
+}
+
+func TestUpdateVerticalApplyLimiter(t *testing.T) {
+	l := NewVerticalApplyLimiter(1, 2)
+	if !l.Allow() || !l.Allow() {
+		t.Fatalf("the limiter should allow the burst")
+	}
+	if l.Allow() {
+		t.Fatalf("the limiter should not allow more than the burst")
+	}
+
+	// The reload with a larger burst shouldn't refill the tokens already consumed.
+	UpdateVerticalApplyLimiter(l, 1, 5)
+	if l.Allow() {
+		t.Errorf("the limiter should not be refilled by the update")
+	}
+
+	UpdateVerticalApplyLimiter(l, 0, 5)
+	if !l.Allow() {
+		t.Errorf("the limiter should allow anything when the limit is disabled")
+	}
+}
@@ -0,0 +1,130 @@
+package usage
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// maxDecayExponent is the maximum exponent of the decay factor.
+	// When a sample is farther than this from the reference time, the reference time is shifted
+	// so that the weights don't overflow.
+	maxDecayExponent = 100
+
+	epsilon = 0.0001
+)
+
+// histogram is the histogram of the resource usage, in which the weights of samples decay exponentially over time.
+// So, the recent usage has a bigger impact on the percentiles than the old usage.
+// The bucket sizes grow exponentially so that the relative error is the same in all buckets.
+type histogram struct {
+	// firstBucketSize is the size of the first bucket.
+	firstBucketSize float64
+	// ratio is the ratio of the size of the bucket to the previous bucket.
+	ratio float64
+	// halfLife is the time after which the weight of a sample is halved.
+	halfLife time.Duration
+	// referenceTime is the time at which the weight of a sample is not decayed.
+	referenceTime time.Time
+
+	bucketWeight []float64
+	totalWeight  float64
+}
+
+// newHistogram returns the histogram which covers [0, maxValue] with the exponentially growing buckets.
+func newHistogram(maxValue, firstBucketSize, ratio float64, halfLife time.Duration) *histogram {
+	numBuckets := int(math.Ceil(math.Log(maxValue*(ratio-1)/firstBucketSize+1)/math.Log(ratio))) + 1
+	return &histogram{
+		firstBucketSize: firstBucketSize,
+		ratio:           ratio,
+		halfLife:        halfLife,
+		bucketWeight:    make([]float64, numBuckets),
+	}
+}
+
+// newCPUHistogram returns the histogram for the CPU usage in cores.
+func newCPUHistogram(halfLife time.Duration) *histogram {
+	// 0.01 cores ~ 1000 cores
+	return newHistogram(1000.0, 0.01, 1.05, halfLife)
+}
+
+// newMemoryHistogram returns the histogram for the memory usage in bytes.
+func newMemoryHistogram(halfLife time.Duration) *histogram {
+	// 10MB ~ 1TB
+	return newHistogram(1e12, 1e7, 1.05, halfLife)
+}
+
+// AddSample adds the sample observed at the given time.
+func (h *histogram) AddSample(value, weight float64, t time.Time) {
+	if h.referenceTime.IsZero() {
+		h.referenceTime = t
+	}
+	if t.Sub(h.referenceTime) > time.Duration(maxDecayExponent)*h.halfLife {
+		h.shiftReferenceTime(t)
+	}
+
+	w := weight * h.decayFactor(t)
+	h.bucketWeight[h.findBucket(value)] += w
+	h.totalWeight += w
+}
+
+// Percentile returns the approximated value at the given percentile (0 ~ 1).
+// It returns 0 when the histogram is empty.
+func (h *histogram) Percentile(percentile float64) float64 {
+	if h.IsEmpty() {
+		return 0
+	}
+
+	threshold := percentile * h.totalWeight
+	partialSum := 0.0
+	bucket := 0
+	for ; bucket < len(h.bucketWeight)-1; bucket++ {
+		partialSum += h.bucketWeight[bucket]
+		if partialSum >= threshold {
+			break
+		}
+	}
+	// Use the end of the bucket so that the value isn't underestimated.
+	return h.bucketStart(bucket + 1)
+}
+
+// IsEmpty returns true if the histogram doesn't have any (non-negligible) sample.
+func (h *histogram) IsEmpty() bool {
+	return h.totalWeight < epsilon
+}
+
+func (h *histogram) decayFactor(t time.Time) float64 {
+	return math.Exp2(float64(t.Sub(h.referenceTime)) / float64(h.halfLife))
+}
+
+// shiftReferenceTime moves the reference time to t, and rescales the weights accordingly.
+func (h *histogram) shiftReferenceTime(t time.Time) {
+	// Round the new reference time to the multiple of halfLife so that the scale factor is the power of 2.
+	newReferenceTime := t.Truncate(h.halfLife)
+	scale := math.Exp2(float64(h.referenceTime.Sub(newReferenceTime)) / float64(h.halfLife))
+	h.totalWeight = 0
+	for i := range h.bucketWeight {
+		h.bucketWeight[i] *= scale
+		h.totalWeight += h.bucketWeight[i]
+	}
+	h.referenceTime = newReferenceTime
+}
+
+func (h *histogram) findBucket(value float64) int {
+	if value < h.firstBucketSize {
+		return 0
+	}
+	bucket := int(math.Floor(math.Log(value*(h.ratio-1)/h.firstBucketSize+1) / math.Log(h.ratio)))
+	if bucket >= len(h.bucketWeight) {
+		return len(h.bucketWeight) - 1
+	}
+	return bucket
+}
+
+// bucketStart returns the smallest value which falls in the bucket.
+func (h *histogram) bucketStart(bucket int) float64 {
+	if bucket >= len(h.bucketWeight) {
+		bucket = len(h.bucketWeight) - 1
+	}
+	return h.firstBucketSize * (math.Pow(h.ratio, float64(bucket)) - 1) / (h.ratio - 1)
+}
@@ -0,0 +1,99 @@
+package usage
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogram_Percentile(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		samples    []float64
+		percentile float64
+		// The histogram is approximated by the buckets, and we allow 5% error (= the ratio of the bucket size).
+		want float64
+	}{
+		{
+			name:       "empty",
+			percentile: 0.9,
+			want:       0,
+		},
+		{
+			name:       "single sample",
+			samples:    []float64{1.0},
+			percentile: 0.9,
+			want:       1.0,
+		},
+		{
+			name:       "p90 of 1 ~ 10",
+			samples:    []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			percentile: 0.9,
+			want:       9,
+		},
+		{
+			name:       "p50 of 1 ~ 10",
+			samples:    []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			percentile: 0.5,
+			want:       5,
+		},
+		{
+			name:       "the sample bigger than the max value goes to the last bucket",
+			samples:    []float64{10000},
+			percentile: 1,
+			want:       1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newCPUHistogram(24 * time.Hour)
+			for _, s := range tt.samples {
+				h.AddSample(s, 1.0, now)
+			}
+			got := h.Percentile(tt.percentile)
+			if math.Abs(got-tt.want) > tt.want*0.05 {
+				t.Errorf("Percentile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogram_Decay(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	h := newCPUHistogram(time.Hour)
+
+	// The old samples have the same weight in total as the new sample after 3 half-lives.
+	for i := 0; i < 8; i++ {
+		h.AddSample(1.0, 1.0, now)
+	}
+	h.AddSample(10.0, 1.0, now.Add(3*time.Hour))
+
+	if got := h.Percentile(0.4); math.Abs(got-1.0) > 0.05 {
+		t.Errorf("Percentile(0.4) = %v, want 1.0", got)
+	}
+	if got := h.Percentile(0.6); math.Abs(got-10.0) > 0.5 {
+		t.Errorf("Percentile(0.6) = %v, want 10.0", got)
+	}
+}
+
+func TestHistogram_ShiftReferenceTime(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	h := newMemoryHistogram(time.Hour)
+
+	h.AddSample(1e9, 1.0, now)
+	// Far enough from the reference time to overflow without shifting it.
+	later := now.Add(2000 * time.Hour)
+	h.AddSample(2e9, 1.0, later)
+
+	if math.IsInf(h.totalWeight, 0) || math.IsNaN(h.totalWeight) {
+		t.Fatalf("totalWeight = %v, want finite", h.totalWeight)
+	}
+	if !h.referenceTime.After(now) {
+		t.Errorf("referenceTime = %v, want after %v", h.referenceTime, now)
+	}
+	// The old sample is negligible.
+	if got := h.Percentile(0.01); math.Abs(got-2e9) > 2e9*0.05 {
+		t.Errorf("Percentile(0.01) = %v, want 2e9", got)
+	}
+}
@@ -0,0 +1,121 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+const (
+	// historyPercentile is the percentile of the historical usage used as the recommendation.
+	historyPercentile = 0.99
+	// historyStep is the resolution of the subqueries to get the historical usage.
+	historyStep = 5 * time.Minute
+	// historySinceStep is the resolution of the subquery to find the oldest sample.
+	historySinceStep = time.Hour
+)
+
+// ContainerHistory is the historical resource usage of one container, aggregated over all the Pods.
+type ContainerHistory struct {
+	ContainerName string
+	// P99 is the 99th percentile of the usage in the window.
+	P99 corev1.ResourceList
+	// Max is the max usage in the window.
+	Max corev1.ResourceList
+	// Since is the time of the oldest sample in the window.
+	Since time.Time
+}
+
+// HistorySource provides the historical resource usage of containers.
+type HistorySource interface {
+	// ContainerUsageHistory returns the historical resource usage of the containers in the Pods owned by the workload,
+	// including the ones that have been already replaced, over the window until now.
+	ContainerUsageHistory(ctx context.Context, namespace string, workload v1beta3.CrossVersionObjectReference, window time.Duration, now time.Time) ([]ContainerHistory, error)
+}
+
+// ContainerUsageHistory gets the usage from the cAdvisor metrics,
+// and selects the Pods of the workload by their owners in kube-state-metrics (kube_pod_owner and kube_replicaset_owner).
+// The Pods aren't selected by their names because the name prefix can match the Pods of another workload (e.g., "app" and "app-worker").
+func (s *PrometheusSource) ContainerUsageHistory(ctx context.Context, namespace string, workload v1beta3.CrossVersionObjectReference, window time.Duration, now time.Time) ([]ContainerHistory, error) {
+	selector := fmt.Sprintf(`namespace=%q, container!="", container!="POD"`, namespace)
+	pods := workloadPods(namespace, workload)
+	usage := map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    fmt.Sprintf(`sum by (pod, container) (rate(container_cpu_usage_seconds_total{%s}[5m]) * on (namespace, pod) group_left() %s)`, selector, pods),
+		corev1.ResourceMemory: fmt.Sprintf(`sum by (pod, container) (container_memory_working_set_bytes{%s} * on (namespace, pod) group_left() %s)`, selector, pods),
+	}
+	subquery := fmt.Sprintf("[%s:%s]", model.Duration(window), model.Duration(historyStep))
+
+	histories := map[string]*ContainerHistory{}
+	history := func(container string) *ContainerHistory {
+		if _, ok := histories[container]; !ok {
+			histories[container] = &ContainerHistory{ContainerName: container, P99: corev1.ResourceList{}, Max: corev1.ResourceList{}}
+		}
+		return histories[container]
+	}
+
+	for rn, q := range usage {
+		// The usage of the busiest Pod is used for each container.
+		vector, err := s.queryVector(ctx, fmt.Sprintf(`max by (container) (quantile_over_time(%v, (%s)%s))`, historyPercentile, q, subquery), now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query p99 %s usage to prometheus: %w", rn, err)
+		}
+		for _, sample := range vector {
+			history(string(sample.Metric["container"])).P99[rn] = toQuantity(rn, float64(sample.Value))
+		}
+
+		vector, err = s.queryVector(ctx, fmt.Sprintf(`max by (container) (max_over_time((%s)%s))`, q, subquery), now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query max %s usage to prometheus: %w", rn, err)
+		}
+		for _, sample := range vector {
+			history(string(sample.Metric["container"])).Max[rn] = toQuantity(rn, float64(sample.Value))
+		}
+	}
+
+	// Look back a bit more than the window so that we can tell if the data covers the whole window.
+	since := fmt.Sprintf(`min by (container) (min_over_time(timestamp(%s)[%s:%s]))`, usage[corev1.ResourceMemory], model.Duration(window+historySinceStep), model.Duration(historySinceStep))
+	vector, err := s.queryVector(ctx, since, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the oldest sample to prometheus: %w", err)
+	}
+	for _, sample := range vector {
+		history(string(sample.Metric["container"])).Since = time.Unix(int64(sample.Value), 0)
+	}
+
+	result := make([]ContainerHistory, 0, len(histories))
+	for _, h := range histories {
+		result = append(result, *h)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ContainerName < result[j].ContainerName
+	})
+	return result, nil
+}
+
+// workloadPods returns the query which has the value 1 for each Pod owned by the workload at each point of time.
+// The Pods are owned by the workload directly (e.g., StatefulSet, DaemonSet), or through ReplicaSets (e.g., Deployment, Argo Rollouts).
+func workloadPods(namespace string, workload v1beta3.CrossVersionObjectReference) string {
+	direct := fmt.Sprintf(`kube_pod_owner{namespace=%q, owner_kind=%q, owner_name=%q}`, namespace, workload.Kind, workload.Name)
+	// The name of the ReplicaSet is put into owner_name so that it can be joined with the owner of the Pods.
+	replicaSets := fmt.Sprintf(`max by (namespace, owner_name) (label_replace(kube_replicaset_owner{namespace=%q, owner_kind=%q, owner_name=%q}, "owner_name", "$1", "replicaset", "(.+)"))`, namespace, workload.Kind, workload.Name)
+	throughReplicaSets := fmt.Sprintf(`kube_pod_owner{namespace=%q, owner_kind="ReplicaSet"} * on (namespace, owner_name) group_left() %s`, namespace, replicaSets)
+	return fmt.Sprintf(`max by (namespace, pod) (%s or %s)`, direct, throughReplicaSets)
+}
+
+func (s *PrometheusSource) queryVector(ctx context.Context, query string, now time.Time) (model.Vector, error) {
+	result, _, err := s.api.Query(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	vector, ok := result.(model.Vector)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type from prometheus: %s", result.Type())
+	}
+	return vector, nil
+}
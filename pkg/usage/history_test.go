@@ -0,0 +1,68 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+func TestPrometheusSource_ContainerUsageHistory(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	// The Pods are selected by the owner, not by the name.
+	server := fakePrometheus(t, `* on (namespace, pod) group_left() max by (namespace, pod) (kube_pod_owner{namespace="default", owner_kind="Deployment", owner_name="app"} or kube_pod_owner{namespace="default", owner_kind="ReplicaSet"} * on (namespace, owner_name) group_left() max by (namespace, owner_name) (label_replace(kube_replicaset_owner{namespace="default", owner_kind="Deployment", owner_name="app"}, "owner_name", "$1", "replicaset", "(.+)")))`, map[string]string{
+		"quantile_over_time(0.99, (sum by (pod, container) (rate(container_cpu_usage_seconds_total": `{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[1696118400,"0.5"]},
+			{"metric":{"container":"istio-proxy"},"value":[1696118400,"0.05"]}
+		]}`,
+		"max_over_time((sum by (pod, container) (rate(container_cpu_usage_seconds_total": `{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[1696118400,"0.8"]},
+			{"metric":{"container":"istio-proxy"},"value":[1696118400,"0.1"]}
+		]}`,
+		"quantile_over_time(0.99, (sum by (pod, container) (container_memory_working_set_bytes": `{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[1696118400,"524288000"]},
+			{"metric":{"container":"istio-proxy"},"value":[1696118400,"52428800"]}
+		]}`,
+		"max_over_time((sum by (pod, container) (container_memory_working_set_bytes": `{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[1696118400,"629145600"]},
+			{"metric":{"container":"istio-proxy"},"value":[1696118400,"62914560"]}
+		]}`,
+		"timestamp(": `{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[1696118400,"1695510000"]},
+			{"metric":{"container":"istio-proxy"},"value":[1696118400,"1696032000"]}
+		]}`,
+	})
+	defer server.Close()
+
+	s, err := NewPrometheusSource(server.URL, fake.NewClientBuilder().Build())
+	if err != nil {
+		t.Fatalf("NewPrometheusSource() error = %v", err)
+	}
+	got, err := s.ContainerUsageHistory(context.Background(), "default", v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app", APIVersion: "apps/v1"}, 7*24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("ContainerUsageHistory() error = %v", err)
+	}
+	want := []ContainerHistory{
+		{
+			ContainerName: "app",
+			P99:           corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("500Mi")},
+			Max:           corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("800m"), corev1.ResourceMemory: resource.MustParse("600Mi")},
+			Since:         time.Unix(1695510000, 0),
+		},
+		{
+			ContainerName: "istio-proxy",
+			P99:           corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("50Mi")},
+			Max:           corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("60Mi")},
+			Since:         time.Unix(1696032000, 0),
+		},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("ContainerUsageHistory() diff = %v", d)
+	}
+}
@@ -0,0 +1,182 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+const (
+	// targetPercentile is the percentile of the usage used as the target recommendation.
+	targetPercentile = 0.9
+	// upperBoundPercentile is the percentile of the usage used as the upper bound of the recommendation.
+	upperBoundPercentile = 0.95
+	// safetyMarginFraction is the fraction added on top of the percentiles, same as VPA.
+	safetyMarginFraction = 0.15
+
+	// minSampleInterval is the minimum interval to record the usage of the same tortoise
+	// so that frequent reconciliations don't give too much weight to a short period.
+	minSampleInterval = time.Minute
+)
+
+// Service is the in-process recommender which calculates the resource recommendation from the decaying histograms of the usage,
+// instead of the monitor VPA.
+// The histograms are kept in memory, so they're lost when the controller restarts.
+// That's why the recommendation isn't regarded as ready until the usage is observed for minObservationPeriod.
+type Service struct {
+	source   Source
+	halfLife time.Duration
+	// minObservationPeriod is the minimum period of the observation before the recommendation gets ready
+	// so that the recommendation from only a few samples (e.g., right after the restart) doesn't replace the established one.
+	minObservationPeriod time.Duration
+
+	mu sync.Mutex
+	// tortoise → container name → resource name → histogram
+	histograms map[client.ObjectKey]map[string]map[corev1.ResourceName]*histogram
+	// firstSampleTime is the first time the usage of the tortoise was recorded.
+	firstSampleTime map[client.ObjectKey]time.Time
+	// lastSampleTime is the last time the usage of the tortoise was recorded.
+	lastSampleTime map[client.ObjectKey]time.Time
+}
+
+func New(source Source, halfLife, minObservationPeriod time.Duration) *Service {
+	return &Service{
+		source:               source,
+		halfLife:             halfLife,
+		minObservationPeriod: minObservationPeriod,
+		histograms:           map[client.ObjectKey]map[string]map[corev1.ResourceName]*histogram{},
+		firstSampleTime:      map[client.ObjectKey]time.Time{},
+		lastSampleTime:       map[client.ObjectKey]time.Time{},
+	}
+}
+
+// RecordUsage records the current resource usage of the Pods which the tortoise targets.
+// podLabels are the labels of the pod template of the workload.
+func (s *Service) RecordUsage(ctx context.Context, tortoise *v1beta3.Tortoise, podLabels map[string]string, now time.Time) error {
+	key := client.ObjectKeyFromObject(tortoise)
+	s.mu.Lock()
+	last, ok := s.lastSampleTime[key]
+	s.mu.Unlock()
+	if ok && now.Sub(last) < minSampleInterval {
+		return nil
+	}
+
+	usages, err := s.source.ContainerUsage(ctx, tortoise.Namespace, podLabels, now)
+	if err != nil {
+		return fmt.Errorf("get the container usage: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range usages {
+		for rn, q := range u.Usage {
+			if rn != corev1.ResourceCPU && rn != corev1.ResourceMemory {
+				continue
+			}
+			s.histogram(key, u.ContainerName, rn).AddSample(toValue(rn, q), 1.0, now)
+		}
+	}
+	if _, ok := s.firstSampleTime[key]; !ok {
+		s.firstSampleTime[key] = now
+	}
+	s.lastSampleTime[key] = now
+
+	return nil
+}
+
+// histogram returns the histogram of the resource in the container, creating it if it doesn't exist.
+// The caller must hold s.mu.
+func (s *Service) histogram(key client.ObjectKey, containerName string, rn corev1.ResourceName) *histogram {
+	if _, ok := s.histograms[key]; !ok {
+		s.histograms[key] = map[string]map[corev1.ResourceName]*histogram{}
+	}
+	if _, ok := s.histograms[key][containerName]; !ok {
+		s.histograms[key][containerName] = map[corev1.ResourceName]*histogram{}
+	}
+	h, ok := s.histograms[key][containerName][rn]
+	if !ok {
+		if rn == corev1.ResourceCPU {
+			h = newCPUHistogram(s.halfLife)
+		} else {
+			h = newMemoryHistogram(s.halfLife)
+		}
+		s.histograms[key][containerName][rn] = h
+	}
+	return h
+}
+
+// GetRecommendation returns the recommendation in the same format as VPA
+// so that tortoise can handle it in the same way as the recommendation from the monitor VPA.
+// The second return value is false when the recommendation isn't ready for all the containers in the tortoise,
+// or the usage hasn't been observed for minObservationPeriod yet.
+func (s *Service) GetRecommendation(tortoise *v1beta3.Tortoise, now time.Time) (*vpav1.RecommendedPodResources, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := client.ObjectKeyFromObject(tortoise)
+	recommendation := &vpav1.RecommendedPodResources{}
+	containers := s.histograms[key]
+	for containerName, hs := range containers {
+		r := vpav1.RecommendedContainerResources{
+			ContainerName: containerName,
+			Target:        corev1.ResourceList{},
+			UpperBound:    corev1.ResourceList{},
+		}
+		for rn, h := range hs {
+			if h.IsEmpty() {
+				continue
+			}
+			r.Target[rn] = toQuantity(rn, h.Percentile(targetPercentile)*(1+safetyMarginFraction))
+			r.UpperBound[rn] = toQuantity(rn, h.Percentile(upperBoundPercentile)*(1+safetyMarginFraction))
+		}
+		recommendation.ContainerRecommendations = append(recommendation.ContainerRecommendations, r)
+	}
+	sort.Slice(recommendation.ContainerRecommendations, func(i, j int) bool {
+		return recommendation.ContainerRecommendations[i].ContainerName < recommendation.ContainerRecommendations[j].ContainerName
+	})
+
+	first, ok := s.firstSampleTime[key]
+	observed := ok && now.Sub(first) >= s.minObservationPeriod
+	return recommendation, observed && isRecommendationReady(recommendation, tortoise)
+}
+
+// isRecommendationReady checks if the recommendation has the CPU and memory recommendation for all the containers registered in the tortoise.
+func isRecommendationReady(recommendation *vpav1.RecommendedPodResources, tortoise *v1beta3.Tortoise) bool {
+	ready := map[string]bool{}
+	for _, c := range recommendation.ContainerRecommendations {
+		ready[c.ContainerName] = !c.Target.Cpu().IsZero() && !c.Target.Memory().IsZero()
+	}
+	for _, p := range tortoise.Status.AutoscalingPolicy {
+		if !ready[p.ContainerName] {
+			return false
+		}
+	}
+	return true
+}
+
+// Forget removes the histograms of the tortoise.
+func (s *Service) Forget(tortoise *v1beta3.Tortoise) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := client.ObjectKeyFromObject(tortoise)
+	delete(s.histograms, key)
+	delete(s.firstSampleTime, key)
+	delete(s.lastSampleTime, key)
+}
+
+// toValue converts the quantity to the value in the histogram (cores or bytes).
+func toValue(rn corev1.ResourceName, q resource.Quantity) float64 {
+	if rn == corev1.ResourceCPU {
+		return float64(q.MilliValue()) / 1000
+	}
+	return float64(q.Value())
+}
@@ -0,0 +1,161 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+type fakeSource struct {
+	usages []ContainerUsage
+	called int
+}
+
+func (f *fakeSource) ContainerUsage(_ context.Context, _ string, _ map[string]string, _ time.Time) ([]ContainerUsage, error) {
+	f.called++
+	return f.usages, nil
+}
+
+func tortoiseWithContainers(containers ...string) *v1beta3.Tortoise {
+	t := &v1beta3.Tortoise{ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"}}
+	for _, c := range containers {
+		t.Status.AutoscalingPolicy = append(t.Status.AutoscalingPolicy, v1beta3.ContainerAutoscalingPolicy{ContainerName: c})
+	}
+	return t
+}
+
+func usage(container, cpu, memory string) ContainerUsage {
+	return ContainerUsage{
+		ContainerName: container,
+		Usage: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		},
+	}
+}
+
+func TestService_GetRecommendation(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		tortoise *v1beta3.Tortoise
+		usages   []ContainerUsage
+		// observedFor is the period from the first sample to GetRecommendation.
+		observedFor time.Duration
+		want        *vpav1.RecommendedPodResources
+		wantReady   bool
+	}{
+		{
+			name:     "no usage yet",
+			tortoise: tortoiseWithContainers("app"),
+			want:     &vpav1.RecommendedPodResources{},
+		},
+		{
+			name:     "recommendation for all containers",
+			tortoise: tortoiseWithContainers("app", "istio-proxy"),
+			usages: []ContainerUsage{
+				usage("app", "1", "1Gi"),
+				usage("istio-proxy", "100m", "100Mi"),
+			},
+			observedFor: 24 * time.Hour,
+			want: &vpav1.RecommendedPodResources{
+				ContainerRecommendations: []vpav1.RecommendedContainerResources{
+					{
+						ContainerName: "app",
+						// the end of the bucket which the usage falls in, plus the safety margin (15%)
+						Target:     corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1168m"), corev1.ResourceMemory: resource.MustParse("1238659776")},
+						UpperBound: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1168m"), corev1.ResourceMemory: resource.MustParse("1238659776")},
+					},
+					{
+						ContainerName: "istio-proxy",
+						Target:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("126m"), corev1.ResourceMemory: resource.MustParse("126805489")},
+						UpperBound:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("126m"), corev1.ResourceMemory: resource.MustParse("126805489")},
+					},
+				},
+			},
+			wantReady: true,
+		},
+		{
+			name:     "not ready when some container doesn't have the usage",
+			tortoise: tortoiseWithContainers("app", "istio-proxy"),
+			usages: []ContainerUsage{
+				usage("app", "1", "1Gi"),
+			},
+			observedFor: 24 * time.Hour,
+			want: &vpav1.RecommendedPodResources{
+				ContainerRecommendations: []vpav1.RecommendedContainerResources{
+					{
+						ContainerName: "app",
+						Target:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1168m"), corev1.ResourceMemory: resource.MustParse("1238659776")},
+						UpperBound:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1168m"), corev1.ResourceMemory: resource.MustParse("1238659776")},
+					},
+				},
+			},
+			wantReady: false,
+		},
+		{
+			// e.g., right after the controller restarts.
+			name:     "not ready until the usage is observed for the minimum observation period",
+			tortoise: tortoiseWithContainers("app"),
+			usages: []ContainerUsage{
+				usage("app", "1", "1Gi"),
+			},
+			observedFor: 23 * time.Hour,
+			want: &vpav1.RecommendedPodResources{
+				ContainerRecommendations: []vpav1.RecommendedContainerResources{
+					{
+						ContainerName: "app",
+						Target:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1168m"), corev1.ResourceMemory: resource.MustParse("1238659776")},
+						UpperBound:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1168m"), corev1.ResourceMemory: resource.MustParse("1238659776")},
+					},
+				},
+			},
+			wantReady: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(&fakeSource{usages: tt.usages}, 24*time.Hour, 24*time.Hour)
+			if err := s.RecordUsage(context.Background(), tt.tortoise, nil, now.Add(-tt.observedFor)); err != nil {
+				t.Fatalf("RecordUsage() error = %v", err)
+			}
+			got, gotReady := s.GetRecommendation(tt.tortoise, now)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("GetRecommendation() diff = %v", d)
+			}
+			if gotReady != tt.wantReady {
+				t.Errorf("GetRecommendation() ready = %v, want %v", gotReady, tt.wantReady)
+			}
+		})
+	}
+}
+
+func TestService_RecordUsage(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	source := &fakeSource{usages: []ContainerUsage{usage("app", "1", "1Gi")}}
+	s := New(source, 24*time.Hour, 0)
+	tortoise := tortoiseWithContainers("app")
+
+	for _, at := range []time.Time{now, now.Add(30 * time.Second), now.Add(time.Minute)} {
+		if err := s.RecordUsage(context.Background(), tortoise, nil, at); err != nil {
+			t.Fatalf("RecordUsage() error = %v", err)
+		}
+	}
+	// The second one is skipped because it's too soon after the first one.
+	if source.called != 2 {
+		t.Errorf("the source is called %d times, want 2", source.called)
+	}
+
+	s.Forget(tortoise)
+	if _, ready := s.GetRecommendation(tortoise, now.Add(time.Minute)); ready {
+		t.Errorf("GetRecommendation() is ready after Forget()")
+	}
+}
@@ -0,0 +1,157 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ContainerUsage is the resource usage of one container in one Pod.
+type ContainerUsage struct {
+	ContainerName string
+	Usage         corev1.ResourceList
+}
+
+// Source provides the current resource usage of containers.
+type Source interface {
+	// ContainerUsage returns the current resource usage of the containers in the Pods which have all the given labels.
+	ContainerUsage(ctx context.Context, namespace string, podLabels map[string]string, now time.Time) ([]ContainerUsage, error)
+}
+
+// podMetricsListGVK is the GVK of PodMetricsList served by metrics-server.
+// We use unstructured to handle it so that we don't need to depend on k8s.io/metrics.
+var podMetricsListGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetricsList"}
+
+type metricsAPISource struct {
+	c client.Reader
+}
+
+// NewMetricsAPISource returns the Source which gets the resource usage from the metrics.k8s.io API (metrics-server).
+func NewMetricsAPISource(c client.Reader) Source {
+	return &metricsAPISource{c: c}
+}
+
+func (s *metricsAPISource) ContainerUsage(ctx context.Context, namespace string, podLabels map[string]string, _ time.Time) ([]ContainerUsage, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(podMetricsListGVK)
+	if err := s.c.List(ctx, list, client.InNamespace(namespace), client.MatchingLabels(podLabels)); err != nil {
+		return nil, fmt.Errorf("failed to list pod metrics: %w", err)
+	}
+
+	usages := []ContainerUsage{}
+	for _, pm := range list.Items {
+		containers, _, err := unstructured.NestedSlice(pm.Object, "containers")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get containers from pod metrics %s: %w", pm.GetName(), err)
+		}
+		for _, c := range containers {
+			cm, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(cm, "name")
+			rawUsage, _, _ := unstructured.NestedStringMap(cm, "usage")
+			u := ContainerUsage{ContainerName: name, Usage: corev1.ResourceList{}}
+			for rn, raw := range rawUsage {
+				q, err := resource.ParseQuantity(raw)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse the %s usage of container %s in pod metrics %s: %w", rn, name, pm.GetName(), err)
+				}
+				u.Usage[corev1.ResourceName(rn)] = q
+			}
+			usages = append(usages, u)
+		}
+	}
+
+	return usages, nil
+}
+
+// PrometheusSource gets the resource usage from Prometheus scraping cAdvisor metrics.
+// It implements both Source and HistorySource.
+type PrometheusSource struct {
+	api promv1.API
+	c   client.Reader
+}
+
+func NewPrometheusSource(address string, c client.Reader) (*PrometheusSource, error) {
+	cli, err := api.NewClient(api.Config{Address: address})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prometheus client: %w", err)
+	}
+	return &PrometheusSource{api: promv1.NewAPI(cli), c: c}, nil
+}
+
+func (s *PrometheusSource) ContainerUsage(ctx context.Context, namespace string, podLabels map[string]string, now time.Time) ([]ContainerUsage, error) {
+	selector, err := s.podSelector(ctx, namespace, podLabels)
+	if err != nil {
+		return nil, err
+	}
+	if selector == "" {
+		return nil, nil
+	}
+
+	queries := map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    fmt.Sprintf(`sum by (pod, container) (rate(container_cpu_usage_seconds_total{%s}[5m]))`, selector),
+		corev1.ResourceMemory: fmt.Sprintf(`sum by (pod, container) (container_memory_working_set_bytes{%s})`, selector),
+	}
+
+	// pod/container → usage
+	usageMap := map[string]*ContainerUsage{}
+	keys := []string{}
+	for rn, q := range queries {
+		vector, err := s.queryVector(ctx, q, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s usage to prometheus: %w", rn, err)
+		}
+		for _, sample := range vector {
+			key := string(sample.Metric["pod"]) + "/" + string(sample.Metric["container"])
+			if _, ok := usageMap[key]; !ok {
+				usageMap[key] = &ContainerUsage{ContainerName: string(sample.Metric["container"]), Usage: corev1.ResourceList{}}
+				keys = append(keys, key)
+			}
+			usageMap[key].Usage[rn] = toQuantity(rn, float64(sample.Value))
+		}
+	}
+
+	usages := make([]ContainerUsage, 0, len(keys))
+	for _, k := range keys {
+		usages = append(usages, *usageMap[k])
+	}
+	return usages, nil
+}
+
+// podSelector returns the label selector of the PromQL to select the containers in the Pods which have all the given labels.
+// It returns the empty string when no Pod is found.
+func (s *PrometheusSource) podSelector(ctx context.Context, namespace string, podLabels map[string]string) (string, error) {
+	pods := &corev1.PodList{}
+	if err := s.c.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels(podLabels)); err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", nil
+	}
+
+	names := make([]string, 0, len(pods.Items))
+	for _, p := range pods.Items {
+		names = append(names, regexp.QuoteMeta(p.Name))
+	}
+	return fmt.Sprintf(`namespace=%q, pod=~%q, container!="", container!="POD"`, namespace, strings.Join(names, "|")), nil
+}
+
+// toQuantity converts the value in the Prometheus metric (cores or bytes) to the quantity.
+func toQuantity(rn corev1.ResourceName, v float64) resource.Quantity {
+	if rn == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(int64(v*1000), resource.DecimalSI)
+	}
+	return *resource.NewQuantity(int64(v), resource.BinarySI)
+}
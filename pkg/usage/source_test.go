@@ -0,0 +1,160 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func podMetrics(name string, labels map[string]string, containers ...interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"containers": containers,
+	}}
+	u.SetAPIVersion("metrics.k8s.io/v1beta1")
+	u.SetKind("PodMetrics")
+	u.SetName(name)
+	u.SetNamespace("default")
+	u.SetLabels(labels)
+	return u
+}
+
+func containerMetrics(name, cpu, memory string) interface{} {
+	return map[string]interface{}{
+		"name": name,
+		"usage": map[string]interface{}{
+			"cpu":    cpu,
+			"memory": memory,
+		},
+	}
+}
+
+func sortUsages(usages []ContainerUsage) {
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].ContainerName != usages[j].ContainerName {
+			return usages[i].ContainerName < usages[j].ContainerName
+		}
+		return usages[i].Usage.Cpu().Cmp(*usages[j].Usage.Cpu()) < 0
+	})
+}
+
+func TestMetricsAPISource_ContainerUsage(t *testing.T) {
+	c := fake.NewClientBuilder().WithObjects(
+		podMetrics("app-1", map[string]string{"app": "app"}, containerMetrics("app", "100m", "100Mi"), containerMetrics("istio-proxy", "10m", "50Mi")),
+		podMetrics("app-2", map[string]string{"app": "app"}, containerMetrics("app", "200m", "120Mi"), containerMetrics("istio-proxy", "20m", "60Mi")),
+		podMetrics("other-1", map[string]string{"app": "other"}, containerMetrics("app", "1", "1Gi")),
+	).Build()
+
+	got, err := NewMetricsAPISource(c).ContainerUsage(context.Background(), "default", map[string]string{"app": "app"}, time.Now())
+	if err != nil {
+		t.Fatalf("ContainerUsage() error = %v", err)
+	}
+	sortUsages(got)
+	want := []ContainerUsage{
+		{ContainerName: "app", Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("100Mi")}},
+		{ContainerName: "app", Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m"), corev1.ResourceMemory: resource.MustParse("120Mi")}},
+		{ContainerName: "istio-proxy", Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m"), corev1.ResourceMemory: resource.MustParse("50Mi")}},
+		{ContainerName: "istio-proxy", Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("20m"), corev1.ResourceMemory: resource.MustParse("60Mi")}},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("ContainerUsage() diff = %v", d)
+	}
+}
+
+// fakePrometheus returns the server which responds to the instant queries with the given results.
+// The key of results is the part of the query, and each query should contain exactly one of them.
+func fakePrometheus(t *testing.T, podMatcher string, results map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse the form: %v", err)
+		}
+		query := r.Form.Get("query")
+		if !strings.Contains(query, podMatcher) {
+			t.Errorf("unexpected pod selector in the query: %s", query)
+		}
+		for part, result := range results {
+			if strings.Contains(query, part) {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"status": "success",
+					"data":   json.RawMessage(result),
+				})
+				return
+			}
+		}
+		t.Errorf("unexpected query: %s", query)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+}
+
+func TestPrometheusSource_ContainerUsage(t *testing.T) {
+	server := fakePrometheus(t, `pod=~"app-1|app-2"`, map[string]string{
+		"container_cpu_usage_seconds_total": `{"resultType":"vector","result":[
+			{"metric":{"pod":"app-1","container":"app"},"value":[1696118400,"0.1"]},
+			{"metric":{"pod":"app-2","container":"app"},"value":[1696118400,"0.2"]}
+		]}`,
+		"container_memory_working_set_bytes": `{"resultType":"vector","result":[
+			{"metric":{"pod":"app-1","container":"app"},"value":[1696118400,"104857600"]},
+			{"metric":{"pod":"app-2","container":"app"},"value":[1696118400,"125829120"]}
+		]}`,
+	})
+	defer server.Close()
+
+	pod := func(name string, labels map[string]string) client.Object {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+	}
+	c := fake.NewClientBuilder().WithObjects(
+		pod("app-1", map[string]string{"app": "app", "pod-template-hash": "abc"}),
+		pod("app-2", map[string]string{"app": "app", "pod-template-hash": "abc"}),
+		pod("other-1", map[string]string{"app": "other"}),
+	).Build()
+
+	s, err := NewPrometheusSource(server.URL, c)
+	if err != nil {
+		t.Fatalf("NewPrometheusSource() error = %v", err)
+	}
+	got, err := s.ContainerUsage(context.Background(), "default", map[string]string{"app": "app"}, time.Now())
+	if err != nil {
+		t.Fatalf("ContainerUsage() error = %v", err)
+	}
+	sortUsages(got)
+	want := []ContainerUsage{
+		{ContainerName: "app", Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("100Mi")}},
+		{ContainerName: "app", Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m"), corev1.ResourceMemory: resource.MustParse("120Mi")}},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("ContainerUsage() diff = %v", d)
+	}
+}
+
+func TestPrometheusSource_ContainerUsage_NoPod(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("prometheus shouldn't be called when no Pod is found")
+	}))
+	defer server.Close()
+
+	s, err := NewPrometheusSource(server.URL, fake.NewClientBuilder().Build())
+	if err != nil {
+		t.Fatalf("NewPrometheusSource() error = %v", err)
+	}
+	got, err := s.ContainerUsage(context.Background(), "default", map[string]string{"app": "app"}, time.Now())
+	if err != nil {
+		t.Fatalf("ContainerUsage() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ContainerUsage() = %v, want empty", got)
+	}
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type PodBuilder struct {
+	pod *corev1.Pod
+}
+
+func NewPodBuilder() *PodBuilder {
+	return &PodBuilder{
+		pod: &corev1.Pod{},
+	}
+}
+
+func (b *PodBuilder) SetName(name string) *PodBuilder {
+	b.pod.ObjectMeta.Name = name
+	return b
+}
+
+func (b *PodBuilder) SetNamespace(namespace string) *PodBuilder {
+	b.pod.ObjectMeta.Namespace = namespace
+	return b
+}
+
+func (b *PodBuilder) AddLabel(key, value string) *PodBuilder {
+	if b.pod.ObjectMeta.Labels == nil {
+		b.pod.ObjectMeta.Labels = map[string]string{}
+	}
+	b.pod.ObjectMeta.Labels[key] = value
+	return b
+}
+
+func (b *PodBuilder) AddAnnotation(key, value string) *PodBuilder {
+	if b.pod.ObjectMeta.Annotations == nil {
+		b.pod.ObjectMeta.Annotations = map[string]string{}
+	}
+	b.pod.ObjectMeta.Annotations[key] = value
+	return b
+}
+
+func (b *PodBuilder) SetCreationTimestamp(t time.Time) *PodBuilder {
+	b.pod.ObjectMeta.CreationTimestamp = metav1.NewTime(t)
+	return b
+}
+
+func (b *PodBuilder) AddContainer(container corev1.Container) *PodBuilder {
+	b.pod.Spec.Containers = append(b.pod.Spec.Containers, container)
+	return b
+}
+
+func (b *PodBuilder) AddContainerStatus(status corev1.ContainerStatus) *PodBuilder {
+	b.pod.Status.ContainerStatuses = append(b.pod.Status.ContainerStatuses, status)
+	return b
+}
+
+func (b *PodBuilder) SetReady() *PodBuilder {
+	b.pod.Status.Conditions = append(b.pod.Status.Conditions, corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue})
+	return b
+}
+
+func (b *PodBuilder) SetResizeStatus(status corev1.PodResizeStatus) *PodBuilder {
+	b.pod.Status.Resize = status
+	return b
+}
+
+func (b *PodBuilder) Build() *corev1.Pod {
+	return b.pod
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -95,3 +97,90 @@ func GetRequestFromTortoise(t *v1beta3.Tortoise, containerName string, resourceN
 
 	return resource.Quantity{}, false
 }
+
+// GetTortoiseResourceFloor returns the floor of the resource of the container from tortoise.Status.Conditions.ContainerResourceFloors.
+func GetTortoiseResourceFloor(t *v1beta3.Tortoise, containerName string, rn corev1.ResourceName) (v1beta3.ResourceFloor, bool) {
+	for _, f := range t.Status.Conditions.ContainerResourceFloors {
+		if f.ContainerName == containerName {
+			r, ok := f.Resource[rn]
+			return r, ok
+		}
+	}
+
+	return v1beta3.ResourceFloor{}, false
+}
+
+// RaiseTortoiseResourceFloor raises the floor of the resource of the container to q unless the floor is already higher.
+// The floor is renewed at now either way so that it's kept for the decay window after it's raised last time.
+func RaiseTortoiseResourceFloor(t *v1beta3.Tortoise, containerName string, rn corev1.ResourceName, q resource.Quantity, now time.Time) *v1beta3.Tortoise {
+	for i, f := range t.Status.Conditions.ContainerResourceFloors {
+		if f.ContainerName != containerName {
+			continue
+		}
+		if current, ok := f.Resource[rn]; ok && current.Quantity.Cmp(q) > 0 {
+			q = current.Quantity
+		}
+		if t.Status.Conditions.ContainerResourceFloors[i].Resource == nil {
+			t.Status.Conditions.ContainerResourceFloors[i].Resource = map[corev1.ResourceName]v1beta3.ResourceFloor{}
+		}
+		t.Status.Conditions.ContainerResourceFloors[i].Resource[rn] = v1beta3.ResourceFloor{Quantity: q, RaisedAt: metav1.NewTime(now)}
+		return t
+	}
+	t.Status.Conditions.ContainerResourceFloors = append(t.Status.Conditions.ContainerResourceFloors, v1beta3.ContainerResourceFloor{
+		ContainerName: containerName,
+		Resource:      map[corev1.ResourceName]v1beta3.ResourceFloor{rn: {Quantity: q, RaisedAt: metav1.NewTime(now)}},
+	})
+	return t
+}
+
+// GetResourceBehavior returns the behavior of the resource in the container from tortoise.Spec.ResourcePolicy.
+// It returns the empty behavior if it's not specified, which means all the cluster wide default values are used.
+func GetResourceBehavior(t *v1beta3.Tortoise, containerName string, resourceName v1.ResourceName) v1beta3.ResourceBehavior {
+	for _, p := range t.Spec.ResourcePolicy {
+		if p.ContainerName == containerName {
+			return p.Behavior[resourceName]
+		}
+	}
+
+	return v1beta3.ResourceBehavior{}
+}
+
+const (
+	// ReasonApplyFrozen is the reason why the recommendations aren't applied until .spec.applySchedule.freezeUntil.
+	ReasonApplyFrozen = "ApplyFrozen"
+	// ReasonOutsideApplyWindow is the reason why the recommendations aren't applied outside .spec.applySchedule.windows.
+	ReasonOutsideApplyWindow = "OutsideApplyWindow"
+)
+
+// ApplyBlockedBySchedule returns the reason and the message when .spec.applySchedule doesn't allow applying the recommendations now.
+// It returns the empty reason if they can be applied.
+func ApplyBlockedBySchedule(t *v1beta3.Tortoise, now time.Time) (string, string) {
+	s := t.Spec.ApplySchedule
+	if s == nil {
+		return "", ""
+	}
+	if s.FreezeUntil != nil && now.Before(s.FreezeUntil.Time) {
+		return ReasonApplyFrozen, fmt.Sprintf("The recommendations aren't applied until %s", s.FreezeUntil.Format(time.RFC3339))
+	}
+	if len(s.Windows) == 0 {
+		return "", ""
+	}
+
+	loc := time.UTC
+	if s.TimeZone != "" {
+		// The time zone is validated in the webhook. If it's invalid anyway, just use UTC.
+		if l, err := time.LoadLocation(s.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	now = now.In(loc)
+	for _, w := range s.Windows {
+		if int32(now.Hour()) < w.From || int32(now.Hour()) >= w.To {
+			continue
+		}
+		if len(w.WeekDays) == 0 || slices.Contains(w.WeekDays, v1beta3.WeekDay(now.Weekday().String())) {
+			return "", ""
+		}
+	}
+	return ReasonOutsideApplyWindow, "The recommendations wait for the next window in .spec.applySchedule"
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"k8s.io/apimachinery/pkg/types"
+
 	"github.com/mercari/tortoise/api/v1beta3"
 )
 
@@ -24,6 +26,19 @@ func (b *TortoiseBuilder) SetNamespace(namespace string) *TortoiseBuilder {
 	return b
 }
 
+func (b *TortoiseBuilder) SetUID(uid types.UID) *TortoiseBuilder {
+	b.tortoise.ObjectMeta.UID = uid
+	return b
+}
+
+func (b *TortoiseBuilder) AddAnnotation(key, value string) *TortoiseBuilder {
+	if b.tortoise.ObjectMeta.Annotations == nil {
+		b.tortoise.ObjectMeta.Annotations = map[string]string{}
+	}
+	b.tortoise.ObjectMeta.Annotations[key] = value
+	return b
+}
+
 func (b *TortoiseBuilder) SetTargetRefs(targetRefs v1beta3.TargetRefs) *TortoiseBuilder {
 	b.tortoise.Spec.TargetRefs = targetRefs
 	return b
@@ -48,6 +63,11 @@ func (b *TortoiseBuilder) AddResourcePolicy(resourcePolicy v1beta3.ContainerReso
 	return b
 }
 
+func (b *TortoiseBuilder) SetVerticalRollout(rollout *v1beta3.VerticalRollout) *TortoiseBuilder {
+	b.tortoise.Spec.VerticalRollout = rollout
+	return b
+}
+
 func (b *TortoiseBuilder) SetTortoisePhase(phase v1beta3.TortoisePhase) *TortoiseBuilder {
 	b.tortoise.Status.TortoisePhase = phase
 	return b
@@ -63,6 +83,16 @@ func (b *TortoiseBuilder) AddContainerResourceRequests(actualContainerResource v
 	return b
 }
 
+func (b *TortoiseBuilder) AddPreviousContainerResourceRequests(previousContainerResource v1beta3.ContainerResourceRequests) *TortoiseBuilder {
+	b.tortoise.Status.Conditions.PreviousContainerResourceRequests = append(b.tortoise.Status.Conditions.PreviousContainerResourceRequests, previousContainerResource)
+	return b
+}
+
+func (b *TortoiseBuilder) AddContainerResourceFloors(floor v1beta3.ContainerResourceFloor) *TortoiseBuilder {
+	b.tortoise.Status.Conditions.ContainerResourceFloors = append(b.tortoise.Status.Conditions.ContainerResourceFloors, floor)
+	return b
+}
+
 func (b *TortoiseBuilder) AddTortoiseConditions(condition v1beta3.TortoiseCondition) *TortoiseBuilder {
 	b.tortoise.Status.Conditions.TortoiseConditions = append(b.tortoise.Status.Conditions.TortoiseConditions, condition)
 	return b
@@ -73,6 +103,11 @@ func (b *TortoiseBuilder) SetRecommendations(recommendations v1beta3.Recommendat
 	return b
 }
 
+func (b *TortoiseBuilder) SetVerticalRolloutStatus(status *v1beta3.VerticalRolloutStatus) *TortoiseBuilder {
+	b.tortoise.Status.VerticalRollout = status
+	return b
+}
+
 func (b *TortoiseBuilder) SetTargetsStatus(targetsStatus v1beta3.TargetsStatus) *TortoiseBuilder {
 	b.tortoise.Status.Targets = targetsStatus
 	return b
@@ -87,6 +87,11 @@ func (c *Service) UpdateVPAContainerResourcePolicy(ctx context.Context, tortoise
 
 func (c *Service) CreateTortoiseMonitorVPA(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*v1.VerticalPodAutoscaler, *autoscalingv1beta3.Tortoise, error) {
 	off := v1.UpdateModeOff
+	apiVersion := tortoise.Spec.TargetRefs.ScaleTargetRef.APIVersion
+	if apiVersion == "" {
+		// Deployment and StatefulSet.
+		apiVersion = "apps/v1"
+	}
 	vpa := &v1.VerticalPodAutoscaler{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: tortoise.Namespace,
@@ -94,9 +99,9 @@ func (c *Service) CreateTortoiseMonitorVPA(ctx context.Context, tortoise *autosc
 		},
 		Spec: v1.VerticalPodAutoscalerSpec{
 			TargetRef: &autoscaling.CrossVersionObjectReference{
-				Kind:       "Deployment",
+				Kind:       tortoise.Spec.TargetRefs.ScaleTargetRef.Kind,
 				Name:       tortoise.Spec.TargetRefs.ScaleTargetRef.Name,
-				APIVersion: "apps/v1",
+				APIVersion: apiVersion,
 			},
 			UpdatePolicy: &v1.PodUpdatePolicy{
 				UpdateMode: &off,
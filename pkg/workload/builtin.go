@@ -0,0 +1,153 @@
+package workload
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/daemonset"
+	"github.com/mercari/tortoise/pkg/deployment"
+	"github.com/mercari/tortoise/pkg/statefulset"
+)
+
+type deploymentAdapter struct {
+	s *deployment.Service
+}
+
+var _ Adapter = &deploymentAdapter{}
+
+func (a *deploymentAdapter) Get(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*Workload, error) {
+	dm, err := a.s.GetDeploymentOnTortoise(ctx, tortoise)
+	if err != nil {
+		return nil, err
+	}
+	return &Workload{Object: dm, Replicas: dm.Spec.Replicas, PodTemplate: &dm.Spec.Template}, nil
+}
+
+func (a *deploymentAdapter) RolloutRestart(ctx context.Context, w *Workload, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	dm, ok := w.Object.(*v1.Deployment)
+	if !ok {
+		return fmt.Errorf("unexpected workload type for Deployment: %T", w.Object)
+	}
+	return a.s.RolloutRestart(ctx, dm, tortoise, now)
+}
+
+func (a *deploymentAdapter) RestartBlocker(w *Workload) (string, string) {
+	dm, ok := w.Object.(*v1.Deployment)
+	if !ok || dm.Status.ObservedGeneration == 0 {
+		// The Deployment controller hasn't reported the status yet.
+		return "", ""
+	}
+	replicas := int32(1)
+	if dm.Spec.Replicas != nil {
+		replicas = *dm.Spec.Replicas
+	}
+	if dm.Status.ObservedGeneration < dm.Generation || dm.Status.UpdatedReplicas < replicas || dm.Status.Replicas > dm.Status.UpdatedReplicas {
+		return ReasonRolloutInProgress, fmt.Sprintf("Deployment %s is in the middle of a rollout (%d/%d replicas are updated)", dm.Name, dm.Status.UpdatedReplicas, replicas)
+	}
+	for _, c := range dm.Status.Conditions {
+		if c.Type == v1.DeploymentAvailable && c.Status == corev1.ConditionFalse {
+			return ReasonWorkloadUnavailable, fmt.Sprintf("Deployment %s is unavailable: %s", dm.Name, c.Message)
+		}
+	}
+	return "", ""
+}
+
+// SetPodTemplate does nothing because PodTemplate points to the pod template in the Deployment.
+func (a *deploymentAdapter) SetPodTemplate(w *Workload) error {
+	return nil
+}
+
+type statefulSetAdapter struct {
+	s *statefulset.Service
+}
+
+var _ Adapter = &statefulSetAdapter{}
+
+func (a *statefulSetAdapter) Get(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*Workload, error) {
+	sts, err := a.s.GetStatefulSetOnTortoise(ctx, tortoise)
+	if err != nil {
+		return nil, err
+	}
+	return &Workload{Object: sts, Replicas: sts.Spec.Replicas, PodTemplate: &sts.Spec.Template}, nil
+}
+
+func (a *statefulSetAdapter) RolloutRestart(ctx context.Context, w *Workload, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	sts, ok := w.Object.(*v1.StatefulSet)
+	if !ok {
+		return fmt.Errorf("unexpected workload type for StatefulSet: %T", w.Object)
+	}
+	return a.s.RolloutRestart(ctx, sts, tortoise, now)
+}
+
+func (a *statefulSetAdapter) RestartBlocker(w *Workload) (string, string) {
+	sts, ok := w.Object.(*v1.StatefulSet)
+	if !ok || sts.Status.ObservedGeneration == 0 {
+		// The StatefulSet controller hasn't reported the status yet.
+		return "", ""
+	}
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	if sts.Status.ObservedGeneration < sts.Generation || sts.Status.UpdatedReplicas < replicas || sts.Status.CurrentRevision != sts.Status.UpdateRevision {
+		return ReasonRolloutInProgress, fmt.Sprintf("StatefulSet %s is in the middle of a rollout (%d/%d replicas are updated)", sts.Name, sts.Status.UpdatedReplicas, replicas)
+	}
+	if sts.Status.AvailableReplicas < replicas {
+		return ReasonWorkloadUnavailable, fmt.Sprintf("StatefulSet %s has only %d/%d available replicas", sts.Name, sts.Status.AvailableReplicas, replicas)
+	}
+	return "", ""
+}
+
+// SetPodTemplate does nothing because PodTemplate points to the pod template in the StatefulSet.
+func (a *statefulSetAdapter) SetPodTemplate(w *Workload) error {
+	return nil
+}
+
+type daemonSetAdapter struct {
+	s *daemonset.Service
+}
+
+var _ Adapter = &daemonSetAdapter{}
+
+func (a *daemonSetAdapter) Get(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*Workload, error) {
+	ds, err := a.s.GetDaemonSetOnTortoise(ctx, tortoise)
+	if err != nil {
+		return nil, err
+	}
+	// DaemonSet doesn't have replicas; we regard the number of nodes which should run the Pod as the number of replicas.
+	replicas := ds.Status.DesiredNumberScheduled
+	return &Workload{Object: ds, Replicas: &replicas, PodTemplate: &ds.Spec.Template}, nil
+}
+
+func (a *daemonSetAdapter) RolloutRestart(ctx context.Context, w *Workload, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	ds, ok := w.Object.(*v1.DaemonSet)
+	if !ok {
+		return fmt.Errorf("unexpected workload type for DaemonSet: %T", w.Object)
+	}
+	return a.s.RolloutRestart(ctx, ds, tortoise, now)
+}
+
+func (a *daemonSetAdapter) RestartBlocker(w *Workload) (string, string) {
+	ds, ok := w.Object.(*v1.DaemonSet)
+	if !ok || ds.Status.ObservedGeneration == 0 {
+		// The DaemonSet controller hasn't reported the status yet.
+		return "", ""
+	}
+	if ds.Status.ObservedGeneration < ds.Generation || ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled {
+		return ReasonRolloutInProgress, fmt.Sprintf("DaemonSet %s is in the middle of a rollout (%d/%d Pods are updated)", ds.Name, ds.Status.UpdatedNumberScheduled, ds.Status.DesiredNumberScheduled)
+	}
+	if ds.Status.NumberUnavailable > 0 {
+		return ReasonWorkloadUnavailable, fmt.Sprintf("DaemonSet %s has %d unavailable Pods", ds.Name, ds.Status.NumberUnavailable)
+	}
+	return "", ""
+}
+
+// SetPodTemplate does nothing because PodTemplate points to the pod template in the DaemonSet.
+func (a *daemonSetAdapter) SetPodTemplate(w *Workload) error {
+	return nil
+}
@@ -0,0 +1,125 @@
+package workload
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/event"
+)
+
+// scaleSubresourceAdapter handles any workload which exposes the scale subresource and has a pod template, e.g., Argo Rollouts.
+// It reads the number of replicas from the scale subresource, and the pod template from podTemplatePath.
+type scaleSubresourceAdapter struct {
+	c        client.Client
+	recorder record.EventRecorder
+
+	gvk             schema.GroupVersionKind
+	podTemplatePath []string
+}
+
+var _ Adapter = &scaleSubresourceAdapter{}
+
+func newScaleSubresourceAdapter(c client.Client, recorder record.EventRecorder, w config.ScaleSubresourceWorkload) *scaleSubresourceAdapter {
+	return &scaleSubresourceAdapter{
+		c:               c,
+		recorder:        recorder,
+		gvk:             schema.FromAPIVersionAndKind(w.APIVersion, w.Kind),
+		podTemplatePath: w.PodTemplatePathFields(),
+	}
+}
+
+func (a *scaleSubresourceAdapter) Get(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*Workload, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(a.gvk)
+	if err := a.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Spec.TargetRefs.ScaleTargetRef.Name}, obj); err != nil {
+		return nil, fmt.Errorf("failed to get %s on tortoise: %w", a.gvk.Kind, err)
+	}
+
+	scale := &autoscalingv1.Scale{}
+	if err := a.c.SubResource("scale").Get(ctx, obj, scale); err != nil {
+		return nil, fmt.Errorf("failed to get the scale subresource of %s: %w", a.gvk.Kind, err)
+	}
+	replicas := scale.Spec.Replicas
+
+	m, found, err := unstructured.NestedMap(obj.Object, a.podTemplatePath...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the pod template of %s: %w", a.gvk.Kind, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("the pod template of %s isn't found in %v", a.gvk.Kind, a.podTemplatePath)
+	}
+	template := &corev1.PodTemplateSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, template); err != nil {
+		return nil, fmt.Errorf("failed to convert the pod template of %s: %w", a.gvk.Kind, err)
+	}
+
+	return &Workload{Object: obj, Replicas: &replicas, PodTemplate: template}, nil
+}
+
+func (a *scaleSubresourceAdapter) RolloutRestart(ctx context.Context, w *Workload, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	obj, ok := w.Object.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected workload type for %s: %T", a.gvk.Kind, w.Object)
+	}
+
+	annotationPath := append(append([]string{}, a.podTemplatePath...), "metadata", "annotations", annotation.UpdatedAtAnnotation)
+	if err := unstructured.SetNestedField(obj.Object, now.Format(time.RFC3339), annotationPath...); err != nil {
+		return fmt.Errorf("failed to set the annotation on the pod template of %s: %w", a.gvk.Kind, err)
+	}
+
+	if err := a.c.Update(ctx, obj); err != nil {
+		return fmt.Errorf("failed to update %s: %w", a.gvk.Kind, err)
+	}
+
+	msg := fmt.Sprintf("%s is restarted to apply the recommendation from Tortoise", a.gvk.Kind)
+	a.recorder.Event(tortoise, corev1.EventTypeNormal, event.RestartWorkload, msg)
+	log.FromContext(ctx).Info(msg, "tortoise", tortoise)
+
+	return nil
+}
+
+func (a *scaleSubresourceAdapter) SetPodTemplate(w *Workload) error {
+	obj, ok := w.Object.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected workload type for %s: %T", a.gvk.Kind, w.Object)
+	}
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(w.PodTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to convert the pod template of %s: %w", a.gvk.Kind, err)
+	}
+	if err := unstructured.SetNestedMap(obj.Object, m, a.podTemplatePath...); err != nil {
+		return fmt.Errorf("failed to set the pod template of %s: %w", a.gvk.Kind, err)
+	}
+	return nil
+}
+
+// RestartBlocker only checks whether the controller of the workload has observed the latest generation
+// because the status of the custom workloads varies.
+func (a *scaleSubresourceAdapter) RestartBlocker(w *Workload) (string, string) {
+	obj, ok := w.Object.(*unstructured.Unstructured)
+	if !ok {
+		return "", ""
+	}
+	observedGeneration, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err != nil || !found || observedGeneration == 0 {
+		return "", ""
+	}
+	if observedGeneration < obj.GetGeneration() {
+		return ReasonRolloutInProgress, fmt.Sprintf("%s %s is in the middle of a rollout", a.gvk.Kind, obj.GetName())
+	}
+	return "", ""
+}
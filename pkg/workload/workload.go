@@ -0,0 +1,241 @@
+package workload
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/daemonset"
+	"github.com/mercari/tortoise/pkg/deployment"
+	"github.com/mercari/tortoise/pkg/statefulset"
+)
+
+// Workload is the workload which a tortoise targets.
+type Workload struct {
+	// Object is the workload object fetched from the API server.
+	Object client.Object
+	// Replicas is the desired number of replicas. It's nil when the workload doesn't define it.
+	Replicas *int32
+	// PodTemplate is the pod template of the workload.
+	PodTemplate *corev1.PodTemplateSpec
+}
+
+// Adapter handles one kind of workload that tortoise can target.
+type Adapter interface {
+	// Get returns the workload which the tortoise targets.
+	Get(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*Workload, error)
+	// RolloutRestart restarts the Pods of the workload so that they get the resource requests from the tortoise.
+	RolloutRestart(ctx context.Context, w *Workload, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error
+	// RestartBlocker returns the reason and the message why the workload shouldn't be restarted now,
+	// e.g., a rollout is in progress. The reason is empty when it can be restarted.
+	RestartBlocker(w *Workload) (string, string)
+	// SetPodTemplate writes PodTemplate of the workload back to Object.
+	SetPodTemplate(w *Workload) error
+}
+
+const (
+	// The reasons why the Pods of the workload shouldn't be restarted now.
+	ReasonRolloutInProgress            = "RolloutInProgress"
+	ReasonWorkloadUnavailable          = "WorkloadUnavailable"
+	ReasonPodDisruptionBudgetUnhealthy = "PodDisruptionBudgetUnhealthy"
+	ReasonPodDisruptionBudgetExhausted = "PodDisruptionBudgetExhausted"
+)
+
+// PDBCheck is how the PodDisruptionBudgets matching the Pods are checked before restarting the Pods.
+type PDBCheck int
+
+const (
+	// PDBCheckNone doesn't check PodDisruptionBudgets, e.g., for the in-place resize, which doesn't disrupt the Pods.
+	PDBCheckNone PDBCheck = iota
+	// PDBCheckHealthy blocks the restart when a PodDisruptionBudget has fewer healthy Pods than desired.
+	// It's for the rolling restart, which doesn't use the eviction API, and the workload controller keeps enough Pods available by its strategy.
+	// So, a PodDisruptionBudget which never allows any disruption (e.g., minAvailable: 1 for a single replica) doesn't block it.
+	PDBCheckHealthy
+	// PDBCheckEviction blocks the restart when a PodDisruptionBudget doesn't allow any more disruption.
+	// It's for evicting the Pods (e.g., the canary rollout), which the eviction API would reject anyway.
+	PDBCheckEviction
+)
+
+type Service struct {
+	c client.Client
+	// adapters is keyed by the kind of the workload.
+	adapters map[string]Adapter
+
+	// IstioSidecarProxyDefaultCPU is the default CPU resource request of the istio sidecar proxy.
+	istioSidecarProxyDefaultCPU string
+	// IstioSidecarProxyDefaultMemory is the default Memory resource request of the istio sidecar proxy.
+	istioSidecarProxyDefaultMemory string
+}
+
+func New(
+	c client.Client,
+	recorder record.EventRecorder,
+	istioSidecarProxyDefaultCPU, istioSidecarProxyDefaultMemory string,
+	scaleSubresourceWorkloads []config.ScaleSubresourceWorkload,
+) *Service {
+	adapters := map[string]Adapter{
+		"Deployment":  &deploymentAdapter{s: deployment.New(c, recorder)},
+		"StatefulSet": &statefulSetAdapter{s: statefulset.New(c, recorder)},
+		"DaemonSet":   &daemonSetAdapter{s: daemonset.New(c, recorder)},
+	}
+	for _, w := range scaleSubresourceWorkloads {
+		adapters[w.Kind] = newScaleSubresourceAdapter(c, recorder, w)
+	}
+
+	return &Service{
+		c:                              c,
+		adapters:                       adapters,
+		istioSidecarProxyDefaultCPU:    istioSidecarProxyDefaultCPU,
+		istioSidecarProxyDefaultMemory: istioSidecarProxyDefaultMemory,
+	}
+}
+
+func (s *Service) adapter(tortoise *autoscalingv1beta3.Tortoise) (Adapter, error) {
+	a, ok := s.adapters[tortoise.Spec.TargetRefs.ScaleTargetRef.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported workload kind: %s", tortoise.Spec.TargetRefs.ScaleTargetRef.Kind)
+	}
+	return a, nil
+}
+
+// GetWorkloadOnTortoise returns the workload which the tortoise targets.
+func (s *Service) GetWorkloadOnTortoise(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*Workload, error) {
+	a, err := s.adapter(tortoise)
+	if err != nil {
+		return nil, err
+	}
+	return a.Get(ctx, tortoise)
+}
+
+// RolloutRestart restarts the Pods of the workload.
+func (s *Service) RolloutRestart(ctx context.Context, w *Workload, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	a, err := s.adapter(tortoise)
+	if err != nil {
+		return err
+	}
+	return a.RolloutRestart(ctx, w, tortoise, now)
+}
+
+// UpdatePodTemplate updates the workload with the pod template modified in w.PodTemplate.
+func (s *Service) UpdatePodTemplate(ctx context.Context, w *Workload, tortoise *autoscalingv1beta3.Tortoise) error {
+	a, err := s.adapter(tortoise)
+	if err != nil {
+		return err
+	}
+	if err := a.SetPodTemplate(w); err != nil {
+		return err
+	}
+	if err := s.c.Update(ctx, w.Object); err != nil {
+		return fmt.Errorf("failed to update %s: %w", tortoise.Spec.TargetRefs.ScaleTargetRef.Kind, err)
+	}
+	return nil
+}
+
+// RestartBlocker returns the reason and the message why the Pods of the workload shouldn't be restarted now,
+// that is, a rollout of the workload is in progress, the workload is unavailable,
+// or a PodDisruptionBudget matching the Pods blocks it, depending on pdbCheck.
+// The reason is empty when the Pods can be restarted.
+func (s *Service) RestartBlocker(ctx context.Context, w *Workload, tortoise *autoscalingv1beta3.Tortoise, pdbCheck PDBCheck) (string, string, error) {
+	a, err := s.adapter(tortoise)
+	if err != nil {
+		return "", "", err
+	}
+	if reason, msg := a.RestartBlocker(w); reason != "" {
+		return reason, msg, nil
+	}
+	if pdbCheck == PDBCheckNone {
+		return "", "", nil
+	}
+
+	pdbs := &policyv1.PodDisruptionBudgetList{}
+	if err := s.c.List(ctx, pdbs, client.InNamespace(tortoise.Namespace)); err != nil {
+		return "", "", fmt.Errorf("failed to list PodDisruptionBudgets: %w", err)
+	}
+	for _, pdb := range pdbs.Items {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(w.PodTemplate.Labels)) {
+			continue
+		}
+		if pdb.Status.CurrentHealthy < pdb.Status.DesiredHealthy {
+			return ReasonPodDisruptionBudgetUnhealthy, fmt.Sprintf("PodDisruptionBudget %s doesn't have enough healthy Pods (%d/%d healthy Pods)", pdb.Name, pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy), nil
+		}
+		if pdbCheck == PDBCheckEviction && pdb.Status.DisruptionsAllowed <= 0 {
+			return ReasonPodDisruptionBudgetExhausted, fmt.Sprintf("PodDisruptionBudget %s doesn't allow any more disruption (%d/%d healthy Pods)", pdb.Name, pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy), nil
+		}
+	}
+	return "", "", nil
+}
+
+// GetResourceRequests returns the resource requests of the containers in the workload.
+func (s *Service) GetResourceRequests(w *Workload) ([]autoscalingv1beta3.ContainerResourceRequests, error) {
+	actualContainerResource := []autoscalingv1beta3.ContainerResourceRequests{}
+
+	istioProxyIndex := -1
+	for i, c := range w.PodTemplate.Spec.Containers {
+		rcr := autoscalingv1beta3.ContainerResourceRequests{
+			ContainerName: c.Name,
+			Resource:      corev1.ResourceList{},
+		}
+		for name, r := range c.Resources.Requests {
+			rcr.Resource[name] = r
+		}
+		actualContainerResource = append(actualContainerResource, rcr)
+		if c.Name == "istio-proxy" {
+			istioProxyIndex = i
+		}
+	}
+
+	if w.PodTemplate.Annotations != nil {
+		if v, ok := w.PodTemplate.Annotations[annotation.IstioSidecarInjectionAnnotation]; ok && v == "true" {
+			// Istio sidecar injection is enabled.
+			// Because the istio container spec is not in the pod template, we need to get it from the pod template's annotation.
+
+			cpuReq, ok := w.PodTemplate.Annotations[annotation.IstioSidecarProxyCPUAnnotation]
+			if !ok {
+				cpuReq = s.istioSidecarProxyDefaultCPU
+			}
+			cpu, err := resource.ParseQuantity(cpuReq)
+			if err != nil {
+				return nil, fmt.Errorf("parse CPU request of istio sidecar: %w", err)
+			}
+
+			memoryReq, ok := w.PodTemplate.Annotations[annotation.IstioSidecarProxyMemoryAnnotation]
+			if !ok {
+				memoryReq = s.istioSidecarProxyDefaultMemory
+			}
+			memory, err := resource.ParseQuantity(memoryReq)
+			if err != nil {
+				return nil, fmt.Errorf("parse Memory request of istio sidecar: %w", err)
+			}
+
+			if istioProxyIndex == -1 {
+				// If the pod template has the sidecar injection annotation, the Pods will have the sidecar container in addition.
+				actualContainerResource = append(actualContainerResource, autoscalingv1beta3.ContainerResourceRequests{
+					ContainerName: "istio-proxy",
+					Resource: corev1.ResourceList{
+						corev1.ResourceCPU:    cpu,
+						corev1.ResourceMemory: memory,
+					},
+				})
+			} else {
+				// the pod template has the sidecar injection annotation and it's using the custom injection:
+				// https://istio.io/latest/docs/setup/additional-setup/sidecar-injection/#customizing-injection
+				actualContainerResource[istioProxyIndex].Resource[corev1.ResourceCPU] = cpu
+				actualContainerResource[istioProxyIndex].Resource[corev1.ResourceMemory] = memory
+			}
+		}
+	}
+
+	return actualContainerResource, nil
+}
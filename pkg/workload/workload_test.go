@@ -0,0 +1,425 @@
+package workload
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
+)
+
+var rolloutGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+
+func tortoiseFor(kind string) *v1beta3.Tortoise {
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef: v1beta3.CrossVersionObjectReference{
+					Kind: kind,
+					Name: "app",
+				},
+			},
+		},
+	}
+}
+
+func podTemplate() corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("100m"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func rollout() *unstructured.Unstructured {
+	template, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ptr.To(podTemplate()))
+	if err != nil {
+		panic(err)
+	}
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(4),
+			"template": template,
+		},
+	}}
+	u.SetGroupVersionKind(rolloutGVK)
+	u.SetName("app")
+	u.SetNamespace("default")
+	return u
+}
+
+// fakeClient returns the fake client which serves the scale subresource of Rollout.
+func fakeClient(objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().WithObjects(objs...).WithInterceptorFuncs(interceptor.Funcs{
+		SubResourceGet: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+			u, ok := obj.(*unstructured.Unstructured)
+			if subResourceName != "scale" || !ok || u.GroupVersionKind() != rolloutGVK {
+				return c.SubResource(subResourceName).Get(ctx, obj, subResource, opts...)
+			}
+			replicas, _, err := unstructured.NestedInt64(u.Object, "spec", "replicas")
+			if err != nil {
+				return err
+			}
+			subResource.(*autoscalingv1.Scale).Spec.Replicas = int32(replicas)
+			return nil
+		},
+	}).Build()
+}
+
+func TestService_GetWorkloadOnTortoise(t *testing.T) {
+	tests := []struct {
+		name         string
+		tortoise     *v1beta3.Tortoise
+		objs         []client.Object
+		wantReplicas *int32
+		wantErr      bool
+	}{
+		{
+			name:     "Deployment",
+			tortoise: tortoiseFor("Deployment"),
+			objs: []client.Object{
+				&v1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+					Spec:       v1.DeploymentSpec{Replicas: ptr.To[int32](2), Template: podTemplate()},
+				},
+			},
+			wantReplicas: ptr.To[int32](2),
+		},
+		{
+			name:     "StatefulSet",
+			tortoise: tortoiseFor("StatefulSet"),
+			objs: []client.Object{
+				&v1.StatefulSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+					Spec:       v1.StatefulSetSpec{Replicas: ptr.To[int32](3), Template: podTemplate()},
+				},
+			},
+			wantReplicas: ptr.To[int32](3),
+		},
+		{
+			name:     "DaemonSet",
+			tortoise: tortoiseFor("DaemonSet"),
+			objs: []client.Object{
+				&v1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+					Spec:       v1.DaemonSetSpec{Template: podTemplate()},
+					Status:     v1.DaemonSetStatus{DesiredNumberScheduled: 5},
+				},
+			},
+			wantReplicas: ptr.To[int32](5),
+		},
+		{
+			name:         "Rollout via the scale subresource",
+			tortoise:     tortoiseFor("Rollout"),
+			objs:         []client.Object{rollout()},
+			wantReplicas: ptr.To[int32](4),
+		},
+		{
+			name:     "unsupported kind",
+			tortoise: tortoiseFor("DeploymentConfig"),
+			wantErr:  true,
+		},
+		{
+			name:     "workload not found",
+			tortoise: tortoiseFor("Deployment"),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(fakeClient(tt.objs...), record.NewFakeRecorder(10), "100m", "100Mi", []config.ScaleSubresourceWorkload{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout"}})
+			got, err := s.GetWorkloadOnTortoise(context.Background(), tt.tortoise)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetWorkloadOnTortoise() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if d := cmp.Diff(tt.wantReplicas, got.Replicas); d != "" {
+				t.Errorf("GetWorkloadOnTortoise() replicas diff = %v", d)
+			}
+			if d := cmp.Diff(ptr.To(podTemplate()), got.PodTemplate); d != "" {
+				t.Errorf("GetWorkloadOnTortoise() pod template diff = %v", d)
+			}
+		})
+	}
+}
+
+func TestService_RolloutRestart_ScaleSubresource(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	c := fakeClient(rollout())
+	s := New(c, record.NewFakeRecorder(10), "100m", "100Mi", []config.ScaleSubresourceWorkload{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout"}})
+	tortoise := tortoiseFor("Rollout")
+
+	w, err := s.GetWorkloadOnTortoise(context.Background(), tortoise)
+	if err != nil {
+		t.Fatalf("GetWorkloadOnTortoise() error = %v", err)
+	}
+	if err := s.RolloutRestart(context.Background(), w, tortoise, now); err != nil {
+		t.Fatalf("RolloutRestart() error = %v", err)
+	}
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(rolloutGVK)
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "app"}, got); err != nil {
+		t.Fatalf("failed to get rollout: %v", err)
+	}
+	v, _, err := unstructured.NestedString(got.Object, "spec", "template", "metadata", "annotations", annotation.UpdatedAtAnnotation)
+	if err != nil {
+		t.Fatalf("failed to get annotation: %v", err)
+	}
+	if v != now.Format(time.RFC3339) {
+		t.Errorf("RolloutRestart() annotation = %q, want %q", v, now.Format(time.RFC3339))
+	}
+}
+
+func TestService_RestartBlocker(t *testing.T) {
+	// deployment returns the Deployment with 3 replicas whose status is modified by the given function.
+	deployment := func(modify func(d *v1.Deployment)) *v1.Deployment {
+		d := &v1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Generation: 2},
+			Spec:       v1.DeploymentSpec{Replicas: ptr.To[int32](3), Template: podTemplate()},
+			Status:     v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+		}
+		d.Spec.Template.Labels = map[string]string{"app": "app"}
+		if modify != nil {
+			modify(d)
+		}
+		return d
+	}
+	// pdb returns the PodDisruptionBudget with 3 healthy Pods.
+	pdb := func(name string, disruptionsAllowed, desiredHealthy int32, matchLabels map[string]string) *policyv1.PodDisruptionBudget {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: matchLabels}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: disruptionsAllowed, CurrentHealthy: 3, DesiredHealthy: desiredHealthy},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		objs       []client.Object
+		pdbCheck   PDBCheck
+		wantReason string
+	}{
+		{
+			name:     "can be restarted",
+			objs:     []client.Object{deployment(nil), pdb("pdb", 1, 2, map[string]string{"app": "app"})},
+			pdbCheck: PDBCheckEviction,
+		},
+		{
+			name: "the Deployment controller hasn't observed the Deployment yet",
+			objs: []client.Object{deployment(func(d *v1.Deployment) {
+				d.Status = v1.DeploymentStatus{}
+			})},
+			pdbCheck: PDBCheckEviction,
+		},
+		{
+			name: "the new generation isn't observed yet",
+			objs: []client.Object{deployment(func(d *v1.Deployment) {
+				d.Status.ObservedGeneration = 1
+			})},
+			pdbCheck:   PDBCheckEviction,
+			wantReason: ReasonRolloutInProgress,
+		},
+		{
+			name: "the old Pods still remain",
+			objs: []client.Object{deployment(func(d *v1.Deployment) {
+				d.Status.Replicas = 4
+			})},
+			pdbCheck:   PDBCheckEviction,
+			wantReason: ReasonRolloutInProgress,
+		},
+		{
+			name: "the Deployment is unavailable",
+			objs: []client.Object{deployment(func(d *v1.Deployment) {
+				d.Status.Conditions = []v1.DeploymentCondition{{Type: v1.DeploymentAvailable, Status: corev1.ConditionFalse}}
+			})},
+			pdbCheck:   PDBCheckEviction,
+			wantReason: ReasonWorkloadUnavailable,
+		},
+		{
+			name:       "the PodDisruptionBudget doesn't allow any more disruption for the eviction",
+			objs:       []client.Object{deployment(nil), pdb("other", 0, 3, map[string]string{"app": "other"}), pdb("pdb", 0, 3, map[string]string{"app": "app"})},
+			pdbCheck:   PDBCheckEviction,
+			wantReason: ReasonPodDisruptionBudgetExhausted,
+		},
+		{
+			name: "the PodDisruptionBudget doesn't allow any disruption, but it doesn't block the rolling restart",
+			objs: []client.Object{deployment(nil), pdb("pdb", 0, 3, map[string]string{"app": "app"})},
+			// e.g., maxUnavailable: 0
+			pdbCheck: PDBCheckHealthy,
+		},
+		{
+			name: "the PodDisruptionBudget of the single replica workload doesn't block the rolling restart",
+			objs: []client.Object{
+				deployment(func(d *v1.Deployment) {
+					d.Spec.Replicas = ptr.To[int32](1)
+					d.Status.Replicas, d.Status.UpdatedReplicas, d.Status.AvailableReplicas = 1, 1, 1
+				}),
+				// minAvailable: 1
+				func() *policyv1.PodDisruptionBudget {
+					p := pdb("pdb", 0, 1, map[string]string{"app": "app"})
+					p.Status.CurrentHealthy = 1
+					return p
+				}(),
+			},
+			pdbCheck: PDBCheckHealthy,
+		},
+		{
+			name:       "the PodDisruptionBudget is unhealthy",
+			objs:       []client.Object{deployment(nil), pdb("pdb", 0, 4, map[string]string{"app": "app"})},
+			pdbCheck:   PDBCheckHealthy,
+			wantReason: ReasonPodDisruptionBudgetUnhealthy,
+		},
+		{
+			name: "the PodDisruptionBudget isn't checked",
+			objs: []client.Object{deployment(nil), pdb("pdb", 0, 4, map[string]string{"app": "app"})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(fakeClient(tt.objs...), record.NewFakeRecorder(10), "100m", "100Mi", nil)
+			tortoise := tortoiseFor("Deployment")
+			w, err := s.GetWorkloadOnTortoise(context.Background(), tortoise)
+			if err != nil {
+				t.Fatalf("GetWorkloadOnTortoise() error = %v", err)
+			}
+			reason, _, err := s.RestartBlocker(context.Background(), w, tortoise, tt.pdbCheck)
+			if err != nil {
+				t.Fatalf("RestartBlocker() error = %v", err)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("RestartBlocker() reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestService_GetResourceRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		template corev1.PodTemplateSpec
+		want     []v1beta3.ContainerResourceRequests
+	}{
+		{
+			name:     "no istio",
+			template: podTemplate(),
+			want: []v1beta3.ContainerResourceRequests{
+				{
+					ContainerName: "app",
+					Resource:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				},
+			},
+		},
+		{
+			name: "istio is injected with the default resource",
+			template: func() corev1.PodTemplateSpec {
+				t := podTemplate()
+				t.Annotations = map[string]string{annotation.IstioSidecarInjectionAnnotation: "true"}
+				return t
+			}(),
+			want: []v1beta3.ContainerResourceRequests{
+				{
+					ContainerName: "app",
+					Resource:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				},
+				{
+					ContainerName: "istio-proxy",
+					Resource: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("100m"),
+						corev1.ResourceMemory: resource.MustParse("100Mi"),
+					},
+				},
+			},
+		},
+		{
+			name: "istio is injected with the resource in the annotation",
+			template: func() corev1.PodTemplateSpec {
+				t := podTemplate()
+				t.Annotations = map[string]string{
+					annotation.IstioSidecarInjectionAnnotation:   "true",
+					annotation.IstioSidecarProxyCPUAnnotation:    "200m",
+					annotation.IstioSidecarProxyMemoryAnnotation: "300Mi",
+				}
+				return t
+			}(),
+			want: []v1beta3.ContainerResourceRequests{
+				{
+					ContainerName: "app",
+					Resource:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				},
+				{
+					ContainerName: "istio-proxy",
+					Resource: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("200m"),
+						corev1.ResourceMemory: resource.MustParse("300Mi"),
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(fake.NewClientBuilder().Build(), record.NewFakeRecorder(10), "100m", "100Mi", nil)
+			got, err := s.GetResourceRequests(&Workload{PodTemplate: &tt.template})
+			if err != nil {
+				t.Fatalf("GetResourceRequests() error = %v", err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("GetResourceRequests() diff = %v", d)
+			}
+		})
+	}
+}
+
+func TestService_UpdatePodTemplate_ScaleSubresource(t *testing.T) {
+	c := fakeClient(rollout())
+	s := New(c, record.NewFakeRecorder(10), "100m", "100Mi", []config.ScaleSubresourceWorkload{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout"}})
+	tortoise := tortoiseFor("Rollout")
+
+	w, err := s.GetWorkloadOnTortoise(context.Background(), tortoise)
+	if err != nil {
+		t.Fatalf("GetWorkloadOnTortoise() error = %v", err)
+	}
+	w.PodTemplate.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("200m")
+	if err := s.UpdatePodTemplate(context.Background(), w, tortoise); err != nil {
+		t.Fatalf("UpdatePodTemplate() error = %v", err)
+	}
+
+	got, err := s.GetWorkloadOnTortoise(context.Background(), tortoise)
+	if err != nil {
+		t.Fatalf("GetWorkloadOnTortoise() error = %v", err)
+	}
+	if d := cmp.Diff(w.PodTemplate, got.PodTemplate); d != "" {
+		t.Errorf("UpdatePodTemplate() pod template diff = %s", d)
+	}
+}